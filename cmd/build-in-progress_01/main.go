@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
@@ -11,65 +13,144 @@ import (
 	"syscall"
 	"time"
 
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/routes"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
+	"github.com/kartikey1188/build-in-progress_01/internal/outbox"
 	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/memory"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage/postgres"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
 
 	gin.SetMode(gin.ReleaseMode) // to disable debug mode in gin
 
+	// cancelled on SIGINT/SIGTERM, which stops the server, the outbox relay and the listeners
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// loading config
 
 	cfg := config.MustLoad()
 
-	// setting up database
+	// "migrate up|down|status" manages the database schema instead of starting the server
+
+	args := os.Args[1:]
+	if flag.Parsed() {
+		args = flag.Args() // Without the -config flag
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(ctx, cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// setting up the database (Postgres or in-memory, depending on the config)
 
-	storage, err := postgres.New(cfg)
+	storage, err := openStorage(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	slog.Info("storage initialized", slog.String("env", cfg.Env), slog.String("version", "1.0.0"))
+	slog.Info("storage initialized", slog.String("env", cfg.Env), slog.String("backend", cfg.Storage), slog.String("version", "1.0.0"))
 
-	// initializing Pub/Sub client
-	pubsubClient, err := pubsub.NewClient(context.Background(), cfg.GCPProjectID) // the env variable GOOGLE_APPLICATION_CREDENTIALS is automatically picked up by the client (env variables must be loaded before this)
+	// creating the first admin from the config, on the first run only
+
+	if err := bootstrapAdmin(ctx, cfg, storage); err != nil {
+		log.Fatal(err)
+	}
+
+	// initializing the event bus (Pub/Sub, Kafka or in-memory, depending on the config)
+	bus, err := eventbus.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to create Pub/Sub client: %v", err)
+		log.Fatalf("Failed to create event bus: %v", err)
 	}
-	defer pubsubClient.Close()
+	defer bus.Close()
 
-	// initializing Pub/Sub topics
+	slog.Info("event bus initialized", slog.String("backend", cfg.EventBus))
 
-	err = pub_sub.InitTopics(pubsubClient)
+	// initializing topics
+
+	err = pub_sub.InitTopics(ctx, bus)
 	if err != nil {
-		log.Fatalf("Failed to initialize Pub/Sub topics: %v", err)
+		log.Fatalf("Failed to initialize topics: %v", err)
 	}
-	slog.Info("Pub/Sub topics initialized")
+	slog.Info("topics initialized")
 
-	// initializing Pub/Sub subscriptions
+	// initializing subscriptions
 
-	err = pub_sub.InitSubscriptions(pubsubClient)
+	err = pub_sub.InitSubscriptions(ctx, bus, cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize subscriptions: %v", err)
 	}
-	slog.Info("Pub/Sub subscriptions initialized")
+	slog.Info("subscriptions initialized")
 
-	// starting listeners in a separate goroutine
+	// starting the outbox relay, which publishes the events committed together with database writes
 
+	relayDone := make(chan struct{})
+	relay := outbox.NewRelay(storage, bus)
+	relay.Retention = cfg.OutboxRetention
 	go func() {
-		err = pub_sub.StartListeners(context.Background(), pubsubClient, cfg, storage)
-		if err != nil {
-			log.Fatalf("Failed to start listeners: %v", err)
-		}
-		slog.Info("Pub/Sub listeners started")
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
+
+	// setting up notifications, recorded in each user's inbox and delivered over the channels they chose
+
+	notifier, err := notify.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up notifications: %v", err)
+	}
+	sender := notify.NewSender(notifier, notify.NewTemplates(storage, cfg.DefaultLocale), storage)
+
+	// the hub broadcasts the locations received by the listeners to the clients tracking a pickup
+	hub := realtime.NewHub()
+
+	// starting the listeners, each subscriber is restarted by the supervisor if it fails
+
+	listeners := pub_sub.NewListeners(bus, cfg, storage, sender, hub)
+	listenersDone := make(chan struct{})
+	go func() {
+		defer close(listenersDone)
+		listeners.Run(ctx)
+	}()
+	slog.Info("listeners started")
+
+	go pub_sub.PurgeProcessedEvents(ctx, storage, time.Hour)
+
+	// starting the webhook dispatcher, which delivers the events queued for webhook endpoints
+
+	dispatcherDone := make(chan struct{})
+	dispatcher := webhooks.NewDispatcher(storage, cfg.WebhookMaxAttempts)
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(ctx)
 	}()
 
+	// geocoding the addresses of the businesses and collectors that do not give their coordinates
+
+	geocoder, err := geocode.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up geocoding: %v", err)
+	}
+
+	// pickup requests are checked against the service areas of their collector
+
+	checker := coverage.NewChecker(storage, cfg)
+
 	// setting up router and routes
 
 	router := gin.Default()
@@ -84,7 +165,7 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	routes.SetupRoutes(router, storage, pubsubClient)
+	routes.SetupRoutes(router, storage, bus, listeners, dispatcher, hub, geocoder, checker)
 
 	//setting up server (with graceful shutdown)
 
@@ -95,27 +176,88 @@ func main() {
 
 	slog.Info("server started", slog.String("address", fmt.Sprintf("localhost:%s", cfg.Port)))
 
-	done := make(chan os.Signal, 1)
-
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
 	go func() {
 		err := server.ListenAndServe()
-		if err != nil {
-			log.Fatal("failed to start server")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("failed to start server", slog.String("error", err.Error()))
+			stop()
 		}
 	}()
 
-	<-done
+	<-ctx.Done()
 
 	slog.Info("shutting down the server")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Failed to shutdown server", slog.String("error", err.Error()))
 	}
 
 	slog.Info("sever shutdown successfully")
+
+	// waiting for in-flight message handlers and the current relay and dispatcher batches
+
+	select {
+	case <-listenersDone:
+		slog.Info("listeners stopped")
+	case <-time.After(30 * time.Second):
+		slog.Warn("timed out waiting for listeners to stop")
+	}
+	<-relayDone
+	<-dispatcherDone
+}
+
+// openStorage opens the storage backend selected by the config. The in-memory storage starts out
+// empty on every run, which suits tests and local runs without a database.
+func openStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage {
+	case "postgres", "":
+		if cfg.StoragePath == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for the postgres storage")
+		}
+		return postgres.New(cfg)
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q (expected postgres or memory)", cfg.Storage)
+	}
+}
+
+// bootstrapAdmin creates the admin of the config unless an admin exists already. The admin has to
+// change the configured password on first login.
+func bootstrapAdmin(ctx context.Context, cfg *config.Config, storage storage.Storage) error {
+	email, password, err := cfg.AdminCredentials()
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		slog.Warn("no admin password configured, skipping the admin bootstrap")
+		return nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	created, err := storage.BootstrapAdmin(ctx, types.User{
+		Email:        email,
+		PasswordHash: string(hashedPassword),
+		FullName:     "Application Admin",
+		Role:         "Admin",
+		PhoneNumber:  "10000000",
+		Address:      "N/A",
+		ProfileImage: "default.jpg",
+		Registration: types.Date{Time: time.Now()},
+		LastLogin:    types.DateTime{Time: time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap admin: %w", err)
+	}
+	if created {
+		slog.Info("admin created, its password has to be changed on first login", slog.String("email", email))
+	}
+	return nil
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/postgres"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate runs the migrate command: up applies the pending migrations, down reverts the last
+// one (or the given number of them) and status lists every migration.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	if cfg.Storage != "postgres" && cfg.Storage != "" {
+		return fmt.Errorf("the %s storage has no migrations", cfg.Storage)
+	}
+	if cfg.StoragePath == "" {
+		return fmt.Errorf("DATABASE_URL is required for the postgres storage")
+	}
+
+	migrator, err := postgres.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number")
+			}
+		} else if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+
+	case "status":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			name := status.Name
+			if name == "" {
+				name = "(unknown to this build)"
+			}
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -11,9 +14,39 @@ import (
 
 type Config struct {
 	Env          string `yaml:"env" env:"ENV" env-required:"true"`
-	StoragePath  string `env:"DATABASE_URL" env-required:"true"`
+	StoragePath  string `env:"DATABASE_URL"` // Required by the postgres storage
 	Port         string `env:"PORT" env-required:"true"`
-	GCPProjectID string `env:"GCP_PROJECT_ID" env-required:"true"`
+	GCPProjectID string `env:"GCP_PROJECT_ID"`
+
+	Storage        string        `yaml:"storage" env:"STORAGE" env-default:"postgres"`               // postgres or memory (nothing is persisted)
+	QueryTimeout   time.Duration `yaml:"query_timeout" env:"QUERY_TIMEOUT" env-default:"5s"`         // Per storage call, 0 for none
+	MigrateOnStart bool          `yaml:"migrate_on_start" env:"MIGRATE_ON_START" env-default:"true"` // Otherwise run the migrate command before deploying
+
+	AdminEmail        string `yaml:"admin_email" env:"ADMIN_EMAIL"`                 // Of the admin created on the first run, when there is none yet
+	AdminPassword     string `env:"ADMIN_PASSWORD"`                                 // Temporary, the admin has to change it on first login
+	AdminPasswordFile string `yaml:"admin_password_file" env:"ADMIN_PASSWORD_FILE"` // Secrets file holding the password, instead of ADMIN_PASSWORD
+
+	EventBus     string   `yaml:"event_bus" env:"EVENT_BUS" env-default:"pubsub"` // pubsub, kafka or memory
+	KafkaBrokers []string `yaml:"kafka_brokers" env:"KAFKA_BROKERS" env-separator:"," env-default:"localhost:29092"`
+
+	MaxDeliveryAttempts int           `yaml:"max_delivery_attempts" env:"MAX_DELIVERY_ATTEMPTS" env-default:"5"` // Before a message is dead-lettered
+	ProcessedEventTTL   time.Duration `yaml:"processed_event_ttl" env:"PROCESSED_EVENT_TTL" env-default:"168h"`  // How long handled event IDs are remembered
+	OutboxRetention     time.Duration `yaml:"outbox_retention" env:"OUTBOX_RETENTION" env-default:"168h"`        // How long delivered outbox events are kept
+
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST" env-default:"smtp.gmail.com"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" env-default:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	SMTPFrom     string `yaml:"smtp_from" env:"SMTP_FROM"` // Defaults to SMTP_USERNAME
+
+	SMSGatewayURL    string `yaml:"sms_gateway_url" env:"SMS_GATEWAY_URL"` // SMS notifications are disabled if empty
+	SMSGatewayAPIKey string `env:"SMS_GATEWAY_API_KEY"`
+	SMSSender        string `yaml:"sms_sender" env:"SMS_SENDER"`
+
+	NotificationWebhookURL      string   `yaml:"notification_webhook_url" env:"NOTIFICATION_WEBHOOK_URL"` // Webhook notifications are disabled if empty
+	NotificationLogPath         string   `yaml:"notification_log_path" env:"NOTIFICATION_LOG_PATH"`       // The log channel writes to stdout if empty
+	DefaultNotificationChannels []string `yaml:"default_notification_channels" env:"DEFAULT_NOTIFICATION_CHANNELS" env-separator:"," env-default:"email"`
+	DefaultLocale               string   `yaml:"default_locale" env:"DEFAULT_LOCALE" env-default:"en"` // Notification locale for users without one
 
 	PickupRequestSubscriptionID       string `yaml:"pickup_request_subscription_id" env:"PICKUP_REQUEST_SUBSCRIPTION_ID" env-required:"true"`
 	DriverLocationSubscriptionID      string `yaml:"driver_location_subscription_id" env:"DRIVER_LOCATION_SUBSCRIPTION_ID" env-required:"true"`
@@ -23,6 +56,28 @@ type Config struct {
 	EndDeliverySubscriptionID         string `yaml:"end_delivery_subscription_id" env:"END_DELIVERY_SUBSCRIPTION_ID" env-required:"true"`
 	AssignDriverSubscriptionID        string `yaml:"assign_driver_subscription_id" env:"ASSIGN_DRIVER_SUBSCRIPTION_ID" env-required:"true"`
 	UnassignDriverSubscriptionID      string `yaml:"unassign_driver_subscription_id" env:"UNASSIGN_DRIVER_SUBSCRIPTION_ID" env-required:"true"`
+	CancelPickupRequestSubscriptionID string `yaml:"cancel_pickup_request_subscription_id" env:"CANCEL_PICKUP_REQUEST_SUBSCRIPTION_ID" env-default:"cancel-pickup-request-subscription-id"`
+
+	WebhookPickupRequestsSubscriptionID string `yaml:"webhook_pickup_requests_subscription_id" env:"WEBHOOK_PICKUP_REQUESTS_SUBSCRIPTION_ID" env-default:"webhook-pickup-requests-subscription-id"`
+	WebhookAssignmentsSubscriptionID    string `yaml:"webhook_assignments_subscription_id" env:"WEBHOOK_ASSIGNMENTS_SUBSCRIPTION_ID" env-default:"webhook-assignments-subscription-id"`
+	WebhookDeliverySubscriptionID       string `yaml:"webhook_delivery_subscription_id" env:"WEBHOOK_DELIVERY_SUBSCRIPTION_ID" env-default:"webhook-delivery-subscription-id"`
+	WebhookMaxAttempts                  int    `yaml:"webhook_max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"` // Before a webhook delivery is marked failed
+
+	ETARoadFactor    float64 `yaml:"eta_road_factor" env:"ETA_ROAD_FACTOR" env-default:"1.3"`              // Road distance over straight-line distance
+	ETASpeedWindow   int     `yaml:"eta_speed_window" env:"ETA_SPEED_WINDOW" env-default:"5"`              // Locations the speed is averaged over
+	ETAFallbackSpeed float64 `yaml:"eta_fallback_speed_kmh" env:"ETA_FALLBACK_SPEED_KMH" env-default:"20"` // When the driver is not moving yet
+
+	GeofenceRadius                float64       `yaml:"geofence_radius_meters" env:"GEOFENCE_RADIUS_METERS" env-default:"100"` // Of the sites without their own
+	GeofenceDwell                 time.Duration `yaml:"geofence_dwell" env:"GEOFENCE_DWELL" env-default:"1m"`                  // Inside or outside a fence before an arrival or departure, of the sites without their own
+	GeofenceSubscriptionID        string        `yaml:"geofence_subscription_id" env:"GEOFENCE_SUBSCRIPTION_ID" env-default:"geofence-subscription-id"`
+	WebhookGeofenceSubscriptionID string        `yaml:"webhook_geofence_subscription_id" env:"WEBHOOK_GEOFENCE_SUBSCRIPTION_ID" env-default:"webhook-geofence-subscription-id"`
+
+	Geocoder       string `yaml:"geocoder" env:"GEOCODER" env-default:"local"` // local, google or nominatim, which fall back to the local gazetteer
+	GeocoderURL    string `yaml:"geocoder_url" env:"GEOCODER_URL"`             // Overrides the endpoint of the provider, e.g. for a self-hosted Nominatim
+	GeocoderAPIKey string `env:"GEOCODER_API_KEY"`                             // Required by google
+	GazetteerPath  string `yaml:"gazetteer_path" env:"GAZETTEER_PATH"`         // CSV of places and pincodes added to the embedded gazetteer
+
+	ServiceAreaEnforcement string `yaml:"service_area_enforcement" env:"SERVICE_AREA_ENFORCEMENT" env-default:"reject"` // reject or warn, of pickup requests outside the service areas of their collector
 }
 
 func MustLoad() *Config {
@@ -64,3 +119,35 @@ func MustLoad() *Config {
 
 	return &cfg
 }
+
+// Credentials of the bootstrap admin when none are configured, which only the dev environment
+// accepts.
+const (
+	DefaultAdminEmail    = "admin@gmail.com"
+	DefaultAdminPassword = "admin"
+)
+
+// AdminCredentials returns the credentials of the bootstrap admin, reading the password from
+// AdminPasswordFile if it is set. Outside the dev environment the default password is refused,
+// and the password is empty if none is configured.
+func (cfg *Config) AdminCredentials() (email string, password string, err error) {
+	email, password = cfg.AdminEmail, cfg.AdminPassword
+	if cfg.AdminPasswordFile != "" {
+		data, err := os.ReadFile(cfg.AdminPasswordFile)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read the admin password file: %w", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+
+	if email == "" {
+		email = DefaultAdminEmail
+	}
+	if password == "" && cfg.Env == "dev" {
+		password = DefaultAdminPassword
+	}
+	if password == DefaultAdminPassword && cfg.Env != "dev" {
+		return "", "", fmt.Errorf("refusing the default admin password in the %s environment, set ADMIN_PASSWORD or ADMIN_PASSWORD_FILE", cfg.Env)
+	}
+	return email, password, nil
+}
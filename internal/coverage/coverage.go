@@ -0,0 +1,174 @@
+// Package coverage decides whether collectors serve a pickup location, from their service
+// areas.
+package coverage
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+type Verdict string
+
+const (
+	Unrestricted Verdict = "unrestricted" // The collector has no service area for the category
+	Covered      Verdict = "covered"
+	NotCovered   Verdict = "not_covered"
+	Unknown      Verdict = "unknown" // What is known of the location cannot be checked against the areas
+)
+
+// Location is where a pickup is, as far as it is known.
+type Location struct {
+	Point   *geo.Point
+	Pincode string
+}
+
+// PickupLocation returns where request is picked up: at its own coordinates, or else at the
+// business's, and at the pincode of the business address.
+func PickupLocation(request types.PickupRequest, business types.Business) Location {
+	var location Location
+	switch {
+	case request.PickupLatitude != nil && request.PickupLongitude != nil:
+		location.Point = &geo.Point{Lat: *request.PickupLatitude, Lng: *request.PickupLongitude}
+	case business.Latitude != nil && business.Longitude != nil:
+		location.Point = &geo.Point{Lat: *business.Latitude, Lng: *business.Longitude}
+	}
+	if pincodes := geocode.Pincodes(business.Business_address); len(pincodes) > 0 {
+		location.Pincode = pincodes[0]
+	}
+	return location
+}
+
+// Check returns whether areas, the service areas of a collector, cover location for a category.
+// For categoryID 0 the areas of every category apply. Pincode areas are checked by the pincode
+// of the location and polygon areas by its point, so a location outside of one area can still
+// be covered by another.
+func Check(areas []types.ServiceArea, categoryID int64, location Location) Verdict {
+	verdict := Unrestricted
+	for _, area := range areas {
+		if categoryID != 0 && area.CategoryID != nil && *area.CategoryID != categoryID {
+			continue
+		}
+		switch covers(area, location) {
+		case Covered:
+			return Covered
+		case NotCovered:
+			verdict = NotCovered
+		case Unknown:
+			if verdict == Unrestricted {
+				verdict = Unknown
+			}
+		}
+	}
+	return verdict
+}
+
+func covers(area types.ServiceArea, location Location) Verdict {
+	if len(area.Polygon) == 0 {
+		if location.Pincode == "" {
+			return Unknown
+		}
+		if slices.Contains(area.Pincodes, location.Pincode) {
+			return Covered
+		}
+		return NotCovered
+	}
+
+	if location.Point == nil {
+		return Unknown
+	}
+	polygon, err := geo.ParseGeoJSONArea(area.Polygon)
+	if err != nil {
+		// Areas are validated when they are saved
+		slog.Error("invalid service area", slog.Int64("area_id", area.AreaID), slog.String("error", err.Error()))
+		return Unknown
+	}
+	if polygon.Contains(*location.Point) {
+		return Covered
+	}
+	return NotCovered
+}
+
+// Checker checks pickup locations against the service areas in storage.
+type Checker struct {
+	storage storage.Storage
+	Reject  bool // Pickup requests outside of the areas of their collector are rejected, rather than accepted with a warning
+}
+
+func NewChecker(storage storage.Storage, cfg *config.Config) *Checker {
+	return &Checker{storage: storage, Reject: cfg.ServiceAreaEnforcement != "warn"}
+}
+
+// CheckCollector checks location against the areas of a collector for a waste type.
+func (c *Checker) CheckCollector(ctx context.Context, collectorID int64, wasteType string, location Location) (Verdict, error) {
+	areas, err := c.storage.ListServiceAreas(ctx, collectorID)
+	if err != nil {
+		return "", err
+	}
+	categoryID, err := c.categoryID(ctx, wasteType)
+	if err != nil {
+		return "", err
+	}
+	return Check(areas, categoryID, location), nil
+}
+
+// Filter keeps the collectors that cover location for a waste type, or every category if it
+// is empty, or may cover it: those without areas for it, and those whose areas cannot be checked
+// against what is known of the location.
+func (c *Checker) Filter(ctx context.Context, collectors []types.Collector, wasteType string, location Location) ([]types.Collector, error) {
+	verdicts, err := c.Verdicts(ctx, wasteType, location)
+	if err != nil {
+		return nil, err
+	}
+	covering := make([]types.Collector, 0, len(collectors))
+	for _, collector := range collectors {
+		if verdicts(collector.UserID) != NotCovered {
+			covering = append(covering, collector)
+		}
+	}
+	return covering, nil
+}
+
+// Verdicts loads the areas of every collector at once, for checking location against many of
+// them.
+func (c *Checker) Verdicts(ctx context.Context, wasteType string, location Location) (func(collectorID int64) Verdict, error) {
+	areas, err := c.storage.ListAllServiceAreas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categoryID, err := c.categoryID(ctx, wasteType)
+	if err != nil {
+		return nil, err
+	}
+
+	byCollector := make(map[int64][]types.ServiceArea)
+	for _, area := range areas {
+		byCollector[area.CollectorID] = append(byCollector[area.CollectorID], area)
+	}
+	return func(collectorID int64) Verdict {
+		return Check(byCollector[collectorID], categoryID, location)
+	}, nil
+}
+
+// categoryID returns the category of a waste type, 0 if it is empty or not in the catalog.
+func (c *Checker) categoryID(ctx context.Context, wasteType string) (int64, error) {
+	if wasteType == "" {
+		return 0, nil
+	}
+	categories, err := c.storage.GetAllServiceCategories(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, category := range categories {
+		if category.WasteType == wasteType {
+			return category.CategoryID, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,102 @@
+// Package eta estimates when the driver of a pickup request reaches the pickup site.
+package eta
+
+import (
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+const (
+	// minSpeed is the average speed, in km/h, below which the driver counts as standing, when the
+	// fallback speed is used so that a traffic light does not push the ETA to infinity.
+	minSpeed = 5
+	// arrivalRadius is the distance in meters at which the driver counts as arrived.
+	arrivalRadius = 50
+)
+
+// Estimator computes ETAs from the haversine distance to the pickup site, scaled by a road
+// factor, and the moving average of the driver's recent speeds.
+type Estimator struct {
+	RoadFactor    float64 // Road distance over straight-line distance
+	SpeedWindow   int     // Locations the speed is averaged over
+	FallbackSpeed float64 // km/h, when the driver is standing or reports no speed
+}
+
+func NewEstimator(cfg *config.Config) *Estimator {
+	return &Estimator{
+		RoadFactor:    cfg.ETARoadFactor,
+		SpeedWindow:   cfg.ETASpeedWindow,
+		FallbackSpeed: cfg.ETAFallbackSpeed,
+	}
+}
+
+// Estimate returns the ETA of the driver of a pickup request to pickup, the located pickup site,
+// from the driver's latest locations, oldest first. It returns false if the site is not located
+// or there are no locations.
+func (e *Estimator) Estimate(request types.PickupRequest, pickup *geo.Point, recent []types.DriverLocation) (types.PickupETA, bool) {
+	if pickup == nil || len(recent) == 0 {
+		return types.PickupETA{}, false
+	}
+	if e.SpeedWindow > 0 && len(recent) > e.SpeedWindow {
+		recent = recent[len(recent)-e.SpeedWindow:]
+	}
+
+	latest := recent[len(recent)-1]
+	position := geo.Point{Lat: latest.Latitude, Lng: latest.Longitude}
+
+	distance := geo.Distance(position, *pickup)
+	if distance > arrivalRadius {
+		distance *= max(e.RoadFactor, 1)
+	} else {
+		distance = 0
+	}
+
+	speed := e.speed(recent)
+	remaining := time.Duration(distance / (speed / 3.6) * float64(time.Second))
+
+	return types.PickupETA{
+		RequestID:        request.RequestID,
+		DriverID:         latest.DriverID,
+		DriverLatitude:   latest.Latitude,
+		DriverLongitude:  latest.Longitude,
+		DistanceMeters:   distance,
+		SpeedKmh:         speed,
+		RemainingSeconds: remaining.Seconds(),
+		ArrivalAt:        types.DateTime{Time: latest.Timestamp.Add(remaining)},
+		LocatedAt:        latest.Timestamp,
+		CalculatedAt:     types.DateTime{Time: time.Now().UTC()},
+	}, true
+}
+
+// speed averages the reported speeds of the locations, or derives the speed from their positions
+// if the device reports none.
+func (e *Estimator) speed(recent []types.DriverLocation) float64 {
+	var total float64
+	reported := 0
+	for _, location := range recent {
+		if location.Speed > 0 {
+			total += float64(location.Speed)
+			reported++
+		}
+	}
+
+	var speed float64
+	if reported > 0 {
+		// Standing locations count, a driver stuck in traffic is slower
+		speed = total / float64(len(recent))
+	} else if len(recent) > 1 {
+		points := make([]geo.TrackPoint, len(recent))
+		for i, location := range recent {
+			points[i] = geo.TrackPoint{Point: geo.Point{Lat: location.Latitude, Lng: location.Longitude}, Time: location.Timestamp.Time}
+		}
+		speed = geo.Summarize(points).AverageSpeed
+	}
+
+	if speed < minSpeed {
+		return max(e.FallbackSpeed, minSpeed)
+	}
+	return speed
+}
@@ -0,0 +1,52 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DedupStore remembers which messages a subscription has already handled.
+type DedupStore interface {
+	IsEventProcessed(ctx context.Context, subscriptionID string, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, subscriptionID string, eventID string, ttl time.Duration) error
+}
+
+// Idempotent skips, and acks, messages that the subscription already handled. key returns the ID
+// a message is deduplicated by; messages are remembered for ttl once handler acks them.
+//
+// Deliveries of the same message that overlap can still both reach handler, this only guards
+// against redeliveries of messages that were handled before.
+func Idempotent(handler Handler, store DedupStore, subscriptionID string, ttl time.Duration, key func(*Message) string) Handler {
+	return func(ctx context.Context, msg *Message) {
+		id := key(msg)
+
+		processed, err := store.IsEventProcessed(ctx, subscriptionID, id)
+		if err != nil {
+			msg.Fail(fmt.Errorf("failed to check for duplicate delivery: %w", err))
+			return
+		}
+		if processed {
+			slog.Info("skipping duplicate delivery", slog.String("subscription", subscriptionID), slog.String("event_id", id))
+			msg.Ack()
+			return
+		}
+
+		var wrapped *Message
+		wrapped = msg.wrap(
+			func() {
+				if err := store.MarkEventProcessed(ctx, subscriptionID, id, ttl); err != nil {
+					// Acking anyway, the worst case is handling a later redelivery again
+					slog.Error("failed to record processed event", slog.String("subscription", subscriptionID), slog.String("event_id", id), slog.String("error", err.Error()))
+				}
+				msg.Ack()
+			},
+			func() {
+				msg.err = wrapped.err
+				msg.Nack()
+			},
+		)
+		handler(ctx, wrapped)
+	}
+}
@@ -0,0 +1,151 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/memory"
+)
+
+// dedupStore is a DedupStore over a map, failing with checkErr or markErr if set.
+type dedupStore struct {
+	processed map[string]time.Duration // TTL by subscription and event ID
+	checkErr  error
+	markErr   error
+}
+
+func (s *dedupStore) IsEventProcessed(ctx context.Context, subscriptionID string, eventID string) (bool, error) {
+	if s.checkErr != nil {
+		return false, s.checkErr
+	}
+	_, ok := s.processed[subscriptionID+"/"+eventID]
+	return ok, nil
+}
+
+func (s *dedupStore) MarkEventProcessed(ctx context.Context, subscriptionID string, eventID string, ttl time.Duration) error {
+	if s.markErr != nil {
+		return s.markErr
+	}
+	s.processed[subscriptionID+"/"+eventID] = ttl
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	store := &dedupStore{processed: map[string]time.Duration{}}
+	handled := 0
+	fail := false
+	failure := errors.New("collector not found")
+	// Deduplicating by event ID rather than broker message ID, like the subscribers
+	handler := Idempotent(func(ctx context.Context, msg *Message) {
+		handled++
+		if fail {
+			msg.Fail(failure)
+			return
+		}
+		msg.Ack()
+	}, store, "sub", time.Hour, func(msg *Message) string { return msg.Attributes["event_id"] })
+
+	deliver := func(messageID string, eventID string) (*Message, *settlement) {
+		var s settlement
+		msg := s.message(messageID, 1)
+		msg.Attributes = map[string]string{"event_id": eventID}
+		handler(t.Context(), msg)
+		return msg, &s
+	}
+
+	// A failed delivery is not remembered, and its error reaches the middleware below
+	fail = true
+	msg, s := deliver("m1", "e1")
+	if handled != 1 || s.nacks != 1 || s.acks != 0 || !errors.Is(msg.err, failure) || len(store.processed) != 0 {
+		t.Errorf("failed delivery: handled %d times, %+v, error %v, processed %v", handled, *s, msg.err, store.processed)
+	}
+
+	fail = false
+	_, s = deliver("m2", "e1")
+	if handled != 2 || s.acks != 1 || store.processed["sub/e1"] != time.Hour {
+		t.Errorf("first success: handled %d times, %+v, processed %v", handled, *s, store.processed)
+	}
+
+	// Redeliveries, under any message ID, are acked without reaching the handler
+	_, s = deliver("m3", "e1")
+	if handled != 2 || s.acks != 1 || s.nacks != 0 {
+		t.Errorf("redelivery: handled %d times, %+v", handled, *s)
+	}
+	_, s = deliver("m3", "e2")
+	if handled != 3 || s.acks != 1 {
+		t.Errorf("other event: handled %d times, %+v", handled, *s)
+	}
+}
+
+func TestIdempotentStoreErrors(t *testing.T) {
+	handled := 0
+	handle := func(ctx context.Context, msg *Message) {
+		handled++
+		msg.Ack()
+	}
+	key := func(msg *Message) string { return msg.ID }
+
+	// Without knowing whether the event was handled, the message is retried
+	down := errors.New("database down")
+	var s settlement
+	msg := s.message("m1", 1)
+	Idempotent(handle, &dedupStore{checkErr: down}, "sub", time.Hour, key)(t.Context(), msg)
+	if handled != 0 || s.nacks != 1 || !errors.Is(msg.err, down) {
+		t.Errorf("failed check: handled %d times, %+v, error %v", handled, s, msg.err)
+	}
+
+	// A handled event is acked even if it cannot be remembered
+	s = settlement{}
+	store := &dedupStore{processed: map[string]time.Duration{}, markErr: down}
+	Idempotent(handle, store, "sub", time.Hour, key)(t.Context(), s.message("m1", 1))
+	if handled != 1 || s.acks != 1 || s.nacks != 0 {
+		t.Errorf("failed mark: handled %d times, %+v", handled, s)
+	}
+}
+
+// Subscriptions are deduplicated separately, so every subscriber handles each event once.
+func TestIdempotentPerSubscription(t *testing.T) {
+	store := &dedupStore{processed: map[string]time.Duration{}}
+	handled := map[string]int{}
+	for _, subscriptionID := range []string{"accept", "webhooks", "accept"} {
+		handler := Idempotent(func(ctx context.Context, msg *Message) {
+			handled[subscriptionID]++
+			msg.Ack()
+		}, store, subscriptionID, time.Hour, func(msg *Message) string { return msg.ID })
+
+		var s settlement
+		handler(t.Context(), s.message("e1", 1))
+		if s.acks != 1 {
+			t.Errorf("%s: %+v, want acked", subscriptionID, s)
+		}
+	}
+	if handled["accept"] != 1 || handled["webhooks"] != 1 {
+		t.Errorf("handled %v, want each subscription once", handled)
+	}
+}
+
+// An event the outbox publishes twice, e.g. after its lease ran out, is handled once.
+func TestIdempotentOnMemory(t *testing.T) {
+	bus := newMemory(t, "pickups", Subscription{ID: "sub", Topic: "pickups"})
+	handled := make(chan string, 4)
+	stop := receive(t, bus, "sub", Idempotent(func(ctx context.Context, msg *Message) {
+		msg.Ack()
+		handled <- string(msg.Data)
+	}, memory.New(), "sub", time.Hour, func(msg *Message) string { return msg.Attributes["event_id"] }))
+	defer stop()
+
+	for _, data := range []string{"first", "second"} {
+		if err := bus.Publish(t.Context(), "pickups", []byte(data), map[string]string{"event_id": "e1"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if data == "first" {
+			// Handled before the duplicate arrives, as deliveries that overlap are not deduplicated
+			if got := next(t, handled); got != "first" {
+				t.Errorf("handled %q, want first", got)
+			}
+		}
+	}
+	none(t, handled, 300*time.Millisecond)
+}
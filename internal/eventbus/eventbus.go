@@ -0,0 +1,151 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+)
+
+// Bus is a message broker with topics and acknowledged subscriptions. Every backend delivers
+// messages at least once: a message that is nacked, or neither acked nor nacked, is redelivered.
+type Bus interface {
+	// Publish returns once the broker has accepted the message.
+	Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error
+
+	// EnsureTopic creates the topic if it does not exist yet.
+	EnsureTopic(ctx context.Context, topic string) error
+
+	// EnsureSubscription creates the subscription if it does not exist yet. It has to be called
+	// before Receive, even for existing subscriptions, so that the bus knows the topic.
+	EnsureSubscription(ctx context.Context, sub Subscription) error
+
+	// Receive calls handler for every message of the subscription. It blocks until ctx is done
+	// or the subscription fails, and waits for in-flight handlers before returning.
+	Receive(ctx context.Context, subscriptionID string, handler Handler) error
+
+	Close() error
+}
+
+type Subscription struct {
+	ID    string
+	Topic string
+
+	// Filter, if set, restricts the subscription to some of the messages published on Topic.
+	// Messages it rejects are acked without reaching the handler.
+	Filter *AttributeFilter
+}
+
+// AttributeFilter matches messages whose attribute has one of the given values.
+type AttributeFilter struct {
+	Attribute string
+	Values    []string
+}
+
+// Matches reports whether a message with the given attributes passes the filter. A nil filter
+// matches every message.
+func (f *AttributeFilter) Matches(attributes map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	value, ok := attributes[f.Attribute]
+	if !ok {
+		return false
+	}
+	for _, v := range f.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// expression returns the filter in the Pub/Sub filter syntax.
+func (f *AttributeFilter) expression() string {
+	if f == nil {
+		return ""
+	}
+	terms := make([]string, len(f.Values))
+	for i, v := range f.Values {
+		terms[i] = fmt.Sprintf("attributes.%s = %q", f.Attribute, v)
+	}
+	return strings.Join(terms, " OR ")
+}
+
+type Handler func(ctx context.Context, msg *Message)
+
+// Message is a message delivered to a subscription handler.
+type Message struct {
+	ID              string
+	Topic           string
+	Data            []byte
+	Attributes      map[string]string
+	PublishTime     time.Time
+	DeliveryAttempt int // Starts at 1; 0 if the broker does not track attempts
+
+	once sync.Once
+	ack  func()
+	nack func()
+	err  error
+}
+
+// Ack marks the message as handled. Only the first call to Ack or Nack has an effect.
+func (m *Message) Ack() {
+	m.once.Do(func() {
+		if m.ack != nil {
+			m.ack()
+		}
+	})
+}
+
+// Nack asks for the message to be redelivered. Only the first call to Ack or Nack has an effect.
+func (m *Message) Nack() {
+	m.once.Do(func() {
+		if m.nack != nil {
+			m.nack()
+		}
+	})
+}
+
+// Fail nacks the message, recording why handling it failed.
+func (m *Message) Fail(err error) {
+	m.err = err
+	m.Nack()
+}
+
+// wrap returns a copy of m for handler middleware, with its own ack and nack.
+func (m *Message) wrap(ack func(), nack func()) *Message {
+	return &Message{
+		ID:              m.ID,
+		Topic:           m.Topic,
+		Data:            m.Data,
+		Attributes:      m.Attributes,
+		PublishTime:     m.PublishTime,
+		DeliveryAttempt: m.DeliveryAttempt,
+		ack:             ack,
+		nack:            nack,
+	}
+}
+
+// New creates the bus selected by cfg.EventBus.
+func New(ctx context.Context, cfg *config.Config) (Bus, error) {
+	switch cfg.EventBus {
+	case "pubsub", "":
+		if cfg.GCPProjectID == "" {
+			return nil, fmt.Errorf("GCP_PROJECT_ID is required for the pubsub event bus")
+		}
+		return NewPubSub(ctx, cfg.GCPProjectID)
+	case "kafka":
+		if len(cfg.KafkaBrokers) == 0 {
+			return nil, fmt.Errorf("KAFKA_BROKERS is required for the kafka event bus")
+		}
+		return NewKafka(cfg.KafkaBrokers), nil
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown event bus %q (expected pubsub, kafka or memory)", cfg.EventBus)
+	}
+}
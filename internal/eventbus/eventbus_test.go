@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttributeFilter(t *testing.T) {
+	filter := &AttributeFilter{Attribute: "event_type", Values: []string{"pickup.accepted", "pickup.rejected"}}
+	tests := []struct {
+		name       string
+		filter     *AttributeFilter
+		attributes map[string]string
+		want       bool
+	}{
+		{"first value", filter, map[string]string{"event_type": "pickup.accepted"}, true},
+		{"second value", filter, map[string]string{"event_type": "pickup.rejected", "event_id": "1"}, true},
+		{"other value", filter, map[string]string{"event_type": "pickup.created"}, false},
+		{"empty value", filter, map[string]string{"event_type": ""}, false},
+		{"other attribute", filter, map[string]string{"type": "pickup.accepted"}, false},
+		{"no attributes", filter, nil, false},
+		{"nil filter", nil, nil, true},
+		{"nil filter with attributes", nil, map[string]string{"event_type": "pickup.created"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Matches(test.attributes); got != test.want {
+				t.Errorf("Matches(%v) = %v, want %v", test.attributes, got, test.want)
+			}
+		})
+	}
+
+	if got, want := filter.expression(), `attributes.event_type = "pickup.accepted" OR attributes.event_type = "pickup.rejected"`; got != want {
+		t.Errorf("expression() = %s, want %s", got, want)
+	}
+	if got := (*AttributeFilter)(nil).expression(); got != "" {
+		t.Errorf("expression() of a nil filter = %s, want none", got)
+	}
+}
+
+// Subscriptions sharing a topic only receive the messages their filter matches.
+func TestMemoryFilters(t *testing.T) {
+	bus := newMemory(t, "pickups",
+		Subscription{ID: "accepted", Topic: "pickups", Filter: &AttributeFilter{Attribute: "event_type", Values: []string{"pickup.accepted"}}},
+		Subscription{ID: "all", Topic: "pickups"},
+	)
+	for _, eventType := range []string{"pickup.created", "pickup.accepted", ""} {
+		attributes := map[string]string{"event_type": eventType}
+		if eventType == "" {
+			attributes = nil
+		}
+		if err := bus.Publish(t.Context(), "pickups", []byte(eventType), attributes); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	received := map[string]chan string{}
+	for _, id := range []string{"accepted", "all"} {
+		ch := make(chan string, 4)
+		received[id] = ch
+		stop := receive(t, bus, id, func(ctx context.Context, msg *Message) {
+			msg.Ack()
+			ch <- string(msg.Data)
+		})
+		defer stop()
+	}
+
+	if got := next(t, received["accepted"]); got != "pickup.accepted" {
+		t.Errorf("filtered subscription received %q, want pickup.accepted", got)
+	}
+	none(t, received["accepted"], 50*time.Millisecond)
+
+	all := map[string]bool{}
+	for range 3 {
+		all[next(t, received["all"])] = true
+	}
+	if len(all) != 3 {
+		t.Errorf("unfiltered subscription received %v, want every message", all)
+	}
+}
@@ -0,0 +1,183 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka is a Bus backed by Kafka. A subscription maps to a consumer group, whose members share
+// the partitions of the topic. Kafka cannot redeliver a single message, so a nacked message is
+// retried in-process with backoff before the consumer moves on; handlers therefore have to ack or
+// nack before they return.
+type Kafka struct {
+	brokers []string
+	writer  *kafka.Writer
+
+	mu            sync.Mutex
+	subscriptions map[string]Subscription
+}
+
+func NewKafka(brokers []string) *Kafka {
+	return &Kafka{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.LeastBytes{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		subscriptions: make(map[string]Subscription),
+	}
+}
+
+func (b *Kafka) Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error {
+	headers := make([]kafka.Header, 0, len(attributes))
+	for key, value := range attributes {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	err := b.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   topic,
+		Value:   data,
+		Headers: headers,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (b *Kafka) EnsureTopic(ctx context.Context, topic string) error {
+	conn, err := kafka.DialContext(ctx, "tcp", b.brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find kafka controller: %w", err)
+	}
+
+	controllerConn, err := kafka.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, fmt.Sprint(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	// Creating an existing topic is a no-op
+	err = controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (b *Kafka) EnsureSubscription(ctx context.Context, sub Subscription) error {
+	// Consumer groups are created by the first reader that joins them
+	b.mu.Lock()
+	b.subscriptions[sub.ID] = sub
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Kafka) Receive(ctx context.Context, subscriptionID string, handler Handler) error {
+	b.mu.Lock()
+	sub, ok := b.subscriptions[subscriptionID]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown subscription %s", subscriptionID)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  b.brokers,
+		GroupID:  subscriptionID,
+		Topic:    sub.Topic,
+		MinBytes: 10e3, // 10KB
+		MaxBytes: 10e6, // 10MB
+	})
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read from subscription %s: %w", subscriptionID, err)
+		}
+
+		// Messages rejected by the filter are committed without being delivered
+		if sub.Filter.Matches(headerAttributes(m.Headers)) && !b.deliver(ctx, m, handler) {
+			// Shutting down before the message was acked, it is delivered again after a restart
+			return nil
+		}
+
+		// Committing even when ctx is done, so that handled messages are not delivered again
+		commitCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = reader.CommitMessages(commitCtx, m)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to commit offset on subscription %s: %w", subscriptionID, err)
+		}
+	}
+}
+
+// deliver calls handler until it acks the message, backing off between attempts. It returns
+// false if ctx is done before that.
+func (b *Kafka) deliver(ctx context.Context, m kafka.Message, handler Handler) bool {
+	attributes := headerAttributes(m.Headers)
+
+	for attempt := 1; ; attempt++ {
+		acked := make(chan bool, 1)
+		msg := &Message{
+			ID:              fmt.Sprintf("%s/%d/%d", m.Topic, m.Partition, m.Offset),
+			Topic:           m.Topic,
+			Data:            m.Value,
+			Attributes:      attributes,
+			PublishTime:     m.Time,
+			DeliveryAttempt: attempt,
+			ack:             func() { acked <- true },
+			nack:            func() { acked <- false },
+		}
+		handler(ctx, msg)
+
+		select {
+		case ok := <-acked:
+			if ok {
+				return true
+			}
+		default:
+			slog.Warn("kafka handler returned without acking", slog.String("message_id", msg.ID))
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(redeliveryDelay(attempt)):
+		}
+	}
+}
+
+func headerAttributes(headers []kafka.Header) map[string]string {
+	attributes := make(map[string]string, len(headers))
+	for _, header := range headers {
+		attributes[header.Key] = string(header.Value)
+	}
+	return attributes
+}
+
+func (b *Kafka) Close() error {
+	return b.writer.Close()
+}
@@ -0,0 +1,212 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Bus for local development and tests. Messages are lost when the process
+// exits. Every subscription receives its own copy of each message published to its topic after
+// the subscription was created.
+type Memory struct {
+	mu            sync.Mutex
+	topics        map[string]bool
+	subscriptions map[string]*memorySubscription
+	nextID        int64
+	closed        bool
+}
+
+type memorySubscription struct {
+	topic  string
+	filter *AttributeFilter
+
+	mu     sync.Mutex
+	queue  []*memoryMessage
+	notify chan struct{} // Signalled whenever the queue grows
+}
+
+type memoryMessage struct {
+	id          string
+	topic       string
+	data        []byte
+	attributes  map[string]string
+	publishTime time.Time
+	attempt     int
+}
+
+// memoryWorkers is the number of handlers running concurrently per Receive call.
+const memoryWorkers = 4
+
+func NewMemory() *Memory {
+	return &Memory{
+		topics:        make(map[string]bool),
+		subscriptions: make(map[string]*memorySubscription),
+	}
+}
+
+func (b *Memory) Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("event bus is closed")
+	}
+	if !b.topics[topic] {
+		return fmt.Errorf("topic %s does not exist", topic)
+	}
+
+	b.nextID++
+	now := time.Now()
+	for _, sub := range b.subscriptions {
+		if sub.topic != topic || !sub.filter.Matches(attributes) {
+			continue
+		}
+		sub.push(&memoryMessage{
+			id:          fmt.Sprint(b.nextID),
+			topic:       topic,
+			data:        data,
+			attributes:  attributes,
+			publishTime: now,
+			attempt:     1,
+		})
+	}
+	return nil
+}
+
+func (b *Memory) EnsureTopic(ctx context.Context, topic string) error {
+	b.mu.Lock()
+	b.topics[topic] = true
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Memory) EnsureSubscription(ctx context.Context, sub Subscription) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.topics[sub.Topic] {
+		return fmt.Errorf("topic %s does not exist", sub.Topic)
+	}
+	if _, ok := b.subscriptions[sub.ID]; !ok {
+		b.subscriptions[sub.ID] = &memorySubscription{
+			topic:  sub.Topic,
+			filter: sub.Filter,
+			notify: make(chan struct{}, 1),
+		}
+	}
+	return nil
+}
+
+func (b *Memory) Receive(ctx context.Context, subscriptionID string, handler Handler) error {
+	b.mu.Lock()
+	sub, ok := b.subscriptions[subscriptionID]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown subscription %s", subscriptionID)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < memoryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				m, ok := sub.pop(ctx)
+				if !ok {
+					return
+				}
+				msg := sub.message(m)
+				handler(ctx, msg)
+				msg.Nack() // No effect if the handler acked
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (b *Memory) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return nil
+}
+
+func (s *memorySubscription) push(m *memoryMessage) {
+	s.mu.Lock()
+	s.queue = append(s.queue, m)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop blocks until a message is queued or ctx is done.
+func (s *memorySubscription) pop(ctx context.Context) (*memoryMessage, bool) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			m := s.queue[0]
+			s.queue = s.queue[1:]
+			remaining := len(s.queue)
+			s.mu.Unlock()
+
+			// Waking up another worker for the rest of the queue
+			if remaining > 0 {
+				select {
+				case s.notify <- struct{}{}:
+				default:
+				}
+			}
+			return m, true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-s.notify:
+		}
+	}
+}
+
+// message wraps m for a handler. Nacked messages, and messages the handler neither acks nor
+// nacks, are queued again after a delay.
+func (s *memorySubscription) message(m *memoryMessage) *Message {
+	redeliver := func() {
+		time.AfterFunc(redeliveryDelay(m.attempt), func() {
+			next := *m
+			next.attempt++
+			s.push(&next)
+		})
+	}
+
+	msg := &Message{
+		ID:              m.id,
+		Topic:           m.topic,
+		Data:            m.data,
+		Attributes:      m.attributes,
+		PublishTime:     m.publishTime,
+		DeliveryAttempt: m.attempt,
+		ack:             func() {},
+		nack:            redeliver,
+	}
+	return msg
+}
+
+// redeliveryDelay is the wait before redelivering a message that failed on the given attempt,
+// for backends that handle redelivery themselves.
+func redeliveryDelay(attempt int) time.Duration {
+	delay := 100 * time.Millisecond
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= 30*time.Second {
+			return 30 * time.Second
+		}
+	}
+	return delay
+}
@@ -0,0 +1,231 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receive runs bus.Receive on the subscription in the background, returning a function that
+// stops it and waits for it to return.
+func receive(t *testing.T, bus Bus, subscriptionID string, handler Handler) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Receive(ctx, subscriptionID, handler)
+	}()
+	return func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Receive(%s): %v", subscriptionID, err)
+		}
+	}
+}
+
+// next waits for a value on ch, failing the test after a second.
+func next[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for a delivery")
+		var zero T
+		return zero
+	}
+}
+
+// none fails the test if a value arrives on ch within wait.
+func none[T any](t *testing.T, ch <-chan T, wait time.Duration) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Errorf("unexpected delivery %v", v)
+	case <-time.After(wait):
+	}
+}
+
+func newMemory(t *testing.T, topic string, subscriptions ...Subscription) *Memory {
+	t.Helper()
+	bus := NewMemory()
+	if err := bus.EnsureTopic(t.Context(), topic); err != nil {
+		t.Fatalf("EnsureTopic: %v", err)
+	}
+	for _, sub := range subscriptions {
+		if err := bus.EnsureSubscription(t.Context(), sub); err != nil {
+			t.Fatalf("EnsureSubscription(%s): %v", sub.ID, err)
+		}
+	}
+	return bus
+}
+
+func TestMessageSettlesOnce(t *testing.T) {
+	var acks, nacks int
+	msg := &Message{ack: func() { acks++ }, nack: func() { nacks++ }}
+	msg.Ack()
+	msg.Nack()
+	msg.Ack()
+	if acks != 1 || nacks != 0 {
+		t.Errorf("Ack, Nack, Ack = %d acks and %d nacks, want the first ack only", acks, nacks)
+	}
+
+	acks, nacks = 0, 0
+	failed := &Message{ack: func() { acks++ }, nack: func() { nacks++ }}
+	failure := errors.New("handler failed")
+	failed.Fail(failure)
+	failed.Ack()
+	if acks != 0 || nacks != 1 || failed.err != failure {
+		t.Errorf("Fail, Ack = %d acks, %d nacks and error %v, want one nack with the failure", acks, nacks, failed.err)
+	}
+
+	// Settling from several goroutines, as handlers and the bus may
+	var mu sync.Mutex
+	settled := 0
+	count := func() {
+		mu.Lock()
+		settled++
+		mu.Unlock()
+	}
+	concurrent := &Message{ack: count, nack: count}
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				concurrent.Ack()
+			} else {
+				concurrent.Nack()
+			}
+		}()
+	}
+	wg.Wait()
+	if settled != 1 {
+		t.Errorf("concurrent Ack and Nack settled %d times, want 1", settled)
+	}
+
+	// Messages without ack or nack functions settle without panicking
+	(&Message{}).Ack()
+	(&Message{}).Nack()
+}
+
+func TestMemoryDelivers(t *testing.T) {
+	bus := newMemory(t, "pickups", Subscription{ID: "first", Topic: "pickups"}, Subscription{ID: "second", Topic: "pickups"})
+	if err := bus.EnsureTopic(t.Context(), "other"); err != nil {
+		t.Fatalf("EnsureTopic: %v", err)
+	}
+	if err := bus.Publish(t.Context(), "pickups", []byte("created"), map[string]string{"event_type": "pickup.created"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := bus.Publish(t.Context(), "other", []byte("elsewhere"), nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	// Subscriptions only receive what is published after they are created
+	if err := bus.EnsureSubscription(t.Context(), Subscription{ID: "late", Topic: "pickups"}); err != nil {
+		t.Fatalf("EnsureSubscription: %v", err)
+	}
+
+	deliveries := map[string]chan *Message{}
+	for _, id := range []string{"first", "second", "late"} {
+		ch := make(chan *Message, 4)
+		deliveries[id] = ch
+		stop := receive(t, bus, id, func(ctx context.Context, msg *Message) {
+			msg.Ack()
+			ch <- msg
+		})
+		defer stop()
+	}
+
+	// Every subscription gets its own copy of the message
+	first, second := next(t, deliveries["first"]), next(t, deliveries["second"])
+	for _, msg := range []*Message{first, second} {
+		if string(msg.Data) != "created" || msg.Topic != "pickups" || msg.Attributes["event_type"] != "pickup.created" ||
+			msg.DeliveryAttempt != 1 || msg.PublishTime.IsZero() {
+			t.Errorf("delivered %+v", msg)
+		}
+	}
+	if first.ID != second.ID {
+		t.Errorf("copies of a message have IDs %q and %q", first.ID, second.ID)
+	}
+	none(t, deliveries["first"], 50*time.Millisecond)
+	none(t, deliveries["late"], 0)
+}
+
+func TestMemoryRedelivers(t *testing.T) {
+	bus := newMemory(t, "pickups", Subscription{ID: "sub", Topic: "pickups"})
+
+	type delivery struct {
+		id      string
+		attempt int
+	}
+	deliveries := make(chan delivery, 8)
+	stop := receive(t, bus, "sub", func(ctx context.Context, msg *Message) {
+		deliveries <- delivery{msg.ID, msg.DeliveryAttempt}
+		switch msg.DeliveryAttempt {
+		case 1:
+			msg.Nack()
+		case 2:
+			// Neither acked nor nacked, which the bus treats as a nack
+		default:
+			msg.Ack()
+		}
+	})
+	defer stop()
+
+	if err := bus.Publish(t.Context(), "pickups", []byte("created"), nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	var id string
+	for attempt := 1; attempt <= 3; attempt++ {
+		got := next(t, deliveries)
+		if attempt == 1 {
+			id = got.id
+		}
+		if got.id != id || got.attempt != attempt {
+			t.Errorf("delivery %d = message %s attempt %d, want message %s attempt %d", attempt, got.id, got.attempt, id, attempt)
+		}
+	}
+	// Acked messages are not redelivered; the next redelivery would come after 400ms
+	none(t, deliveries, 600*time.Millisecond)
+}
+
+func TestMemoryErrors(t *testing.T) {
+	bus := newMemory(t, "pickups")
+	if err := bus.Publish(t.Context(), "unknown", nil, nil); err == nil {
+		t.Errorf("Publish(unknown topic) succeeded")
+	}
+	if err := bus.EnsureSubscription(t.Context(), Subscription{ID: "sub", Topic: "unknown"}); err == nil {
+		t.Errorf("EnsureSubscription(unknown topic) succeeded")
+	}
+	if err := bus.Receive(t.Context(), "unknown", func(context.Context, *Message) {}); err == nil {
+		t.Errorf("Receive(unknown subscription) succeeded")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := bus.Publish(t.Context(), "pickups", nil, nil); err == nil {
+		t.Errorf("Publish after Close succeeded")
+	}
+}
+
+func TestRedeliveryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{9, 25600 * time.Millisecond},
+		{10, 30 * time.Second},
+		{50, 30 * time.Second},
+	}
+	for _, test := range tests {
+		if got := redeliveryDelay(test.attempt); got != test.want {
+			t.Errorf("redeliveryDelay(%d) = %v, want %v", test.attempt, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// PubSub is a Bus backed by Google Cloud Pub/Sub.
+type PubSub struct {
+	client *pubsub.Client
+
+	mu            sync.Mutex
+	topics        map[string]*pubsub.Topic
+	subscriptions map[string]Subscription
+}
+
+// NewPubSub connects to Pub/Sub. The env variable GOOGLE_APPLICATION_CREDENTIALS is automatically
+// picked up by the client (env variables must be loaded before this).
+func NewPubSub(ctx context.Context, projectID string) (*PubSub, error) {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+	return &PubSub{
+		client:        client,
+		topics:        make(map[string]*pubsub.Topic),
+		subscriptions: make(map[string]Subscription),
+	}, nil
+}
+
+// topic reuses topic handles, each of which runs its own publishing goroutines.
+func (b *PubSub) topic(name string) *pubsub.Topic {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	topic, ok := b.topics[name]
+	if !ok {
+		topic = b.client.Topic(name)
+		b.topics[name] = topic
+	}
+	return topic
+}
+
+func (b *PubSub) Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error {
+	result := b.topic(topic).Publish(ctx, &pubsub.Message{
+		Data:       data,
+		Attributes: attributes,
+	})
+
+	// Confirming whether the message was published
+	if _, err := result.Get(ctx); err != nil {
+		return fmt.Errorf("failed to publish to topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (b *PubSub) EnsureTopic(ctx context.Context, topic string) error {
+	exists, err := b.client.Topic(topic).Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking topic %s: %w", topic, err)
+	}
+
+	if !exists {
+		if _, err := b.client.CreateTopic(ctx, topic); err != nil {
+			return fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+func (b *PubSub) EnsureSubscription(ctx context.Context, sub Subscription) error {
+	b.mu.Lock()
+	b.subscriptions[sub.ID] = sub
+	b.mu.Unlock()
+
+	subscription := b.client.Subscription(sub.ID)
+	exists, err := subscription.Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking subscription %s: %w", sub.ID, err)
+	}
+
+	if !exists {
+		_, err := b.client.CreateSubscription(ctx, sub.ID, pubsub.SubscriptionConfig{
+			Topic:  b.client.Topic(sub.Topic),
+			Filter: sub.Filter.expression(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create subscription %s: %w", sub.ID, err)
+		}
+		return nil
+	}
+
+	// Filters cannot be changed on existing subscriptions, Receive applies them instead
+	existing, err := subscription.Config(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading subscription %s: %w", sub.ID, err)
+	}
+	if existing.Filter != sub.Filter.expression() {
+		slog.Warn("subscription filter differs from the broker, filtering on receive",
+			slog.String("subscription", sub.ID),
+			slog.String("broker_filter", existing.Filter),
+			slog.String("filter", sub.Filter.expression()))
+	}
+	return nil
+}
+
+func (b *PubSub) Receive(ctx context.Context, subscriptionID string, handler Handler) error {
+	sub := b.client.Subscription(subscriptionID)
+
+	b.mu.Lock()
+	config := b.subscriptions[subscriptionID]
+	b.mu.Unlock()
+
+	return sub.Receive(ctx, func(ctx context.Context, m *pubsub.Message) {
+		if !config.Filter.Matches(m.Attributes) {
+			m.Ack()
+			return
+		}
+
+		msg := &Message{
+			ID:          m.ID,
+			Topic:       config.Topic,
+			Data:        m.Data,
+			Attributes:  m.Attributes,
+			PublishTime: m.PublishTime,
+			ack:         m.Ack,
+			nack:        m.Nack,
+		}
+		if m.DeliveryAttempt != nil {
+			msg.DeliveryAttempt = *m.DeliveryAttempt
+		}
+		handler(ctx, msg)
+	})
+}
+
+func (b *PubSub) Close() error {
+	b.mu.Lock()
+	for _, topic := range b.topics {
+		topic.Stop()
+	}
+	b.mu.Unlock()
+
+	return b.client.Close()
+}
@@ -0,0 +1,63 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DeadLetterFunc takes over a message that failed on its last allowed delivery attempt. lastErr is
+// the error passed to Fail, or a generic error if the handler only nacked.
+type DeadLetterFunc func(ctx context.Context, msg *Message, lastErr error) error
+
+// WithMaxAttempts bounds the deliveries of every message to handler. When a message fails on
+// attempt maxAttempts, deadLetter is called and the message is acked if it succeeds, so the
+// broker stops redelivering it. Brokers that do not count attempts are counted in-process, per
+// message ID.
+func WithMaxAttempts(handler Handler, maxAttempts int, deadLetter DeadLetterFunc) Handler {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+
+	return func(ctx context.Context, msg *Message) {
+		attempt := msg.DeliveryAttempt
+		if attempt == 0 {
+			mu.Lock()
+			attempts[msg.ID]++
+			attempt = attempts[msg.ID]
+			mu.Unlock()
+		}
+		forget := func() {
+			mu.Lock()
+			delete(attempts, msg.ID)
+			mu.Unlock()
+		}
+
+		var wrapped *Message
+		wrapped = msg.wrap(
+			func() {
+				forget()
+				msg.Ack()
+			},
+			func() {
+				if attempt < maxAttempts {
+					msg.Nack()
+					return
+				}
+
+				lastErr := wrapped.err
+				if lastErr == nil {
+					lastErr = fmt.Errorf("message nacked")
+				}
+				if err := deadLetter(ctx, wrapped, lastErr); err != nil {
+					// Trying again on the next delivery
+					msg.Nack()
+					return
+				}
+				forget()
+				msg.Ack()
+			},
+		)
+		wrapped.DeliveryAttempt = attempt
+		handler(ctx, wrapped)
+	}
+}
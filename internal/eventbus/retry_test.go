@@ -0,0 +1,148 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// settlement records how a message delivered to middleware was settled.
+type settlement struct {
+	acks, nacks int
+}
+
+func (s *settlement) message(id string, attempt int) *Message {
+	return &Message{
+		ID:              id,
+		DeliveryAttempt: attempt,
+		ack:             func() { s.acks++ },
+		nack:            func() { s.nacks++ },
+	}
+}
+
+func TestWithMaxAttempts(t *testing.T) {
+	failure := errors.New("collector not found")
+	tests := []struct {
+		name           string
+		attempt        int
+		handle         func(*Message)
+		deadLetterErr  error
+		wantAcks       int
+		wantNacks      int
+		wantDeadLetter error // nil if the message must not be dead-lettered
+	}{
+		{name: "acked", attempt: 1, handle: (*Message).Ack, wantAcks: 1},
+		{name: "acked on the last attempt", attempt: 3, handle: (*Message).Ack, wantAcks: 1},
+		{name: "failed before the last attempt", attempt: 2, handle: func(m *Message) { m.Fail(failure) }, wantNacks: 1},
+		{name: "failed on the last attempt", attempt: 3, handle: func(m *Message) { m.Fail(failure) }, wantAcks: 1, wantDeadLetter: failure},
+		{name: "failed after the last attempt", attempt: 5, handle: func(m *Message) { m.Fail(failure) }, wantAcks: 1, wantDeadLetter: failure},
+		{name: "nacked on the last attempt", attempt: 3, handle: (*Message).Nack, wantAcks: 1, wantDeadLetter: errors.New("message nacked")},
+		{
+			name: "dead-lettering failed", attempt: 3, handle: func(m *Message) { m.Fail(failure) },
+			deadLetterErr: errors.New("database down"), wantNacks: 1, wantDeadLetter: failure,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var deadLettered error
+			var deadLetteredAttempt int
+			handler := WithMaxAttempts(func(ctx context.Context, msg *Message) {
+				if msg.DeliveryAttempt != test.attempt {
+					t.Errorf("handler got attempt %d, want %d", msg.DeliveryAttempt, test.attempt)
+				}
+				test.handle(msg)
+			}, 3, func(ctx context.Context, msg *Message, lastErr error) error {
+				deadLettered, deadLetteredAttempt = lastErr, msg.DeliveryAttempt
+				return test.deadLetterErr
+			})
+
+			var s settlement
+			handler(t.Context(), s.message("1", test.attempt))
+			if s.acks != test.wantAcks || s.nacks != test.wantNacks {
+				t.Errorf("settled with %d acks and %d nacks, want %d and %d", s.acks, s.nacks, test.wantAcks, test.wantNacks)
+			}
+			switch {
+			case test.wantDeadLetter == nil && deadLettered != nil:
+				t.Errorf("dead-lettered with %v", deadLettered)
+			case test.wantDeadLetter != nil && (deadLettered == nil || deadLettered.Error() != test.wantDeadLetter.Error()):
+				t.Errorf("dead-lettered with %v, want %v", deadLettered, test.wantDeadLetter)
+			case test.wantDeadLetter != nil && deadLetteredAttempt != test.attempt:
+				t.Errorf("dead-lettered attempt %d, want %d", deadLetteredAttempt, test.attempt)
+			}
+		})
+	}
+}
+
+// Brokers without delivery attempts are counted per message ID, until the message is settled for
+// good.
+func TestWithMaxAttemptsCountsInProcess(t *testing.T) {
+	var attempts []int
+	fail := true
+	deadLetters := 0
+	handler := WithMaxAttempts(func(ctx context.Context, msg *Message) {
+		attempts = append(attempts, msg.DeliveryAttempt)
+		if fail {
+			msg.Fail(errors.New("broker down"))
+		} else {
+			msg.Ack()
+		}
+	}, 3, func(ctx context.Context, msg *Message, lastErr error) error {
+		deadLetters++
+		return nil
+	})
+
+	var s settlement
+	for range 3 {
+		handler(t.Context(), s.message("a", 0))
+	}
+	handler(t.Context(), s.message("b", 0))
+	if s.nacks != 3 || s.acks != 1 || deadLetters != 1 {
+		t.Errorf("settled with %d acks, %d nacks and %d dead letters, want 1, 3 and 1", s.acks, s.nacks, deadLetters)
+	}
+
+	// Dead-lettered and acked messages start counting again
+	fail = false
+	handler(t.Context(), s.message("a", 0))
+	handler(t.Context(), s.message("b", 0))
+	handler(t.Context(), s.message("b", 0))
+
+	want := []int{1, 2, 3, 1, 1, 2, 1}
+	if len(attempts) != len(want) {
+		t.Fatalf("handler got attempts %v, want %v", attempts, want)
+	}
+	for i := range want {
+		if attempts[i] != want[i] {
+			t.Errorf("handler got attempts %v, want %v", attempts, want)
+			break
+		}
+	}
+}
+
+// On the memory bus, a message failing every attempt is dead-lettered once and then left alone.
+func TestWithMaxAttemptsOnMemory(t *testing.T) {
+	bus := newMemory(t, "pickups", Subscription{ID: "sub", Topic: "pickups"})
+	attempts := make(chan int, 8)
+	deadLetters := make(chan error, 4)
+	stop := receive(t, bus, "sub", WithMaxAttempts(func(ctx context.Context, msg *Message) {
+		attempts <- msg.DeliveryAttempt
+		msg.Fail(errors.New("collector not found"))
+	}, 3, func(ctx context.Context, msg *Message, lastErr error) error {
+		deadLetters <- lastErr
+		return nil
+	}))
+	defer stop()
+
+	if err := bus.Publish(t.Context(), "pickups", []byte("created"), nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	for want := 1; want <= 3; want++ {
+		if got := next(t, attempts); got != want {
+			t.Errorf("attempt %d, want %d", got, want)
+		}
+	}
+	if err := next(t, deadLetters); err == nil || err.Error() != "collector not found" {
+		t.Errorf("dead-lettered with %v, want the handler's failure", err)
+	}
+	none(t, attempts, 600*time.Millisecond)
+}
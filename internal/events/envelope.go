@@ -0,0 +1,124 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Version is the envelope version written by this build. Decode rejects newer versions.
+const Version = 1
+
+type Type string
+
+// Event types, each published on a single topic
+const (
+	PickupCreated     Type = "pickup.created"
+	PickupAccepted    Type = "pickup.accepted"
+	PickupRejected    Type = "pickup.rejected"
+	PickupCancelled   Type = "pickup.cancelled"
+	DriverAssigned    Type = "pickup.assigned"
+	DriverUnassigned  Type = "pickup.unassigned"
+	DeliveryStarted   Type = "delivery.started"
+	DeliveryCompleted Type = "delivery.completed"
+	DriverLocation    Type = "driver.location"
+	DriverArrived     Type = "driver.arrived"
+	DriverDeparted    Type = "driver.departed"
+)
+
+var topics = map[Type]string{
+	PickupCreated:     PickupRequestsTopic,
+	PickupAccepted:    PickupRequestsTopic,
+	PickupRejected:    PickupRequestsTopic,
+	PickupCancelled:   PickupRequestsTopic,
+	DriverAssigned:    AssignmentsTopic,
+	DriverUnassigned:  AssignmentsTopic,
+	DeliveryStarted:   DeliveryTopic,
+	DeliveryCompleted: DeliveryTopic,
+	DriverLocation:    DriverLocationTopic,
+	DriverArrived:     GeofenceTopic,
+	DriverDeparted:    GeofenceTopic,
+}
+
+// Topic returns the topic events of this type are published on.
+func (t Type) Topic() string {
+	return topics[t]
+}
+
+// Message attributes set on every published envelope, so that subscriptions can filter by type
+// without decoding the payload
+const (
+	TypeAttribute    = "event_type"
+	IDAttribute      = "event_id"
+	VersionAttribute = "event_version"
+)
+
+// Envelope wraps the payload of every event published on the bus.
+type Envelope struct {
+	Version    int             `json:"version"`
+	ID         string          `json:"id"`
+	Type       Type            `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      *types.Actor    `json:"actor,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// New wraps payload in an envelope with a fresh ID. actor is nil for events not caused by a user.
+func New(eventType Type, actor *types.Actor, payload any) (Envelope, error) {
+	if eventType.Topic() == "" {
+		return Envelope{}, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	return Envelope{
+		Version:    Version,
+		ID:         NewID(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Actor:      actor,
+		Payload:    data,
+	}, nil
+}
+
+// Attributes returns the message attributes to publish the envelope with.
+func (e Envelope) Attributes() map[string]string {
+	return map[string]string{
+		TypeAttribute:    string(e.Type),
+		IDAttribute:      e.ID,
+		VersionAttribute: fmt.Sprint(e.Version),
+	}
+}
+
+// Decode parses an envelope from message data and unmarshals its payload into payload.
+func Decode(data []byte, payload any) (Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+	}
+	if envelope.Version < 1 || envelope.Version > Version {
+		return Envelope{}, fmt.Errorf("unsupported event envelope version %d", envelope.Version)
+	}
+
+	if payload != nil {
+		if err := json.Unmarshal(envelope.Payload, payload); err != nil {
+			return Envelope{}, fmt.Errorf("failed to unmarshal %s payload: %w", envelope.Type, err)
+		}
+	}
+	return envelope, nil
+}
+
+// NewID returns a random UUID (version 4), the format of envelope IDs.
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
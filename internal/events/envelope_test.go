@@ -0,0 +1,127 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	actor := &types.Actor{UserID: 7, Role: "Collector"}
+	payload := types.PickupRequest{RequestID: 42, BusinessID: 3, CollectorID: 7, Quantity: 120.5, Status: "Accepted"}
+
+	before := time.Now()
+	envelope, err := New(PickupAccepted, actor, payload)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if envelope.Version != Version || envelope.Type != PickupAccepted || envelope.Actor != actor ||
+		envelope.OccurredAt.Before(before.Add(-time.Second)) || envelope.OccurredAt.Location() != time.UTC {
+		t.Errorf("New = %+v", envelope)
+	}
+
+	attributes := envelope.Attributes()
+	want := map[string]string{TypeAttribute: "pickup.accepted", IDAttribute: envelope.ID, VersionAttribute: "1"}
+	if len(attributes) != len(want) {
+		t.Errorf("Attributes() = %v, want %v", attributes, want)
+	}
+	for key, value := range want {
+		if attributes[key] != value {
+			t.Errorf("Attributes()[%s] = %q, want %q", key, attributes[key], value)
+		}
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decodedPayload types.PickupRequest
+	decoded, err := Decode(data, &decodedPayload)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.ID != envelope.ID || decoded.Type != envelope.Type || decoded.Version != envelope.Version ||
+		!decoded.OccurredAt.Equal(envelope.OccurredAt) || decoded.Actor == nil || *decoded.Actor != *actor {
+		t.Errorf("Decode = %+v, want %+v", decoded, envelope)
+	}
+	if decodedPayload.RequestID != 42 || decodedPayload.Quantity != 120.5 || decodedPayload.Status != "Accepted" {
+		t.Errorf("decoded payload = %+v, want %+v", decodedPayload, payload)
+	}
+
+	// Events not caused by a user have no actor, and the payload can be left undecoded
+	system, err := New(DriverLocation, nil, map[string]float64{"latitude": 18.5})
+	if err != nil {
+		t.Fatalf("New(without actor): %v", err)
+	}
+	data, _ = json.Marshal(system)
+	decoded, err = Decode(data, nil)
+	if err != nil || decoded.Actor != nil || decoded.Type != DriverLocation || string(decoded.Payload) != `{"latitude":18.5}` {
+		t.Errorf("Decode(without actor) = %+v, %v", decoded, err)
+	}
+}
+
+func TestNewRejectsUnknownTypes(t *testing.T) {
+	if _, err := New("pickup.lost", nil, nil); err == nil {
+		t.Errorf("New(unknown type) succeeded")
+	}
+	if _, err := New(PickupCreated, nil, func() {}); err == nil {
+		t.Errorf("New(unmarshalable payload) succeeded")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not JSON", `pickup`},
+		{"no version", `{"id":"1","type":"pickup.created","payload":{}}`},
+		{"newer version", fmt.Sprintf(`{"version":%d,"id":"1","type":"pickup.created","payload":{}}`, Version+1)},
+		{"payload of another shape", `{"version":1,"id":"1","type":"pickup.created","payload":"created"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var payload types.PickupRequest
+			if envelope, err := Decode([]byte(test.data), &payload); err == nil {
+				t.Errorf("Decode(%s) = %+v, want an error", test.data, envelope)
+			}
+		})
+	}
+}
+
+func TestTopics(t *testing.T) {
+	all := []Type{
+		PickupCreated, PickupAccepted, PickupRejected, PickupCancelled, DriverAssigned, DriverUnassigned,
+		DeliveryStarted, DeliveryCompleted, DriverLocation, DriverArrived, DriverDeparted,
+	}
+	if len(all) != len(topics) {
+		t.Errorf("%d event types have topics, want %d", len(topics), len(all))
+	}
+	for _, eventType := range all {
+		if eventType.Topic() == "" {
+			t.Errorf("%s has no topic", eventType)
+		}
+	}
+	if topic := Type("pickup.lost").Topic(); topic != "" {
+		t.Errorf("unknown type has topic %q", topic)
+	}
+}
+
+func TestNewID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for range 100 {
+		id := NewID()
+		if !uuid.MatchString(id) {
+			t.Fatalf("NewID() = %s, want a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewID() returned %s twice", id)
+		}
+		seen[id] = true
+	}
+}
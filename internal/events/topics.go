@@ -0,0 +1,10 @@
+package events
+
+// Topics carried by the event broker
+const (
+	PickupRequestsTopic = "PICKUP-REQUESTS"
+	DriverLocationTopic = "DRIVER-LOCATION"
+	DeliveryTopic       = "DELIVERY"
+	AssignmentsTopic    = "ASSIGNMENTS"
+	GeofenceTopic       = "GEOFENCE"
+)
@@ -0,0 +1,140 @@
+package geo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Content types of the encodings, for HTTP responses.
+const (
+	GPXContentType     = "application/gpx+xml"
+	KMLContentType     = "application/vnd.google-earth.kml+xml"
+	GeoJSONContentType = "application/geo+json"
+)
+
+type gpxDocument struct {
+	XMLName xml.Name `xml:"gpx"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Track   struct {
+		Name    string `xml:"name"`
+		Segment struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time,omitempty"`
+}
+
+// WriteGPX encodes a track as a GPX 1.1 document with a single track segment.
+func WriteGPX(w io.Writer, track Track) error {
+	doc := gpxDocument{Xmlns: "http://www.topografix.com/GPX/1/1", Version: "1.1", Creator: "build-in-progress_01"}
+	doc.Track.Name = track.Name
+	for _, point := range track.Points {
+		doc.Track.Segment.Points = append(doc.Track.Segment.Points, gpxPoint{Lat: point.Lat, Lon: point.Lng, Time: timestamp(point.Time)})
+	}
+	return writeXML(w, doc)
+}
+
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Document struct {
+		Name      string `xml:"name"`
+		Placemark struct {
+			Name     string `xml:"name"`
+			TimeSpan *struct {
+				Begin string `xml:"begin"`
+				End   string `xml:"end"`
+			} `xml:"TimeSpan,omitempty"`
+			LineString struct {
+				Tessellate  int    `xml:"tessellate"`
+				Coordinates string `xml:"coordinates"`
+			} `xml:"LineString"`
+		} `xml:"Placemark"`
+	} `xml:"Document"`
+}
+
+// WriteKML encodes a track as a KML 2.2 placemark holding a line string, with the time span of
+// the track.
+func WriteKML(w io.Writer, track Track) error {
+	doc := kmlDocument{Xmlns: "http://www.opengis.net/kml/2.2"}
+	doc.Document.Name = track.Name
+	placemark := &doc.Document.Placemark
+	placemark.Name = track.Name
+	placemark.LineString.Tessellate = 1
+
+	coordinates := make([]string, 0, len(track.Points))
+	for _, point := range track.Points {
+		coordinates = append(coordinates, fmt.Sprintf("%g,%g,0", point.Lng, point.Lat))
+	}
+	placemark.LineString.Coordinates = strings.Join(coordinates, " ")
+
+	if len(track.Points) > 0 {
+		placemark.TimeSpan = &struct {
+			Begin string `xml:"begin"`
+			End   string `xml:"end"`
+		}{Begin: timestamp(track.Points[0].Time), End: timestamp(track.Points[len(track.Points)-1].Time)}
+	}
+	return writeXML(w, doc)
+}
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string       `json:"type"`
+		Coordinates [][2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Name       string    `json:"name"`
+		CoordTimes []string  `json:"coordTimes"` // The convention of togeojson and Mapbox
+		Speeds     []float64 `json:"speeds"`     // km/h
+	} `json:"properties"`
+}
+
+// WriteGeoJSON encodes a track as a GeoJSON LineString feature, with the times and speeds of its
+// points as properties.
+func WriteGeoJSON(w io.Writer, track Track) error {
+	feature := geoJSONFeature{Type: "Feature"}
+	feature.Geometry.Type = "LineString"
+	feature.Geometry.Coordinates = make([][2]float64, 0, len(track.Points))
+	feature.Properties.Name = track.Name
+	feature.Properties.CoordTimes = make([]string, 0, len(track.Points))
+	feature.Properties.Speeds = make([]float64, 0, len(track.Points))
+	for _, point := range track.Points {
+		// GeoJSON positions are longitude first
+		feature.Geometry.Coordinates = append(feature.Geometry.Coordinates, [2]float64{point.Lng, point.Lat})
+		feature.Properties.CoordTimes = append(feature.Properties.CoordTimes, timestamp(point.Time))
+		feature.Properties.Speeds = append(feature.Properties.Speeds, point.Speed)
+	}
+	return json.NewEncoder(w).Encode(feature)
+}
+
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,109 @@
+// Package geo holds the geometry of driver tracks: distances on the earth's surface, trace
+// simplification and the GPX, KML and GeoJSON encodings.
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6_371_008.8
+
+// Point is a WGS84 position in degrees.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// TrackPoint is a position of a track with the time it was recorded.
+type TrackPoint struct {
+	Point
+	Time  time.Time
+	Speed float64 // Reported by the device in km/h, 0 if unknown
+}
+
+// Track is a named path, ordered by time.
+type Track struct {
+	Name   string
+	Points []TrackPoint
+}
+
+// Distance returns the great-circle distance between two points in meters, by the haversine
+// formula.
+func Distance(a Point, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox returns the south-west and north-east corners of a box holding every point within
+// radius meters of center, for narrowing down candidates with an index before measuring the
+// distances. Boxes reaching a pole or the antimeridian span every longitude.
+func BoundingBox(center Point, radius float64) (sw Point, ne Point) {
+	dLat := degrees(radius / EarthRadius)
+	sw.Lat, ne.Lat = center.Lat-dLat, center.Lat+dLat
+	if sw.Lat <= -90 || ne.Lat >= 90 {
+		return Point{Lat: max(sw.Lat, -90), Lng: -180}, Point{Lat: min(ne.Lat, 90), Lng: 180}
+	}
+
+	dLng := degrees(math.Asin(math.Min(1, math.Sin(radius/EarthRadius)/math.Cos(radians(center.Lat)))))
+	sw.Lng, ne.Lng = center.Lng-dLng, center.Lng+dLng
+	if sw.Lng < -180 || ne.Lng > 180 {
+		sw.Lng, ne.Lng = -180, 180
+	}
+	return sw, ne
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// Summary is the distance and timing of a track.
+type Summary struct {
+	Distance     float64       // Meters, along the track
+	Duration     time.Duration // From the first point to the last
+	AverageSpeed float64       // km/h
+	MaxSpeed     float64       // km/h, of the reported speeds
+}
+
+// Summarize measures a track. The average speed weighs the reported speeds by the time until
+// the next point, which holds up better than distance over duration when GPS fixes jitter
+// around stops. Tracks without reported speeds use distance over duration.
+func Summarize(points []TrackPoint) Summary {
+	var summary Summary
+	if len(points) < 2 {
+		return summary
+	}
+
+	var weighted, reported float64
+	for i := 1; i < len(points); i++ {
+		prev, next := points[i-1], points[i]
+		summary.Distance += Distance(prev.Point, next.Point)
+
+		if prev.Speed > 0 {
+			seconds := next.Time.Sub(prev.Time).Seconds()
+			weighted += prev.Speed * seconds
+			reported += seconds
+		}
+	}
+	for _, point := range points {
+		summary.MaxSpeed = math.Max(summary.MaxSpeed, point.Speed)
+	}
+
+	summary.Duration = points[len(points)-1].Time.Sub(points[0].Time)
+	switch {
+	case reported > 0:
+		summary.AverageSpeed = weighted / reported
+	case summary.Duration > 0:
+		summary.AverageSpeed = summary.Distance / summary.Duration.Seconds() * 3.6
+	}
+	return summary
+}
@@ -0,0 +1,158 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxAreaVertices bounds the positions of an area, which is checked against every pickup.
+const MaxAreaVertices = 10_000
+
+// Polygon is an outer ring with holes in it. Rings are closed, their last position repeating the
+// first.
+type Polygon struct {
+	Outer []Point
+	Holes [][]Point
+}
+
+// Area is a union of polygons.
+type Area []Polygon
+
+// Contains reports whether p is inside the area, by casting a ray from p across the rings of
+// its polygons on a plane of longitudes and latitudes, which is accurate enough for areas of a
+// city or a state. Points on the boundary of a polygon, the boundaries of its holes included,
+// are inside it. Areas across the antimeridian are not supported.
+func (a Area) Contains(p Point) bool {
+	for _, polygon := range a {
+		if !onRing(polygon.Outer, p) && !inRing(polygon.Outer, p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon.Holes {
+			if inRing(hole, p) && !onRing(hole, p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+func inRing(ring []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) && p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// boundaryTolerance is how far off a ring, in degrees, a point still counts as on it: about a
+// centimeter, well below the precision of a geocoded address.
+const boundaryTolerance = 1e-7
+
+// onRing reports whether p is on one of the edges of ring, which the ray cast counts as inside
+// or outside depending on its side.
+func onRing(ring []Point, p Point) bool {
+	for i := 1; i < len(ring); i++ {
+		a, b := ring[i-1], ring[i]
+		if p.Lng < min(a.Lng, b.Lng)-boundaryTolerance || p.Lng > max(a.Lng, b.Lng)+boundaryTolerance ||
+			p.Lat < min(a.Lat, b.Lat)-boundaryTolerance || p.Lat > max(a.Lat, b.Lat)+boundaryTolerance {
+			continue
+		}
+		// The distance of p from the line through a and b
+		cross := (b.Lng-a.Lng)*(p.Lat-a.Lat) - (b.Lat-a.Lat)*(p.Lng-a.Lng)
+		if math.Abs(cross) <= boundaryTolerance*math.Hypot(b.Lng-a.Lng, b.Lat-a.Lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGeoJSONArea decodes a GeoJSON Polygon or MultiPolygon, bare or as the geometry of a
+// Feature, checking that its rings are closed and its positions are on the earth.
+func ParseGeoJSONArea(data []byte) (Area, error) {
+	var object struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	var polygons [][][][]float64
+	switch object.Type {
+	case "Feature":
+		if len(object.Geometry) == 0 || string(object.Geometry) == "null" {
+			return nil, errors.New("the GeoJSON feature has no geometry")
+		}
+		return ParseGeoJSONArea(object.Geometry)
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		polygons = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("GeoJSON areas are Polygons or MultiPolygons, not %q", object.Type)
+	}
+	if len(polygons) == 0 {
+		return nil, errors.New("the GeoJSON area has no polygons")
+	}
+
+	area := make(Area, 0, len(polygons))
+	vertices := 0
+	for _, rings := range polygons {
+		if len(rings) == 0 {
+			return nil, errors.New("a polygon has no rings")
+		}
+		var polygon Polygon
+		for i, positions := range rings {
+			vertices += len(positions)
+			if vertices > MaxAreaVertices {
+				return nil, fmt.Errorf("the area has more than %d positions", MaxAreaVertices)
+			}
+			ring, err := parseRing(positions)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				polygon.Outer = ring
+			} else {
+				polygon.Holes = append(polygon.Holes, ring)
+			}
+		}
+		area = append(area, polygon)
+	}
+	return area, nil
+}
+
+func parseRing(positions [][]float64) ([]Point, error) {
+	if len(positions) < 4 {
+		return nil, errors.New("a ring has fewer than 4 positions")
+	}
+	ring := make([]Point, len(positions))
+	for i, position := range positions {
+		// GeoJSON positions are longitude first, with an optional altitude
+		if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return nil, fmt.Errorf("invalid position %v", position)
+		}
+		ring[i] = Point{Lat: position[1], Lng: position[0]}
+	}
+	if ring[0] != ring[len(ring)-1] {
+		return nil, errors.New("a ring is not closed, its last position has to repeat the first")
+	}
+	return ring, nil
+}
@@ -0,0 +1,120 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAreaContains(t *testing.T) {
+	// A 10 x 10 square with a 2 x 2 hole in the middle, and a second square east of it
+	area, err := ParseGeoJSONArea([]byte(`{
+		"type": "MultiPolygon",
+		"coordinates": [
+			[
+				[[73, 18], [73.1, 18], [73.1, 18.1], [73, 18.1], [73, 18]],
+				[[73.04, 18.04], [73.06, 18.04], [73.06, 18.06], [73.04, 18.06], [73.04, 18.04]]
+			],
+			[
+				[[73.2, 18], [73.3, 18], [73.3, 18.1], [73.2, 18.1], [73.2, 18]]
+			]
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseGeoJSONArea: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{"inside", Point{Lat: 18.02, Lng: 73.02}, true},
+		{"outside", Point{Lat: 18.2, Lng: 73.02}, false},
+		{"between the polygons", Point{Lat: 18.05, Lng: 73.15}, false},
+		{"inside the second polygon", Point{Lat: 18.05, Lng: 73.25}, true},
+		{"in the hole", Point{Lat: 18.05, Lng: 73.05}, false},
+		{"on the edge of the hole", Point{Lat: 18.05, Lng: 73.06}, true},
+		{"on a vertex of the hole", Point{Lat: 18.04, Lng: 73.04}, true},
+		{"on the west edge", Point{Lat: 18.05, Lng: 73}, true},
+		{"on the east edge", Point{Lat: 18.05, Lng: 73.1}, true},
+		{"on the south edge", Point{Lat: 18, Lng: 73.05}, true},
+		{"on the north edge", Point{Lat: 18.1, Lng: 73.05}, true},
+		{"on the south-west vertex", Point{Lat: 18, Lng: 73}, true},
+		{"on the north-east vertex", Point{Lat: 18.1, Lng: 73.1}, true},
+		{"in line with an edge, past its end", Point{Lat: 18.1, Lng: 73.15}, false},
+		{"just outside an edge", Point{Lat: 18.05, Lng: 73.10001}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := area.Contains(test.point); got != test.want {
+				t.Errorf("Contains(%v) = %v, want %v", test.point, got, test.want)
+			}
+		})
+	}
+
+	// A concave polygon, a U open to the north
+	u, err := ParseGeoJSONArea([]byte(`{"type": "Polygon", "coordinates": [[[0, 0], [3, 0], [3, 3], [2, 3], [2, 1], [1, 1], [1, 3], [0, 3], [0, 0]]]}`))
+	if err != nil {
+		t.Fatalf("ParseGeoJSONArea(concave): %v", err)
+	}
+	if !u.Contains(Point{Lat: 2, Lng: 0.5}) || u.Contains(Point{Lat: 2, Lng: 1.5}) || !u.Contains(Point{Lat: 2, Lng: 1}) {
+		t.Errorf("concave polygon containment is wrong")
+	}
+}
+
+func TestParseGeoJSONArea(t *testing.T) {
+	tests := []struct {
+		name     string
+		geojson  string
+		polygons int
+		holes    int
+		err      string // A part of the error, empty if the area is valid
+	}{
+		{
+			name:     "polygon",
+			geojson:  `{"type": "Polygon", "coordinates": [[[73, 18], [73.1, 18], [73.1, 18.1], [73, 18]]]}`,
+			polygons: 1,
+		},
+		{
+			name:     "polygon with a hole and altitudes",
+			geojson:  `{"type": "Polygon", "coordinates": [[[73, 18, 5], [73.1, 18, 5], [73.1, 18.1, 5], [73, 18, 5]], [[73.05, 18.01], [73.08, 18.01], [73.08, 18.04], [73.05, 18.01]]]}`,
+			polygons: 1, holes: 1,
+		},
+		{
+			name:     "feature",
+			geojson:  `{"type": "Feature", "properties": {}, "geometry": {"type": "MultiPolygon", "coordinates": [[[[73, 18], [73.1, 18], [73.1, 18.1], [73, 18]]], [[[74, 18], [74.1, 18], [74.1, 18.1], [74, 18]]]]}}`,
+			polygons: 2,
+		},
+		{name: "unclosed ring", geojson: `{"type": "Polygon", "coordinates": [[[73, 18], [73.1, 18], [73.1, 18.1], [73, 18.1]]]}`, err: "not closed"},
+		{name: "ring of 3 positions", geojson: `{"type": "Polygon", "coordinates": [[[73, 18], [73.1, 18], [73, 18]]]}`, err: "fewer than 4"},
+		{name: "latitude out of range", geojson: `{"type": "Polygon", "coordinates": [[[73, 18], [73.1, 95], [73.1, 18.1], [73, 18]]]}`, err: "invalid position"},
+		{name: "position without latitude", geojson: `{"type": "Polygon", "coordinates": [[[73, 18], [73.1], [73.1, 18.1], [73, 18]]]}`, err: "invalid position"},
+		{name: "unclosed hole", geojson: `{"type": "Polygon", "coordinates": [[[73, 18], [73.1, 18], [73.1, 18.1], [73, 18]], [[73.05, 18.01], [73.08, 18.01], [73.08, 18.04], [73.06, 18.02]]]}`, err: "not closed"},
+		{name: "polygon without rings", geojson: `{"type": "Polygon", "coordinates": []}`, err: "no rings"},
+		{name: "empty multipolygon", geojson: `{"type": "MultiPolygon", "coordinates": []}`, err: "no polygons"},
+		{name: "point", geojson: `{"type": "Point", "coordinates": [73, 18]}`, err: "not \"Point\""},
+		{name: "feature without geometry", geojson: `{"type": "Feature", "geometry": null}`, err: "no geometry"},
+		{name: "not JSON", geojson: `{"type": `, err: "invalid GeoJSON"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			area, err := ParseGeoJSONArea([]byte(test.geojson))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("ParseGeoJSONArea = %v, want an error about %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseGeoJSONArea: %v", err)
+			}
+			holes := 0
+			for _, polygon := range area {
+				holes += len(polygon.Holes)
+			}
+			if len(area) != test.polygons || holes != test.holes {
+				t.Errorf("ParseGeoJSONArea = %d polygons with %d holes, want %d with %d", len(area), holes, test.polygons, test.holes)
+			}
+		})
+	}
+}
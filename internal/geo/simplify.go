@@ -0,0 +1,67 @@
+package geo
+
+import "math"
+
+// Simplify reduces a track by the Douglas–Peucker algorithm and returns the indexes of the
+// points kept, in order. A point is dropped when it lies within tolerance meters of the line
+// between the points kept around it. The first and last points are always kept, and a
+// tolerance of 0 or less keeps every point.
+func Simplify(points []Point, tolerance float64) []int {
+	if len(points) <= 2 || tolerance <= 0 {
+		kept := make([]int, len(points))
+		for i := range points {
+			kept[i] = i
+		}
+		return kept
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// Iterating over the segments still to split rather than recursing, as long traces would
+	// otherwise recurse once per point in the worst case
+	type segment struct{ first, last int }
+	stack := []segment{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		seg := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDistance := -1, 0.0
+		for i := seg.first + 1; i < seg.last; i++ {
+			if d := crossTrackDistance(points[i], points[seg.first], points[seg.last]); d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest >= 0 && maxDistance > tolerance {
+			keep[farthest] = true
+			stack = append(stack, segment{seg.first, farthest}, segment{farthest, seg.last})
+		}
+	}
+
+	var kept []int
+	for i, k := range keep {
+		if k {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// crossTrackDistance returns the distance in meters from p to the segment between a and b. At
+// the scale of a trip, an equirectangular projection around a is accurate enough.
+func crossTrackDistance(p Point, a Point, b Point) float64 {
+	scale := math.Cos(radians(a.Lat))
+	project := func(q Point) (x float64, y float64) {
+		return radians(q.Lng-a.Lng) * scale * EarthRadius, radians(q.Lat-a.Lat) * EarthRadius
+	}
+	px, py := project(p)
+	bx, by := project(b)
+
+	length := bx*bx + by*by
+	if length == 0 {
+		return math.Hypot(px, py)
+	}
+	// The closest point of the segment, clamped to its ends
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/length))
+	return math.Hypot(px-t*bx, py-t*by)
+}
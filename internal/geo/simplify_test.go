@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSimplify(t *testing.T) {
+	// Along the 18.52 parallel, where 0.0005 degrees of latitude are about 55.6 m
+	line := func(lngs ...float64) []Point {
+		points := make([]Point, len(lngs))
+		for i, lng := range lngs {
+			points[i] = Point{Lat: 18.52, Lng: lng}
+		}
+		return points
+	}
+	spike := func(offset float64) []Point {
+		points := line(73.80, 73.81, 73.82)
+		points[1].Lat += offset
+		return points
+	}
+
+	tests := []struct {
+		name      string
+		points    []Point
+		tolerance float64
+		want      []int
+	}{
+		{"no points", nil, 10, []int{}},
+		{"one point", line(73.80), 10, []int{0}},
+		{"two points", line(73.80, 73.81), 10, []int{0, 1}},
+		{"straight line", line(73.80, 73.805, 73.81, 73.815, 73.82), 1, []int{0, 4}},
+		{"spike above the tolerance", spike(0.0005), 50, []int{0, 1, 2}},
+		{"spike below the tolerance", spike(0.0005), 60, []int{0, 2}},
+		{"spike below the line", spike(-0.0005), 50, []int{0, 1, 2}},
+		{"zero tolerance", spike(0.00001), 0, []int{0, 1, 2}},
+		{
+			// The spike splits the line, and only the far half has a detour worth keeping
+			"nested detours",
+			[]Point{{18.52, 73.80}, {18.52501, 73.805}, {18.53, 73.81}, {18.5205, 73.815}, {18.52, 73.82}},
+			50,
+			[]int{0, 2, 3, 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Simplify(test.points, test.tolerance); !slices.Equal(got, test.want) {
+				t.Errorf("Simplify = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	a := Point{Lat: 18.52, Lng: 73.80}
+	b := Point{Lat: 18.53, Lng: 73.80}
+	c := Point{Lat: 18.54, Lng: 73.80}
+	at := func(p Point, seconds int, speed float64) TrackPoint {
+		return TrackPoint{Point: p, Time: start.Add(time.Duration(seconds) * time.Second), Speed: speed}
+	}
+
+	tests := []struct {
+		name   string
+		points []TrackPoint
+		want   Summary
+	}{
+		{"no points", nil, Summary{}},
+		{"one point", []TrackPoint{at(a, 0, 30)}, Summary{}},
+		{
+			"without reported speeds",
+			[]TrackPoint{at(a, 0, 0), at(b, 120, 0)},
+			Summary{Distance: Distance(a, b), Duration: 2 * time.Minute, AverageSpeed: Distance(a, b) / 120 * 3.6},
+		},
+		{
+			// 30 km/h for a minute and 60 km/h for another, the speed of the last point counting
+			// for no time
+			"with reported speeds",
+			[]TrackPoint{at(a, 0, 30), at(b, 60, 60), at(c, 120, 90)},
+			Summary{Distance: Distance(a, b) + Distance(b, c), Duration: 2 * time.Minute, AverageSpeed: 45, MaxSpeed: 90},
+		},
+		{
+			// Standing points weigh nothing
+			"with a stop",
+			[]TrackPoint{at(a, 0, 40), at(b, 60, 0), at(b, 300, 40), at(c, 360, 0)},
+			Summary{Distance: Distance(a, b) + Distance(b, c), Duration: 6 * time.Minute, AverageSpeed: 40, MaxSpeed: 40},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Summarize(test.points)
+			if math.Abs(got.Distance-test.want.Distance) > 1e-6 || got.Duration != test.want.Duration ||
+				math.Abs(got.AverageSpeed-test.want.AverageSpeed) > 1e-6 || got.MaxSpeed != test.want.MaxSpeed {
+				t.Errorf("Summarize = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
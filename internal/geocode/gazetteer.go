@@ -0,0 +1,145 @@
+package geocode
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+)
+
+//go:embed gazetteer.csv
+var embeddedGazetteer string
+
+// Gazetteer locates addresses by the pincode they contain, or else by the name of a place in
+// them. Pincodes match exactly, or by their first three digits, the sorting district, which
+// is about as precise as a city. Names match whole words, longer names first so that "Navi
+// Mumbai" is not taken for Mumbai, and later ones first, as addresses end with the city.
+type Gazetteer struct {
+	pincodes map[string]geo.Point // Of six digits, or their first three
+	places   map[string]geo.Point // Lower case words, separated by single spaces
+	words    int                  // Of the longest place name
+}
+
+// NewGazetteer returns the embedded gazetteer, with the places and pincodes of the CSV file at
+// path added if it is set.
+func NewGazetteer(path string) (*Gazetteer, error) {
+	g := &Gazetteer{pincodes: make(map[string]geo.Point), places: make(map[string]geo.Point)}
+	if err := g.Load(strings.NewReader(embeddedGazetteer)); err != nil {
+		return nil, fmt.Errorf("failed to load the embedded gazetteer: %w", err)
+	}
+	if path == "" {
+		return g, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the gazetteer: %w", err)
+	}
+	defer file.Close()
+	if err := g.Load(file); err != nil {
+		return nil, fmt.Errorf("failed to load the gazetteer %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// Load adds the rows of a CSV with a place name, a six digit pincode or the first three digits
+// of pincodes, then the latitude and the longitude. Rows override earlier ones of the same
+// place, lines starting with # are comments, and a first row starting with "place" is a header.
+func (g *Gazetteer) Load(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first && record[0] == "place" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		lat, err := strconv.ParseFloat(record[1], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return fmt.Errorf("line %d: invalid latitude %q", line, record[1])
+		}
+		lng, err := strconv.ParseFloat(record[2], 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return fmt.Errorf("line %d: invalid longitude %q", line, record[2])
+		}
+		point := geo.Point{Lat: lat, Lng: lng}
+
+		place := record[0]
+		switch {
+		case isDigits(place) && (len(place) == 6 || len(place) == 3):
+			g.pincodes[place] = point
+		case isDigits(place):
+			return fmt.Errorf("line %d: pincodes have six digits, or three for a sorting district: %q", line, place)
+		default:
+			words := splitWords(place)
+			if len(words) == 0 {
+				return fmt.Errorf("line %d: empty place name", line)
+			}
+			g.places[strings.Join(words, " ")] = point
+			g.words = max(g.words, len(words))
+		}
+	}
+}
+
+var pincodePattern = regexp.MustCompile(`\b[1-9]\d{2} ?\d{3}\b`)
+
+// Pincodes returns the six digit pincodes in address, last first since addresses end with them.
+// Pincodes written with a space after the sorting district are found too.
+func Pincodes(address string) []string {
+	matches := pincodePattern.FindAllString(address, -1)
+	pincodes := make([]string, 0, len(matches))
+	for i := len(matches) - 1; i >= 0; i-- {
+		pincodes = append(pincodes, strings.ReplaceAll(matches[i], " ", ""))
+	}
+	return pincodes
+}
+
+func (g *Gazetteer) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	for _, pincode := range Pincodes(address) {
+		if point, ok := g.pincodes[pincode]; ok {
+			return point, nil
+		}
+		if point, ok := g.pincodes[pincode[:3]]; ok {
+			return point, nil
+		}
+	}
+
+	words := splitWords(address)
+	for n := min(g.words, len(words)); n > 0; n-- {
+		for i := len(words) - n; i >= 0; i-- {
+			if point, ok := g.places[strings.Join(words[i:i+n], " ")]; ok {
+				return point, nil
+			}
+		}
+	}
+	return geo.Point{}, fmt.Errorf("%q is not in the gazetteer: %w", address, ErrNotFound)
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
@@ -0,0 +1,81 @@
+// Package geocode locates addresses. The local gazetteer knows Indian pincodes and the main
+// cities without any network access, and HTTP providers can be put in front of it for street
+// level positions.
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+)
+
+// ErrNotFound is wrapped by the errors of geocoders that could not locate an address.
+var ErrNotFound = errors.New("address not found")
+
+type Geocoder interface {
+	// Geocode returns the position of address, or an error wrapping ErrNotFound.
+	Geocode(ctx context.Context, address string) (geo.Point, error)
+}
+
+// Chain tries its geocoders in order and returns the first position found, so that a provider
+// being down or not knowing an address falls back to the next one.
+type Chain []Geocoder
+
+func (c Chain) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	var errs []error
+	for _, geocoder := range c {
+		point, err := geocoder.Geocode(ctx, address)
+		if err == nil {
+			return point, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			slog.Warn("geocoder failed, falling back", slog.String("error", err.Error()))
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return geo.Point{}, fmt.Errorf("%q: %w", address, ErrNotFound)
+	}
+	return geo.Point{}, errors.Join(errs...)
+}
+
+// New returns the geocoder chosen by cfg, which falls back to the gazetteer.
+func New(cfg *config.Config) (Geocoder, error) {
+	gazetteer, err := NewGazetteer(cfg.GazetteerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Geocoder {
+	case "", "local":
+		return gazetteer, nil
+	case "google":
+		if cfg.GeocoderAPIKey == "" {
+			return nil, fmt.Errorf("the google geocoder requires GEOCODER_API_KEY")
+		}
+		return Chain{&Google{URL: cfg.GeocoderURL, APIKey: cfg.GeocoderAPIKey}, gazetteer}, nil
+	case "nominatim":
+		return Chain{&Nominatim{URL: cfg.GeocoderURL}, gazetteer}, nil
+	default:
+		return nil, fmt.Errorf("unknown geocoder %q", cfg.Geocoder)
+	}
+}
+
+// Locate returns the coordinates of address, or nils if it is empty or cannot be located, which
+// is logged rather than failing the registration or update it is part of.
+func Locate(ctx context.Context, geocoder Geocoder, address string) (lat *float64, lng *float64) {
+	if geocoder == nil || strings.TrimSpace(address) == "" {
+		return nil, nil
+	}
+	point, err := geocoder.Geocode(ctx, address)
+	if err != nil {
+		slog.Warn("failed to geocode address", slog.String("error", err.Error()))
+		return nil, nil
+	}
+	return &point.Lat, &point.Lng
+}
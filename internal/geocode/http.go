@@ -0,0 +1,139 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+)
+
+// HTTPDoer is the part of *http.Client the HTTP providers use, so that tests can stub them.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var defaultClient HTTPDoer = &http.Client{Timeout: 10 * time.Second}
+
+// Results are biased to India, where the businesses and collectors are.
+const region = "in"
+
+// Google geocodes with the Google Maps Geocoding API.
+type Google struct {
+	URL    string // Defaults to the public endpoint
+	APIKey string
+	Client HTTPDoer // Defaults to an http.Client with a timeout
+}
+
+func (g *Google) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	endpoint := g.URL
+	if endpoint == "" {
+		endpoint = "https://maps.googleapis.com/maps/api/geocode/json"
+	}
+	query := url.Values{"address": {address}, "region": {region}, "key": {g.APIKey}}
+
+	var body struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Results      []struct {
+			Geometry struct {
+				Location struct {
+					Lat float64 `json:"lat"`
+					Lng float64 `json:"lng"`
+				} `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, g.Client, endpoint, query, nil, &body); err != nil {
+		return geo.Point{}, err
+	}
+
+	switch {
+	case body.Status == "ZERO_RESULTS" || (body.Status == "OK" && len(body.Results) == 0):
+		return geo.Point{}, fmt.Errorf("%q is unknown to google: %w", address, ErrNotFound)
+	case body.Status != "OK":
+		return geo.Point{}, fmt.Errorf("google geocoding failed with status %s: %s", body.Status, body.ErrorMessage)
+	}
+	location := body.Results[0].Geometry.Location
+	return geo.Point{Lat: location.Lat, Lng: location.Lng}, nil
+}
+
+// Nominatim geocodes with the OpenStreetMap search API, which can be self-hosted. The public
+// instance allows about one request per second, which registrations stay well under.
+type Nominatim struct {
+	URL       string   // Defaults to the public instance
+	UserAgent string   // Identifies the application, as the usage policy requires
+	Client    HTTPDoer // Defaults to an http.Client with a timeout
+}
+
+func (n *Nominatim) Geocode(ctx context.Context, address string) (geo.Point, error) {
+	endpoint := n.URL
+	if endpoint == "" {
+		endpoint = "https://nominatim.openstreetmap.org/search"
+	}
+	userAgent := n.UserAgent
+	if userAgent == "" {
+		userAgent = "build-in-progress_01"
+	}
+	query := url.Values{"q": {address}, "format": {"jsonv2"}, "limit": {"1"}, "countrycodes": {region}}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := getJSON(ctx, n.Client, endpoint, query, map[string]string{"User-Agent": userAgent}, &results); err != nil {
+		return geo.Point{}, err
+	}
+	if len(results) == 0 {
+		return geo.Point{}, fmt.Errorf("%q is unknown to nominatim: %w", address, ErrNotFound)
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return geo.Point{}, fmt.Errorf("nominatim returned an invalid latitude %q", results[0].Lat)
+	}
+	lng, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return geo.Point{}, fmt.Errorf("nominatim returned an invalid longitude %q", results[0].Lon)
+	}
+	return geo.Point{Lat: lat, Lng: lng}, nil
+}
+
+func getJSON(ctx context.Context, client HTTPDoer, endpoint string, query url.Values, headers map[string]string, v any) error {
+	if client == nil {
+		client = defaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Leaving out the URL of the error, which holds the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to %s failed with status %d", endpoint, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode the response of %s: %w", endpoint, err)
+	}
+	return nil
+}
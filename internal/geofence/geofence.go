@@ -0,0 +1,134 @@
+// Package geofence detects drivers arriving at and departing from sites, from their locations
+// entering and leaving the circular fences around the sites.
+package geofence
+
+import (
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Detector advances the visits of trips to sites. A driver arrives once inside a fence for the
+// dwell time, and departs once outside it for the dwell time again, so that GPS jitter at the
+// edge of a fence raises no events.
+type Detector struct {
+	Radius float64       // Meters, of the sites without their own radius
+	Dwell  time.Duration // Of the sites without their own dwell time
+}
+
+func NewDetector(cfg *config.Config) *Detector {
+	return &Detector{Radius: cfg.GeofenceRadius, Dwell: cfg.GeofenceDwell}
+}
+
+// Fence returns the radius and the dwell time of a site.
+func (d *Detector) Fence(site types.Site) (float64, time.Duration) {
+	radius, dwell := d.Radius, d.Dwell
+	if site.RadiusMeters != nil {
+		radius = *site.RadiusMeters
+	}
+	if site.DwellSeconds != nil {
+		dwell = time.Duration(*site.DwellSeconds) * time.Second
+	}
+	return radius, dwell
+}
+
+// Sites returns the sites that apply to a pickup request: the pickup sites of its business and
+// the drop-off sites of its collector.
+func Sites(request types.PickupRequest, businessSites []types.Site, collectorSites []types.Site) []types.Site {
+	var sites []types.Site
+	for _, site := range businessSites {
+		if site.OwnerID == request.BusinessID && site.Kind == types.SitePickup {
+			sites = append(sites, site)
+		}
+	}
+	for _, site := range collectorSites {
+		if site.OwnerID == request.CollectorID && site.Kind == types.SiteDropOff {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// Step applies a location of the driver of a pickup request to the visit of the trip to a site,
+// version 0 if there is none yet. It returns the visit to store and the event the location
+// confirms, if any, and false if the visit is unchanged. Locations older than the latest change
+// of the visit are ignored, and a new driver starts the visit over.
+func (d *Detector) Step(request types.PickupRequest, site types.Site, visit types.SiteVisit, location types.DriverLocation) (types.SiteVisit, *types.GeofenceEvent, bool) {
+	if visit.Version > 0 && !location.Timestamp.After(visit.LastSeenAt.Time) {
+		return visit, nil, false
+	}
+
+	next := visit
+	next.TripID, next.SiteID = request.RequestID, site.SiteID
+	newDriver := visit.DriverID != location.DriverID
+	if newDriver {
+		next.EnteredAt, next.ArrivedAt, next.ExitedAt = nil, nil, nil
+	}
+	next.DriverID = location.DriverID
+	next.LastSeenAt = location.Timestamp
+
+	radius, dwell := d.Fence(site)
+	position := geo.Point{Lat: location.Latitude, Lng: location.Longitude}
+	inside := geo.Distance(position, geo.Point{Lat: site.Latitude, Lng: site.Longitude}) <= radius
+	at := location.Timestamp
+
+	switch {
+	case inside && next.ArrivedAt == nil:
+		if next.EnteredAt == nil {
+			next.EnteredAt = &at
+		}
+		if at.Sub(next.EnteredAt.Time) >= dwell {
+			next.ArrivedAt = next.EnteredAt
+			return next, newEvent(events.DriverArrived, request, site, location, *next.EnteredAt), true
+		}
+		return next, nil, visit.EnteredAt == nil || newDriver
+
+	case inside:
+		// Back inside before the departure was confirmed
+		if next.ExitedAt == nil {
+			return visit, nil, false
+		}
+		next.ExitedAt = nil
+		return next, nil, true
+
+	case next.ArrivedAt == nil:
+		// Left before the arrival was confirmed
+		if visit.EnteredAt == nil || newDriver {
+			return visit, nil, false
+		}
+		next.EnteredAt = nil
+		return next, nil, true
+
+	default:
+		if next.ExitedAt == nil {
+			next.ExitedAt = &at
+		}
+		if at.Sub(next.ExitedAt.Time) >= dwell {
+			exited := *next.ExitedAt
+			next.EnteredAt, next.ArrivedAt, next.ExitedAt = nil, nil, nil
+			return next, newEvent(events.DriverDeparted, request, site, location, exited), true
+		}
+		return next, nil, visit.ExitedAt == nil
+	}
+}
+
+func newEvent(eventType events.Type, request types.PickupRequest, site types.Site, location types.DriverLocation, at types.DateTime) *types.GeofenceEvent {
+	return &types.GeofenceEvent{
+		Event:       string(eventType),
+		RequestID:   request.RequestID,
+		BusinessID:  request.BusinessID,
+		CollectorID: request.CollectorID,
+		DriverID:    location.DriverID,
+		SiteID:      site.SiteID,
+		SiteKind:    site.Kind,
+		SiteName:    site.Name,
+		AutoAdvance: site.AutoAdvance,
+		Latitude:    location.Latitude,
+		Longitude:   location.Longitude,
+		At:          at,
+		DetectedAt:  location.Timestamp,
+	}
+}
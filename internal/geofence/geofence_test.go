@@ -0,0 +1,130 @@
+package geofence
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// fix is a location of the driver, meters north of the site, seconds into the trip.
+type fix struct {
+	meters  float64
+	seconds int
+}
+
+func TestStep(t *testing.T) {
+	const (
+		inside  = 0
+		edgeIn  = 95  // Jitter around the 100 m fence
+		edgeOut = 105 // Jitter around the 100 m fence
+		outside = 250
+	)
+
+	tests := []struct {
+		name  string
+		fixes []fix
+		want  []events.Type // Per fix, empty for none
+		at    []int         // Seconds into the trip the driver entered or left the fence, per event
+		// The stay the visit ends with
+		wantEntered, wantArrived, wantExited bool
+	}{
+		{
+			name:        "enter and exit inside the dwell window",
+			fixes:       []fix{{outside, 0}, {inside, 10}, {inside, 40}, {outside, 50}, {outside, 200}},
+			want:        []events.Type{"", "", "", "", ""},
+			wantEntered: false,
+		},
+		{
+			name:        "stay for the dwell time",
+			fixes:       []fix{{inside, 0}, {inside, 30}, {inside, 60}, {inside, 90}},
+			want:        []events.Type{"", "", events.DriverArrived, ""},
+			at:          []int{0},
+			wantEntered: true, wantArrived: true,
+		},
+		{
+			// Each exit before the arrival starts the dwell time over
+			name:        "jitter at the boundary before arriving",
+			fixes:       []fix{{edgeIn, 0}, {edgeOut, 40}, {edgeIn, 50}, {edgeOut, 100}, {edgeIn, 110}, {edgeIn, 170}},
+			want:        []events.Type{"", "", "", "", "", events.DriverArrived},
+			at:          []int{110},
+			wantEntered: true, wantArrived: true,
+		},
+		{
+			// Back inside before the dwell time is out cancels the departure
+			name:        "jitter at the boundary after arriving",
+			fixes:       []fix{{edgeIn, 0}, {edgeIn, 60}, {edgeOut, 70}, {edgeIn, 100}, {edgeOut, 110}, {edgeIn, 160}, {edgeIn, 300}},
+			want:        []events.Type{"", events.DriverArrived, "", "", "", "", ""},
+			at:          []int{0},
+			wantEntered: true, wantArrived: true,
+		},
+		{
+			name:        "arrival then departure",
+			fixes:       []fix{{outside, 0}, {inside, 10}, {inside, 70}, {outside, 100}, {outside, 130}, {outside, 160}, {outside, 300}},
+			want:        []events.Type{"", "", events.DriverArrived, "", "", events.DriverDeparted, ""},
+			at:          []int{10, 100},
+			wantEntered: false,
+		},
+	}
+
+	detector := &Detector{Radius: 100, Dwell: time.Minute}
+	request := types.PickupRequest{RequestID: 7, BusinessID: 2, CollectorID: 3}
+	site := types.Site{SiteID: 5, Kind: types.SitePickup, Latitude: 18.52, Longitude: 73.85}
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	location := func(f fix) types.DriverLocation {
+		return types.DriverLocation{
+			DriverID:  42,
+			Latitude:  site.Latitude + f.meters/(geo.EarthRadius*math.Pi/180),
+			Longitude: site.Longitude,
+			Timestamp: types.DateTime{Time: start.Add(time.Duration(f.seconds) * time.Second)},
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var visit types.SiteVisit
+			var at []int
+			for i, f := range test.fixes {
+				next, event, changed := detector.Step(request, site, visit, location(f))
+				if changed {
+					next.Version = visit.Version + 1
+					visit = next
+				}
+
+				var got events.Type
+				if event != nil {
+					if !changed {
+						t.Errorf("fix %d raised %s without changing the visit", i, event.Event)
+					}
+					got = events.Type(event.Event)
+					at = append(at, int(event.At.Sub(start).Seconds()))
+				}
+				if got != test.want[i] {
+					t.Fatalf("fix %d (%v m, %d s) raised %q, want %q", i, f.meters, f.seconds, got, test.want[i])
+				}
+			}
+			if !slices.Equal(at, test.at) {
+				t.Errorf("events at %v s, want %v s", at, test.at)
+			}
+			if (visit.EnteredAt != nil) != test.wantEntered || (visit.ArrivedAt != nil) != test.wantArrived || (visit.ExitedAt != nil) != test.wantExited {
+				t.Errorf("visit = entered %v, arrived %v, exited %v", visit.EnteredAt, visit.ArrivedAt, visit.ExitedAt)
+			}
+		})
+	}
+}
+
+func TestStepIgnoresStaleLocations(t *testing.T) {
+	detector := &Detector{Radius: 100, Dwell: time.Minute}
+	site := types.Site{SiteID: 5, Latitude: 18.52, Longitude: 73.85}
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	visit := types.SiteVisit{DriverID: 42, LastSeenAt: types.DateTime{Time: now}, Version: 1}
+
+	stale := types.DriverLocation{DriverID: 42, Latitude: site.Latitude, Longitude: site.Longitude, Timestamp: types.DateTime{Time: now.Add(-time.Second)}}
+	if _, event, changed := detector.Step(types.PickupRequest{}, site, visit, stale); changed || event != nil {
+		t.Errorf("Step(stale location) = %v, %v, want the visit unchanged", event, changed)
+	}
+}
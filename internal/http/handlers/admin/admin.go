@@ -1,10 +1,17 @@
 package admin
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
+	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
@@ -14,7 +21,7 @@ func VerifyUser(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		if err := storage.VerifyUser(id); err != nil {
+		if err := storage.VerifyUser(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -27,7 +34,7 @@ func UnverifyUser(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		if err := storage.UnverifyUser(id); err != nil {
+		if err := storage.UnverifyUser(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -40,7 +47,7 @@ func FlagUser(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		if err := storage.FlagUser(id); err != nil {
+		if err := storage.FlagUser(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -53,7 +60,7 @@ func UnflagUser(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		if err := storage.UnflagUser(id); err != nil {
+		if err := storage.UnflagUser(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -71,7 +78,7 @@ func AddServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		id, err := storage.AddServiceCategory(serviceCategory)
+		id, err := storage.AddServiceCategory(c.Request.Context(), serviceCategory)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -90,7 +97,7 @@ func AddVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		id, err := storage.AddVehicle(vehicle)
+		id, err := storage.AddVehicle(c.Request.Context(), vehicle)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -108,7 +115,7 @@ func DeleteServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		if err := storage.DeleteServiceCategory(id); err != nil {
+		if err := storage.DeleteServiceCategory(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -125,7 +132,7 @@ func DeleteVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		if err := storage.DeleteVehicle(id); err != nil {
+		if err := storage.DeleteVehicle(c.Request.Context(), id); err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
@@ -136,7 +143,7 @@ func DeleteVehicle(storage storage.Storage) gin.HandlerFunc {
 
 func GetAllCollectors(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		collectors, err := storage.GetAllCollectors()
+		collectors, err := storage.GetAllCollectors(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -147,7 +154,7 @@ func GetAllCollectors(storage storage.Storage) gin.HandlerFunc {
 
 func GetAllBusinesses(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		businesses, err := storage.GetAllBusinesses()
+		businesses, err := storage.GetAllBusinesses(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -158,7 +165,7 @@ func GetAllBusinesses(storage storage.Storage) gin.HandlerFunc {
 
 func GetAllUsers(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		users, err := storage.GetAllUsers()
+		users, err := storage.GetAllUsers(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -167,9 +174,21 @@ func GetAllUsers(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
+// GetAdminAuditRecords lists how every admin came to exist, bootstrapped or created by whom.
+func GetAdminAuditRecords(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		records, err := storage.GetAdminAuditRecords(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, records)
+	}
+}
+
 func GetAllPickupRequests(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		pickupRequests, err := storage.GetAllPickupRequests()
+		pickupRequests, err := storage.GetAllPickupRequests(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -177,3 +196,270 @@ func GetAllPickupRequests(storage storage.Storage) gin.HandlerFunc {
 		c.JSON(http.StatusOK, pickupRequests)
 	}
 }
+
+func GetSubscriberStatus(listeners *pub_sub.Supervisor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, listeners.Status())
+	}
+}
+
+func GetDeadLetters(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		if status != "" && status != "pending" && status != "replayed" && status != "discarded" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, replayed or discarded"})
+			return
+		}
+
+		deadLetters, err := storage.ListDeadLetters(c.Request.Context(), status)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, deadLetters)
+	}
+}
+
+func GetDeadLetter(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadLetterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter ID"})
+			return
+		}
+
+		deadLetter, err := storage.GetDeadLetter(c.Request.Context(), deadLetterID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, deadLetter)
+	}
+}
+
+func ReplayDeadLetter(storage storage.Storage, bus eventbus.Bus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadLetterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter ID"})
+			return
+		}
+
+		if _, err := storage.GetDeadLetter(c.Request.Context(), deadLetterID); err != nil {
+			c.JSON(http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		if err := pub_sub.ReplayDeadLetter(c.Request.Context(), storage, bus, deadLetterID); err != nil {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Replayed Dead Letter ID": deadLetterID})
+	}
+}
+
+func DiscardDeadLetter(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadLetterID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter ID"})
+			return
+		}
+
+		if _, err := storage.GetDeadLetter(c.Request.Context(), deadLetterID); err != nil {
+			c.JSON(http.StatusNotFound, response.GeneralError(err))
+			return
+		}
+
+		if err := pub_sub.DiscardDeadLetter(c.Request.Context(), storage, deadLetterID); err != nil {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Discarded Dead Letter ID": deadLetterID})
+	}
+}
+
+// errNotFound is storage.ErrNotFound, which the storage parameters of the handlers shadow.
+var errNotFound = storage.ErrNotFound
+
+// GetNotificationTemplates lists the built-in templates, with the admin overrides in their place.
+func GetNotificationTemplates(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		templates, err := notify.EmbeddedTemplates()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		overrides, err := storage.ListNotificationTemplates(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		index := make(map[string]int, len(templates))
+		for i, tpl := range templates {
+			index[tpl.Locale+"/"+tpl.Name] = i
+		}
+		for _, override := range overrides {
+			if i, ok := index[override.Locale+"/"+override.Name]; ok {
+				templates[i] = override
+			} else {
+				templates = append(templates, override)
+			}
+		}
+
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+func GetNotificationTemplate(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, locale := c.Param("name"), c.Param("locale")
+
+		tpl, err := storage.GetNotificationTemplate(c.Request.Context(), name, locale)
+		if errors.Is(err, errNotFound) {
+			tpl, err = notify.EmbeddedTemplate(name, locale)
+		}
+		if err != nil {
+			if errors.Is(err, errNotFound) {
+				c.JSON(http.StatusNotFound, response.GeneralError(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, tpl)
+	}
+}
+
+func UpdateNotificationTemplate(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, locale := c.Param("name"), c.Param("locale")
+		if !notify.TemplateExists(name) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown notification template"})
+			return
+		}
+
+		var input types.NotificationTemplate
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		input.Name = name
+		input.Locale = locale
+		input.UpdatedBy = middleware.Actor(c).UserID
+
+		// Rejecting templates that do not parse or reference missing fields before they reach users
+		if _, err := notify.RenderTemplate(input, notify.SampleData()); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := storage.SaveNotificationTemplate(c.Request.Context(), input); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		saved, err := storage.GetNotificationTemplate(c.Request.Context(), name, locale)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+// DeleteNotificationTemplate removes an override, restoring the built-in template.
+func DeleteNotificationTemplate(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, locale := c.Param("name"), c.Param("locale")
+
+		if err := storage.DeleteNotificationTemplate(c.Request.Context(), name, locale); err != nil {
+			if errors.Is(err, errNotFound) {
+				c.JSON(http.StatusNotFound, response.GeneralError(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Deleted Notification Template": name + " (" + locale + ")"})
+	}
+}
+
+// PreviewNotificationTemplate renders a template, or a draft of it, without sending anything.
+func PreviewNotificationTemplate(storage storage.Storage) gin.HandlerFunc {
+	templates := notify.NewTemplates(storage, "")
+
+	return func(c *gin.Context) {
+		name, locale := c.Param("name"), c.Param("locale")
+		if !notify.TemplateExists(name) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown notification template"})
+			return
+		}
+
+		var input types.NotificationTemplatePreview
+		if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		tpl, err := templates.Lookup(c.Request.Context(), name, locale)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if input.Subject != "" {
+			tpl.Subject = input.Subject
+		}
+		if input.Text != "" {
+			tpl.Text = input.Text
+		}
+		if input.HTML != "" {
+			tpl.HTML = input.HTML
+		}
+
+		data := notify.SampleData()
+		if input.PickupRequestID != 0 {
+			data, err = templateData(c.Request.Context(), storage, input.PickupRequestID)
+			if err != nil {
+				c.JSON(http.StatusNotFound, response.GeneralError(err))
+				return
+			}
+		}
+
+		notification, err := notify.RenderTemplate(tpl, data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"name":    tpl.Name,
+			"locale":  tpl.Locale,
+			"subject": notification.Heading,
+			"text":    notification.Message,
+			"html":    notification.HTML,
+		})
+	}
+}
+
+func templateData(ctx context.Context, storage storage.Storage, requestID int64) (notify.TemplateData, error) {
+	pr, err := storage.GetPickupRequestByID(ctx, requestID)
+	if err != nil {
+		return notify.TemplateData{}, err
+	}
+	collector, err := storage.GetCollectorByID(ctx, pr.CollectorID)
+	if err != nil {
+		return notify.TemplateData{}, err
+	}
+	business, err := storage.GetBusinessByID(ctx, pr.BusinessID)
+	if err != nil {
+		return notify.TemplateData{}, err
+	}
+	return notify.TemplateData{PickupRequest: pr, Collector: collector, Business: business}, nil
+}
@@ -1,17 +1,24 @@
 package business
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
 )
 
+var errNotFound = storage.ErrNotFound
+
 // GetBusinessByID retrieves a business by its ID.
 func GetBusinessByID(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -20,7 +27,7 @@ func GetBusinessByID(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid business ID"})
 			return
 		}
-		business, err := storage.GetBusinessByID(id)
+		business, err := storage.GetBusinessByID(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -41,7 +48,7 @@ func GetBusinessByEmail(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 		email := emailInput.Email
-		business, err := storage.GetBusinessByEmail(email)
+		business, err := storage.GetBusinessByEmail(c.Request.Context(), email)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -51,7 +58,7 @@ func GetBusinessByEmail(storage storage.Storage) gin.HandlerFunc {
 }
 
 // UpdateBusinessProfile updates a business's profile.
-func UpdateBusinessProfile(storage storage.Storage) gin.HandlerFunc {
+func UpdateBusinessProfile(storage storage.Storage, geocoder geocode.Geocoder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
@@ -63,7 +70,10 @@ func UpdateBusinessProfile(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
-		updatedID, err := storage.UpdateBusinessProfile(userID, input)
+		if input.Latitude == nil {
+			input.Latitude, input.Longitude = geocode.Locate(c.Request.Context(), geocoder, input.Business_address)
+		}
+		updatedID, err := storage.UpdateBusinessProfile(c.Request.Context(), userID, input)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -72,45 +82,123 @@ func UpdateBusinessProfile(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
-func CreatePickupRequest(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+// CreatePickupRequest creates a pickup request, once its site is checked against the service
+// areas of the collector. Sites outside of them are rejected, or accepted with a warning if
+// service areas are not enforced. Without a collector_id, the request goes on the open market.
+func CreatePickupRequest(storage storage.Storage, checker *coverage.Checker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input types.PickupRequest
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
+		input.OpenMarket, input.PricePerKg, input.WindowStart, input.WindowEnd = false, nil, nil, nil
+		if input.BusinessID != middleware.Actor(c).UserID {
+			c.JSON(http.StatusForbidden, response.GeneralError(fmt.Errorf("pickup requests can only be created for the caller's own business")))
+			return
+		}
 
-		_, err := storage.GetBusinessByID(input.BusinessID)
+		business, err := storage.GetBusinessByID(c.Request.Context(), input.BusinessID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "business ID not found"})
 			return
 		}
-		_, err = storage.GetCollectorByID(input.CollectorID)
+		if input.CollectorID == 0 {
+			createOpenPickupRequest(c, storage, checker, input, business)
+			return
+		}
+		_, err = storage.GetCollectorByID(c.Request.Context(), input.CollectorID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "collector ID not found"})
 			return
 		}
 
-		id, err1 := pub_sub.CreatePickupRequest(storage, pubsubClient, input)
+		verdict, err := checker.CheckCollector(c.Request.Context(), input.CollectorID, input.WasteType, coverage.PickupLocation(input, business))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		var warning string
+		switch verdict {
+		case coverage.NotCovered:
+			if checker.Reject {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "the pickup site is outside the service areas of the collector"})
+				return
+			}
+			warning = "the pickup site is outside the service areas of the collector"
+		case coverage.Unknown:
+			warning = "the pickup site could not be checked against the service areas of the collector"
+		}
+
+		id, err1 := pub_sub.CreatePickupRequest(c.Request.Context(), storage, input, middleware.Actor(c))
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "OK", "message": "Pickup request created successfully", "pickup_request_id": id})
+		body := gin.H{"status": "OK", "message": "Pickup request created successfully", "pickup_request_id": id}
+		if warning != "" {
+			body["warning"] = warning
+		}
+		c.JSON(http.StatusOK, body)
+	}
+}
+
+// createOpenPickupRequest puts a pickup request without a collector on the open market, inviting
+// the collectors MatchCollectors would list for it at their list prices.
+func createOpenPickupRequest(c *gin.Context, storage storage.Storage, checker *coverage.Checker, input types.PickupRequest, business types.Business) {
+	if input.WasteType == "" || input.PickupDate.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "waste_type and pickup_date are required for open-market requests"})
+		return
+	}
+
+	matches, err := matchCollectors(c.Request.Context(), storage, checker, types.MatchRequest{
+		WasteType:  input.WasteType,
+		Quantity:   input.Quantity,
+		PickupDate: input.PickupDate,
+		Latitude:   input.PickupLatitude,
+		Longitude:  input.PickupLongitude,
+	}, business)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+	if len(matches) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "no collector can take the pickup"})
+		return
+	}
+	invitations := make([]types.MarketInvitation, len(matches))
+	for i, match := range matches {
+		invitations[i] = types.MarketInvitation{CollectorID: match.CollectorID, PricePerKg: match.PricePerKg}
+	}
+
+	id, err := pub_sub.CreateOpenPickupRequest(c.Request.Context(), storage, input, invitations, middleware.Actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": "OK", "message": "Open pickup request created successfully", "pickup_request_id": id, "invited_collectors": len(invitations)})
 }
 
+// GetPickupRequestByID returns one of the business's pickup requests, with the ETA of its driver
+// while it is on its way.
 func GetPickupRequestByID(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup request ID"})
+		pickupRequest, ok := ownPickupRequest(c, storage)
+		if !ok {
 			return
 		}
-		pickupRequest, err := storage.GetPickupRequestByID(id)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
-			return
+
+		// The ETA of the assigned driver, while the request is on its way
+		status := lifecycle.Status(pickupRequest.Status)
+		if status == lifecycle.Assigned || status == lifecycle.InTransit {
+			eta, err := storage.GetPickupETA(c.Request.Context(), pickupRequest.RequestID)
+			if err != nil && !errors.Is(err, errNotFound) {
+				c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+			if err == nil && eta.DriverID == pickupRequest.AssignedDriver {
+				pickupRequest.ETA = &eta
+			}
 		}
 		c.JSON(http.StatusOK, pickupRequest)
 	}
@@ -118,12 +206,16 @@ func GetPickupRequestByID(storage storage.Storage) gin.HandlerFunc {
 
 func GetAllPickupRequestsForBusiness(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		businessID, err := strconv.ParseInt(c.Param("business_id"), 10, 64)
+		businessID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid business ID"})
 			return
 		}
-		pickupRequests, err := storage.GetAllPickupRequestsForBusiness(businessID)
+		if businessID != middleware.Actor(c).UserID {
+			c.JSON(http.StatusForbidden, response.GeneralError(fmt.Errorf("pickup requests of other businesses cannot be listed")))
+			return
+		}
+		pickupRequests, err := storage.GetAllPickupRequestsForBusiness(c.Request.Context(), businessID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -132,17 +224,12 @@ func GetAllPickupRequestsForBusiness(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
+// UpdatePickupRequest edits a pickup request of the caller while it is Pending, or cancels it.
+// Other status changes are left to the collector and driver flows.
 func UpdatePickupRequest(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup request ID"})
-			return
-		}
-
-		_, err = storage.GetPickupRequestByID(id)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
+		pickupRequest, ok := ownPickupRequest(c, storage)
+		if !ok {
 			return
 		}
 
@@ -151,7 +238,11 @@ func UpdatePickupRequest(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
-		err = storage.UpdatePickupRequest(id, input)
+		err := storage.UpdatePickupRequest(c.Request.Context(), pickupRequest.RequestID, input, middleware.Actor(c))
+		if errors.Is(err, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -160,6 +251,44 @@ func UpdatePickupRequest(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
+// ownPickupRequest returns the pickup request of the id parameter if it belongs to the caller,
+// responding with an error and returning false otherwise.
+func ownPickupRequest(c *gin.Context, storage storage.Storage) (types.PickupRequest, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup request ID"})
+		return types.PickupRequest{}, false
+	}
+
+	pickupRequest, err := storage.GetPickupRequestByID(c.Request.Context(), id)
+	if errors.Is(err, errNotFound) || (err == nil && pickupRequest.BusinessID != middleware.Actor(c).UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
+		return types.PickupRequest{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return types.PickupRequest{}, false
+	}
+	return pickupRequest, true
+}
+
+// GetPickupRequestTimeline returns the status history of one of the business's pickup requests.
+func GetPickupRequestTimeline(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequest, ok := ownPickupRequest(c, storage)
+		if !ok {
+			return
+		}
+
+		timeline, err := storage.GetPickupRequestTimeline(c.Request.Context(), pickupRequest.RequestID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, timeline)
+	}
+}
+
 // func CancelPickupRequest(storage storage.Storage) gin.HandlerFunc {
 // 	return func(c *gin.Context) {
 // 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -168,7 +297,7 @@ func UpdatePickupRequest(storage storage.Storage) gin.HandlerFunc {
 // 			return
 // 		}
 
-// 		_, err = storage.GetPickupRequestByID(id)
+// 		_, err = storage.GetPickupRequestByID(c.Request.Context(), id)
 // 		if err != nil {
 // 			c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
 // 			return
@@ -191,7 +320,7 @@ func UpdatePickupRequest(storage storage.Storage) gin.HandlerFunc {
 // 			return
 // 		}
 
-// 		_, err = storage.GetPickupRequestByID(id)
+// 		_, err = storage.GetPickupRequestByID(c.Request.Context(), id)
 // 		if err != nil {
 // 			c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
 // 			return
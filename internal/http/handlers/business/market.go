@@ -0,0 +1,79 @@
+package business
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+var errNotOpen = storage.ErrNotOpen
+
+// GetPickupBids lists the bids on an open-market pickup request of the caller, cheapest first.
+func GetPickupBids(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequest, ok := ownPickupRequest(c, storage)
+		if !ok {
+			return
+		}
+
+		bids, err := storage.ListPickupBids(c.Request.Context(), pickupRequest.RequestID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, bids)
+	}
+}
+
+// AcceptPickupBid awards an open-market pickup request of the caller to the collector of one of
+// its bids, at the price and window of the bid. It fails with 409 once a collector won it, or once
+// the window of the bid has passed.
+func AcceptPickupBid(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequest, ok := ownPickupRequest(c, storage)
+		if !ok {
+			return
+		}
+		bidID, err := strconv.ParseInt(c.Param("bid_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bid ID"})
+			return
+		}
+
+		// Bids are placed with a future window, which may have passed since
+		bids, err := storage.ListPickupBids(c.Request.Context(), pickupRequest.RequestID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		for _, bid := range bids {
+			if bid.BidID == bidID && !bid.WindowEnd.After(time.Now()) {
+				c.JSON(http.StatusConflict, gin.H{"error": "the window of the bid has passed"})
+				return
+			}
+		}
+
+		err = pub_sub.AcceptPickupBid(c.Request.Context(), storage, pickupRequest.RequestID, bidID, middleware.Actor(c))
+		if errors.Is(err, errNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bid not found"})
+			return
+		}
+		if errors.Is(err, errNotOpen) || errors.Is(err, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Accepted Request ID": pickupRequest.RequestID, "bid_id": bidID})
+	}
+}
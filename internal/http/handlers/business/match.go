@@ -0,0 +1,86 @@
+package business
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/matching"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+// MatchCollectors ranks the collectors able to take a pickup of the caller, for them to pick the
+// collector_id of their pickup request from. Candidates offer the waste type, have the quantity
+// left of their capacity for the pickup date, and cover the pickup location unless service areas
+// are only warned about. Each comes with the breakdown of its score.
+func MatchCollectors(storage storage.Storage, checker *coverage.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.MatchRequest
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if input.PickupDate.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pickup_date is required"})
+			return
+		}
+
+		business, err := storage.GetBusinessByID(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		matches, err := matchCollectors(c.Request.Context(), storage, checker, input, business)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, matches)
+	}
+}
+
+// matchCollectors ranks the collectors able to take the pickup of input for business.
+func matchCollectors(ctx context.Context, storage storage.Storage, checker *coverage.Checker, input types.MatchRequest, business types.Business) ([]types.CollectorMatch, error) {
+	location := coverage.PickupLocation(types.PickupRequest{PickupLatitude: input.Latitude, PickupLongitude: input.Longitude}, business)
+	if input.Pincode != "" {
+		location.Pincode = input.Pincode
+	}
+
+	// Capacities are daily, over the calendar day of the pickup
+	pickupDate := input.PickupDate.Time
+	day := time.Date(pickupDate.Year(), pickupDate.Month(), pickupDate.Day(), 0, 0, 0, 0, pickupDate.Location())
+	candidates, err := storage.GetMatchCandidates(ctx, types.MatchCandidatesQuery{
+		WasteType: input.WasteType,
+		From:      day,
+		To:        day.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verdicts, err := checker.Verdicts(ctx, input.WasteType, location)
+	if err != nil {
+		return nil, err
+	}
+	if checker.Reject {
+		covering := candidates[:0]
+		for _, candidate := range candidates {
+			if verdicts(candidate.CollectorID) != coverage.NotCovered {
+				covering = append(covering, candidate)
+			}
+		}
+		candidates = covering
+	}
+
+	matches := matching.Rank(candidates, matching.Pickup{Quantity: input.Quantity, Point: location.Point})
+	for i := range matches {
+		matches[i].Coverage = string(verdicts(matches[i].CollectorID))
+	}
+	return matches, nil
+}
@@ -1,33 +1,61 @@
 package collector
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
 )
 
-// ListCollectors lists all collectors (unchanged)
-func ListCollectors(storage storage.Storage) gin.HandlerFunc {
+// ListCollectors lists all collectors. Given a pincode, or lat and lng, and optionally a
+// waste_type, it leaves out those whose service areas do not cover the location.
+func ListCollectors(storage storage.Storage, checker *coverage.Checker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		collectors, err := storage.GetCollectors()
+		location := coverage.Location{Pincode: c.Query("pincode")}
+		if c.Query("lat") != "" || c.Query("lng") != "" {
+			lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+			if err != nil || lat < -90 || lat > 90 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "lat has to be a latitude in degrees"})
+				return
+			}
+			lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+			if err != nil || lng < -180 || lng > 180 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "lng has to be a longitude in degrees"})
+				return
+			}
+			location.Point = &geo.Point{Lat: lat, Lng: lng}
+		}
+
+		collectors, err := storage.GetCollectors(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
 
+		if location.Point != nil || location.Pincode != "" {
+			collectors, err = checker.Filter(c.Request.Context(), collectors, c.Query("waste_type"), location)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+				return
+			}
+		}
 		c.JSON(http.StatusOK, collectors)
 	}
 }
 
 // UpdateProfile updates a collector's profile (unchanged)
-func UpdateProfile(storage storage.Storage) gin.HandlerFunc {
+func UpdateProfile(storage storage.Storage, geocoder geocode.Geocoder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get ID from URL parameter
 		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -41,9 +69,12 @@ func UpdateProfile(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, response.GeneralError(err))
 			return
 		}
+		if input.Latitude == nil {
+			input.Latitude, input.Longitude = geocode.Locate(c.Request.Context(), geocoder, input.Address)
+		}
 
 		// Pass the URL parameter ID to the storage layer
-		id, err := storage.UpdateCollectorProfile(userID, input)
+		id, err := storage.UpdateCollectorProfile(c.Request.Context(), userID, input)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -70,7 +101,7 @@ func OfferServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		id, err := storage.AddCollectorServiceCategory(input, userID)
+		id, err := storage.AddCollectorServiceCategory(c.Request.Context(), input, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -102,7 +133,7 @@ func UpdateOfferedServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		err = storage.UpdateCollectorServiceCategory(input, userID)
+		err = storage.UpdateCollectorServiceCategory(c.Request.Context(), input, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -136,7 +167,7 @@ func DeleteOfferedServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		err := storage.DeleteCollectorServiceCategory(req.CategoryID, userID)
+		err := storage.DeleteCollectorServiceCategory(c.Request.Context(), req.CategoryID, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -166,7 +197,7 @@ func AppendCollectorVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		id, err := storage.AddCollectorVehicle(input, userID)
+		id, err := storage.AddCollectorVehicle(c.Request.Context(), input, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -196,7 +227,7 @@ func UpdateCollectorVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		err = storage.UpdateCollectorVehicle(input, userID)
+		err = storage.UpdateCollectorVehicle(c.Request.Context(), input, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -228,7 +259,7 @@ func RemoveCollectorVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		err := storage.DeleteCollectorVehicle(req.VehicleID, userID)
+		err := storage.DeleteCollectorVehicle(c.Request.Context(), req.VehicleID, userID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -250,7 +281,7 @@ func GetCollectorByID(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		collector, err := storage.GetCollectorByID(id)
+		collector, err := storage.GetCollectorByID(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -269,7 +300,7 @@ func GetCollectorServiceCategories(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		categories, err := storage.GetCollectorServiceCategories(id)
+		categories, err := storage.GetCollectorServiceCategories(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -288,7 +319,7 @@ func GetCollectorVehicles(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		vehicles, err := storage.GetCollectorVehicles(id)
+		vehicles, err := storage.GetCollectorVehicles(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -310,7 +341,7 @@ func GetCollectorVehicle(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle ID"})
 			return
 		}
-		vehicle, err := storage.GetCollectorVehicle(collectorID, vehicleID)
+		vehicle, err := storage.GetCollectorVehicle(c.Request.Context(), collectorID, vehicleID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -327,7 +358,7 @@ func GetCollectorDrivers(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collector ID"})
 			return
 		}
-		drivers, err := storage.GetCollectorDrivers(collectorID)
+		drivers, err := storage.GetCollectorDrivers(c.Request.Context(), collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -349,7 +380,7 @@ func GetCollectorDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid driver ID"})
 			return
 		}
-		driver, err := storage.GetCollectorDriver(collectorID, driverID)
+		driver, err := storage.GetCollectorDriver(c.Request.Context(), collectorID, driverID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -371,7 +402,7 @@ func CreateCollectorDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		id, err := storage.CreateCollectorDriver(input, collectorID)
+		id, err := storage.CreateCollectorDriver(c.Request.Context(), input, collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -397,7 +428,7 @@ func UpdateCollectorDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "driver_id is required"})
 			return
 		}
-		err = storage.UpdateCollectorDriver(input, collectorID)
+		err = storage.UpdateCollectorDriver(c.Request.Context(), input, collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -426,7 +457,7 @@ func DeleteCollectorDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "driver_id is required"})
 			return
 		}
-		err = storage.DeleteCollectorDriver(req.DriverID, collectorID)
+		err = storage.DeleteCollectorDriver(c.Request.Context(), req.DriverID, collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -452,7 +483,7 @@ func AssignVehicleToDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		err = storage.AssignVehicleToDriver(assign.DriverID, assign.VehicleID, collectorID)
+		err = storage.AssignVehicleToDriver(c.Request.Context(), assign.DriverID, assign.VehicleID, collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -477,7 +508,7 @@ func UnassignVehicleFromDriver(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		err = storage.UnassignVehicleFromDriver(assign.DriverID, assign.VehicleID, collectorID)
+		err = storage.UnassignVehicleFromDriver(c.Request.Context(), assign.DriverID, assign.VehicleID, collectorID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -497,7 +528,7 @@ func GetCollectorByEmail(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 		email := emailInput.Email
-		collector, err := storage.GetCollectorByEmail(email)
+		collector, err := storage.GetCollectorByEmail(c.Request.Context(), email)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -507,15 +538,22 @@ func GetCollectorByEmail(storage storage.Storage) gin.HandlerFunc {
 }
 
 // AcceptPickupRequest accepts a pickup request for a collector.
-func AcceptPickupRequest(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+func AcceptPickupRequest(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !ownPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
 
-		err1 := pub_sub.AcceptPickupRequest(storage, pubsubClient, pickupRequestID)
+		err1 := pub_sub.AcceptPickupRequest(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c))
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -526,15 +564,33 @@ func AcceptPickupRequest(storage storage.Storage, pubsubClient *pubsub.Client) g
 }
 
 // RejectPickupRequest rejects a pickup request for a collector.
-func RejectPickupRequest(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+func RejectPickupRequest(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !ownPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
+
+		// The rejection reason is optional
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, response.GeneralError(err))
+				return
+			}
+		}
 
-		err1 := pub_sub.RejectPickupRequest(storage, pubsubClient, pickupRequestID)
+		err1 := pub_sub.RejectPickupRequest(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c), body.Reason)
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -544,13 +600,16 @@ func RejectPickupRequest(storage storage.Storage, pubsubClient *pubsub.Client) g
 	}
 }
 
-func AssignTripToDriver(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+func AssignTripToDriver(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !ownPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
 
 		driverID, err := strconv.ParseInt(c.Param("did"), 10, 64)
 		if err != nil {
@@ -564,15 +623,19 @@ func AssignTripToDriver(storage storage.Storage, pubsubClient *pubsub.Client) gi
 			return
 		}
 
-		collector_id := uid.(int64)
+		collector_id := int64(uid.(uint64))
 
-		driver, err := storage.GetCollectorDriver(collector_id, driverID)
+		driver, err := storage.GetCollectorDriver(c.Request.Context(), collector_id, driverID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get driver: %v", err)})
 			return
 		}
 
-		err1 := pub_sub.AssignTripToDriver(storage, pubsubClient, pickupRequestID, driver)
+		err1 := pub_sub.AssignTripToDriver(c.Request.Context(), storage, pickupRequestID, driver, middleware.Actor(c))
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -582,15 +645,22 @@ func AssignTripToDriver(storage storage.Storage, pubsubClient *pubsub.Client) gi
 	}
 }
 
-func UnassignTripFromDriver(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+func UnassignTripFromDriver(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !ownPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
 
-		err1 := pub_sub.UnassignTripFromDriver(storage, pubsubClient, pickupRequestID)
+		err1 := pub_sub.UnassignTripFromDriver(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c))
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -599,3 +669,46 @@ func UnassignTripFromDriver(storage storage.Storage, pubsubClient *pubsub.Client
 		c.JSON(http.StatusOK, gin.H{"status": "OK", "message": fmt.Sprintf("Unassigned Trip for Request ID: %d", pickupRequestID)})
 	}
 }
+
+// GetPickupRequestTimeline returns the status history of a pickup request sent to the collector.
+func GetPickupRequestTimeline(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
+			return
+		}
+
+		if !ownPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
+
+		timeline, err := storage.GetPickupRequestTimeline(c.Request.Context(), pickupRequestID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, timeline)
+	}
+}
+
+// ownPickupRequest responds with an error and returns false unless the pickup request was sent
+// to the caller.
+func ownPickupRequest(c *gin.Context, storage storage.Storage, pickupRequestID int64) bool {
+	pickupRequest, err := storage.GetPickupRequestByID(c.Request.Context(), pickupRequestID)
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return false
+	}
+
+	if pickupRequest.CollectorID != middleware.Actor(c).UserID {
+		c.JSON(http.StatusForbidden, response.GeneralError(fmt.Errorf("pickup request belongs to another collector")))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+var errNotOpen = storage.ErrNotOpen
+
+// GetOpenPickupRequests lists the open-market pickup requests the caller is invited to and can
+// still win, with their list price and the caller's bid.
+func GetOpenPickupRequests(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requests, err := storage.ListOpenPickupRequests(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, requests)
+	}
+}
+
+// PlaceBid bids on an open-market pickup request for the caller, replacing their previous bid.
+// The window of the bid has to end in the future.
+func PlaceBid(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
+			return
+		}
+
+		var bid types.PickupBid
+		if err := c.ShouldBindJSON(&bid); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if bid.WindowStart.IsZero() || bid.WindowEnd.IsZero() || !bid.WindowEnd.After(bid.WindowStart.Time) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window_start and window_end are required, window_end after window_start"})
+			return
+		}
+		if !bid.WindowEnd.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window_end has already passed"})
+			return
+		}
+		bid.RequestID = pickupRequestID
+		bid.CollectorID = middleware.Actor(c).UserID
+
+		bidID, err := storage.PlaceBid(c.Request.Context(), bid)
+		if errors.Is(err, errNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pickup request not found among the open ones"})
+			return
+		}
+		if errors.Is(err, errNotOpen) {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "bid_id": bidID})
+	}
+}
+
+// AcceptOpenPickupRequest wins an open-market pickup request for the caller at their list price.
+// Only the first of the invited collectors to accept wins; the others get 409.
+func AcceptOpenPickupRequest(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
+			return
+		}
+
+		err = pub_sub.AcceptOpenPickupRequest(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c))
+		if errors.Is(err, errNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "pickup request not found among the open ones"})
+			return
+		}
+		if errors.Is(err, errNotOpen) || errors.Is(err, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Accepted Request ID": pickupRequestID})
+	}
+}
@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+const (
+	defaultNearbyRadius = 25.0  // km
+	maxNearbyRadius     = 200.0 // km
+	distanceBand        = 1.0   // km, within which collectors count as equally near
+)
+
+// GetNearbyCollectors ranks the collectors around a point. Query parameters: lat and lng,
+// radius_km, 25 by default, and waste_type, to only find collectors offering it. Collectors are
+// ranked by distance, in bands of a kilometre since depots a few hundred meters apart are as
+// good as each other, then by price and by capacity.
+func GetNearbyCollectors(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat has to be a latitude in degrees"})
+			return
+		}
+		lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+		if err != nil || lng < -180 || lng > 180 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lng has to be a longitude in degrees"})
+			return
+		}
+		radius, err := strconv.ParseFloat(c.DefaultQuery("radius_km", strconv.FormatFloat(defaultNearbyRadius, 'f', -1, 64)), 64)
+		if err != nil || radius <= 0 || radius > maxNearbyRadius {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km has to be a number of kilometres up to 200"})
+			return
+		}
+
+		collectors, err := storage.GetNearbyCollectors(c.Request.Context(), types.NearbyCollectorsQuery{
+			Latitude:  lat,
+			Longitude: lng,
+			RadiusKm:  radius,
+			WasteType: c.Query("waste_type"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		rankNearbyCollectors(collectors)
+		c.JSON(http.StatusOK, collectors)
+	}
+}
+
+// rankNearbyCollectors sorts collectors, nearest first, by distance band, then lowest price and
+// largest capacity, with the collectors offering nothing last within their band.
+func rankNearbyCollectors(collectors []types.NearbyCollector) {
+	sort.SliceStable(collectors, func(i, j int) bool {
+		a, b := collectors[i], collectors[j]
+		if bandA, bandB := math.Floor(a.DistanceKm/distanceBand), math.Floor(b.DistanceKm/distanceBand); bandA != bandB {
+			return bandA < bandB
+		}
+		if priceA, priceB := valueOr(a.PricePerKg, math.Inf(1)), valueOr(b.PricePerKg, math.Inf(1)); priceA != priceB {
+			return priceA < priceB
+		}
+		return valueOr(a.MaximumCapacity, math.Inf(-1)) > valueOr(b.MaximumCapacity, math.Inf(-1))
+	})
+}
+
+func valueOr(value *float64, fallback float64) float64 {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
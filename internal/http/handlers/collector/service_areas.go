@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+var errNotFound = storage.ErrNotFound
+
+// CreateServiceArea adds a service area of the caller, for one of the categories they offer or,
+// without a category_id, for all of them.
+func CreateServiceArea(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		area := types.ServiceArea{CollectorID: middleware.Actor(c).UserID}
+		if !bindServiceArea(c, storage, &area) {
+			return
+		}
+
+		areaID, err := storage.CreateServiceArea(c.Request.Context(), area)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		created, err := storage.GetServiceArea(c.Request.Context(), areaID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func GetServiceAreas(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		areas, err := storage.ListServiceAreas(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, areas)
+	}
+}
+
+func GetServiceArea(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		area, ok := ownServiceArea(c, storage)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, area)
+	}
+}
+
+// UpdateServiceArea replaces a service area. Pickup requests already made are not checked again.
+func UpdateServiceArea(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		area, ok := ownServiceArea(c, storage)
+		if !ok {
+			return
+		}
+		if !bindServiceArea(c, storage, &area) {
+			return
+		}
+
+		if err := storage.UpdateServiceArea(c.Request.Context(), area); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		updated, err := storage.GetServiceArea(c.Request.Context(), area.AreaID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteServiceArea(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		area, ok := ownServiceArea(c, storage)
+		if !ok {
+			return
+		}
+
+		if err := storage.DeleteServiceArea(c.Request.Context(), area.AreaID); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Deleted Service Area ID": area.AreaID})
+	}
+}
+
+// ownServiceArea loads the service area in the id parameter if it belongs to the caller. It
+// responds with an error and returns false otherwise.
+func ownServiceArea(c *gin.Context, storage storage.Storage) (types.ServiceArea, bool) {
+	areaID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service area ID"})
+		return types.ServiceArea{}, false
+	}
+
+	area, err := storage.GetServiceArea(c.Request.Context(), areaID)
+	if errors.Is(err, errNotFound) || (err == nil && area.CollectorID != middleware.Actor(c).UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "service area not found"})
+		return types.ServiceArea{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return types.ServiceArea{}, false
+	}
+	return area, true
+}
+
+// bindServiceArea sets the fields of area from the request body, once its polygon is valid
+// GeoJSON and its category one the collector offers. It responds with an error and returns false
+// otherwise.
+func bindServiceArea(c *gin.Context, storage storage.Storage, area *types.ServiceArea) bool {
+	var input types.ServiceAreaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, response.GeneralError(err))
+		return false
+	}
+
+	if len(input.Polygon) > 0 {
+		if _, err := geo.ParseGeoJSONArea(input.Polygon); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return false
+		}
+	} else if len(input.Pincodes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a service area has pincodes or a polygon"})
+		return false
+	}
+
+	if input.CategoryID != nil {
+		offers, err := storage.GetCollectorServiceCategories(c.Request.Context(), area.CollectorID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return false
+		}
+		offered := slices.ContainsFunc(offers, func(offer types.CollectorServiceCategory) bool {
+			return offer.CategoryID == *input.CategoryID
+		})
+		if !offered {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("service category %d is not offered by the collector", *input.CategoryID)})
+			return false
+		}
+	}
+
+	area.CategoryID = input.CategoryID
+	area.Name = input.Name
+	area.Pincodes = nil
+	area.Polygon = nil
+	if len(input.Polygon) > 0 {
+		area.Polygon = input.Polygon
+	} else {
+		slices.Sort(input.Pincodes)
+		area.Pincodes = slices.Compact(input.Pincodes)
+	}
+	return true
+}
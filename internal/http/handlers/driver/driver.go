@@ -1,25 +1,46 @@
 package driver
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"time"
 
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
 )
 
-func StartDelivery(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+var errNotFound = storage.ErrNotFound
+
+// maxClockSkew is how far in the future a reported location may be, for devices whose clock is
+// slightly ahead.
+const maxClockSkew = 2 * time.Minute
+
+func StartDelivery(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !assignedPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
 
-		err1 := pub_sub.StartDelivery(storage, pubsubClient, pickupRequestID)
+		err1 := pub_sub.StartDelivery(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c))
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -28,15 +49,22 @@ func StartDelivery(storage storage.Storage, pubsubClient *pubsub.Client) gin.Han
 		c.JSON(http.StatusOK, gin.H{"status": "OK", "Start Delivery for Request ID": pickupRequestID})
 	}
 }
-func EndDelivery(storage storage.Storage, pubsubClient *pubsub.Client) gin.HandlerFunc {
+func EndDelivery(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pickupRequestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
 			return
 		}
+		if !assignedPickupRequest(c, storage, pickupRequestID) {
+			return
+		}
 
-		err1 := pub_sub.EndDelivery(storage, pubsubClient, pickupRequestID)
+		err1 := pub_sub.EndDelivery(c.Request.Context(), storage, pickupRequestID, middleware.Actor(c))
+		if errors.Is(err1, lifecycle.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, response.GeneralError(err1))
+			return
+		}
 		if err1 != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err1))
 			return
@@ -45,3 +73,115 @@ func EndDelivery(storage storage.Storage, pubsubClient *pubsub.Client) gin.Handl
 		c.JSON(http.StatusOK, gin.H{"status": "OK", "Completed Delivery for Request ID": pickupRequestID})
 	}
 }
+
+// assignedPickupRequest responds with an error and returns false unless the pickup request is
+// assigned to the caller.
+func assignedPickupRequest(c *gin.Context, storage storage.Storage, pickupRequestID int64) bool {
+	pickupRequest, err := storage.GetPickupRequestByID(c.Request.Context(), pickupRequestID)
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return false
+	}
+
+	if pickupRequest.AssignedDriver != middleware.Actor(c).UserID {
+		c.JSON(http.StatusForbidden, response.GeneralError(fmt.Errorf("pickup request is assigned to another driver")))
+		return false
+	}
+	return true
+}
+
+// PostLocation accepts a GPS point from a driver with an active trip. The point is published and
+// persisted by the driver location subscriber.
+func PostLocation(storage storage.Storage, bus eventbus.Bus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.DriverLocationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		publishLocations(c, storage, bus, []types.DriverLocationInput{input})
+	}
+}
+
+// PostLocationBatch accepts the points a driver buffered while offline, published oldest first.
+// If publishing fails partway, the response lists the indexes of the points left unpublished,
+// the only ones to send again.
+func PostLocationBatch(storage storage.Storage, bus eventbus.Bus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.DriverLocationBatch
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		publishLocations(c, storage, bus, input.Points)
+	}
+}
+
+func publishLocations(c *gin.Context, storage storage.Storage, bus eventbus.Bus, points []types.DriverLocationInput) {
+	driverID := middleware.Actor(c).UserID
+	trip, err := storage.GetActiveTrip(c.Request.Context(), driverID)
+	if errors.Is(err, errNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"error": "no active trip is assigned to the driver"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return
+	}
+
+	now := time.Now().UTC()
+	locations := make([]types.DriverLocation, 0, len(points))
+	for _, point := range points {
+		timestamp := point.Timestamp.Time
+		if timestamp.IsZero() {
+			timestamp = now
+		}
+		if timestamp.After(now.Add(maxClockSkew)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "location timestamp is in the future"})
+			return
+		}
+		locations = append(locations, types.DriverLocation{
+			DriverID:    driverID,
+			CollectorID: trip.CollectorID,
+			VehicleID:   trip.AssignedVehicle,
+			Latitude:    *point.Latitude,
+			Longitude:   *point.Longitude,
+			Timestamp:   types.DateTime{Time: timestamp},
+			Accuracy:    point.Accuracy,
+			Speed:       point.Speed,
+			Bearing:     point.Bearing,
+			IsActive:    true,
+			TripID:      trip.RequestID,
+		})
+	}
+	// Publishing oldest first, by index into points
+	order := make([]int, len(locations))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return locations[order[a]].Timestamp.Before(locations[order[b]].Timestamp.Time)
+	})
+
+	for published, i := range order {
+		if err := pub_sub.PublishDriverLocation(c.Request.Context(), bus, locations[i]); err != nil {
+			// The points published so far are kept, the driver only resends the others
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":      response.StatusError,
+				"error":       err.Error(),
+				"trip_id":     trip.RequestID,
+				"accepted":    published,
+				"unpublished": slices.Sorted(slices.Values(order[published:])),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "OK", "trip_id": trip.RequestID, "accepted": len(locations)})
+}
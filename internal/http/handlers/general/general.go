@@ -1,17 +1,26 @@
 package general
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
 )
 
+// errNotFound is storage.ErrNotFound, which the storage parameters of the handlers shadow.
+var errNotFound = storage.ErrNotFound
+
 func GetAllServiceCategories(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		categories, err := storage.GetAllServiceCategories()
+		categories, err := storage.GetAllServiceCategories(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -28,7 +37,7 @@ func GetServiceCategory(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		category, err := storage.GetServiceCategory(id)
+		category, err := storage.GetServiceCategory(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -39,7 +48,7 @@ func GetServiceCategory(storage storage.Storage) gin.HandlerFunc {
 
 func GetAllVehicles(storage storage.Storage) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		vehicles, err := storage.GetAllVehicles()
+		vehicles, err := storage.GetAllVehicles(c.Request.Context())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -56,7 +65,7 @@ func GetVehicle(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		vehicle, err := storage.GetVehicle(id)
+		vehicle, err := storage.GetVehicle(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -73,7 +82,7 @@ func GetUserByID(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		user, err := storage.GetUserByID(id)
+		user, err := storage.GetUserByID(c.Request.Context(), id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -94,7 +103,7 @@ func GetUserByEmail(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		user, err := storage.GetUserByEmail(email.Email)
+		user, err := storage.GetUserByEmail(c.Request.Context(), email.Email)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -103,3 +112,320 @@ func GetUserByEmail(storage storage.Storage) gin.HandlerFunc {
 
 	}
 }
+
+func GetNotificationPreferences(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preferences, err := storage.GetNotificationPreferences(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		// No stored channels means the default channels
+		if preferences.Channels == nil {
+			preferences.Channels = []string{}
+		}
+		c.JSON(http.StatusOK, preferences)
+	}
+}
+
+func UpdateNotificationPreferences(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.NotificationPreferences
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		if err := storage.SetNotificationPreferences(c.Request.Context(), middleware.Actor(c).UserID, input); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, input)
+	}
+}
+
+// GetNotifications lists the caller's inbox, newest first. Query parameters: page, page_size and
+// unread=true to only list unread notifications.
+func GetNotifications(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize, ok := pagination(c)
+		if !ok {
+			return
+		}
+		unreadOnly := c.Query("unread") == "true"
+
+		userID := middleware.Actor(c).UserID
+		notifications, total, err := storage.ListNotifications(c.Request.Context(), userID, unreadOnly, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		unread, err := storage.CountUnreadNotifications(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.NotificationPage{
+			Notifications: notifications,
+			Total:         total,
+			Unread:        unread,
+			Page:          page,
+			PageSize:      pageSize,
+		})
+	}
+}
+
+func GetUnreadNotificationCount(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		unread, err := storage.CountUnreadNotifications(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"unread": unread})
+	}
+}
+
+func MarkNotificationRead(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+			return
+		}
+
+		if err := storage.MarkNotificationRead(c.Request.Context(), middleware.Actor(c).UserID, notificationID); err != nil {
+			if errors.Is(err, errNotFound) {
+				c.JSON(http.StatusNotFound, response.GeneralError(err))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Read Notification ID": notificationID})
+	}
+}
+
+func MarkAllNotificationsRead(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		marked, err := storage.MarkAllNotificationsRead(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "marked_read": marked})
+	}
+}
+
+// pagination reads the page (from 1) and page_size (up to 100) query parameters. It responds
+// with 400 and returns false if they are invalid.
+func pagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive integer"})
+		return 0, 0, false
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page_size must be between 1 and 100"})
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+func CreateWebhookEndpoint(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.WebhookEndpointInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := validateWebhookInput(input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		endpoint := types.WebhookEndpoint{
+			UserID:      middleware.Actor(c).UserID,
+			URL:         input.URL,
+			Description: input.Description,
+			EventTypes:  input.EventTypes,
+			Secret:      webhooks.NewSecret(),
+			IsActive:    input.IsActive == nil || *input.IsActive,
+		}
+		endpointID, err := storage.CreateWebhookEndpoint(c.Request.Context(), endpoint)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		created, err := storage.GetWebhookEndpoint(c.Request.Context(), endpointID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		// The only time the secret is returned
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func GetWebhookEndpoints(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoints, err := storage.ListWebhookEndpoints(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		for i := range endpoints {
+			endpoints[i].Secret = ""
+		}
+		c.JSON(http.StatusOK, endpoints)
+	}
+}
+
+func GetWebhookEndpoint(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := ownWebhookEndpoint(c, storage)
+		if !ok {
+			return
+		}
+		endpoint.Secret = ""
+		c.JSON(http.StatusOK, endpoint)
+	}
+}
+
+func UpdateWebhookEndpoint(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := ownWebhookEndpoint(c, storage)
+		if !ok {
+			return
+		}
+
+		var input types.WebhookEndpointInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if err := validateWebhookInput(input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		endpoint.URL = input.URL
+		endpoint.Description = input.Description
+		endpoint.EventTypes = input.EventTypes
+		if input.IsActive != nil {
+			endpoint.IsActive = *input.IsActive
+		}
+		if err := storage.UpdateWebhookEndpoint(c.Request.Context(), endpoint); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		updated, err := storage.GetWebhookEndpoint(c.Request.Context(), endpoint.EndpointID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		updated.Secret = ""
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteWebhookEndpoint(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := ownWebhookEndpoint(c, storage)
+		if !ok {
+			return
+		}
+
+		if err := storage.DeleteWebhookEndpoint(c.Request.Context(), endpoint.EndpointID); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Deleted Webhook Endpoint ID": endpoint.EndpointID})
+	}
+}
+
+// SendTestWebhook delivers a test event to an endpoint right away and returns the delivery.
+func SendTestWebhook(storage storage.Storage, dispatcher *webhooks.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := ownWebhookEndpoint(c, storage)
+		if !ok {
+			return
+		}
+
+		delivery, err := dispatcher.SendTest(c.Request.Context(), endpoint)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, delivery)
+	}
+}
+
+// GetWebhookDeliveries returns the delivery log of an endpoint, newest first.
+func GetWebhookDeliveries(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint, ok := ownWebhookEndpoint(c, storage)
+		if !ok {
+			return
+		}
+		page, pageSize, ok := pagination(c)
+		if !ok {
+			return
+		}
+
+		deliveries, total, err := storage.ListWebhookDeliveries(c.Request.Context(), endpoint.EndpointID, pageSize, (page-1)*pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, types.WebhookDeliveryPage{
+			Deliveries: deliveries,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+		})
+	}
+}
+
+// ownWebhookEndpoint loads the endpoint in the id parameter if it belongs to the caller. It
+// responds with an error and returns false otherwise.
+func ownWebhookEndpoint(c *gin.Context, storage storage.Storage) (types.WebhookEndpoint, bool) {
+	endpointID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook endpoint ID"})
+		return types.WebhookEndpoint{}, false
+	}
+
+	endpoint, err := storage.GetWebhookEndpoint(c.Request.Context(), endpointID)
+	if errors.Is(err, errNotFound) || (err == nil && endpoint.UserID != middleware.Actor(c).UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook endpoint not found"})
+		return types.WebhookEndpoint{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return types.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+func validateWebhookInput(input types.WebhookEndpointInput) error {
+	if !strings.HasPrefix(input.URL, "https://") && !strings.HasPrefix(input.URL, "http://") {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	for _, eventType := range input.EventTypes {
+		if !webhooks.ValidEventType(eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
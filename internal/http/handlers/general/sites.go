@@ -0,0 +1,142 @@
+package general
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+// CreateSite adds a geofenced site of the caller: a pickup location for businesses, a drop-off
+// facility for collectors.
+func CreateSite(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.SiteInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		actor := middleware.Actor(c)
+		site := types.Site{OwnerID: actor.UserID, Kind: types.SitePickup}
+		if actor.Role == "Collector" {
+			site.Kind = types.SiteDropOff
+		}
+		applySiteInput(&site, input)
+
+		siteID, err := storage.CreateSite(c.Request.Context(), site)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		created, err := storage.GetSite(c.Request.Context(), siteID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func GetSites(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sites, err := storage.ListSites(c.Request.Context(), middleware.Actor(c).UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, sites)
+	}
+}
+
+func GetSite(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		site, ok := ownSite(c, storage)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, site)
+	}
+}
+
+// UpdateSite replaces the fence of a site. Trips already inside it are judged by the new fence
+// from their next location on.
+func UpdateSite(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		site, ok := ownSite(c, storage)
+		if !ok {
+			return
+		}
+
+		var input types.SiteInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		applySiteInput(&site, input)
+
+		if err := storage.UpdateSite(c.Request.Context(), site); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		updated, err := storage.GetSite(c.Request.Context(), site.SiteID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteSite(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		site, ok := ownSite(c, storage)
+		if !ok {
+			return
+		}
+
+		if err := storage.DeleteSite(c.Request.Context(), site.SiteID); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK", "Deleted Site ID": site.SiteID})
+	}
+}
+
+// ownSite loads the site in the id parameter if it belongs to the caller. It responds with an
+// error and returns false otherwise.
+func ownSite(c *gin.Context, storage storage.Storage) (types.Site, bool) {
+	siteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid site ID"})
+		return types.Site{}, false
+	}
+
+	site, err := storage.GetSite(c.Request.Context(), siteID)
+	if errors.Is(err, errNotFound) || (err == nil && site.OwnerID != middleware.Actor(c).UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "site not found"})
+		return types.Site{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+		return types.Site{}, false
+	}
+	return site, true
+}
+
+func applySiteInput(site *types.Site, input types.SiteInput) {
+	site.Name = input.Name
+	site.Address = input.Address
+	site.Latitude = *input.Latitude
+	site.Longitude = *input.Longitude
+	site.RadiusMeters = input.RadiusMeters
+	site.DwellSeconds = input.DwellSeconds
+	site.AutoAdvance = input.AutoAdvance
+}
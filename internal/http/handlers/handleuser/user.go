@@ -1,6 +1,7 @@
 package handleuser
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,13 +10,15 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func CreateBusinessUser(storage storage.Storage) gin.HandlerFunc {
+func CreateBusinessUser(storage storage.Storage, geocoder geocode.Geocoder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var business types.Business
 		if err := c.ShouldBindJSON(&business); err != nil {
@@ -27,8 +30,11 @@ func CreateBusinessUser(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
+		if business.Latitude == nil {
+			business.Latitude, business.Longitude = geocode.Locate(c.Request.Context(), geocoder, business.Business_address)
+		}
 
-		lastId, err := storage.CreateBusinessUser(business)
+		lastId, err := storage.CreateBusinessUser(c.Request.Context(), business)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -39,7 +45,7 @@ func CreateBusinessUser(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
-func CreateCollectorUser(storage storage.Storage) gin.HandlerFunc {
+func CreateCollectorUser(storage storage.Storage, geocoder geocode.Geocoder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var collector types.Collector
 		if err := c.ShouldBindJSON(&collector); err != nil {
@@ -51,8 +57,11 @@ func CreateCollectorUser(storage storage.Storage) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
 		}
+		if collector.Latitude == nil {
+			collector.Latitude, collector.Longitude = geocode.Locate(c.Request.Context(), geocoder, collector.Address)
+		}
 
-		lastId, err := storage.CreateCollectorUser(collector)
+		lastId, err := storage.CreateCollectorUser(c.Request.Context(), collector)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
 			return
@@ -113,7 +122,7 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 			return
 		}
 
-		user, err := storage.GetUserByEmail(loginData.Email)
+		user, err := storage.GetUserByEmail(c.Request.Context(), loginData.Email)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, response.GeneralError(fmt.Errorf("User with this email not found")))
 			return
@@ -131,13 +140,13 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 		switch user.Role {
 
 		case "Business":
-			business, err = storage.GetBusinessByEmail(loginData.Email)
+			business, err = storage.GetBusinessByEmail(c.Request.Context(), loginData.Email)
 			if err != nil {
 				c.JSON(http.StatusUnauthorized, err)
 				return
 			}
 
-			timestamp, err := UpdateLoginTimestamp(storage, user)
+			timestamp, err := UpdateLoginTimestamp(c.Request.Context(), storage, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -145,13 +154,13 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 			business.LastLogin = timestamp
 
 		case "Collector":
-			collector, err = storage.GetCollectorByEmail(loginData.Email)
+			collector, err = storage.GetCollectorByEmail(c.Request.Context(), loginData.Email)
 			if err != nil {
 				c.JSON(http.StatusUnauthorized, err)
 				return
 			}
 
-			timestamp, err := UpdateLoginTimestamp(storage, user)
+			timestamp, err := UpdateLoginTimestamp(c.Request.Context(), storage, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -159,11 +168,11 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 			collector.LastLogin = timestamp
 
 		case "Admin":
-			admin, err = storage.GetUserByEmail(loginData.Email)
+			admin, err = storage.GetUserByEmail(c.Request.Context(), loginData.Email)
 			if err != nil {
 				c.JSON(http.StatusUnauthorized, err)
 			}
-			timestamp, err := UpdateLoginTimestamp(storage, user)
+			timestamp, err := UpdateLoginTimestamp(c.Request.Context(), storage, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, err)
 				return
@@ -186,6 +195,9 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 			"exp":     time.Now().Add(24 * time.Hour).Unix(),
 			"iat":     time.Now().Unix(),
 		}
+		if user.MustChangePassword {
+			claims["must_change_password"] = true // Only PUT /auth/password is allowed with this token
+		}
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 		signedToken, err := token.SignedString([]byte(secret))
@@ -223,10 +235,85 @@ func Login(storage storage.Storage) gin.HandlerFunc {
 	}
 }
 
-func UpdateLoginTimestamp(storage storage.Storage, user types.User) (types.DateTime, error) {
+func UpdateLoginTimestamp(ctx context.Context, storage storage.Storage, user types.User) (types.DateTime, error) {
 	user.LastLogin = types.DateTime{Time: time.Now()}
-	if err := storage.UpdateLastLogin(user.UserID, user.LastLogin); err != nil {
+	if err := storage.UpdateLastLogin(ctx, user.UserID, user.LastLogin); err != nil {
 		return types.DateTime{}, err
 	}
 	return user.LastLogin, nil
 }
+
+// ChangePassword replaces the password of the authenticated user, who has to give the current
+// one. Tokens issued before keep their claims, so admins who had to change it log in again.
+func ChangePassword(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.PasswordChange
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+		if input.NewPassword == input.CurrentPassword {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the new password must differ from the current one"})
+			return
+		}
+
+		actor := middleware.Actor(c)
+		user, err := storage.GetUserByID(c.Request.Context(), uint64(actor.UserID))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, response.GeneralError(fmt.Errorf("user not found")))
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+			c.JSON(http.StatusUnauthorized, response.GeneralError(fmt.Errorf("invalid current password")))
+			return
+		}
+
+		hashedPassword, err := hashPassword(input.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+		if err := storage.ChangePassword(c.Request.Context(), user.UserID, hashedPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("password changed", slog.Int64("user_id", user.UserID))
+		c.JSON(http.StatusOK, gin.H{"status": "OK"})
+	}
+}
+
+// CreateAdminUser lets an admin create another admin, recording who did. The new admin has to
+// change the given password on first login.
+func CreateAdminUser(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input types.NewAdmin
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, response.GeneralError(err))
+			return
+		}
+
+		hashedPassword, err := hashPassword(input.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		actor := middleware.Actor(c)
+		id, err := storage.CreateAdminUser(c.Request.Context(), types.User{
+			Email:        input.Email,
+			PasswordHash: hashedPassword,
+			FullName:     input.FullName,
+			PhoneNumber:  input.PhoneNumber,
+			Registration: types.Date{Time: time.Now()},
+			LastLogin:    types.DateTime{Time: time.Now()},
+		}, actor.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		slog.Info("admin user created", slog.Int64("user_id", id), slog.Int64("created_by", actor.UserID))
+		c.JSON(http.StatusCreated, gin.H{"status": "OK", "user": id})
+	}
+}
@@ -0,0 +1,94 @@
+package tracking
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+// GetTrack returns the path the driver took for a pickup request. Query parameters: tolerance,
+// in meters, to simplify the path by, and format, one of json (the default), gpx, kml or
+// geojson.
+func GetTrack(storage storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tolerance, err := strconv.ParseFloat(c.DefaultQuery("tolerance", "0"), 64)
+		if err != nil || tolerance < 0 || math.IsInf(tolerance, 0) || math.IsNaN(tolerance) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tolerance has to be a number of meters"})
+			return
+		}
+		format := c.DefaultQuery("format", "json")
+		switch format {
+		case "json", "gpx", "kml", "geojson":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format has to be json, gpx, kml or geojson"})
+			return
+		}
+
+		tracker, ok := authorize(c, storage)
+		if !ok {
+			return
+		}
+
+		locations, err := storage.GetTripLocations(c.Request.Context(), tracker.request.RequestID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, response.GeneralError(err))
+			return
+		}
+
+		points := make([]geo.TrackPoint, len(locations))
+		positions := make([]geo.Point, len(locations))
+		for i, location := range locations {
+			positions[i] = geo.Point{Lat: location.Latitude, Lng: location.Longitude}
+			points[i] = geo.TrackPoint{Point: positions[i], Time: location.Timestamp.Time, Speed: float64(location.Speed)}
+		}
+		summary := geo.Summarize(points)
+
+		kept := geo.Simplify(positions, tolerance)
+		track := geo.Track{Name: fmt.Sprintf("Pickup request %d", tracker.request.RequestID), Points: make([]geo.TrackPoint, 0, len(kept))}
+		simplified := make([]types.DriverLocation, 0, len(kept))
+		for _, i := range kept {
+			track.Points = append(track.Points, points[i])
+			simplified = append(simplified, locations[i])
+		}
+
+		filename := fmt.Sprintf("pickup-request-%d-track", tracker.request.RequestID)
+		switch format {
+		case "gpx":
+			writeTrack(c, geo.GPXContentType, filename+".gpx", track, geo.WriteGPX)
+		case "kml":
+			writeTrack(c, geo.KMLContentType, filename+".kml", track, geo.WriteKML)
+		case "geojson":
+			writeTrack(c, geo.GeoJSONContentType, filename+".geojson", track, geo.WriteGeoJSON)
+		default:
+			c.JSON(http.StatusOK, types.TripTrack{
+				RequestID:       tracker.request.RequestID,
+				Tolerance:       tolerance,
+				RecordedPoints:  len(locations),
+				DistanceMeters:  math.Round(summary.Distance*10) / 10,
+				DurationSeconds: summary.Duration.Seconds(),
+				AverageSpeedKmh: math.Round(summary.AverageSpeed*100) / 100,
+				MaxSpeedKmh:     summary.MaxSpeed,
+				Points:          simplified,
+			})
+		}
+	}
+}
+
+func writeTrack(c *gin.Context, contentType string, filename string, track geo.Track, write func(w io.Writer, track geo.Track) error) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := write(c.Writer, track); err != nil {
+		// The status is already sent, all that is left is to cut the response short
+		c.Error(err)
+		c.Abort()
+	}
+}
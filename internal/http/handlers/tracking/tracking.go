@@ -0,0 +1,226 @@
+package tracking
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+const (
+	pingInterval = 30 * time.Second // Keeps proxies from closing idle streams
+	writeTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Clients authenticate with a token rather than cookies, so any origin can connect, like CORS
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// WebSocket streams the locations and ETAs of the driver of a pickup request as JSON
+// realtime.Message objects, starting with the latest ones stored.
+func WebSocket(storage storage.Storage, hub *realtime.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracker, ok := authorize(c, storage)
+		if !ok {
+			return
+		}
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader already responded
+			slog.Warn("websocket upgrade failed", slog.String("error", err.Error()))
+			return
+		}
+		defer conn.Close()
+
+		sub := hub.Subscribe(tracker.request.RequestID)
+		defer sub.Close()
+
+		// Reading until the client goes away, which also handles its pongs and close frames
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		send := func(message realtime.Message) bool {
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			return conn.WriteJSON(message) == nil
+		}
+		for _, message := range tracker.latest(c) {
+			if !send(message) {
+				return
+			}
+		}
+
+		ping := time.NewTicker(pingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-gone:
+				return
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+			case message, ok := <-sub.Messages():
+				if !ok {
+					return
+				}
+				if tracker.allowed(c, message) && !send(message) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Events is WebSocket over Server-Sent Events, for clients without WebSockets. Locations are
+// sent as "location" events and ETAs as "eta" events.
+func Events(storage storage.Storage, hub *realtime.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracker, ok := authorize(c, storage)
+		if !ok {
+			return
+		}
+
+		sub := hub.Subscribe(tracker.request.RequestID)
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no") // Or nginx holds the events back
+		for _, message := range tracker.latest(c) {
+			sendEvent(c, message)
+		}
+		c.Writer.Flush()
+
+		ping := time.NewTicker(pingInterval)
+		defer ping.Stop()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+				return true
+			case message, ok := <-sub.Messages():
+				if !ok {
+					return false
+				}
+				if tracker.allowed(c, message) {
+					sendEvent(c, message)
+				}
+				return true
+			}
+		})
+	}
+}
+
+func sendEvent(c *gin.Context, message realtime.Message) {
+	if message.Location != nil {
+		c.SSEvent(message.Type, message.Location)
+	} else {
+		c.SSEvent(message.Type, message.ETA)
+	}
+}
+
+// tracker is a client tracking a pickup request.
+type tracker struct {
+	storage storage.Storage
+	actor   types.Actor
+	request types.PickupRequest
+}
+
+// authorize lets businesses track their own pickup requests, collectors the requests they
+// serve and drivers the requests assigned to them. It responds to the client if not.
+func authorize(c *gin.Context, storage storage.Storage) (*tracker, bool) {
+	requestID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pickup-request ID"})
+		return nil, false
+	}
+
+	request, err := storage.GetPickupRequestByID(c.Request.Context(), requestID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pickup request ID not found"})
+		return nil, false
+	}
+
+	actor := middleware.Actor(c)
+	var owner int64
+	switch actor.Role {
+	case "Business":
+		owner = request.BusinessID
+	case "Collector":
+		owner = request.CollectorID
+	case "Driver":
+		owner = request.AssignedDriver
+	}
+	if owner == 0 || owner != actor.UserID {
+		c.JSON(http.StatusForbidden, response.GeneralError(fmt.Errorf("pickup request cannot be tracked by this user")))
+		return nil, false
+	}
+
+	return &tracker{storage: storage, actor: actor, request: request}, true
+}
+
+// allowed tells whether the client may see a message about its pickup request: collectors only
+// see their own fleet, businesses and drivers only the driver now assigned to the request.
+func (t *tracker) allowed(c *gin.Context, message realtime.Message) bool {
+	if t.actor.Role == "Collector" {
+		return message.Location == nil || message.Location.CollectorID == t.actor.UserID
+	}
+
+	driverID := message.DriverID()
+	if driverID != t.request.AssignedDriver {
+		// The request may have been reassigned since the client connected
+		request, err := t.storage.GetPickupRequestByID(c.Request.Context(), t.request.RequestID)
+		if err != nil {
+			return false
+		}
+		t.request = request
+	}
+	if t.actor.Role == "Driver" && t.request.AssignedDriver != t.actor.UserID {
+		return false
+	}
+	return driverID == t.request.AssignedDriver
+}
+
+// latest returns the location stored last for the pickup request and its ETA, those the client
+// may see.
+func (t *tracker) latest(c *gin.Context) []realtime.Message {
+	var messages []realtime.Message
+	locations, err := t.storage.GetLatestTripLocations(c.Request.Context(), t.request.RequestID, 1)
+	if err == nil && len(locations) > 0 {
+		messages = append(messages, realtime.Message{Type: realtime.LocationMessage, Location: &locations[0]})
+	}
+	if eta, err := t.storage.GetPickupETA(c.Request.Context(), t.request.RequestID); err == nil {
+		messages = append(messages, realtime.Message{Type: realtime.ETAMessage, ETA: &eta})
+	}
+
+	allowed := messages[:0]
+	for _, message := range messages {
+		if t.allowed(c, message) {
+			allowed = append(allowed, message)
+		}
+	}
+	return allowed
+}
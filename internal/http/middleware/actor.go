@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Actor returns the authenticated user set on the Gin context by the role middlewares.
+func Actor(c *gin.Context) types.Actor {
+	var actor types.Actor
+	if uid, ok := c.Get("user_id"); ok {
+		actor.UserID = int64(uid.(uint64))
+	}
+	actor.Role = c.GetString("role")
+	return actor
+}
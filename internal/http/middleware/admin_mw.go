@@ -64,10 +64,20 @@ func AdminOnly() gin.HandlerFunc {
 			return
 		}
 
-		// adding user_id to Gin context
+		// admins given their password by someone else can only change it until they do
+		if mustChange, _ := claims["must_change_password"].(bool); mustChange {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				response.GeneralError(fmt.Errorf("password change required, use PUT /auth/password and log in again")),
+			)
+			return
+		}
+
+		// adding user_id and role to Gin context
 		if uid, ok := claims["user_id"].(float64); ok {
 			c.Set("user_id", uint64(uid))
 		}
+		c.Set("role", role)
 
 		c.Next()
 	}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kartikey1188/build-in-progress_01/internal/utils/response"
+)
+
+// Authenticated accepts a valid token of any role, including admins who still have to change
+// their password.
+func Authenticated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.GeneralError(fmt.Errorf("authorization header required")))
+			return
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == authHeader {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.GeneralError(fmt.Errorf("invalid authorization header format")))
+			return
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+
+			jwtSecret := os.Getenv("JWT_SECRET")
+			if jwtSecret == "" {
+				return nil, fmt.Errorf("jwt secret not configured")
+			}
+
+			return []byte(jwtSecret), nil
+		})
+
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				response.GeneralError(fmt.Errorf("invalid or expired token")),
+			)
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				response.GeneralError(fmt.Errorf("invalid token claims")),
+			)
+			return
+		}
+
+		role, ok := claims["role"].(string)
+		if !ok {
+			c.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				response.GeneralError(fmt.Errorf("invalid token claims")),
+			)
+			return
+		}
+
+		// adding user_id and role to Gin context
+		if uid, ok := claims["user_id"].(float64); ok {
+			c.Set("user_id", uint64(uid))
+		}
+		c.Set("role", role)
+
+		c.Next()
+	}
+}
+
+// StreamAuthenticated is Authenticated for WebSocket and EventSource clients, which cannot set
+// headers: the token may also be passed as the access_token query parameter.
+func StreamAuthenticated() gin.HandlerFunc {
+	authenticated := Authenticated()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		authenticated(c)
+	}
+}
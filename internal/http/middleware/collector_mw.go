@@ -64,10 +64,11 @@ func CollectorOnly() gin.HandlerFunc {
 			return
 		}
 
-		// adding user_id to Gin context
+		// adding user_id and role to Gin context
 		if uid, ok := claims["user_id"].(float64); ok {
 			c.Set("user_id", uint64(uid))
 		}
+		c.Set("role", role)
 
 		c.Next()
 	}
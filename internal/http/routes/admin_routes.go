@@ -1,19 +1,27 @@
 package routes
 
 import (
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/admin"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/business"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/collector"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/general"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/handleuser"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 )
 
-func Admin(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Client) {
+func Admin(router *gin.Engine, storage storage.Storage, bus eventbus.Bus, listeners *pub_sub.Supervisor) {
 	admin_routes := router.Group("/admin")
 	admin_routes.Use(middleware.AdminOnly())
 
+	admin_routes.GET("/notifications", general.GetNotifications(storage))
+	admin_routes.GET("/notifications/unread-count", general.GetUnreadNotificationCount(storage))
+	admin_routes.PUT("/notifications/read-all", general.MarkAllNotificationsRead(storage))
+	admin_routes.PUT("/notifications/:id/read", general.MarkNotificationRead(storage))
+
 	admin_routes.PUT("/verify/:id", admin.VerifyUser(storage))
 	admin_routes.PUT("/unverify/:id", admin.UnverifyUser(storage))
 	admin_routes.PUT("/flag/:id", admin.FlagUser(storage))
@@ -28,8 +36,24 @@ func Admin(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Cli
 	admin_routes.GET("/all/collectors", admin.GetAllCollectors(storage))
 	admin_routes.GET("/all/businesses", admin.GetAllBusinesses(storage))
 	admin_routes.GET("/all/users", admin.GetAllUsers(storage))
+
+	admin_routes.POST("/admins", handleuser.CreateAdminUser(storage))
+	admin_routes.GET("/admins/audit", admin.GetAdminAuditRecords(storage))
 	admin_routes.GET("/collector/:id", collector.GetCollectorByID(storage))
 	admin_routes.GET("/business/:id", business.GetBusinessByID(storage))
 
 	admin_routes.GET("/all/pickup-requests", admin.GetAllPickupRequests(storage))
+
+	admin_routes.GET("/subscribers", admin.GetSubscriberStatus(listeners))
+
+	admin_routes.GET("/dead-letters", admin.GetDeadLetters(storage))
+	admin_routes.GET("/dead-letters/:id", admin.GetDeadLetter(storage))
+	admin_routes.POST("/dead-letters/:id/replay", admin.ReplayDeadLetter(storage, bus))
+	admin_routes.DELETE("/dead-letters/:id", admin.DiscardDeadLetter(storage))
+
+	admin_routes.GET("/notification-templates", admin.GetNotificationTemplates(storage))
+	admin_routes.GET("/notification-templates/:name/:locale", admin.GetNotificationTemplate(storage))
+	admin_routes.PUT("/notification-templates/:name/:locale", admin.UpdateNotificationTemplate(storage))
+	admin_routes.DELETE("/notification-templates/:name/:locale", admin.DeleteNotificationTemplate(storage))
+	admin_routes.POST("/notification-templates/:name/:locale/preview", admin.PreviewNotificationTemplate(storage))
 }
@@ -2,15 +2,18 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/handleuser"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/home"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 )
 
-func SetupAuth(router *gin.Engine, storage storage.Storage) {
+func SetupAuth(router *gin.Engine, storage storage.Storage, geocoder geocode.Geocoder) {
 	router.GET("/", home.Home())
-	router.POST("/auth/register/business", handleuser.CreateBusinessUser(storage))   // Changed
-	router.POST("/auth/register/collector", handleuser.CreateCollectorUser(storage)) // Changed
+	router.POST("/auth/register/business", handleuser.CreateBusinessUser(storage, geocoder))   // Changed
+	router.POST("/auth/register/collector", handleuser.CreateCollectorUser(storage, geocoder)) // Changed
 	router.POST("/auth/login", handleuser.Login(storage))
+	router.PUT("/auth/password", middleware.Authenticated(), handleuser.ChangePassword(storage))
 	router.StaticFile("/docs/openapi.yaml", "./docs/openapi.yaml")
 }
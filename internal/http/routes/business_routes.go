@@ -1,23 +1,55 @@
 package routes
 
 import (
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/business"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/general"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/tracking"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
 )
 
-func BusinessRoutes(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Client) {
+func BusinessRoutes(router *gin.Engine, storage storage.Storage, bus eventbus.Bus, dispatcher *webhooks.Dispatcher, geocoder geocode.Geocoder, checker *coverage.Checker) {
 	business_routes := router.Group("/business")
 	business_routes.Use(middleware.BusinessOnly())
 
+	business_routes.GET("/notification-preferences", general.GetNotificationPreferences(storage))
+	business_routes.PUT("/notification-preferences", general.UpdateNotificationPreferences(storage))
+
+	business_routes.GET("/notifications", general.GetNotifications(storage))
+	business_routes.GET("/notifications/unread-count", general.GetUnreadNotificationCount(storage))
+	business_routes.PUT("/notifications/read-all", general.MarkAllNotificationsRead(storage))
+	business_routes.PUT("/notifications/:id/read", general.MarkNotificationRead(storage))
+
+	business_routes.POST("/webhooks", general.CreateWebhookEndpoint(storage))
+	business_routes.GET("/webhooks", general.GetWebhookEndpoints(storage))
+	business_routes.GET("/webhooks/:id", general.GetWebhookEndpoint(storage))
+	business_routes.PUT("/webhooks/:id", general.UpdateWebhookEndpoint(storage))
+	business_routes.DELETE("/webhooks/:id", general.DeleteWebhookEndpoint(storage))
+	business_routes.POST("/webhooks/:id/test", general.SendTestWebhook(storage, dispatcher))
+	business_routes.GET("/webhooks/:id/deliveries", general.GetWebhookDeliveries(storage))
+
+	business_routes.POST("/sites", general.CreateSite(storage))
+	business_routes.GET("/sites", general.GetSites(storage))
+	business_routes.GET("/sites/:id", general.GetSite(storage))
+	business_routes.PUT("/sites/:id", general.UpdateSite(storage))
+	business_routes.DELETE("/sites/:id", general.DeleteSite(storage))
+
 	business_routes.GET("/:id", business.GetBusinessByID(storage))
 	business_routes.GET("", business.GetBusinessByEmail(storage))
-	business_routes.PATCH("/profile/:id", business.UpdateBusinessProfile(storage))
+	business_routes.PATCH("/profile/:id", business.UpdateBusinessProfile(storage, geocoder))
 
-	business_routes.POST("/pickup-requests", business.CreatePickupRequest(storage, pubsubClient))
+	business_routes.POST("/collector-matches", business.MatchCollectors(storage, checker))
+	business_routes.POST("/pickup-requests", business.CreatePickupRequest(storage, checker))
 	business_routes.GET("/pickup-requests/:id", business.GetPickupRequestByID(storage))
+	business_routes.GET("/pickup-requests/:id/timeline", business.GetPickupRequestTimeline(storage))
+	business_routes.GET("/pickup-requests/:id/track", tracking.GetTrack(storage))
+	business_routes.GET("/pickup-requests/:id/bids", business.GetPickupBids(storage))
+	business_routes.POST("/pickup-requests/:id/bids/:bid_id/accept", business.AcceptPickupBid(storage))
 	// business_routes.DELETE("/pickup-request/:id", business.CancelPickupRequest(storage))
 	business_routes.GET("pickup-requests/all/:id", business.GetAllPickupRequestsForBusiness(storage))
 	business_routes.PATCH("pickup-requests/:id", business.UpdatePickupRequest(storage))
@@ -1,18 +1,51 @@
 package routes
 
 import (
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/collector"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/general"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/tracking"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
 )
 
-func CollectorRoutes(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Client) {
+func CollectorRoutes(router *gin.Engine, storage storage.Storage, bus eventbus.Bus, dispatcher *webhooks.Dispatcher, geocoder geocode.Geocoder, checker *coverage.Checker) {
 	collector_routes := router.Group("/collector")
 	collector_routes.Use(middleware.CollectorOnly())
 
-	collector_routes.PATCH("/profile/:id", collector.UpdateProfile(storage))
+	collector_routes.GET("/notification-preferences", general.GetNotificationPreferences(storage))
+	collector_routes.PUT("/notification-preferences", general.UpdateNotificationPreferences(storage))
+
+	collector_routes.GET("/notifications", general.GetNotifications(storage))
+	collector_routes.GET("/notifications/unread-count", general.GetUnreadNotificationCount(storage))
+	collector_routes.PUT("/notifications/read-all", general.MarkAllNotificationsRead(storage))
+	collector_routes.PUT("/notifications/:id/read", general.MarkNotificationRead(storage))
+
+	collector_routes.POST("/webhooks", general.CreateWebhookEndpoint(storage))
+	collector_routes.GET("/webhooks", general.GetWebhookEndpoints(storage))
+	collector_routes.GET("/webhooks/:id", general.GetWebhookEndpoint(storage))
+	collector_routes.PUT("/webhooks/:id", general.UpdateWebhookEndpoint(storage))
+	collector_routes.DELETE("/webhooks/:id", general.DeleteWebhookEndpoint(storage))
+	collector_routes.POST("/webhooks/:id/test", general.SendTestWebhook(storage, dispatcher))
+	collector_routes.GET("/webhooks/:id/deliveries", general.GetWebhookDeliveries(storage))
+
+	collector_routes.POST("/sites", general.CreateSite(storage))
+	collector_routes.GET("/sites", general.GetSites(storage))
+	collector_routes.GET("/sites/:id", general.GetSite(storage))
+	collector_routes.PUT("/sites/:id", general.UpdateSite(storage))
+	collector_routes.DELETE("/sites/:id", general.DeleteSite(storage))
+
+	collector_routes.POST("/service-areas", collector.CreateServiceArea(storage))
+	collector_routes.GET("/service-areas", collector.GetServiceAreas(storage))
+	collector_routes.GET("/service-areas/:id", collector.GetServiceArea(storage))
+	collector_routes.PUT("/service-areas/:id", collector.UpdateServiceArea(storage))
+	collector_routes.DELETE("/service-areas/:id", collector.DeleteServiceArea(storage))
+
+	collector_routes.PATCH("/profile/:id", collector.UpdateProfile(storage, geocoder))
 	collector_routes.GET("", collector.GetCollectorByEmail(storage))
 	collector_routes.GET("/:id", collector.GetCollectorByID(storage))
 
@@ -36,14 +69,23 @@ func CollectorRoutes(router *gin.Engine, storage storage.Storage, pubsubClient *
 	collector_routes.DELETE("/:id/drivers", collector.DeleteCollectorDriver(storage))
 	collector_routes.PUT("/:id/drivers/assign-vehicle", collector.AssignVehicleToDriver(storage))
 	collector_routes.DELETE("/:id/drivers/unassign-vehicle", collector.UnassignVehicleFromDriver(storage))
-	collector_routes.POST("/assign-trip/:id/driver/:did", collector.AssignTripToDriver(storage, pubsubClient))
-	collector_routes.POST("/unassign-trip/:id/driver", collector.UnassignTripFromDriver(storage, pubsubClient))
+	collector_routes.POST("/assign-trip/:id/driver/:did", collector.AssignTripToDriver(storage))
+	collector_routes.POST("/unassign-trip/:id/driver", collector.UnassignTripFromDriver(storage))
+
+	// Pickup Requests
+	collector_routes.POST("/pickup-request/:id/accept", collector.AcceptPickupRequest(storage))
+	collector_routes.POST("/pickup-request/:id/reject", collector.RejectPickupRequest(storage))
+	collector_routes.GET("/pickup-requests/:id/timeline", collector.GetPickupRequestTimeline(storage))
+	collector_routes.GET("/pickup-requests/:id/track", tracking.GetTrack(storage))
+
+	// Open Market
+	collector_routes.GET("/open-pickup-requests", collector.GetOpenPickupRequests(storage))
+	collector_routes.POST("/open-pickup-requests/:id/bids", collector.PlaceBid(storage))
+	collector_routes.POST("/open-pickup-requests/:id/accept", collector.AcceptOpenPickupRequest(storage))
 
 	// Open-access
-	router.GET("/collectors", collector.ListCollectors(storage))
+	router.GET("/collectors", collector.ListCollectors(storage, checker))
+	router.GET("/collectors/nearby", collector.GetNearbyCollectors(storage))
 	router.GET("/collector/:id/service-categories", collector.GetCollectorServiceCategories(storage))
 	router.GET("/collector/:id/vehicles", collector.GetCollectorVehicles(storage))
-
-	router.POST("/collector/pickup-request/:id/accept", collector.AcceptPickupRequest(storage, pubsubClient))
-	router.POST("/collector/pickup-request/:id/reject", collector.RejectPickupRequest(storage, pubsubClient))
 }
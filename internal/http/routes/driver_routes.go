@@ -1,17 +1,29 @@
 package routes
 
 import (
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/driver"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/general"
 	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 )
 
-func DriverRoutes(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Client) {
+func DriverRoutes(router *gin.Engine, storage storage.Storage, bus eventbus.Bus) {
 	driver_routes := router.Group("/driver")
 	driver_routes.Use(middleware.DriverOnly())
 
-	driver_routes.POST("/delivery/:id/start", driver.StartDelivery(storage, pubsubClient))
-	driver_routes.POST("/delivery/:id/end", driver.EndDelivery(storage, pubsubClient))
+	driver_routes.GET("/notification-preferences", general.GetNotificationPreferences(storage))
+	driver_routes.PUT("/notification-preferences", general.UpdateNotificationPreferences(storage))
+
+	driver_routes.GET("/notifications", general.GetNotifications(storage))
+	driver_routes.GET("/notifications/unread-count", general.GetUnreadNotificationCount(storage))
+	driver_routes.PUT("/notifications/read-all", general.MarkAllNotificationsRead(storage))
+	driver_routes.PUT("/notifications/:id/read", general.MarkNotificationRead(storage))
+
+	driver_routes.POST("/delivery/:id/start", driver.StartDelivery(storage))
+	driver_routes.POST("/delivery/:id/end", driver.EndDelivery(storage))
+
+	driver_routes.POST("/location", driver.PostLocation(storage, bus))
+	driver_routes.POST("/location/batch", driver.PostLocationBatch(storage, bus))
 }
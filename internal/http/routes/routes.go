@@ -1,16 +1,22 @@
 package routes
 
 import (
-	"cloud.google.com/go/pubsub"
 	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/geocode"
+	"github.com/kartikey1188/build-in-progress_01/internal/pub_sub"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
 )
 
-func SetupRoutes(router *gin.Engine, storage storage.Storage, pubsubClient *pubsub.Client) {
-	SetupAuth(router, storage)
-	Admin(router, storage, pubsubClient)
-	CollectorRoutes(router, storage, pubsubClient)
-	General(router, storage, pubsubClient)
-	BusinessRoutes(router, storage, pubsubClient)
-	DriverRoutes(router, storage, pubsubClient)
+func SetupRoutes(router *gin.Engine, storage storage.Storage, bus eventbus.Bus, listeners *pub_sub.Supervisor, dispatcher *webhooks.Dispatcher, hub *realtime.Hub, geocoder geocode.Geocoder, checker *coverage.Checker) {
+	SetupAuth(router, storage, geocoder)
+	Admin(router, storage, bus, listeners)
+	CollectorRoutes(router, storage, bus, dispatcher, geocoder, checker)
+	General(router, storage, bus)
+	BusinessRoutes(router, storage, bus, dispatcher, geocoder, checker)
+	DriverRoutes(router, storage, bus)
+	TrackingRoutes(router, storage, hub)
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/handlers/tracking"
+	"github.com/kartikey1188/build-in-progress_01/internal/http/middleware"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+)
+
+// TrackingRoutes streams the location of the driver of a pickup request to its business,
+// collector and driver.
+func TrackingRoutes(router *gin.Engine, storage storage.Storage, hub *realtime.Hub) {
+	tracking_routes := router.Group("/tracking")
+	tracking_routes.Use(middleware.StreamAuthenticated())
+
+	tracking_routes.GET("/:id/ws", tracking.WebSocket(storage, hub))
+	tracking_routes.GET("/:id/events", tracking.Events(storage, hub))
+}
@@ -0,0 +1,102 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is the lifecycle state of a pickup request.
+type Status string
+
+const (
+	Pending   Status = "Pending"
+	Accepted  Status = "Accepted"
+	Rejected  Status = "Rejected"
+	Assigned  Status = "Assigned"
+	InTransit Status = "InTransit"
+	Completed Status = "Completed"
+	Cancelled Status = "Cancelled"
+)
+
+// All lists every status in lifecycle order.
+var All = []Status{Pending, Accepted, Rejected, Assigned, InTransit, Completed, Cancelled}
+
+// transitions maps each status to the statuses it may move to.
+var transitions = map[Status][]Status{
+	Pending:   {Accepted, Rejected, Cancelled},
+	Accepted:  {Assigned, Cancelled},
+	Assigned:  {Assigned, Accepted, InTransit, Cancelled}, // Assigned -> Assigned is a driver reassignment, Assigned -> Accepted an unassignment
+	InTransit: {Completed},
+	Rejected:  {},
+	Completed: {},
+	Cancelled: {},
+}
+
+// ErrIllegalTransition is wrapped by every TransitionError, and by the errors of Edit, so callers
+// can match it with errors.Is.
+var ErrIllegalTransition = errors.New("illegal pickup request status transition")
+
+// TransitionError reports a status change the lifecycle does not allow.
+type TransitionError struct {
+	From Status
+	To   Status
+	Open bool // The request is on the open market, without a collector yet
+}
+
+func (e *TransitionError) Error() string {
+	if e.Open {
+		return fmt.Sprintf("cannot move open-market pickup request from %q to %q before a collector wins it", e.From, e.To)
+	}
+	return fmt.Sprintf("cannot move pickup request from %q to %q", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// Valid reports whether s is a known status.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// Terminal reports whether no further transitions are possible from s.
+func (s Status) Terminal() bool {
+	return s.Valid() && len(transitions[s]) == 0
+}
+
+// CanTransition reports whether a pickup request may move from one status to another.
+func CanTransition(from Status, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition validates a status change, returning a *TransitionError if it is not allowed.
+func Transition(from Status, to Status) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// TransitionOpen validates a status change of an open-market pickup request no collector won
+// yet. It can only be cancelled, winning it is what accepts it.
+func TransitionOpen(from Status, to Status) error {
+	if to != Cancelled {
+		return &TransitionError{From: from, To: to, Open: true}
+	}
+	return Transition(from, to)
+}
+
+// Edit validates an edit of the details of a pickup request in the given status. They are fixed
+// once the request leaves Pending, as the collector committed to them.
+func Edit(status Status) error {
+	if status != Pending {
+		return fmt.Errorf("cannot edit a pickup request once it is %q: %w", status, ErrIllegalTransition)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	// Every allowed status change; the other pairs of All are illegal
+	allowed := map[[2]Status]bool{
+		{Pending, Accepted}:    true,
+		{Pending, Rejected}:    true,
+		{Pending, Cancelled}:   true,
+		{Accepted, Assigned}:   true,
+		{Accepted, Cancelled}:  true,
+		{Assigned, Assigned}:   true, // Reassignment
+		{Assigned, Accepted}:   true, // Unassignment
+		{Assigned, InTransit}:  true,
+		{Assigned, Cancelled}:  true,
+		{InTransit, Completed}: true,
+	}
+
+	for _, from := range All {
+		for _, to := range All {
+			want := allowed[[2]Status{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+
+			err := Transition(from, to)
+			if want {
+				if err != nil {
+					t.Errorf("Transition(%s, %s) = %v, want nil", from, to, err)
+				}
+				continue
+			}
+			var transitionErr *TransitionError
+			if !errors.As(err, &transitionErr) || transitionErr.From != from || transitionErr.To != to || transitionErr.Open {
+				t.Errorf("Transition(%s, %s) = %#v, want a *TransitionError from %s to %s", from, to, err, from, to)
+			}
+			if !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("Transition(%s, %s) = %v, does not match ErrIllegalTransition", from, to, err)
+			}
+		}
+	}
+}
+
+func TestTransitionOpen(t *testing.T) {
+	for _, from := range All {
+		for _, to := range All {
+			err := TransitionOpen(from, to)
+			switch {
+			case to == Cancelled && CanTransition(from, to):
+				if err != nil {
+					t.Errorf("TransitionOpen(%s, %s) = %v, want nil", from, to, err)
+				}
+			case to == Cancelled:
+				// Illegal regardless of the open market
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) || transitionErr.Open {
+					t.Errorf("TransitionOpen(%s, %s) = %#v, want a *TransitionError", from, to, err)
+				}
+			default:
+				// Only winning the request accepts it
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) || !transitionErr.Open || transitionErr.From != from || transitionErr.To != to {
+					t.Errorf("TransitionOpen(%s, %s) = %#v, want an open *TransitionError", from, to, err)
+				}
+			}
+			if err != nil && !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("TransitionOpen(%s, %s) = %v, does not match ErrIllegalTransition", from, to, err)
+			}
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	terminal := map[Status]bool{Rejected: true, Completed: true, Cancelled: true}
+	for _, status := range All {
+		if !status.Valid() {
+			t.Errorf("%s.Valid() = false", status)
+		}
+		if got := status.Terminal(); got != terminal[status] {
+			t.Errorf("%s.Terminal() = %v, want %v", status, got, terminal[status])
+		}
+	}
+	for _, status := range []Status{"", "pending", "Unknown"} {
+		if status.Valid() || status.Terminal() {
+			t.Errorf("%q is valid or terminal", status)
+		}
+		if err := Transition(status, Pending); !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("Transition(%q, Pending) = %v, want ErrIllegalTransition", status, err)
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	for _, status := range All {
+		err := Edit(status)
+		if status == Pending {
+			if err != nil {
+				t.Errorf("Edit(Pending) = %v, want nil", err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("Edit(%s) = %v, want ErrIllegalTransition", status, err)
+		}
+	}
+}
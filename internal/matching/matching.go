@@ -0,0 +1,158 @@
+// Package matching ranks the collectors that can take a pickup, with the breakdown of their
+// scores.
+package matching
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Weights of the factors, adding up to 100
+const (
+	capacityWeight    = 25.0
+	priceWeight       = 30.0
+	trustWeight       = 15.0
+	distanceWeight    = 20.0
+	reliabilityWeight = 10.0
+)
+
+const (
+	distanceScale   = 25.0 // km, at which the distance score is halved
+	unverifiedTrust = 0.5  // Verified collectors score 1; flagged ones are inactive and never candidates
+	unknownDistance = 0.5  // Score of collectors whose distance cannot be measured
+)
+
+// Pickup is what a business wants collected.
+type Pickup struct {
+	Quantity float64
+	Point    *geo.Point // Nil when the pickup is not located
+}
+
+// Rank scores the candidates with the capacity left for pickup, best first, and leaves out the
+// others. Prices are scored against the cheapest candidate, so scores only compare within a
+// ranking.
+func Rank(candidates []types.MatchCandidate, pickup Pickup) []types.CollectorMatch {
+	cheapest := math.Inf(1)
+	for _, candidate := range candidates {
+		if remaining(candidate) >= pickup.Quantity {
+			cheapest = math.Min(cheapest, candidate.PricePerKg*pickup.Quantity)
+		}
+	}
+
+	matches := []types.CollectorMatch{}
+	for _, candidate := range candidates {
+		if remaining(candidate) < pickup.Quantity {
+			continue
+		}
+		match := types.CollectorMatch{
+			CollectorID:       candidate.CollectorID,
+			CompanyName:       candidate.CompanyName,
+			IsVerified:        candidate.IsVerified,
+			PricePerKg:        candidate.PricePerKg,
+			EstimatedCost:     round(candidate.PricePerKg * pickup.Quantity),
+			RemainingCapacity: round(remaining(candidate)),
+		}
+		if candidate.EndedPickups > 0 {
+			rate := round(float64(candidate.CompletedPickups) / float64(candidate.EndedPickups))
+			match.CompletionRate = &rate
+		}
+		if pickup.Point != nil && candidate.Latitude != nil && candidate.Longitude != nil {
+			distance := round(geo.Distance(*pickup.Point, geo.Point{Lat: *candidate.Latitude, Lng: *candidate.Longitude}) / 1000)
+			match.DistanceKm = &distance
+		}
+
+		match.Breakdown = []types.MatchFactor{
+			capacityFactor(candidate, pickup.Quantity),
+			priceFactor(candidate.PricePerKg*pickup.Quantity, cheapest),
+			trustFactor(candidate.IsVerified),
+			distanceFactor(match.DistanceKm),
+			reliabilityFactor(candidate),
+		}
+		for _, factor := range match.Breakdown {
+			match.Score += factor.Points
+		}
+		match.Score = round(match.Score)
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].CollectorID < matches[j].CollectorID
+	})
+	return matches
+}
+
+func remaining(candidate types.MatchCandidate) float64 {
+	return candidate.MaximumCapacity - candidate.BookedQuantity
+}
+
+// capacityFactor scores the share of the capacity of the day still free after the pickup, as
+// collectors close to full are likelier to turn it down or run late.
+func capacityFactor(candidate types.MatchCandidate, quantity float64) types.MatchFactor {
+	left := remaining(candidate)
+	return factor("capacity", capacityWeight, (left-quantity)/candidate.MaximumCapacity,
+		fmt.Sprintf("%s of %s kg free on the pickup date, %s after this pickup", kg(left), kg(candidate.MaximumCapacity), kg(left-quantity)))
+}
+
+// priceFactor scores the cost of the pickup against the cheapest.
+func priceFactor(cost float64, cheapest float64) types.MatchFactor {
+	score := 1.0
+	if cost > 0 {
+		score = cheapest / cost
+	}
+	if cost == cheapest {
+		return factor("price", priceWeight, score, fmt.Sprintf("%.2f for the pickup, the lowest price", cost))
+	}
+	return factor("price", priceWeight, score, fmt.Sprintf("%.2f for the pickup, against %.2f at the cheapest", cost, cheapest))
+}
+
+func trustFactor(verified bool) types.MatchFactor {
+	if verified {
+		return factor("verification", trustWeight, 1, "verified by an admin, not flagged")
+	}
+	return factor("verification", trustWeight, unverifiedTrust, "not verified yet, not flagged")
+}
+
+// distanceFactor scores the straight line distance from the depot, halving every distanceScale.
+func distanceFactor(distance *float64) types.MatchFactor {
+	if distance == nil {
+		return factor("distance", distanceWeight, unknownDistance, "unknown, the pickup or the depot is not located")
+	}
+	return factor("distance", distanceWeight, 1/(1+*distance/distanceScale), fmt.Sprintf("%.1f km from the depot", *distance))
+}
+
+// reliabilityFactor scores the completion rate of the finished pickups, counting one completed
+// and one not on top so that new collectors start at a half and a few pickups move it little.
+func reliabilityFactor(candidate types.MatchCandidate) types.MatchFactor {
+	score := float64(candidate.CompletedPickups+1) / float64(candidate.EndedPickups+2)
+	if candidate.EndedPickups == 0 {
+		return factor("completion rate", reliabilityWeight, score, "no finished pickups yet")
+	}
+	return factor("completion rate", reliabilityWeight, score,
+		fmt.Sprintf("%d of %d finished pickups completed, the others rejected or cancelled", candidate.CompletedPickups, candidate.EndedPickups))
+}
+
+func factor(name string, weight float64, score float64, reason string) types.MatchFactor {
+	score = math.Max(0, math.Min(1, score))
+	return types.MatchFactor{
+		Factor: name,
+		Weight: weight,
+		Score:  round(score),
+		Points: round(weight * score),
+		Reason: reason,
+	}
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func kg(quantity float64) string {
+	return fmt.Sprintf("%.1f", quantity)
+}
@@ -0,0 +1,109 @@
+package matching
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func TestRank(t *testing.T) {
+	pickup := Pickup{Quantity: 100, Point: &geo.Point{Lat: 18.52, Lng: 73.85}}
+	// 25 km north of the pickup, where the distance score is halved
+	depotLat := 18.52 + 25000/(geo.EarthRadius*math.Pi/180)
+	depotLng := 73.85
+
+	candidates := []types.MatchCandidate{
+		{CollectorID: 1, CompanyName: "green", IsVerified: true, Latitude: &depotLat, Longitude: &depotLng,
+			PricePerKg: 10, MaximumCapacity: 500, BookedQuantity: 100, CompletedPickups: 8, EndedPickups: 10},
+		{CollectorID: 2, CompanyName: "blue", PricePerKg: 8, MaximumCapacity: 300},
+		// Without the capacity left for the pickup
+		{CollectorID: 3, CompanyName: "full", IsVerified: true, PricePerKg: 1, MaximumCapacity: 100, BookedQuantity: 50},
+		// Tied, ranked by collector ID
+		{CollectorID: 5, CompanyName: "twin", PricePerKg: 20, MaximumCapacity: 1000},
+		{CollectorID: 4, CompanyName: "twin", PricePerKg: 20, MaximumCapacity: 1000},
+	}
+
+	matches := Rank(candidates, pickup)
+	ids := make([]int64, len(matches))
+	for i, match := range matches {
+		ids[i] = match.CollectorID
+	}
+	if want := []int64{1, 2, 4, 5}; !slices.Equal(ids, want) {
+		t.Fatalf("Rank = collectors %v, want %v", ids, want)
+	}
+
+	tests := []struct {
+		name      string
+		match     types.CollectorMatch
+		score     float64
+		breakdown []types.MatchFactor
+	}{
+		{
+			name:  "verified, reliable and 25 km away",
+			match: matches[0],
+			score: 71.5,
+			breakdown: []types.MatchFactor{
+				{Factor: "capacity", Weight: 25, Score: 0.6, Points: 15, Reason: "400.0 of 500.0 kg free on the pickup date, 300.0 after this pickup"},
+				{Factor: "price", Weight: 30, Score: 0.8, Points: 24, Reason: "1000.00 for the pickup, against 800.00 at the cheapest"},
+				{Factor: "verification", Weight: 15, Score: 1, Points: 15, Reason: "verified by an admin, not flagged"},
+				{Factor: "distance", Weight: 20, Score: 0.5, Points: 10, Reason: "25.0 km from the depot"},
+				{Factor: "completion rate", Weight: 10, Score: 0.75, Points: 7.5, Reason: "8 of 10 finished pickups completed, the others rejected or cancelled"},
+			},
+		},
+		{
+			name:  "cheapest, new and not located",
+			match: matches[1],
+			score: 69.17,
+			breakdown: []types.MatchFactor{
+				{Factor: "capacity", Weight: 25, Score: 0.67, Points: 16.67, Reason: "300.0 of 300.0 kg free on the pickup date, 200.0 after this pickup"},
+				{Factor: "price", Weight: 30, Score: 1, Points: 30, Reason: "800.00 for the pickup, the lowest price"},
+				{Factor: "verification", Weight: 15, Score: 0.5, Points: 7.5, Reason: "not verified yet, not flagged"},
+				{Factor: "distance", Weight: 20, Score: 0.5, Points: 10, Reason: "unknown, the pickup or the depot is not located"},
+				{Factor: "completion rate", Weight: 10, Score: 0.5, Points: 5, Reason: "no finished pickups yet"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.match.Score != test.score {
+				t.Errorf("score = %v, want %v", test.match.Score, test.score)
+			}
+			if !slices.Equal(test.match.Breakdown, test.breakdown) {
+				t.Errorf("breakdown = %+v\nwant %+v", test.match.Breakdown, test.breakdown)
+			}
+		})
+	}
+
+	green := matches[0]
+	if green.EstimatedCost != 1000 || green.RemainingCapacity != 400 || green.DistanceKm == nil || *green.DistanceKm != 25 ||
+		green.CompletionRate == nil || *green.CompletionRate != 0.8 {
+		t.Errorf("green match = %+v", green)
+	}
+	if blue := matches[1]; blue.DistanceKm != nil || blue.CompletionRate != nil {
+		t.Errorf("blue match = %+v, want no distance nor completion rate", blue)
+	}
+	if matches[2].Score != matches[3].Score {
+		t.Errorf("twin scores = %v and %v, want a tie", matches[2].Score, matches[3].Score)
+	}
+
+	// Scores are out of 100, the sum of the points of the factors
+	for _, match := range matches {
+		var weights, points float64
+		for _, factor := range match.Breakdown {
+			weights += factor.Weight
+			points += factor.Points
+		}
+		if weights != 100 || math.Abs(points-match.Score) > 0.011 || match.Score < 0 || match.Score > 100 {
+			t.Errorf("collector %d scores %v from %v points of %v", match.CollectorID, match.Score, points, weights)
+		}
+	}
+}
+
+func TestRankWithoutCandidates(t *testing.T) {
+	if matches := Rank(nil, Pickup{Quantity: 10}); matches == nil || len(matches) != 0 {
+		t.Errorf("Rank(nil) = %#v, want an empty ranking", matches)
+	}
+}
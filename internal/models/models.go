@@ -19,18 +19,24 @@ type User struct {
 	IsVerified   bool      `gorm:"column:is_verified;not null;default:false"`
 	IsFlagged    bool      `gorm:"column:is_flagged;not null;default:false"`
 
+	NotificationChannels string `gorm:"column:notification_channels;size:255"` // Comma separated, empty for the defaults
+	Locale               string `gorm:"column:locale;size:10"`                 // Empty for the default locale
+	MustChangePassword   bool   `gorm:"column:must_change_password;not null;default:false"`
+
 	Business  *Business        `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
 	Collector *Collector       `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
 	Driver    *CollectorDriver `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
 }
 
 type Business struct {
-	UserID             int64  `gorm:"column:user_id;primaryKey"`
-	BusinessName       string `gorm:"column:business_name;not null;size:255"`
-	BusinessType       string `gorm:"column:business_type;not null;size:255"`
-	RegistrationNumber string `gorm:"column:registration_number;not null;unique;size:100"`
-	GstID              string `gorm:"column:gst_id;not null;unique;size:50"`
-	BusinessAddress    string `gorm:"column:business_address;not null;type:text"`
+	UserID             int64    `gorm:"column:user_id;primaryKey"`
+	BusinessName       string   `gorm:"column:business_name;not null;size:255"`
+	BusinessType       string   `gorm:"column:business_type;not null;size:255"`
+	RegistrationNumber string   `gorm:"column:registration_number;not null;unique;size:100"`
+	GstID              string   `gorm:"column:gst_id;not null;unique;size:50"`
+	BusinessAddress    string   `gorm:"column:business_address;not null;type:text"`
+	Latitude           *float64 `gorm:"column:latitude;type:decimal(10,6)"`
+	Longitude          *float64 `gorm:"column:longitude;type:decimal(10,6)"`
 
 	PickupRequests []*PickupRequest `gorm:"foreignKey:BusinessID;references:UserID;constraint:OnDelete:CASCADE"`
 }
@@ -41,11 +47,29 @@ type Collector struct {
 	LicenseNumber string    `gorm:"column:license_number;not null;unique;size:100"`
 	Capacity      int64     `gorm:"column:capacity;not null"`
 	LicenseExpiry time.Time `gorm:"column:license_expiry;not null"`
+	Latitude      *float64  `gorm:"column:latitude;type:decimal(10,6);index:idx_collectors_location,priority:1"` // Of the depot
+	Longitude     *float64  `gorm:"column:longitude;type:decimal(10,6);index:idx_collectors_location,priority:2"`
 
 	CollectorServiceCategories []*CollectorServiceCategory `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
 	CollectorVehicles          []*CollectorVehicle         `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
 	CollectorDrivers           []*CollectorDriver          `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
 	PickupRequests             []*PickupRequest            `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
+	ServiceAreas               []*ServiceArea              `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
+	MarketInvitations          []*MarketInvitation         `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
+	PickupBids                 []*PickupBid                `gorm:"foreignKey:CollectorID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// ServiceArea holds either pincodes or a polygon, as the check constraint of the migration
+// requires.
+type ServiceArea struct {
+	AreaID      int64     `gorm:"primaryKey;autoIncrement;column:area_id"`
+	CollectorID int64     `gorm:"column:collector_id;not null;index"`
+	CategoryID  *int64    `gorm:"column:category_id;index"` // NULL for every category
+	Name        string    `gorm:"column:name;not null;size:255"`
+	Pincodes    *string   `gorm:"column:pincodes;type:text"` // Comma separated
+	Polygon     *string   `gorm:"column:polygon;type:text"`  // GeoJSON
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
 }
 
 type ServiceCategory struct {
@@ -53,6 +77,7 @@ type ServiceCategory struct {
 	WasteType  string `gorm:"column:waste_type;not null;unique;size:100"`
 
 	CollectorServiceCategories []*CollectorServiceCategory `gorm:"foreignKey:CategoryID;references:CategoryID;constraint:OnDelete:CASCADE"`
+	ServiceAreas               []*ServiceArea              `gorm:"foreignKey:CategoryID;references:CategoryID;constraint:OnDelete:CASCADE"`
 }
 
 type CollectorServiceCategory struct {
@@ -106,33 +131,224 @@ type VehicleDriver struct {
 }
 
 type PickupRequest struct {
-	RequestID            int64     `gorm:"primaryKey;autoIncrement;column:request_id"`
-	BusinessID           int64     `gorm:"column:business_id;not null;index;foreignKey:business_id;references:Business;onDelete:CASCADE"`
-	CollectorID          int64     `gorm:"column:collector_id;not null;index;foreignKey:collector_id;references:Collector;onDelete:CASCADE"`
-	WasteType            string    `gorm:"column:waste_type;not null;size:100"`
-	Quantity             float64   `gorm:"column:quantity;not null;type:decimal(10,2)"`
-	PickupDate           time.Time `gorm:"column:pickup_date;not null"`
-	Status               string    `gorm:"column:status;not null;size:50;check:status IN ('Pending','Assigned','Completed', 'Cancelled'); default:'Pending'"`
-	HandlingRequirements string    `gorm:"column:handling_requirements;type:text"`
-	AssignedDriver       int64     `gorm:"column:assigned_driver"`
-	AssignedVehicle      int64     `gorm:"column:assigned_vehicle"`
-	CreatedAt            time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
-
-	DriverLocations  []DriverLocation `gorm:"foreignKey:DriverID;references:AssignedDriver;constraint:OnDelete:CASCADE"`
-	VehicleLocations []DriverLocation `gorm:"foreignKey:VehicleID;references:AssignedVehicle;constraint:OnDelete:CASCADE"`
+	RequestID            int64      `gorm:"primaryKey;autoIncrement;column:request_id"`
+	BusinessID           int64      `gorm:"column:business_id;not null;index;foreignKey:business_id;references:Business;onDelete:CASCADE"`
+	CollectorID          *int64     `gorm:"column:collector_id;index;foreignKey:collector_id;references:Collector;onDelete:CASCADE"` // NULL until an open-market request is awarded
+	WasteType            string     `gorm:"column:waste_type;not null;size:100"`
+	Quantity             float64    `gorm:"column:quantity;not null;type:decimal(10,2)"`
+	PickupDate           time.Time  `gorm:"column:pickup_date;not null"`
+	Status               string     `gorm:"column:status;not null;size:50;check:status IN ('Pending','Accepted','Rejected','Assigned','InTransit','Completed','Cancelled');default:'Pending'"`
+	HandlingRequirements string     `gorm:"column:handling_requirements;type:text"`
+	AssignedDriver       int64      `gorm:"column:assigned_driver"`
+	AssignedVehicle      int64      `gorm:"column:assigned_vehicle"`
+	CreatedAt            time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	PickupLatitude       *float64   `gorm:"column:pickup_latitude;type:decimal(10,6)"`
+	PickupLongitude      *float64   `gorm:"column:pickup_longitude;type:decimal(10,6)"`
+	OpenMarket           bool       `gorm:"column:open_market;not null;default:false"`
+	PricePerKg           *float64   `gorm:"column:price_per_kg;type:decimal(10,2)"` // Agreed on the open market
+	WindowStart          *time.Time `gorm:"column:window_start"`
+	WindowEnd            *time.Time `gorm:"column:window_end"`
+
+	MarketInvitations []MarketInvitation           `gorm:"foreignKey:RequestID;references:RequestID;constraint:OnDelete:CASCADE"`
+	Bids              []PickupBid                  `gorm:"foreignKey:RequestID;references:RequestID;constraint:OnDelete:CASCADE"`
+	DriverLocations   []DriverLocation             `gorm:"foreignKey:DriverID;references:AssignedDriver;constraint:OnDelete:CASCADE"`
+	VehicleLocations  []DriverLocation             `gorm:"foreignKey:VehicleID;references:AssignedVehicle;constraint:OnDelete:CASCADE"`
+	StatusHistory     []PickupRequestStatusHistory `gorm:"foreignKey:RequestID;references:RequestID;constraint:OnDelete:CASCADE"`
+}
+
+// MarketInvitation lets a collector bid on, or accept, an open-market pickup request at the
+// price it listed when the request was made.
+type MarketInvitation struct {
+	RequestID   int64     `gorm:"column:request_id;primaryKey"`
+	CollectorID int64     `gorm:"column:collector_id;primaryKey;index"`
+	PricePerKg  float64   `gorm:"column:price_per_kg;not null;type:decimal(10,2)"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// PickupBid is the offer of a collector for an open-market pickup request, one per collector.
+type PickupBid struct {
+	BidID       int64     `gorm:"primaryKey;autoIncrement;column:bid_id"`
+	RequestID   int64     `gorm:"column:request_id;not null;uniqueIndex:uni_pickup_bids_request_collector,priority:1"`
+	CollectorID int64     `gorm:"column:collector_id;not null;uniqueIndex:uni_pickup_bids_request_collector,priority:2"`
+	PricePerKg  float64   `gorm:"column:price_per_kg;not null;type:decimal(10,2)"`
+	WindowStart time.Time `gorm:"column:window_start;not null"`
+	WindowEnd   time.Time `gorm:"column:window_end;not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// PickupRequestETA is the latest ETA of the driver of a pickup request.
+type PickupRequestETA struct {
+	RequestID       int64     `gorm:"primaryKey;column:request_id"`
+	DriverID        int64     `gorm:"column:driver_id;not null"`
+	DriverLatitude  float64   `gorm:"column:driver_latitude;type:decimal(10,6);not null"`
+	DriverLongitude float64   `gorm:"column:driver_longitude;type:decimal(10,6);not null"`
+	DistanceMeters  float64   `gorm:"column:distance_meters;not null"`
+	SpeedKmh        float64   `gorm:"column:speed_kmh;not null"`
+	ArrivalAt       time.Time `gorm:"column:arrival_at;not null"`
+	LocatedAt       time.Time `gorm:"column:located_at;not null"`
+	CalculatedAt    time.Time `gorm:"column:calculated_at;not null"`
+}
+
+// TableName spells out the table, GORM would pluralise the acronym as pickup_request_eta.
+func (PickupRequestETA) TableName() string {
+	return "pickup_request_etas"
+}
+
+// Site is a geofenced pickup location of a business or drop-off facility of a collector
+type Site struct {
+	SiteID       int64     `gorm:"primaryKey;autoIncrement;column:site_id"`
+	OwnerID      int64     `gorm:"column:owner_id;not null;index"`
+	Kind         string    `gorm:"column:kind;not null;size:20;check:kind IN ('pickup','dropoff')"`
+	Name         string    `gorm:"column:name;not null;size:255"`
+	Address      string    `gorm:"column:address;size:500"`
+	Latitude     float64   `gorm:"column:latitude;type:decimal(10,6);not null"`
+	Longitude    float64   `gorm:"column:longitude;type:decimal(10,6);not null"`
+	RadiusMeters *float64  `gorm:"column:radius_meters"` // NULL for the configured default
+	DwellSeconds *int64    `gorm:"column:dwell_seconds"` // NULL for the configured default
+	AutoAdvance  bool      `gorm:"column:auto_advance;not null;default:false"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+
+	Visits []SiteVisit `gorm:"foreignKey:SiteID;references:SiteID;constraint:OnDelete:CASCADE"`
+}
+
+// SiteVisit is where a trip stands at a site
+type SiteVisit struct {
+	TripID     int64      `gorm:"primaryKey;column:trip_id"`
+	SiteID     int64      `gorm:"primaryKey;column:site_id"`
+	DriverID   int64      `gorm:"column:driver_id;not null"`
+	EnteredAt  *time.Time `gorm:"column:entered_at"`
+	ArrivedAt  *time.Time `gorm:"column:arrived_at"`
+	ExitedAt   *time.Time `gorm:"column:exited_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;not null"`
+	Version    int64      `gorm:"column:version;not null"` // Incremented by every save, for optimistic locking
+}
+
+type PickupRequestStatusHistory struct {
+	HistoryID   int64     `gorm:"primaryKey;autoIncrement;column:history_id"`
+	RequestID   int64     `gorm:"column:request_id;not null;index"`
+	ActorUserID int64     `gorm:"column:actor_user_id"`
+	ActorRole   string    `gorm:"column:actor_role;size:50"`
+	OldStatus   string    `gorm:"column:old_status;size:50"` // Empty for the creation of the request
+	NewStatus   string    `gorm:"column:new_status;not null;size:50"`
+	Reason      string    `gorm:"column:reason;type:text"`
+	ChangedAt   time.Time `gorm:"column:changed_at;not null;default:CURRENT_TIMESTAMP"`
 }
 
 type DriverLocation struct {
 	LocationID  int64     `gorm:"primaryKey;autoIncrement;column:location_id"`
 	DriverID    int64     `gorm:"column:driver_id;index;foreignKey:DriverID;references:CollectorDriver.UserID"`
-	CollectorID int64     `gorm:"column:collector_id;index"` // For quick filtering by collector
-	VehicleID   int64     `gorm:"column:vehicle_id;index"`   // Optional: If tracking by vehicle
+	CollectorID int64     `gorm:"column:collector_id;index"`                                 // For quick filtering by collector
+	VehicleID   int64     `gorm:"column:vehicle_id;index"`                                   // Optional: If tracking by vehicle
+	TripID      int64     `gorm:"column:trip_id;index:idx_driver_locations_trip,priority:1"` // The pickup request being delivered
 	Latitude    float64   `gorm:"column:latitude;type:decimal(10,6);not null"`
 	Longitude   float64   `gorm:"column:longitude;type:decimal(10,6);not null"`
-	Timestamp   time.Time `gorm:"column:timestamp;not null;default:CURRENT_TIMESTAMP"`
+	Timestamp   time.Time `gorm:"column:timestamp;not null;default:CURRENT_TIMESTAMP;index:idx_driver_locations_trip,priority:2"`
 	Accuracy    float32   `gorm:"column:accuracy;type:decimal(5,2)"` // Optional: GPS accuracy in meters
 	Speed       float32   `gorm:"column:speed;type:decimal(6,2)"`    // Optional: Speed in km/h
 	Bearing     float32   `gorm:"column:bearing;type:decimal(5,2)"`  // Optional: Direction in degrees
 	Date        time.Time `gorm:"column:date;not null;default:CURRENT_TIMESTAMP"`
-	Point       string    `gorm:"column:point;not null"` // could be "START" or "END"
+	Point       string    `gorm:"column:point;not null"` // could be "START" or "END", empty in between
+}
+
+type OutboxEvent struct {
+	OutboxID      int64      `gorm:"primaryKey;autoIncrement;column:outbox_id"`
+	Topic         string     `gorm:"column:topic;not null;size:100"`
+	Payload       []byte     `gorm:"column:payload;not null;type:bytea"`
+	Attributes    string     `gorm:"column:attributes;type:text"` // JSON encoded message attributes
+	Status        string     `gorm:"column:status;not null;size:20;default:'pending';index:idx_outbox_status_next_attempt,priority:1;check:status IN ('pending','delivered')"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;default:CURRENT_TIMESTAMP;index:idx_outbox_status_next_attempt,priority:2"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+	CreatedAt     time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+}
+
+// DeadLetter is a message a subscriber gave up on after its last allowed delivery attempt
+type DeadLetter struct {
+	DeadLetterID   int64      `gorm:"primaryKey;autoIncrement;column:dead_letter_id"`
+	SubscriptionID string     `gorm:"column:subscription_id;not null;size:255;index"`
+	Topic          string     `gorm:"column:topic;not null;size:100"`
+	MessageID      string     `gorm:"column:message_id;size:255"`
+	EventID        string     `gorm:"column:event_id;size:64"`
+	EventType      string     `gorm:"column:event_type;size:100"`
+	Payload        []byte     `gorm:"column:payload;not null;type:bytea"`
+	Attributes     string     `gorm:"column:attributes;type:text"` // JSON encoded message attributes
+	Attempts       int        `gorm:"column:attempts;not null"`
+	LastError      string     `gorm:"column:last_error;type:text"`
+	Status         string     `gorm:"column:status;not null;size:20;default:'pending';index;check:status IN ('pending','replayed','discarded')"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	ResolvedAt     *time.Time `gorm:"column:resolved_at"`
+}
+
+// ProcessedEvent records that a subscription handled an event, so that redeliveries are skipped
+type ProcessedEvent struct {
+	SubscriptionID string    `gorm:"primaryKey;column:subscription_id;size:255"`
+	EventID        string    `gorm:"primaryKey;column:event_id;size:255"`
+	ProcessedAt    time.Time `gorm:"column:processed_at;not null;default:CURRENT_TIMESTAMP"`
+	ExpiresAt      time.Time `gorm:"column:expires_at;not null;index"`
+}
+
+// AdminAuditRecord records the bootstrap admin and every admin created by another admin
+type AdminAuditRecord struct {
+	AuditID     int64     `gorm:"primaryKey;autoIncrement;column:audit_id"`
+	Action      string    `gorm:"column:action;not null;size:20;check:action IN ('bootstrap','create')"`
+	AdminUserID int64     `gorm:"column:admin_user_id;not null"`
+	ActorUserID *int64    `gorm:"column:actor_user_id"` // Null for the bootstrap admin
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// NotificationTemplate is an admin override of an embedded notification template
+type NotificationTemplate struct {
+	Name      string    `gorm:"primaryKey;column:name;size:100"`
+	Locale    string    `gorm:"primaryKey;column:locale;size:10"`
+	Subject   string    `gorm:"column:subject;not null;type:text"`
+	TextBody  string    `gorm:"column:text_body;not null;type:text"`
+	HTMLBody  string    `gorm:"column:html_body;type:text"`
+	UpdatedBy int64     `gorm:"column:updated_by"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// Notification is an entry of a user's in-app inbox
+type Notification struct {
+	NotificationID  int64      `gorm:"primaryKey;autoIncrement;column:notification_id"`
+	UserID          int64      `gorm:"column:user_id;not null;index:idx_notifications_user_read,priority:1;uniqueIndex:idx_notifications_event,priority:1"`
+	EventID         *string    `gorm:"column:event_id;size:64;uniqueIndex:idx_notifications_event,priority:2"` // Null for notifications not caused by an event
+	Type            string     `gorm:"column:type;not null;size:100;uniqueIndex:idx_notifications_event,priority:3"`
+	PickupRequestID *int64     `gorm:"column:pickup_request_id;index"`
+	Heading         string     `gorm:"column:heading;not null;type:text"`
+	Message         string     `gorm:"column:message;not null;type:text"`
+	IsRead          bool       `gorm:"column:is_read;not null;default:false;index:idx_notifications_user_read,priority:2"`
+	CreatedAt       time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	ReadAt          *time.Time `gorm:"column:read_at"`
+}
+
+// WebhookEndpoint is a URL a business or collector registered to receive events on
+type WebhookEndpoint struct {
+	EndpointID  int64     `gorm:"primaryKey;autoIncrement;column:endpoint_id"`
+	UserID      int64     `gorm:"column:user_id;not null;index"`
+	URL         string    `gorm:"column:url;not null;size:2048"`
+	Description string    `gorm:"column:description;size:255"`
+	Secret      string    `gorm:"column:secret;not null;size:100"`       // HMAC-SHA256 key the payloads are signed with
+	EventTypes  string    `gorm:"column:event_types;not null;type:text"` // Comma separated
+	IsActive    bool      `gorm:"column:is_active;not null;default:true"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP"`
+}
+
+// WebhookDelivery is an event queued for, or delivered to, a webhook endpoint
+type WebhookDelivery struct {
+	DeliveryID     int64      `gorm:"primaryKey;autoIncrement;column:delivery_id"`
+	EndpointID     int64      `gorm:"column:endpoint_id;not null;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID        string     `gorm:"column:event_id;not null;size:64;uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+	EventType      string     `gorm:"column:event_type;not null;size:100"`
+	Payload        []byte     `gorm:"column:payload;not null;type:bytea"`
+	Status         string     `gorm:"column:status;not null;size:20;default:'pending';index:idx_webhook_deliveries_status_next_attempt,priority:1;check:status IN ('pending','succeeded','failed')"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0"`
+	LastStatusCode int        `gorm:"column:last_status_code"`
+	LastError      string     `gorm:"column:last_error;type:text"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null;default:CURRENT_TIMESTAMP;index:idx_webhook_deliveries_status_next_attempt,priority:2"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
 }
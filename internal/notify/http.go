@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// HTTPDoer is the part of *http.Client the HTTP based notifiers use, so that tests can stub the
+// gateway.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+var defaultClient HTTPDoer = &http.Client{Timeout: 10 * time.Second}
+
+// SMSGateway sends notifications as text messages through an HTTP SMS gateway, which receives
+// a JSON body with the recipient number, the sender ID and the text.
+type SMSGateway struct {
+	URL    string
+	APIKey string // Sent as a bearer token if set
+	Sender string
+	Client HTTPDoer // Defaults to an http.Client with a timeout
+}
+
+func (s *SMSGateway) Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error {
+	if recipient.GetPhoneNumber() == "" {
+		return fmt.Errorf("user %d has no phone number", recipient.GetUserID())
+	}
+
+	body := map[string]string{
+		"to":   recipient.GetPhoneNumber(),
+		"from": s.Sender,
+		"text": notification.Heading + "\n\n" + notification.Message,
+	}
+	headers := map[string]string{}
+	if s.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.APIKey
+	}
+	return postJSON(ctx, s.Client, s.URL, headers, body)
+}
+
+// OutboundWebhook posts notifications as JSON to a fixed URL, e.g. a chat integration.
+type OutboundWebhook struct {
+	URL    string
+	Client HTTPDoer // Defaults to an http.Client with a timeout
+}
+
+func (w *OutboundWebhook) Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error {
+	body := map[string]any{
+		"user_id": recipient.GetUserID(),
+		"email":   recipient.GetEmail(),
+		"heading": notification.Heading,
+		"message": notification.Message,
+	}
+	return postJSON(ctx, w.Client, w.URL, nil, body)
+}
+
+func postJSON(ctx context.Context, client HTTPDoer, url string, headers map[string]string, body any) error {
+	if client == nil {
+		client = defaultClient
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
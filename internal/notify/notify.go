@@ -0,0 +1,174 @@
+// Package notify delivers notifications to users over email, SMS, webhooks or a local log.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Channels a user can choose to be notified on
+const (
+	Email   = "email"
+	SMS     = "sms"
+	Webhook = "webhook"
+	Log     = "log"
+)
+
+var Channels = []string{Email, SMS, Webhook, Log}
+
+type Notification struct {
+	Heading string
+	Message string // Plain text body
+	HTML    string // Optional HTML body, for channels that support it
+}
+
+// Notifier delivers a notification to a recipient over one channel.
+type Notifier interface {
+	Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error
+}
+
+// Inbox records notifications in the recipient's in-app inbox.
+type Inbox interface {
+	CreateNotification(ctx context.Context, notification types.Notification) error
+}
+
+// Sender renders a named template in the recipient's locale, records it in their inbox and
+// delivers it through a Notifier.
+type Sender struct {
+	notifier  Notifier
+	templates *Templates
+	inbox     Inbox // May be nil to skip the inbox
+}
+
+func NewSender(notifier Notifier, templates *Templates, inbox Inbox) *Sender {
+	return &Sender{notifier: notifier, templates: templates, inbox: inbox}
+}
+
+// Send records the notification in the inbox before delivering it, so that it is not lost when
+// every channel fails. Redeliveries of the same event are recorded once.
+func (s *Sender) Send(ctx context.Context, recipient types.Notifiable, template string, data TemplateData) error {
+	notification, err := s.templates.Render(ctx, template, recipient.GetLocale(), data)
+	if err != nil {
+		return err
+	}
+
+	if s.inbox != nil {
+		err := s.inbox.CreateNotification(ctx, types.Notification{
+			UserID:          recipient.GetUserID(),
+			EventID:         data.EventID,
+			Type:            template,
+			PickupRequestID: data.PickupRequest.RequestID,
+			Heading:         notification.Heading,
+			Message:         notification.Message,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.notifier.Notify(ctx, recipient, notification)
+}
+
+// Router is a Notifier that delivers over every channel the recipient chose, or the default
+// channels if they did not choose any. Channels without a configured notifier are skipped.
+type Router struct {
+	notifiers       map[string]Notifier
+	defaultChannels []string
+}
+
+func NewRouter(defaultChannels []string) *Router {
+	return &Router{
+		notifiers:       make(map[string]Notifier),
+		defaultChannels: defaultChannels,
+	}
+}
+
+// Register sets the notifier used for channel.
+func (r *Router) Register(channel string, notifier Notifier) {
+	r.notifiers[channel] = notifier
+}
+
+func (r *Router) Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error {
+	channels := recipient.GetNotificationChannels()
+	if len(channels) == 0 {
+		channels = r.defaultChannels
+	}
+
+	var errs []error
+	delivered := 0
+	for _, channel := range channels {
+		notifier, ok := r.notifiers[channel]
+		if !ok {
+			slog.Warn("notification channel not configured, skipping",
+				slog.String("channel", channel),
+				slog.Int64("user_id", recipient.GetUserID()))
+			continue
+		}
+
+		if err := notifier.Notify(ctx, recipient, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		delivered++
+	}
+
+	if delivered == 0 && len(errs) == 0 {
+		return fmt.Errorf("no configured notification channel for user %d", recipient.GetUserID())
+	}
+	return errors.Join(errs...)
+}
+
+// ValidChannel reports whether channel is one of Channels.
+func ValidChannel(channel string) bool {
+	for _, c := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// New creates a Router with a notifier for every channel configured in cfg. The log channel is
+// always available.
+func New(cfg *config.Config) (*Router, error) {
+	router := NewRouter(cfg.DefaultNotificationChannels)
+
+	if cfg.SMTPHost != "" {
+		from := cfg.SMTPFrom
+		if from == "" {
+			from = cfg.SMTPUsername
+		}
+		router.Register(Email, &SMTP{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     from,
+		})
+	}
+
+	if cfg.SMSGatewayURL != "" {
+		router.Register(SMS, &SMSGateway{
+			URL:    cfg.SMSGatewayURL,
+			APIKey: cfg.SMSGatewayAPIKey,
+			Sender: cfg.SMSSender,
+		})
+	}
+
+	if cfg.NotificationWebhookURL != "" {
+		router.Register(Webhook, &OutboundWebhook{URL: cfg.NotificationWebhookURL})
+	}
+
+	sink, err := NewSink(cfg.NotificationLogPath)
+	if err != nil {
+		return nil, err
+	}
+	router.Register(Log, sink)
+
+	return router, nil
+}
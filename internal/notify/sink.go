@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Sink writes notifications to a file or stdout instead of delivering them, for local
+// development.
+type Sink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSink appends to the file at path, or writes to stdout if path is empty.
+func NewSink(path string) (*Sink, error) {
+	if path == "" {
+		return &Sink{w: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification log %s: %w", path, err)
+	}
+	return &Sink{w: f}, nil
+}
+
+func (s *Sink) Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.w, "--- %s | user %d <%s> | %s\n%s\n\n",
+		time.Now().Format(time.RFC3339), recipient.GetUserID(), recipient.GetEmail(), notification.Heading, notification.Message)
+	return err
+}
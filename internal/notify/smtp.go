@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-mail/mail"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// SMTP sends notifications as emails, with an HTML alternative if the notification has one.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTP) Notify(ctx context.Context, recipient types.Notifiable, notification Notification) error {
+	if recipient.GetEmail() == "" {
+		return fmt.Errorf("user %d has no email address", recipient.GetUserID())
+	}
+
+	m := mail.NewMessage()
+	m.SetHeader("From", s.From)
+	m.SetHeader("To", recipient.GetEmail())
+	m.SetHeader("Subject", notification.Heading)
+	m.SetBody("text/plain", notification.Message)
+	if notification.HTML != "" {
+		m.AddAlternative("text/html", notification.HTML)
+	}
+
+	d := mail.NewDialer(s.Host, s.Port, s.Username, s.Password)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// DefaultLocale is the locale every embedded template exists in.
+const DefaultLocale = "en"
+
+// Embedded templates live in templates/<locale>/<name>.tmpl and define the "subject", "text"
+// and "html" templates.
+//
+//go:embed templates
+var embeddedFS embed.FS
+
+var (
+	embeddedOnce sync.Once
+	embedded     map[string]types.NotificationTemplate // keyed by locale + "/" + name
+	embeddedErr  error
+)
+
+// TemplateData is what notification templates are executed with.
+type TemplateData struct {
+	EventID       string // Set by subscribers, so that a redelivered event is recorded once in the inbox
+	PickupRequest types.PickupRequest
+	Collector     types.Collector
+	Business      types.Business
+	Geofence      types.GeofenceEvent // Of the driver.arrived and driver.departed notifications
+}
+
+// TemplateStore holds the templates admins override. GetNotificationTemplate returns an error
+// wrapping storage.ErrNotFound if there is no override.
+type TemplateStore interface {
+	GetNotificationTemplate(ctx context.Context, name string, locale string) (types.NotificationTemplate, error)
+}
+
+// Templates renders notifications from admin overrides, falling back to the embedded templates,
+// in the recipient's locale with fallback to the default locale.
+type Templates struct {
+	store         TemplateStore // May be nil to only use embedded templates
+	defaultLocale string
+}
+
+func NewTemplates(store TemplateStore, defaultLocale string) *Templates {
+	if defaultLocale == "" {
+		defaultLocale = DefaultLocale
+	}
+	return &Templates{store: store, defaultLocale: defaultLocale}
+}
+
+// Render renders the named template in the first available of locale, its base language
+// ("hi" for "hi-IN") and the default locales.
+func (t *Templates) Render(ctx context.Context, name string, locale string, data TemplateData) (Notification, error) {
+	tpl, err := t.Lookup(ctx, name, locale)
+	if err != nil {
+		return Notification{}, err
+	}
+	return RenderTemplate(tpl, data)
+}
+
+// Lookup returns the template Render would use.
+func (t *Templates) Lookup(ctx context.Context, name string, locale string) (types.NotificationTemplate, error) {
+	for _, candidate := range t.locales(locale) {
+		tpl, err := t.lookupExact(ctx, name, candidate)
+		if err == nil {
+			return tpl, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return types.NotificationTemplate{}, err
+		}
+	}
+	return types.NotificationTemplate{}, fmt.Errorf("notification template %s: %w", name, storage.ErrNotFound)
+}
+
+// lookupExact returns the override of the template in locale, or the embedded one.
+func (t *Templates) lookupExact(ctx context.Context, name string, locale string) (types.NotificationTemplate, error) {
+	if t.store != nil {
+		tpl, err := t.store.GetNotificationTemplate(ctx, name, locale)
+		if err == nil {
+			return tpl, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return types.NotificationTemplate{}, err
+		}
+	}
+	return EmbeddedTemplate(name, locale)
+}
+
+func (t *Templates) locales(locale string) []string {
+	var candidates []string
+	add := func(l string) {
+		if l == "" {
+			return
+		}
+		for _, c := range candidates {
+			if c == l {
+				return
+			}
+		}
+		candidates = append(candidates, l)
+	}
+
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	add(locale)
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		add(base)
+	}
+	add(t.defaultLocale)
+	add(DefaultLocale)
+	return candidates
+}
+
+// RenderTemplate executes a template with data. The subject and text are text templates, the
+// HTML is an html/template so that data is escaped.
+func RenderTemplate(tpl types.NotificationTemplate, data TemplateData) (Notification, error) {
+	var notification Notification
+
+	subject, err := executeText(tpl.Name+"/subject", tpl.Subject, data)
+	if err != nil {
+		return Notification{}, err
+	}
+	notification.Heading = strings.TrimSpace(subject)
+
+	notification.Message, err = executeText(tpl.Name+"/text", tpl.Text, data)
+	if err != nil {
+		return Notification{}, err
+	}
+
+	if tpl.HTML != "" {
+		t, err := htmltemplate.New(tpl.Name + "/html").Option("missingkey=error").Parse(tpl.HTML)
+		if err != nil {
+			return Notification{}, fmt.Errorf("invalid template %s: %w", tpl.Name+"/html", err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return Notification{}, fmt.Errorf("failed to render template %s: %w", tpl.Name+"/html", err)
+		}
+		notification.HTML = buf.String()
+	}
+
+	return notification, nil
+}
+
+func executeText(name string, source string, data TemplateData) (string, error) {
+	t, err := texttemplate.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// EmbeddedTemplate returns the built-in template, without locale fallback.
+func EmbeddedTemplate(name string, locale string) (types.NotificationTemplate, error) {
+	if err := loadEmbedded(); err != nil {
+		return types.NotificationTemplate{}, err
+	}
+	tpl, ok := embedded[locale+"/"+name]
+	if !ok {
+		return types.NotificationTemplate{}, fmt.Errorf("notification template %s (%s): %w", name, locale, storage.ErrNotFound)
+	}
+	return tpl, nil
+}
+
+// EmbeddedTemplates returns every built-in template, sorted by name and locale.
+func EmbeddedTemplates() ([]types.NotificationTemplate, error) {
+	if err := loadEmbedded(); err != nil {
+		return nil, err
+	}
+	templates := make([]types.NotificationTemplate, 0, len(embedded))
+	for _, tpl := range embedded {
+		templates = append(templates, tpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Locale < templates[j].Locale
+	})
+	return templates, nil
+}
+
+// TemplateExists reports whether name is a built-in template, which admins can override.
+func TemplateExists(name string) bool {
+	_, err := EmbeddedTemplate(name, DefaultLocale)
+	return err == nil
+}
+
+func loadEmbedded() error {
+	embeddedOnce.Do(func() {
+		embedded = make(map[string]types.NotificationTemplate)
+		embeddedErr = fs.WalkDir(embeddedFS, "templates", func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || path.Ext(file) != ".tmpl" {
+				return err
+			}
+
+			locale := path.Base(path.Dir(file))
+			name := strings.TrimSuffix(path.Base(file), ".tmpl")
+			source, err := embeddedFS.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			tpl, err := splitTemplate(name, locale, string(source))
+			if err != nil {
+				return err
+			}
+			embedded[locale+"/"+name] = tpl
+			return nil
+		})
+	})
+	return embeddedErr
+}
+
+// splitTemplate separates the subject, text and html definitions of an embedded template file.
+func splitTemplate(name string, locale string, source string) (types.NotificationTemplate, error) {
+	t, err := texttemplate.New(name).Parse(source)
+	if err != nil {
+		return types.NotificationTemplate{}, fmt.Errorf("invalid embedded template %s/%s: %w", locale, name, err)
+	}
+
+	section := func(part string) (string, error) {
+		defined := t.Lookup(part)
+		if defined == nil || defined.Tree == nil {
+			return "", fmt.Errorf("embedded template %s/%s does not define %q", locale, name, part)
+		}
+		return defined.Tree.Root.String(), nil
+	}
+
+	tpl := types.NotificationTemplate{Name: name, Locale: locale, Source: "embedded"}
+	if tpl.Subject, err = section("subject"); err != nil {
+		return types.NotificationTemplate{}, err
+	}
+	if tpl.Text, err = section("text"); err != nil {
+		return types.NotificationTemplate{}, err
+	}
+	if tpl.HTML, err = section("html"); err != nil {
+		return types.NotificationTemplate{}, err
+	}
+	return tpl, nil
+}
+
+// SampleData is the data templates are previewed with when no pickup request is given.
+func SampleData() TemplateData {
+	now := time.Now()
+	return TemplateData{
+		PickupRequest: types.PickupRequest{
+			RequestID:            1001,
+			BusinessID:           2,
+			CollectorID:          3,
+			WasteType:            "Plastic",
+			Quantity:             125.5,
+			PickupDate:           types.DateTime{Time: now.Add(24 * time.Hour)},
+			Status:               "Pending",
+			HandlingRequirements: "Bagged, no hazardous material",
+			AssignedDriver:       4,
+			AssignedVehicle:      5,
+			CreatedAt:            types.DateTime{Time: now},
+		},
+		Collector: types.Collector{User: types.User{UserID: 3, FullName: "Green Earth Collectors", Email: "collector@example.com"}, Company_name: "Green Earth Collectors"},
+		Business:  types.Business{User: types.User{UserID: 2, FullName: "Sunrise Textiles", Email: "business@example.com"}, Business_name: "Sunrise Textiles"},
+		Geofence: types.GeofenceEvent{
+			RequestID:   1001,
+			BusinessID:  2,
+			CollectorID: 3,
+			DriverID:    4,
+			SiteID:      6,
+			SiteKind:    types.SitePickup,
+			SiteName:    "Sunrise Textiles, Unit 2",
+			At:          types.DateTime{Time: now.Add(-2 * time.Minute)},
+			DetectedAt:  types.DateTime{Time: now},
+		},
+	}
+}
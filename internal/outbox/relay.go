@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+)
+
+// Publisher is the part of a message broker the relay needs. Any broker can be used as long as
+// Publish only returns once the broker has accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error
+}
+
+// Relay publishes the events written to the outbox table and marks them as delivered. Events
+// that fail to publish are retried with exponential backoff, delivered ones are deleted once
+// they are older than Retention.
+type Relay struct {
+	store     storage.Outbox
+	publisher Publisher
+
+	BatchSize    int           // Maximum number of events claimed per poll
+	PollInterval time.Duration // Wait between polls when the outbox is drained
+	Lease        time.Duration // How long a claimed event is hidden from other relays
+	BaseBackoff  time.Duration // Delay before the first retry, doubled on every failure
+	MaxBackoff   time.Duration // Upper bound for the retry delay
+
+	Retention     time.Duration // How long delivered events are kept; forever if 0
+	PurgeInterval time.Duration // Wait between deletions of delivered events
+
+	lastPurge time.Time
+}
+
+func NewRelay(store storage.Outbox, publisher Publisher) *Relay {
+	return &Relay{
+		store:        store,
+		publisher:    publisher,
+		BatchSize:    50,
+		PollInterval: 2 * time.Second,
+		Lease:        30 * time.Second,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+
+		Retention:     7 * 24 * time.Hour,
+		PurgeInterval: time.Hour,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	slog.Info("outbox relay started")
+
+	for {
+		claimed := r.relayBatch(ctx)
+		r.purgeDelivered(ctx)
+
+		// Going straight for the next batch while the outbox keeps returning full batches
+		if claimed == r.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("outbox relay stopped")
+			return
+		case <-time.After(r.PollInterval):
+		}
+	}
+}
+
+// relayBatch publishes one batch of due events and returns how many were claimed.
+func (r *Relay) relayBatch(ctx context.Context) int {
+	events, err := r.store.ClaimOutboxEvents(ctx, r.BatchSize, r.Lease)
+	if err != nil {
+		slog.Error("failed to claim outbox events", slog.String("error", err.Error()))
+		return 0
+	}
+
+	for _, event := range events {
+		err := r.publisher.Publish(ctx, event.Topic, event.Payload, event.Attributes)
+		if err != nil {
+			next := time.Now().Add(r.backoff(event.Attempts))
+			slog.Warn("failed to publish outbox event",
+				slog.Int64("outbox_id", event.OutboxID),
+				slog.String("topic", event.Topic),
+				slog.Int("attempts", event.Attempts+1),
+				slog.String("error", err.Error()))
+
+			if err := r.store.MarkOutboxEventFailed(ctx, event.OutboxID, err.Error(), next); err != nil {
+				slog.Error("failed to record outbox failure", slog.Int64("outbox_id", event.OutboxID), slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		if err := r.store.MarkOutboxEventDelivered(ctx, event.OutboxID); err != nil {
+			// The lease runs out and the event is published again, consumers have to cope with duplicates anyway
+			slog.Error("failed to mark outbox event delivered", slog.Int64("outbox_id", event.OutboxID), slog.String("error", err.Error()))
+		}
+	}
+
+	return len(events)
+}
+
+// purgeDelivered deletes the events delivered more than Retention ago, at most once every
+// PurgeInterval, and returns how many were deleted.
+func (r *Relay) purgeDelivered(ctx context.Context) int64 {
+	if r.Retention <= 0 || time.Since(r.lastPurge) < r.PurgeInterval {
+		return 0
+	}
+	r.lastPurge = time.Now()
+
+	deleted, err := r.store.DeleteDeliveredOutboxEvents(ctx, time.Now().Add(-r.Retention))
+	if err != nil {
+		slog.Error("failed to purge delivered outbox events", slog.String("error", err.Error()))
+		return 0
+	}
+	if deleted > 0 {
+		slog.Info("purged delivered outbox events", slog.Int64("deleted", deleted))
+	}
+	return deleted
+}
+
+// backoff returns the retry delay after the given number of earlier failed attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.BaseBackoff
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return delay
+}
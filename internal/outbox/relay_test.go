@@ -0,0 +1,242 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/memory"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// publisher publishes to an in-memory bus, recording the IDs of the events it accepted. Publishing
+// fails until the topic is created.
+type publisher struct {
+	bus *eventbus.Memory
+
+	mu        sync.Mutex
+	published []string
+}
+
+func (p *publisher) Publish(ctx context.Context, topic string, data []byte, attributes map[string]string) error {
+	if err := p.bus.Publish(ctx, topic, data, attributes); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.published = append(p.published, attributes[events.IDAttribute])
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *publisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.published)
+}
+
+// newOutbox returns a memory store with the creation events of n pickup requests in its outbox.
+func newOutbox(t *testing.T, n int) *memory.Memory {
+	t.Helper()
+	store := memory.New()
+	user := func(name string, role string) types.User {
+		return types.User{Email: name + "@example.com", FullName: name, Role: role, IsActive: true}
+	}
+	businessID, err := store.CreateBusinessUser(t.Context(), types.Business{User: user("acme", "Business"), Business_name: "Acme Ltd"})
+	if err != nil {
+		t.Fatalf("CreateBusinessUser: %v", err)
+	}
+	collectorID, err := store.CreateCollectorUser(t.Context(), types.Collector{User: user("green", "Collector"), Company_name: "Green Recycling"})
+	if err != nil {
+		t.Fatalf("CreateCollectorUser: %v", err)
+	}
+	for range n {
+		_, err := store.CreatePickupRequest(t.Context(), types.PickupRequest{
+			BusinessID:  businessID,
+			CollectorID: collectorID,
+			WasteType:   "Plastic",
+			Quantity:    120.5,
+			PickupDate:  types.DateTime{Time: time.Now().Add(24 * time.Hour)},
+		}, types.Actor{UserID: businessID, Role: "Business"})
+		if err != nil {
+			t.Fatalf("CreatePickupRequest: %v", err)
+		}
+	}
+	return store
+}
+
+// newPublisher returns a publisher to a bus, which has the pickup requests topic if ready.
+func newPublisher(t *testing.T, ready bool) *publisher {
+	t.Helper()
+	bus := eventbus.NewMemory()
+	if ready {
+		if err := bus.EnsureTopic(t.Context(), events.PickupRequestsTopic); err != nil {
+			t.Fatalf("EnsureTopic: %v", err)
+		}
+	}
+	return &publisher{bus: bus}
+}
+
+func TestRelayPublishes(t *testing.T) {
+	store := newOutbox(t, 3)
+	publisher := newPublisher(t, true)
+	relay := NewRelay(store, publisher)
+
+	if claimed := relay.relayBatch(t.Context()); claimed != 3 {
+		t.Errorf("relayBatch claimed %d events, want 3", claimed)
+	}
+	if publisher.count() != 3 {
+		t.Errorf("published %d events, want 3", publisher.count())
+	}
+	for _, id := range publisher.published {
+		if id == "" {
+			t.Errorf("published an event without its ID attribute")
+		}
+	}
+
+	// Delivered events are not published again, even once their lease runs out
+	relay.Lease = 0
+	if claimed := relay.relayBatch(t.Context()); claimed != 0 {
+		t.Errorf("relayBatch claimed %d delivered events", claimed)
+	}
+}
+
+func TestRelayBacksOff(t *testing.T) {
+	store := newOutbox(t, 1)
+	publisher := newPublisher(t, false)
+	relay := NewRelay(store, publisher)
+	relay.BaseBackoff = 200 * time.Millisecond
+
+	// The first failure is retried after BaseBackoff
+	if claimed := relay.relayBatch(t.Context()); claimed != 1 || publisher.count() != 0 {
+		t.Fatalf("relayBatch claimed %d and published %d events, want 1 and 0", claimed, publisher.count())
+	}
+	if claimed := relay.relayBatch(t.Context()); claimed != 0 {
+		t.Errorf("relayBatch claimed %d events before the backoff", claimed)
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	// The second after twice that
+	if claimed := relay.relayBatch(t.Context()); claimed != 1 || publisher.count() != 0 {
+		t.Fatalf("relayBatch claimed %d and published %d events after the backoff, want 1 and 0", claimed, publisher.count())
+	}
+	time.Sleep(250 * time.Millisecond)
+	if claimed := relay.relayBatch(t.Context()); claimed != 0 {
+		t.Errorf("relayBatch claimed %d events before the doubled backoff", claimed)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if err := publisher.bus.EnsureTopic(t.Context(), events.PickupRequestsTopic); err != nil {
+		t.Fatalf("EnsureTopic: %v", err)
+	}
+	if claimed := relay.relayBatch(t.Context()); claimed != 1 || publisher.count() != 1 {
+		t.Errorf("relayBatch claimed %d and published %d events once the broker recovered, want 1 and 1", claimed, publisher.count())
+	}
+}
+
+// Events claimed by a relay that stopped before publishing them are picked up once their lease
+// runs out.
+func TestRelayReclaimsExpiredLeases(t *testing.T) {
+	store := newOutbox(t, 2)
+	if _, err := store.ClaimOutboxEvents(t.Context(), 10, 100*time.Millisecond); err != nil {
+		t.Fatalf("ClaimOutboxEvents: %v", err)
+	}
+
+	publisher := newPublisher(t, true)
+	relay := NewRelay(store, publisher)
+	if claimed := relay.relayBatch(t.Context()); claimed != 0 {
+		t.Errorf("relayBatch claimed %d leased events", claimed)
+	}
+	time.Sleep(120 * time.Millisecond)
+	if claimed := relay.relayBatch(t.Context()); claimed != 2 || publisher.count() != 2 {
+		t.Errorf("relayBatch claimed %d and published %d events after the lease, want 2 and 2", claimed, publisher.count())
+	}
+}
+
+// Run goes straight for the next batch while batches come back full, and stops with ctx.
+func TestRelayRun(t *testing.T) {
+	store := newOutbox(t, 5)
+	publisher := newPublisher(t, true)
+	relay := NewRelay(store, publisher)
+	relay.BatchSize = 2
+	relay.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for publisher.count() < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if publisher.count() != 5 {
+		t.Errorf("published %d events, want all 5 without waiting for a poll", publisher.count())
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run did not return once ctx was cancelled")
+	}
+}
+
+func TestRelayPurgesDeliveredEvents(t *testing.T) {
+	store := newOutbox(t, 2)
+	publisher := newPublisher(t, true)
+	relay := NewRelay(store, publisher)
+	relay.Retention = 100 * time.Millisecond
+	relay.PurgeInterval = 0
+
+	relay.relayBatch(t.Context())
+	if deleted := relay.purgeDelivered(t.Context()); deleted != 0 {
+		t.Errorf("purged %d events delivered within the retention", deleted)
+	}
+	time.Sleep(120 * time.Millisecond)
+	if deleted := relay.purgeDelivered(t.Context()); deleted != 2 {
+		t.Errorf("purged %d events, want the 2 delivered ones", deleted)
+	}
+
+	// At most once every PurgeInterval, and never without a retention
+	store = newOutbox(t, 1)
+	relay = NewRelay(store, publisher)
+	relay.Retention = time.Nanosecond
+	relay.relayBatch(t.Context())
+	relay.lastPurge = time.Now()
+	if deleted := relay.purgeDelivered(t.Context()); deleted != 0 {
+		t.Errorf("purged %d events within the purge interval", deleted)
+	}
+	relay.PurgeInterval, relay.Retention = 0, 0
+	if deleted := relay.purgeDelivered(t.Context()); deleted != 0 {
+		t.Errorf("purged %d events without a retention", deleted)
+	}
+	relay.Retention = time.Nanosecond
+	if deleted := relay.purgeDelivered(t.Context()); deleted != 1 {
+		t.Errorf("purged %d events, want the delivered one", deleted)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	relay := NewRelay(nil, nil)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{8, 256 * time.Second},
+		{9, 5 * time.Minute},
+		{40, 5 * time.Minute},
+	}
+	for _, test := range tests {
+		if got := relay.backoff(test.attempts); got != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
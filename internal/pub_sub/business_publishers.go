@@ -2,51 +2,61 @@ package pub_sub
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
 
-const PickupRequestsTopic = "PICKUP-REQUESTS"
+const PickupRequestsTopic = events.PickupRequestsTopic
+
+// CreatePickupRequest saves a new pickup request. The event for the PICKUP-REQUESTS topic is
+// written to the outbox in the same transaction and published by the outbox relay.
+func CreatePickupRequest(ctx context.Context, storage storage.Storage, pickupRequest types.PickupRequest, actor types.Actor) (int64, error) {
+	// Every pickup request starts its lifecycle as Pending
+	pickupRequest.Status = string(lifecycle.Pending)
 
-func CreatePickupRequest(storage storage.Storage, pubsubClient *pubsub.Client, pickupRequest types.PickupRequest) (int64, error) {
 	// Saving to the database
-	id, err := storage.CreatePickupRequest(pickupRequest)
+	id, err := storage.CreatePickupRequest(ctx, pickupRequest, actor)
 	if err != nil {
 		fmt.Printf("Error creating pickup request in the database: %v", err)
 		return 0, err
 	}
 
-	fmt.Println("Pickup request created successfully in the database")
+	fmt.Println("Pickup request created successfully in the database, queued for topic:", PickupRequestsTopic)
 
-	pickupRequest.RequestID = id
+	return id, nil
+}
 
-	// Converting pickupRequest to JSON
-	messageData, err := json.Marshal(pickupRequest)
+// CreateOpenPickupRequest saves a new pickup request without a collector, inviting the
+// collectors of invitations to bid on it. Its event is broadcast to them through the outbox.
+func CreateOpenPickupRequest(ctx context.Context, storage storage.Storage, pickupRequest types.PickupRequest, invitations []types.MarketInvitation, actor types.Actor) (int64, error) {
+	pickupRequest.Status = string(lifecycle.Pending)
+
+	// Saving to the database
+	id, err := storage.CreateOpenPickupRequest(ctx, pickupRequest, invitations, actor)
 	if err != nil {
-		fmt.Printf("Error marshaling pickup request: %v", err)
-		return id, err
+		fmt.Printf("Error creating open pickup request in the database: %v", err)
+		return 0, err
 	}
 
-	// Publishing to the topic
-	ctx := context.Background()
-	topic := pubsubClient.Topic("PICKUP-REQUESTS")
+	fmt.Println("Open pickup request created successfully in the database, queued for topic:", PickupRequestsTopic)
 
-	result := topic.Publish(ctx, &pubsub.Message{
-		Data: messageData,
-	})
+	return id, nil
+}
 
-	// Confirming whether the message was published
-	_, err = result.Get(ctx)
+// AcceptPickupBid awards an open-market pickup request to the collector of one of its bids.
+func AcceptPickupBid(ctx context.Context, storage storage.Storage, pickupRequestID int64, bidID int64, actor types.Actor) error {
+	// Saving to the database
+	err := storage.AcceptPickupBid(ctx, pickupRequestID, bidID, actor)
 	if err != nil {
-		fmt.Printf("Error publishing to topic %s: %v", PickupRequestsTopic, err)
-		return id, err
+		fmt.Printf("Error accepting pickup bid in the database: %v", err)
+		return err
 	}
 
-	fmt.Println("Published pickup request to Pub/Sub topic:", PickupRequestsTopic)
+	fmt.Println("Pickup bid accepted successfully in the database, queued for topic:", PickupRequestsTopic)
 
-	return id, nil
+	return nil
 }
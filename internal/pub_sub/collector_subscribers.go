@@ -2,44 +2,63 @@ package pub_sub
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
 
-func StartPickupRequestSubscriber(ctx context.Context, storage storage.Storage, client *pubsub.Client, subscriptionID string) error {
-	sub := client.Subscription(subscriptionID)
-
-	err := sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+func StartPickupRequestSubscriber(ctx context.Context, storage storage.Storage, bus eventbus.Bus, sender *notify.Sender, subscriptionID string) error {
+	err := bus.Receive(ctx, subscriptionID, func(ctx context.Context, msg *eventbus.Message) {
 		fmt.Printf("Received message: %s\n", string(msg.Data))
 
 		var pr types.PickupRequest
-		if err := json.Unmarshal(msg.Data, &pr); err != nil {
+		envelope, err := events.Decode(msg.Data, &pr)
+		if err != nil {
 			log.Printf("Error unmarshalling message: %v", err)
 			log.Println("Nacking message due to unmarshalling failure")
-			msg.Nack()
+			msg.Fail(err)
+			return
+		}
+
+		// Open-market requests are broadcast to every invited collector
+		if pr.OpenMarket {
+			collectorIDs, err := invitedCollectors(ctx, storage, pr.RequestID)
+			if err != nil {
+				log.Printf("Error fetching invitations: %v", err)
+				log.Println("Nacking message due to ListMarketInvitations failure")
+				msg.Fail(err)
+				return
+			}
+			if err := inviteCollectors(ctx, storage, sender, envelope.ID, pr, collectorIDs); err != nil {
+				log.Printf("Error inviting collectors: %v", err)
+				log.Println("Nacking message due to inviteCollectors failure")
+				msg.Fail(err)
+				return
+			}
+			msg.Ack()
 			return
 		}
 
-		collector, err := storage.GetCollectorByID(pr.CollectorID)
+		collector, err := storage.GetCollectorByID(ctx, pr.CollectorID)
 		if err != nil {
 			log.Printf("Error fetching collector: %v", err)
 			log.Println("Nacking message due to GetCollectorByID failure")
-			msg.Nack()
+			msg.Fail(err)
 			return
 		}
 
-		heading := fmt.Sprintf("New Request Received")
-		message1 := fmt.Sprintf("Dear Collector,\n\nYou have a new pickup request (ID: %d).\nBusiness ID: %d\nWaste Type: %s\nQuantity: %.2f\n\nRegards,\nXphora AI", pr.RequestID, pr.BusinessID, pr.WasteType, pr.Quantity)
+		data := notify.TemplateData{EventID: envelope.ID, PickupRequest: pr, Collector: collector}
 
-		if err := sendNotification(collector, heading, message1); err != nil {
+		if err := sendNotification(ctx, sender, collector, "pickup.created.collector", data); err != nil {
 			log.Printf("Error sending email: %v", err)
 			log.Println("Nacking message due to sendNotification failure")
-			msg.Nack()
+			msg.Fail(err)
 			return
 		}
 
@@ -49,8 +68,43 @@ func StartPickupRequestSubscriber(ctx context.Context, storage storage.Storage,
 	})
 
 	if err != nil {
-		log.Fatalf("Failed to receive messages: %v", err)
-		return err
+		return fmt.Errorf("failed to receive messages: %w", err)
 	}
 	return nil
 }
+
+// invitedCollectors returns the collectors invited to an open-market pickup request.
+func invitedCollectors(ctx context.Context, storage storage.Storage, requestID int64) ([]int64, error) {
+	invitations, err := storage.ListMarketInvitations(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	collectorIDs := make([]int64, len(invitations))
+	for i, invitation := range invitations {
+		collectorIDs[i] = invitation.CollectorID
+	}
+	return collectorIDs, nil
+}
+
+// inviteCollectors notifies each invited collector of an open-market request. A failure does not
+// keep the collectors after it from being invited; the failures are returned together so that
+// the message is redelivered, which records the inbox entry of each collector once.
+func inviteCollectors(ctx context.Context, storage storage.Storage, sender *notify.Sender, eventID string, pr types.PickupRequest, collectorIDs []int64) error {
+	var errs []error
+	for _, collectorID := range collectorIDs {
+		collector, err := storage.GetCollectorByID(ctx, collectorID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collector %d: %w", collectorID, err))
+			continue
+		}
+
+		data := notify.TemplateData{EventID: eventID, PickupRequest: pr, Collector: collector}
+		if err := sendNotification(ctx, sender, collector, "pickup.open.collector", data); err != nil {
+			errs = append(errs, fmt.Errorf("collector %d: %w", collectorID, err))
+			continue
+		}
+
+		fmt.Printf("Notification sent to collector: %s\n", collector.Email)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package pub_sub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Attributes added to messages published on a dead-letter topic
+const (
+	DeadLetterIDAttribute           = "dead_letter_id"
+	DeadLetterSubscriptionAttribute = "dead_letter_subscription"
+	DeadLetterErrorAttribute        = "dead_letter_error"
+)
+
+// DeadLetterTopic returns the topic messages that failed on topic are moved to.
+func DeadLetterTopic(topic string) string {
+	return topic + "-DLQ"
+}
+
+// deadLetterBus bounds the delivery attempts of every handler passed to Receive. Messages that
+// fail on their last attempt are stored as dead letters and published on the dead-letter topic
+// of their topic, then acked.
+type deadLetterBus struct {
+	eventbus.Bus
+	storage     storage.Storage
+	maxAttempts int
+}
+
+func withDeadLetters(bus eventbus.Bus, storage storage.Storage, maxAttempts int) eventbus.Bus {
+	return &deadLetterBus{Bus: bus, storage: storage, maxAttempts: maxAttempts}
+}
+
+func (b *deadLetterBus) Receive(ctx context.Context, subscriptionID string, handler eventbus.Handler) error {
+	return b.Bus.Receive(ctx, subscriptionID, eventbus.WithMaxAttempts(handler, b.maxAttempts, func(ctx context.Context, msg *eventbus.Message, lastErr error) error {
+		return b.deadLetter(ctx, subscriptionID, msg, lastErr)
+	}))
+}
+
+func (b *deadLetterBus) deadLetter(ctx context.Context, subscriptionID string, msg *eventbus.Message, lastErr error) error {
+	// The stored dead letter is what the admin API works with, the dead-letter topic is only for
+	// external consumers, so failing to publish there does not keep the message alive
+	id, err := b.storage.CreateDeadLetter(ctx, types.DeadLetter{
+		SubscriptionID: subscriptionID,
+		Topic:          msg.Topic,
+		MessageID:      msg.ID,
+		EventID:        msg.Attributes[events.IDAttribute],
+		EventType:      msg.Attributes[events.TypeAttribute],
+		Payload:        string(msg.Data),
+		Attributes:     msg.Attributes,
+		Attempts:       msg.DeliveryAttempt,
+		LastError:      lastErr.Error(),
+	})
+	if err != nil {
+		slog.Error("failed to store dead letter", slog.String("subscription", subscriptionID), slog.String("message_id", msg.ID), slog.String("error", err.Error()))
+		return err
+	}
+
+	slog.Warn("message dead-lettered",
+		slog.Int64("dead_letter_id", id),
+		slog.String("subscription", subscriptionID),
+		slog.String("message_id", msg.ID),
+		slog.Int("attempts", msg.DeliveryAttempt),
+		slog.String("error", lastErr.Error()))
+
+	attributes := make(map[string]string, len(msg.Attributes)+3)
+	for key, value := range msg.Attributes {
+		attributes[key] = value
+	}
+	attributes[DeadLetterIDAttribute] = strconv.FormatInt(id, 10)
+	attributes[DeadLetterSubscriptionAttribute] = subscriptionID
+	attributes[DeadLetterErrorAttribute] = lastErr.Error()
+
+	if msg.Topic != "" {
+		if err := b.Bus.Publish(ctx, DeadLetterTopic(msg.Topic), msg.Data, attributes); err != nil {
+			slog.Error("failed to publish dead letter", slog.Int64("dead_letter_id", id), slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// ReplayDeadLetter publishes a pending dead letter again on its original topic and marks it as
+// replayed.
+func ReplayDeadLetter(ctx context.Context, storage storage.Storage, bus eventbus.Bus, deadLetterID int64) error {
+	deadLetter, err := storage.GetDeadLetter(ctx, deadLetterID)
+	if err != nil {
+		return err
+	}
+	if deadLetter.Status != "pending" {
+		return fmt.Errorf("dead letter %d is already %s", deadLetterID, deadLetter.Status)
+	}
+
+	err = bus.Publish(ctx, deadLetter.Topic, []byte(deadLetter.Payload), deadLetter.Attributes)
+	if err != nil {
+		fmt.Printf("Error replaying dead letter %d on topic %s: %v", deadLetterID, deadLetter.Topic, err)
+		return err
+	}
+
+	fmt.Printf("Dead letter %d replayed on topic: %s\n", deadLetterID, deadLetter.Topic)
+
+	return storage.ResolveDeadLetter(ctx, deadLetterID, "replayed")
+}
+
+func DiscardDeadLetter(ctx context.Context, storage storage.Storage, deadLetterID int64) error {
+	return storage.ResolveDeadLetter(ctx, deadLetterID, "discarded")
+}
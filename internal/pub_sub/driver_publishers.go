@@ -5,36 +5,36 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
 
-const DriverLocationTopic = "DRIVER-LOCATION"
+const DriverLocationTopic = events.DriverLocationTopic
 
-func PublishDriverLocation(pubsubClient *pubsub.Client, driverLocation types.DriverLocation) error {
-	// Converting driverLocation to JSON
-	messageData, err := json.Marshal(driverLocation)
+func PublishDriverLocation(ctx context.Context, bus eventbus.Bus, driverLocation types.DriverLocation) error {
+	// Wrapping driverLocation in an event envelope
+	actor := types.Actor{UserID: driverLocation.DriverID, Role: "Driver"}
+	envelope, err := events.New(events.DriverLocation, &actor, driverLocation)
+	if err != nil {
+		fmt.Printf("Error creating driver location event: %v", err)
+		return err
+	}
+
+	messageData, err := json.Marshal(envelope)
 	if err != nil {
 		fmt.Printf("Error marshaling driver location: %v", err)
 		return err
 	}
 
 	// Publishing to the topic
-	ctx := context.Background()
-	topic := pubsubClient.Topic(DriverLocationTopic)
-
-	result := topic.Publish(ctx, &pubsub.Message{
-		Data: messageData,
-	})
-
-	// Confirming whether the message was published
-	_, err = result.Get(ctx)
+	err = bus.Publish(ctx, DriverLocationTopic, messageData, envelope.Attributes())
 	if err != nil {
 		fmt.Printf("Error publishing to topic %s: %v", DriverLocationTopic, err)
 		return err
 	}
 
-	fmt.Printf("Published driver location for driver %d to Pub/Sub topic: %s\n", driverLocation.DriverID, DriverLocationTopic)
+	fmt.Printf("Published driver location for driver %d to topic: %s\n", driverLocation.DriverID, DriverLocationTopic)
 
 	return nil
 }
@@ -0,0 +1,151 @@
+package pub_sub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/coverage"
+	"github.com/kartikey1188/build-in-progress_01/internal/eta"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/geofence"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// errConflict is storage.ErrConflict, which the storage parameters of the subscribers shadow.
+var errConflict = storage.ErrConflict
+
+// StartDriverLocationSubscriber persists the locations drivers report and broadcasts them to the
+// clients tracking the trip, together with the ETA recalculated from them. The first location
+// of a trip is stored as its START, the END is marked once the delivery is completed. Locations
+// entering and leaving the fences of the trip's sites raise driver.arrived and driver.departed
+// events.
+func StartDriverLocationSubscriber(ctx context.Context, storage storage.Storage, bus eventbus.Bus, hub *realtime.Hub, estimator *eta.Estimator, detector *geofence.Detector, subscriptionID string) error {
+	err := bus.Receive(ctx, subscriptionID, func(ctx context.Context, msg *eventbus.Message) {
+		var location types.DriverLocation
+		if _, err := events.Decode(msg.Data, &location); err != nil {
+			log.Printf("Error unmarshalling driver location: %v", err)
+			log.Println("Nacking message due to unmarshalling failure")
+			msg.Fail(err)
+			return
+		}
+
+		locationID, err := storage.StoreDriverLocation(ctx, location)
+		if err != nil {
+			log.Printf("Error storing location of driver %d: %v", location.DriverID, err)
+			log.Println("Nacking message due to StoreDriverLocation failure")
+			msg.Fail(err)
+			return
+		}
+		location.LocationID = locationID
+		hub.PublishLocation(location)
+
+		// The location is stored, failing the message would store it twice
+		if err := trackTrip(ctx, storage, hub, estimator, detector, location); err != nil {
+			log.Printf("Error tracking pickup request %d: %v", location.TripID, err)
+		}
+
+		msg.Ack() // Marking message as successfully handled
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to receive messages: %w", err)
+	}
+	return nil
+}
+
+// trackTrip updates the ETA and the site visits of the pickup request a location belongs to,
+// while the request is not delivered yet.
+func trackTrip(ctx context.Context, storage storage.Storage, hub *realtime.Hub, estimator *eta.Estimator, detector *geofence.Detector, location types.DriverLocation) error {
+	if location.TripID == 0 {
+		return nil
+	}
+	request, err := storage.GetPickupRequestByID(ctx, location.TripID)
+	if err != nil {
+		return err
+	}
+	status := lifecycle.Status(request.Status)
+	if status != lifecycle.Assigned && status != lifecycle.InTransit {
+		return nil
+	}
+
+	etaErr := updateETA(ctx, storage, hub, estimator, request)
+	return errors.Join(etaErr, detectGeofences(ctx, storage, detector, request, location))
+}
+
+// updateETA recalculates the ETA of the driver of a pickup request, to its own coordinates or else
+// to the geocoded location of the business, like coverage checks.
+func updateETA(ctx context.Context, storage storage.Storage, hub *realtime.Hub, estimator *eta.Estimator, request types.PickupRequest) error {
+	var business types.Business
+	if request.PickupLatitude == nil || request.PickupLongitude == nil {
+		var err error
+		if business, err = storage.GetBusinessByID(ctx, request.BusinessID); err != nil {
+			return err
+		}
+	}
+	recent, err := storage.GetLatestTripLocations(ctx, request.RequestID, max(estimator.SpeedWindow, 1))
+	if err != nil {
+		return err
+	}
+	estimate, ok := estimator.Estimate(request, coverage.PickupLocation(request, business).Point, recent)
+	if !ok {
+		return nil
+	}
+	if err := storage.SavePickupETA(ctx, estimate); err != nil {
+		return err
+	}
+	hub.PublishETA(estimate)
+	return nil
+}
+
+// maxVisitAttempts bounds the retries of a site visit that concurrent locations kept changing.
+const maxVisitAttempts = 3
+
+// detectGeofences applies a location to the visits of the trip to the pickup sites of the
+// business and the drop-off sites of the collector. A visit saved concurrently by another
+// location is read again and the location applied to it.
+func detectGeofences(ctx context.Context, storage storage.Storage, detector *geofence.Detector, request types.PickupRequest, location types.DriverLocation) error {
+	businessSites, err := storage.ListSites(ctx, request.BusinessID)
+	if err != nil {
+		return err
+	}
+	collectorSites, err := storage.ListSites(ctx, request.CollectorID)
+	if err != nil {
+		return err
+	}
+	sites := geofence.Sites(request, businessSites, collectorSites)
+
+	for attempt := 1; len(sites) > 0; attempt++ {
+		visits, err := storage.GetSiteVisits(ctx, request.RequestID)
+		if err != nil {
+			return err
+		}
+		stored := make(map[int64]types.SiteVisit, len(visits))
+		for _, visit := range visits {
+			stored[visit.SiteID] = visit
+		}
+
+		var conflicted []types.Site
+		for _, site := range sites {
+			visit, event, changed := detector.Step(request, site, stored[site.SiteID], location)
+			if !changed {
+				continue
+			}
+			err := storage.SaveSiteVisit(ctx, visit, event)
+			if errors.Is(err, errConflict) && attempt < maxVisitAttempts {
+				conflicted = append(conflicted, site)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		sites = conflicted
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package pub_sub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+const GeofenceTopic = events.GeofenceTopic
+
+// StartGeofenceSubscriber handles the driver.arrived and driver.departed events. At sites with
+// auto-advance, departing the pickup site starts the delivery and arriving at the drop-off site
+// completes it, unless the driver did so already. The business is notified of every event.
+func StartGeofenceSubscriber(ctx context.Context, storage storage.Storage, bus eventbus.Bus, sender *notify.Sender, subscriptionID string) error {
+	err := bus.Receive(ctx, subscriptionID, func(ctx context.Context, msg *eventbus.Message) {
+		fmt.Printf("Received message: %s\n", string(msg.Data))
+
+		var event types.GeofenceEvent
+		envelope, err := events.Decode(msg.Data, &event)
+		if err != nil {
+			log.Printf("Error unmarshalling message: %v", err)
+			log.Println("Nacking message due to unmarshalling failure")
+			msg.Fail(err)
+			return
+		}
+
+		if err := autoAdvance(ctx, storage, envelope.Type, event); err != nil {
+			log.Printf("Error advancing pickup request %d: %v", event.RequestID, err)
+			log.Println("Nacking message due to autoAdvance failure")
+			msg.Fail(err)
+			return
+		}
+
+		pr, err := storage.GetPickupRequestByID(ctx, event.RequestID)
+		if err != nil {
+			log.Printf("Error fetching pickup request: %v", err)
+			log.Println("Nacking message due to GetPickupRequestByID failure")
+			msg.Fail(err)
+			return
+		}
+
+		business, err := storage.GetBusinessByID(ctx, event.BusinessID)
+		if err != nil {
+			log.Printf("Error fetching business: %v", err)
+			log.Println("Nacking message due to GetBusinessByID failure")
+			msg.Fail(err)
+			return
+		}
+
+		data := notify.TemplateData{EventID: envelope.ID, PickupRequest: pr, Business: business, Geofence: event}
+
+		if err := sendNotification(ctx, sender, business, string(envelope.Type)+".business", data); err != nil {
+			log.Printf("Error sending email: %v", err)
+			log.Println("Nacking message due to sendNotification failure")
+			msg.Fail(err)
+			return
+		}
+
+		fmt.Printf("Notification sent to business: %s\n", business.Email)
+
+		msg.Ack() // Marking message as successfully handled
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to receive messages: %w", err)
+	}
+	return nil
+}
+
+// autoAdvance starts or completes the delivery of a pickup request on behalf of its driver, if
+// the event is from a site with auto-advance. A delivery the driver started or completed in the
+// meantime, or that was reassigned to another driver, is left as it is.
+func autoAdvance(ctx context.Context, storage storage.Storage, eventType events.Type, event types.GeofenceEvent) error {
+	if !event.AutoAdvance {
+		return nil
+	}
+
+	var from lifecycle.Status
+	switch {
+	case eventType == events.DriverDeparted && event.SiteKind == types.SitePickup:
+		from = lifecycle.Assigned
+	case eventType == events.DriverArrived && event.SiteKind == types.SiteDropOff:
+		from = lifecycle.InTransit
+	default:
+		return nil
+	}
+
+	pr, err := storage.GetPickupRequestByID(ctx, event.RequestID)
+	if err != nil {
+		return err
+	}
+	if lifecycle.Status(pr.Status) != from || pr.AssignedDriver != event.DriverID {
+		return nil
+	}
+
+	actor := types.Actor{UserID: event.DriverID, Role: "Driver"}
+	if from == lifecycle.Assigned {
+		err = StartDelivery(ctx, storage, event.RequestID, actor)
+	} else {
+		err = EndDelivery(ctx, storage, event.RequestID, actor)
+	}
+	if errors.Is(err, lifecycle.ErrIllegalTransition) {
+		return nil // Advanced concurrently
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package pub_sub
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+)
+
+// idempotentBus makes every handler passed to Receive skip events the subscription already
+// handled, using the processed events table.
+type idempotentBus struct {
+	eventbus.Bus
+	storage storage.Storage
+	ttl     time.Duration
+}
+
+func withIdempotency(bus eventbus.Bus, storage storage.Storage, ttl time.Duration) eventbus.Bus {
+	return &idempotentBus{Bus: bus, storage: storage, ttl: ttl}
+}
+
+func (b *idempotentBus) Receive(ctx context.Context, subscriptionID string, handler eventbus.Handler) error {
+	return b.Bus.Receive(ctx, subscriptionID, eventbus.Idempotent(handler, b.storage, subscriptionID, b.ttl, eventID))
+}
+
+// eventID identifies a message by the ID of its envelope, which stays the same when the outbox
+// relay publishes an event twice, falling back to the broker's message ID.
+func eventID(msg *eventbus.Message) string {
+	if id := msg.Attributes[events.IDAttribute]; id != "" {
+		return id
+	}
+	return msg.ID
+}
+
+// PurgeProcessedEvents deletes expired processed event records every interval until ctx is done.
+func PurgeProcessedEvents(ctx context.Context, storage storage.Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := storage.DeleteExpiredProcessedEvents(ctx)
+			if err != nil {
+				slog.Error("failed to purge processed events", slog.String("error", err.Error()))
+				continue
+			}
+			if deleted > 0 {
+				slog.Info("purged processed events", slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
@@ -2,57 +2,58 @@ package pub_sub
 
 import (
 	"context"
-	"log"
-	"log/slog"
 
-	"cloud.google.com/go/pubsub"
 	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/eta"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/geofence"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
 	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 )
 
-func StartListeners(ctx context.Context, client *pubsub.Client, cfg *config.Config, storage storage.Storage) error {
-	slog.Info("Starting listeners...")
+// startSubscriber is the signature shared by the Start*Subscriber functions.
+type startSubscriber func(ctx context.Context, storage storage.Storage, bus eventbus.Bus, sender *notify.Sender, subscriptionID string) error
 
-	err := StartPickupRequestSubscriber(ctx, storage, client, cfg.PickupRequestSubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start PickupRequestSubscriber: %v", err)
-		return err
-	}
-
-	err = StartAcceptPickupRequestSubscriber(ctx, storage, client, cfg.AcceptPickupRequestSubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start AcceptPickupRequestSubscriber: %v", err)
-		return err
-	}
-	err = StartRejectPickupRequestSubscriber(ctx, storage, client, cfg.RejectPickupRequestSubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start RejectPickupRequestSubscriber: %v", err)
-		return err
-	}
+// NewListeners registers every subscriber with a new Supervisor. The listeners start when the
+// Supervisor is run. Messages failing cfg.MaxDeliveryAttempts times are dead-lettered, and
+// redeliveries of events a subscriber already handled are skipped. Driver locations are
+// broadcast to the tracking clients of hub.
+func NewListeners(bus eventbus.Bus, cfg *config.Config, storage storage.Storage, sender *notify.Sender, hub *realtime.Hub) *Supervisor {
+	supervisor := NewSupervisor()
 
-	err = StartDeliverySubscriber(ctx, storage, client, cfg.StartDeliverySubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start DeliverySubscriber: %v", err)
-		return err
-	}
+	// Deduplicating inside the attempt limit, so that a dead-lettered event is not recorded as
+	// processed and can still be replayed
+	bus = withIdempotency(withDeadLetters(bus, storage, cfg.MaxDeliveryAttempts), storage, cfg.ProcessedEventTTL)
 
-	err = EndDeliverySubscriber(ctx, storage, client, cfg.EndDeliverySubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start EndDeliverySubscriber: %v", err)
-		return err
+	listen := func(name string, subscriptionID string, start startSubscriber) {
+		supervisor.Add(name, subscriptionID, func(ctx context.Context) error {
+			return start(ctx, storage, bus, sender, subscriptionID)
+		})
 	}
 
-	err = StartAssignDriverSubscriber(ctx, storage, client, cfg.AssignDriverSubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start AssignDriverSubscriber: %v", err)
-		return err
-	}
+	listen("PickupRequestSubscriber", cfg.PickupRequestSubscriptionID, StartPickupRequestSubscriber)
+	listen("AcceptPickupRequestSubscriber", cfg.AcceptPickupRequestSubscriptionID, StartAcceptPickupRequestSubscriber)
+	listen("RejectPickupRequestSubscriber", cfg.RejectPickupRequestSubscriptionID, StartRejectPickupRequestSubscriber)
+	listen("CancelPickupRequestSubscriber", cfg.CancelPickupRequestSubscriptionID, StartCancelPickupRequestSubscriber)
+	listen("DeliverySubscriber", cfg.StartDeliverySubscriptionID, StartDeliverySubscriber)
+	listen("EndDeliverySubscriber", cfg.EndDeliverySubscriptionID, EndDeliverySubscriber)
+	listen("AssignDriverSubscriber", cfg.AssignDriverSubscriptionID, StartAssignDriverSubscriber)
+	listen("UnassignDriverSubscriber", cfg.UnassignDriverSubscriptionID, StartUnassignDriverSubscriber)
+	listen("DriverLocationSubscriber", cfg.DriverLocationSubscriptionID, driverLocationSubscriber(hub, eta.NewEstimator(cfg), geofence.NewDetector(cfg)))
+	listen("GeofenceSubscriber", cfg.GeofenceSubscriptionID, StartGeofenceSubscriber)
+	listen("WebhookPickupRequestsSubscriber", cfg.WebhookPickupRequestsSubscriptionID, StartWebhookSubscriber)
+	listen("WebhookAssignmentsSubscriber", cfg.WebhookAssignmentsSubscriptionID, StartWebhookSubscriber)
+	listen("WebhookDeliverySubscriber", cfg.WebhookDeliverySubscriptionID, StartWebhookSubscriber)
+	listen("WebhookGeofenceSubscriber", cfg.WebhookGeofenceSubscriptionID, StartWebhookSubscriber)
+
+	return supervisor
+}
 
-	err = StartUnassignDriverSubscriber(ctx, storage, client, cfg.UnassignDriverSubscriptionID)
-	if err != nil {
-		log.Fatalf("Failed to start UnassignDriverSubscriber: %v", err)
-		return err
+// driverLocationSubscriber adapts StartDriverLocationSubscriber, which broadcasts to hub rather
+// than sending notifications.
+func driverLocationSubscriber(hub *realtime.Hub, estimator *eta.Estimator, detector *geofence.Detector) startSubscriber {
+	return func(ctx context.Context, storage storage.Storage, bus eventbus.Bus, _ *notify.Sender, subscriptionID string) error {
+		return StartDriverLocationSubscriber(ctx, storage, bus, hub, estimator, detector, subscriptionID)
 	}
-
-	return nil
 }
@@ -2,73 +2,105 @@ package pub_sub
 
 import (
 	"context"
-	"fmt"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
 )
 
-type SubscriptionConfig struct {
-	SubscriptionID string
-	TopicID        string
-}
-
-var requiredSubscriptions = []SubscriptionConfig{
-	{
-		SubscriptionID: "pickup-request-subscription-id",
-		TopicID:        "PICKUP-REQUESTS",
-	},
-	{
-		SubscriptionID: "driver-location-subscription-id",
-		TopicID:        "DRIVER-LOCATION",
-	},
-	{
-		SubscriptionID: "accept-pickup-request-subscription-id",
-		TopicID:        "PICKUP-REQUESTS",
-	},
-	{
-		SubscriptionID: "reject-pickup-request-subscription-id",
-		TopicID:        "PICKUP-REQUESTS",
-	},
-	{
-		SubscriptionID: "start-delivery-subscription-id",
-		TopicID:        "DELIVERY",
-	},
-	{
-		SubscriptionID: "end-delivery-subscription-id",
-		TopicID:        "DELIVERY",
-	},
-	{
-		SubscriptionID: "assign-driver-subscription-id",
-		TopicID:        "ASSIGNMENTS",
-	},
-	{
-		SubscriptionID: "unassign-driver-subscription-id",
-		TopicID:        "ASSIGNMENTS",
-	},
+// requiredSubscriptions lists the subscriptions NewListeners receives from, under the IDs of cfg.
+// Every subscription only receives the event type its subscriber handles, even where several
+// subscribers share a topic
+func requiredSubscriptions(cfg *config.Config) []eventbus.Subscription {
+	return []eventbus.Subscription{
+		{
+			ID:     cfg.PickupRequestSubscriptionID,
+			Topic:  PickupRequestsTopic,
+			Filter: ofTypes(events.PickupCreated),
+		},
+		{
+			ID:     cfg.DriverLocationSubscriptionID,
+			Topic:  DriverLocationTopic,
+			Filter: ofTypes(events.DriverLocation),
+		},
+		{
+			ID:     cfg.AcceptPickupRequestSubscriptionID,
+			Topic:  PickupRequestsTopic,
+			Filter: ofTypes(events.PickupAccepted),
+		},
+		{
+			ID:     cfg.RejectPickupRequestSubscriptionID,
+			Topic:  PickupRequestsTopic,
+			Filter: ofTypes(events.PickupRejected),
+		},
+		{
+			ID:     cfg.CancelPickupRequestSubscriptionID,
+			Topic:  PickupRequestsTopic,
+			Filter: ofTypes(events.PickupCancelled),
+		},
+		{
+			ID:     cfg.StartDeliverySubscriptionID,
+			Topic:  DeliveryTopic,
+			Filter: ofTypes(events.DeliveryStarted),
+		},
+		{
+			ID:     cfg.EndDeliverySubscriptionID,
+			Topic:  DeliveryTopic,
+			Filter: ofTypes(events.DeliveryCompleted),
+		},
+		{
+			ID:     cfg.AssignDriverSubscriptionID,
+			Topic:  AssignmentsTopic,
+			Filter: ofTypes(events.DriverAssigned),
+		},
+		{
+			ID:     cfg.UnassignDriverSubscriptionID,
+			Topic:  AssignmentsTopic,
+			Filter: ofTypes(events.DriverUnassigned),
+		},
+		{
+			ID:     cfg.GeofenceSubscriptionID,
+			Topic:  GeofenceTopic,
+			Filter: ofTypes(events.DriverArrived, events.DriverDeparted),
+		},
+		{
+			ID:     cfg.WebhookPickupRequestsSubscriptionID,
+			Topic:  PickupRequestsTopic,
+			Filter: ofTypes(events.PickupCreated, events.PickupAccepted, events.PickupRejected, events.PickupCancelled),
+		},
+		{
+			ID:     cfg.WebhookAssignmentsSubscriptionID,
+			Topic:  AssignmentsTopic,
+			Filter: ofTypes(events.DriverAssigned, events.DriverUnassigned),
+		},
+		{
+			ID:     cfg.WebhookDeliverySubscriptionID,
+			Topic:  DeliveryTopic,
+			Filter: ofTypes(events.DeliveryStarted, events.DeliveryCompleted),
+		},
+		{
+			ID:     cfg.WebhookGeofenceSubscriptionID,
+			Topic:  GeofenceTopic,
+			Filter: ofTypes(events.DriverArrived, events.DriverDeparted),
+		},
+	}
 }
 
-func InitSubscriptions(client *pubsub.Client) error {
-	ctx := context.Background()
-
-	for _, sub := range requiredSubscriptions {
-		subscription := client.Subscription(sub.SubscriptionID)
-
-		exists, err := subscription.Exists(ctx)
-		if err != nil {
-			return fmt.Errorf("error checking subscription %s: %w", sub.SubscriptionID, err)
-		}
-
-		if !exists {
-			topic := client.Topic(sub.TopicID)
-
-			_, err := client.CreateSubscription(ctx, sub.SubscriptionID, pubsub.SubscriptionConfig{
-				Topic: topic,
-			})
-			if err != nil {
-				return fmt.Errorf("failed to create subscription %s: %w", sub.SubscriptionID, err)
-			}
+func InitSubscriptions(ctx context.Context, bus eventbus.Bus, cfg *config.Config) error {
+	for _, sub := range requiredSubscriptions(cfg) {
+		if err := bus.EnsureSubscription(ctx, sub); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// ofTypes restricts a subscription to the given event types.
+func ofTypes(eventTypes ...events.Type) *eventbus.AttributeFilter {
+	values := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		values[i] = string(eventType)
+	}
+	return &eventbus.AttributeFilter{Attribute: events.TypeAttribute, Values: values}
+}
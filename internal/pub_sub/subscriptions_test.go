@@ -0,0 +1,57 @@
+package pub_sub
+
+import (
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/realtime"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/memory"
+)
+
+// Every listener must receive from a subscription InitSubscriptions created, under the IDs of the
+// config rather than the defaults.
+func TestSubscriptionsMatchListeners(t *testing.T) {
+	cfg := &config.Config{
+		PickupRequestSubscriptionID:         "prod-pickup-request",
+		DriverLocationSubscriptionID:        "prod-driver-location",
+		AcceptPickupRequestSubscriptionID:   "prod-accept-pickup-request",
+		RejectPickupRequestSubscriptionID:   "prod-reject-pickup-request",
+		CancelPickupRequestSubscriptionID:   "prod-cancel-pickup-request",
+		StartDeliverySubscriptionID:         "prod-start-delivery",
+		EndDeliverySubscriptionID:           "prod-end-delivery",
+		AssignDriverSubscriptionID:          "prod-assign-driver",
+		UnassignDriverSubscriptionID:        "prod-unassign-driver",
+		GeofenceSubscriptionID:              "prod-geofence",
+		WebhookPickupRequestsSubscriptionID: "prod-webhook-pickup-requests",
+		WebhookAssignmentsSubscriptionID:    "prod-webhook-assignments",
+		WebhookDeliverySubscriptionID:       "prod-webhook-delivery",
+		WebhookGeofenceSubscriptionID:       "prod-webhook-geofence",
+	}
+
+	bus := eventbus.NewMemory()
+	if err := InitTopics(t.Context(), bus); err != nil {
+		t.Fatalf("InitTopics: %v", err)
+	}
+	if err := InitSubscriptions(t.Context(), bus, cfg); err != nil {
+		t.Fatalf("InitSubscriptions: %v", err)
+	}
+
+	subscriptions := map[string]bool{}
+	for _, sub := range requiredSubscriptions(cfg) {
+		if subscriptions[sub.ID] {
+			t.Errorf("subscription %q is required twice", sub.ID)
+		}
+		subscriptions[sub.ID] = true
+	}
+
+	listeners := NewListeners(bus, cfg, memory.New(), nil, realtime.NewHub()).Status()
+	if len(listeners) != len(subscriptions) {
+		t.Errorf("%d listeners for %d subscriptions", len(listeners), len(subscriptions))
+	}
+	for _, listener := range listeners {
+		if !subscriptions[listener.SubscriptionID] {
+			t.Errorf("%s receives from %q, which InitSubscriptions did not create", listener.Name, listener.SubscriptionID)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package pub_sub
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Subscriber receives messages until ctx is done. A subscriber that returns before that, with or
+// without an error, is restarted by the Supervisor.
+type Subscriber func(ctx context.Context) error
+
+// Subscriber states reported by the Supervisor
+const (
+	SubscriberStarting   = "starting"
+	SubscriberRunning    = "running"
+	SubscriberRestarting = "restarting"
+	SubscriberStopped    = "stopped"
+)
+
+type SubscriberStatus struct {
+	Name           string     `json:"name"`
+	SubscriptionID string     `json:"subscription_id"`
+	State          string     `json:"state"`
+	Restarts       int        `json:"restarts"`
+	StartedAt      *time.Time `json:"started_at,omitempty"` // Start of the current run
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+}
+
+type supervised struct {
+	run    Subscriber
+	status SubscriberStatus
+}
+
+// Supervisor runs subscribers concurrently and restarts the ones that fail, with exponential
+// backoff between restarts.
+type Supervisor struct {
+	BaseBackoff time.Duration // Delay before the first restart, doubled on every consecutive failure
+	MaxBackoff  time.Duration // Upper bound for the restart delay
+	StableAfter time.Duration // A run lasting this long resets the backoff
+
+	mu          sync.Mutex
+	subscribers []*supervised
+}
+
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		StableAfter: time.Minute,
+	}
+}
+
+// Add registers a subscriber. It must be called before Run.
+func (s *Supervisor) Add(name string, subscriptionID string, run Subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers = append(s.subscribers, &supervised{
+		run: run,
+		status: SubscriberStatus{
+			Name:           name,
+			SubscriptionID: subscriptionID,
+			State:          SubscriberStarting,
+		},
+	})
+}
+
+// Run starts every subscriber and blocks until ctx is done and all of them have returned, which
+// includes waiting for their in-flight handlers.
+func (s *Supervisor) Run(ctx context.Context) {
+	s.mu.Lock()
+	subscribers := s.subscribers
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sub := range subscribers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.supervise(ctx, sub)
+		}()
+	}
+	wg.Wait()
+}
+
+// Status returns the current status of every subscriber.
+func (s *Supervisor) Status() []SubscriberStatus {
+	if s == nil {
+		return []SubscriberStatus{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]SubscriberStatus, len(s.subscribers))
+	for i, sub := range s.subscribers {
+		statuses[i] = sub.status
+	}
+	return statuses
+}
+
+func (s *Supervisor) supervise(ctx context.Context, sub *supervised) {
+	failures := 0
+
+	for {
+		startedAt := time.Now()
+		s.update(sub, func(status *SubscriberStatus) {
+			status.State = SubscriberRunning
+			status.StartedAt = &startedAt
+		})
+		slog.Info("subscriber started", slog.String("subscriber", sub.status.Name))
+
+		err := runSafely(ctx, sub.run)
+
+		if ctx.Err() != nil {
+			s.update(sub, func(status *SubscriberStatus) {
+				status.State = SubscriberStopped
+			})
+			slog.Info("subscriber stopped", slog.String("subscriber", sub.status.Name))
+			return
+		}
+
+		if err == nil {
+			err = fmt.Errorf("subscriber returned unexpectedly")
+		}
+		if time.Since(startedAt) >= s.StableAfter {
+			failures = 0
+		}
+		delay := s.backoff(failures)
+		failures++
+
+		failedAt := time.Now()
+		s.update(sub, func(status *SubscriberStatus) {
+			status.State = SubscriberRestarting
+			status.Restarts++
+			status.LastError = err.Error()
+			status.LastErrorAt = &failedAt
+		})
+		slog.Error("subscriber failed, restarting",
+			slog.String("subscriber", sub.status.Name),
+			slog.Duration("backoff", delay),
+			slog.String("error", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			s.update(sub, func(status *SubscriberStatus) {
+				status.State = SubscriberStopped
+			})
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *Supervisor) update(sub *supervised, change func(*SubscriberStatus)) {
+	s.mu.Lock()
+	change(&sub.status)
+	s.mu.Unlock()
+}
+
+// backoff returns the restart delay after the given number of consecutive failures.
+func (s *Supervisor) backoff(failures int) time.Duration {
+	delay := s.BaseBackoff
+	for i := 0; i < failures; i++ {
+		delay *= 2
+		if delay >= s.MaxBackoff {
+			return s.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// runSafely turns a panicking subscriber into a failed one.
+func runSafely(ctx context.Context, run Subscriber) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
@@ -2,33 +2,25 @@ package pub_sub
 
 import (
 	"context"
-	"fmt"
 
-	"cloud.google.com/go/pubsub"
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
 )
 
 var requiredTopics = []string{
-	"PICKUP-REQUESTS",
-	"DRIVER-LOCATION",
-	"DELIVERY",
-	"ASSIGNMENTS",
+	PickupRequestsTopic,
+	DriverLocationTopic,
+	DeliveryTopic,
+	AssignmentsTopic,
+	GeofenceTopic,
 }
 
-func InitTopics(client *pubsub.Client) error {
-	ctx := context.Background()
-
+func InitTopics(ctx context.Context, bus eventbus.Bus) error {
 	for _, topicName := range requiredTopics {
-		topic := client.Topic(topicName)
-		exists, err := topic.Exists(ctx)
-		if err != nil {
-			return fmt.Errorf("error checking topic %s: %w", topicName, err)
+		if err := bus.EnsureTopic(ctx, topicName); err != nil {
+			return err
 		}
-
-		if !exists {
-			_, err = client.CreateTopic(ctx, topicName)
-			if err != nil {
-				return fmt.Errorf("failed to create topic %s: %w", topicName, err)
-			}
+		if err := bus.EnsureTopic(ctx, DeadLetterTopic(topicName)); err != nil {
+			return err
 		}
 	}
 
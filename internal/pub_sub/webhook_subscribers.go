@@ -0,0 +1,54 @@
+package pub_sub
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/eventbus"
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/notify"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"github.com/kartikey1188/build-in-progress_01/internal/webhooks"
+)
+
+// StartWebhookSubscriber queues the pickup request and geofence events of a topic for the webhook
+// endpoints of the business and the collector of the request, or the collectors invited to it
+// while it is on the open market. The webhook dispatcher delivers them.
+func StartWebhookSubscriber(ctx context.Context, storage storage.Storage, bus eventbus.Bus, sender *notify.Sender, subscriptionID string) error {
+	err := bus.Receive(ctx, subscriptionID, func(ctx context.Context, msg *eventbus.Message) {
+		// Both kinds of payload name the business and the collector alike
+		var pr types.PickupRequest
+		envelope, err := events.Decode(msg.Data, &pr)
+		if err != nil {
+			log.Printf("Error unmarshalling message: %v", err)
+			msg.Fail(err)
+			return
+		}
+
+		userIDs := []int64{pr.BusinessID, pr.CollectorID}
+		if pr.OpenMarket && pr.CollectorID == 0 {
+			invited, err := invitedCollectors(ctx, storage, pr.RequestID)
+			if err != nil {
+				log.Printf("Error fetching invitations: %v", err)
+				msg.Fail(err)
+				return
+			}
+			userIDs = append([]int64{pr.BusinessID}, invited...)
+		}
+
+		if err := webhooks.Enqueue(ctx, storage, envelope, msg.Data, userIDs); err != nil {
+			log.Printf("Error queueing webhook deliveries: %v", err)
+			msg.Fail(err)
+			return
+		}
+
+		msg.Ack() // Marking message as successfully handled
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to receive messages: %w", err)
+	}
+	return nil
+}
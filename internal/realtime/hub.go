@@ -0,0 +1,125 @@
+// Package realtime fans the locations of drivers out to the clients tracking a pickup request
+// over WebSocket or Server-Sent Events.
+package realtime
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// subscriptionBuffer is how many messages a slow client can fall behind before messages are
+// dropped for it. Only the latest location and ETA matter to a map, so dropping beats blocking
+// the subscriber that feeds the hub.
+const subscriptionBuffer = 16
+
+// Types of Message
+const (
+	LocationMessage = "location"
+	ETAMessage      = "eta"
+)
+
+// Message is what the clients tracking a pickup request receive, a location or an ETA of its
+// driver.
+type Message struct {
+	Type     string                `json:"type"`
+	Location *types.DriverLocation `json:"location,omitempty"`
+	ETA      *types.PickupETA      `json:"eta,omitempty"`
+}
+
+// DriverID is the driver the message is about.
+func (m Message) DriverID() int64 {
+	if m.Location != nil {
+		return m.Location.DriverID
+	}
+	if m.ETA != nil {
+		return m.ETA.DriverID
+	}
+	return 0
+}
+
+// Hub broadcasts the locations received by this replica's driver location subscriber, and the
+// ETAs computed from them. With
+// several replicas sharing the subscription, a client only sees the locations delivered to the
+// replica it is connected to.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[*Subscription]struct{} // By pickup request
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[int64]map[*Subscription]struct{})}
+}
+
+// Subscription receives the messages about the driver of one pickup request. Close it when the
+// client disconnects.
+type Subscription struct {
+	hub       *Hub
+	requestID int64
+	messages  chan Message
+	closed    bool // Guarded by hub.mu
+}
+
+// Subscribe starts receiving the messages about a pickup request.
+func (h *Hub) Subscribe(requestID int64) *Subscription {
+	sub := &Subscription{
+		hub:       h,
+		requestID: requestID,
+		messages:  make(chan Message, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[requestID] == nil {
+		h.subscribers[requestID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[requestID][sub] = struct{}{}
+	return sub
+}
+
+// PublishLocation sends a location to the subscriptions of its trip.
+func (h *Hub) PublishLocation(location types.DriverLocation) {
+	h.publish(location.TripID, Message{Type: LocationMessage, Location: &location})
+}
+
+// PublishETA sends an ETA to the subscriptions of its pickup request.
+func (h *Hub) PublishETA(eta types.PickupETA) {
+	h.publish(eta.RequestID, Message{Type: ETAMessage, ETA: &eta})
+}
+
+// publish sends a message to the subscriptions of a pickup request without waiting for any of
+// them.
+func (h *Hub) publish(requestID int64, message Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[requestID] {
+		select {
+		case sub.messages <- message:
+		default:
+			slog.Warn("dropping message for a slow tracking client", slog.Int64("request_id", requestID), slog.String("type", message.Type))
+		}
+	}
+}
+
+// Messages is closed when the subscription is.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	delete(s.hub.subscribers[s.requestID], s)
+	if len(s.hub.subscribers[s.requestID]) == 0 {
+		delete(s.hub.subscribers, s.requestID)
+	}
+	close(s.messages)
+}
@@ -0,0 +1,14 @@
+package storage
+
+import "errors"
+
+// ErrNotFound is wrapped by the errors of lookups that callers need to tell apart from failures.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is wrapped by the errors of writes that lost a race with a concurrent write. Reading
+// the state again and retrying is safe.
+var ErrConflict = errors.New("conflict")
+
+// ErrNotOpen is wrapped by the errors of bids on and acceptances of pickup requests that are not
+// open to collectors: made to a collector, or won or cancelled since.
+var ErrNotOpen = errors.New("pickup request is not open")
@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// updateUserFlags applies an admin action to a user, identified by its ID as given in the URL.
+func (m *Memory) updateUserFlags(ctx context.Context, userID string, update func(*types.User)) error {
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return fmt.Errorf("user with ID %d not found", id)
+	}
+	update(&user)
+	m.users[id] = user
+	return nil
+}
+
+// VerifyUser sets the user's is_verified status to true
+func (m *Memory) VerifyUser(ctx context.Context, userID string) error {
+	return m.updateUserFlags(ctx, userID, func(user *types.User) {
+		user.IsVerified = true
+	})
+}
+
+// UnverifyUser sets the user's is_verified status to false
+func (m *Memory) UnverifyUser(ctx context.Context, userID string) error {
+	return m.updateUserFlags(ctx, userID, func(user *types.User) {
+		user.IsVerified = false
+	})
+}
+
+// FlagUser sets is_flagged to true and is_active to false
+func (m *Memory) FlagUser(ctx context.Context, userID string) error {
+	return m.updateUserFlags(ctx, userID, func(user *types.User) {
+		user.IsFlagged = true
+		user.IsActive = false
+	})
+}
+
+// UnflagUser sets is_flagged to false and is_active to true
+func (m *Memory) UnflagUser(ctx context.Context, userID string) error {
+	return m.updateUserFlags(ctx, userID, func(user *types.User) {
+		user.IsFlagged = false
+		user.IsActive = true
+	})
+}
+
+// AddServiceCategory inserts a new waste service category and returns its ID.
+func (m *Memory) AddServiceCategory(ctx context.Context, sc types.ServiceCategory) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	for _, existing := range m.categories {
+		if existing.WasteType == sc.WasteType {
+			return 0, fmt.Errorf("failed to insert service category: duplicate key value violates unique constraint on waste_type")
+		}
+	}
+
+	sc.CategoryID = m.nextID("service_categories")
+	m.categories[sc.CategoryID] = sc
+	return sc.CategoryID, nil
+}
+
+// AddVehicle inserts a new vehicle and returns its ID.
+func (m *Memory) AddVehicle(ctx context.Context, v types.Vehicle) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	for _, existing := range m.vehicles {
+		if existing.VehicleType == v.VehicleType && existing.Capacity == v.Capacity {
+			return 0, fmt.Errorf("failed to insert vehicle: duplicate key value violates unique constraint on vehicle_type, capacity")
+		}
+	}
+
+	v.VehicleID = m.nextID("vehicles")
+	m.vehicles[v.VehicleID] = v
+	return v.VehicleID, nil
+}
+
+// DeleteServiceCategory deletes a service category and, like the foreign key, the collectors'
+// offers of it.
+func (m *Memory) DeleteServiceCategory(ctx context.Context, categoryID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	id := int64(categoryID)
+	if _, ok := m.categories[id]; !ok {
+		return fmt.Errorf("service category with ID %d not found", categoryID)
+	}
+
+	delete(m.categories, id)
+	for key := range m.collectorCategories {
+		if key.b == id {
+			delete(m.collectorCategories, key)
+		}
+	}
+	for areaID, area := range m.serviceAreas {
+		if area.CategoryID != nil && *area.CategoryID == id {
+			delete(m.serviceAreas, areaID)
+		}
+	}
+	return nil
+}
+
+// DeleteVehicle deletes a vehicle and, like the foreign keys, the collectors' vehicles, the
+// drivers' assignments of it and the locations recorded with it.
+func (m *Memory) DeleteVehicle(ctx context.Context, vehicleID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	id := int64(vehicleID)
+	if _, ok := m.vehicles[id]; !ok {
+		return fmt.Errorf("vehicle with ID %d not found", vehicleID)
+	}
+
+	delete(m.vehicles, id)
+	for key := range m.collectorVehicles {
+		if key.b == id {
+			delete(m.collectorVehicles, key)
+		}
+	}
+	for driverID, assigned := range m.vehicleDrivers {
+		if assigned == id {
+			delete(m.vehicleDrivers, driverID)
+		}
+	}
+	for locationID, location := range m.driverLocations {
+		if location.VehicleID == id {
+			delete(m.driverLocations, locationID)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetAllCollectors(ctx context.Context) ([]types.Collector, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	collectors := make([]types.Collector, 0, len(m.collectors))
+	for _, id := range sortedKeys(m.collectors) {
+		collectors = append(collectors, m.collector(id))
+	}
+	return collectors, nil
+}
+
+func (m *Memory) GetAllBusinesses(ctx context.Context) ([]types.Business, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	businesses := make([]types.Business, 0, len(m.businesses))
+	for _, id := range sortedKeys(m.businesses) {
+		businesses = append(businesses, m.business(id))
+	}
+	return businesses, nil
+}
+
+func (m *Memory) GetAllUsers(ctx context.Context) ([]types.User, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	users := make([]types.User, 0, len(m.users))
+	for _, id := range sortedKeys(m.users) {
+		users = append(users, cloneUser(m.users[id]))
+	}
+	return users, nil
+}
+
+func (m *Memory) GetAllPickupRequests(ctx context.Context) ([]types.PickupRequest, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	var requests []types.PickupRequest
+	for _, id := range sortedKeys(m.pickupRequests) {
+		requests = append(requests, clonePickupRequest(m.pickupRequests[id]))
+	}
+	return requests, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) BootstrapAdmin(ctx context.Context, admin types.User) (bool, error) {
+	if err := m.lock(ctx); err != nil {
+		return false, err
+	}
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Role == "Admin" {
+			return false, nil
+		}
+	}
+	if _, err := m.createAdmin(admin, "bootstrap", 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Memory) CreateAdminUser(ctx context.Context, admin types.User, createdBy int64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	return m.createAdmin(admin, "create", createdBy)
+}
+
+// createAdmin inserts an admin who has to change their password, with its audit record. Callers
+// hold m.mu.
+func (m *Memory) createAdmin(admin types.User, action string, actorUserID int64) (int64, error) {
+	admin.Role = "Admin"
+	admin.IsActive = true
+	admin.IsVerified = true
+	admin.IsFlagged = false
+	admin.MustChangePassword = true
+
+	id, err := m.insertUser(admin)
+	if err != nil {
+		return 0, err
+	}
+
+	m.adminAudit = append(m.adminAudit, types.AdminAuditRecord{
+		AuditID:     m.nextID("admin_audit_records"),
+		Action:      action,
+		AdminUserID: id,
+		ActorUserID: actorUserID,
+		CreatedAt:   types.DateTime{Time: time.Now()},
+	})
+	return id, nil
+}
+
+func (m *Memory) GetAdminAuditRecords(ctx context.Context) ([]types.AdminAuditRecord, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	return append(make([]types.AdminAuditRecord, 0, len(m.adminAudit)), m.adminAudit...), nil
+}
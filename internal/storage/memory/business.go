@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// GetBusinessByID retrieves a business by its ID.
+func (m *Memory) GetBusinessByID(ctx context.Context, id int64) (types.Business, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Business{}, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.businesses[id]; !ok {
+		return types.Business{}, fmt.Errorf("business not found")
+	}
+	return m.business(id), nil
+}
+
+// UpdateBusinessProfile updates the fields of update that are set.
+func (m *Memory) UpdateBusinessProfile(ctx context.Context, userID int64, update types.BusinessUpdate) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	business, ok := m.businesses[userID]
+	if !ok {
+		return 0, fmt.Errorf("business ID not found")
+	}
+
+	user := m.users[userID]
+	updateUser(&user, update.UserUpdate)
+
+	if update.Business_name != "" {
+		business.Business_name = update.Business_name
+	}
+	if update.Business_type != "" {
+		business.Business_type = update.Business_type
+	}
+	if update.Registration_number != "" {
+		business.Registration_number = update.Registration_number
+	}
+	if update.Gst_id != "" {
+		business.Gst_id = update.Gst_id
+	}
+	if update.Business_address != "" {
+		business.Business_address = update.Business_address
+	}
+	if update.Latitude != nil {
+		business.Latitude = clonePointer(update.Latitude)
+	}
+	if update.Longitude != nil {
+		business.Longitude = clonePointer(update.Longitude)
+	}
+
+	if err := m.checkUser(user, userID); err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+	if err := m.checkBusiness(business, userID); err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+
+	m.users[userID] = user
+	m.businesses[userID] = business
+	return userID, nil
+}
+
+func (m *Memory) CreatePickupRequest(ctx context.Context, request types.PickupRequest, actor types.Actor) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.businesses[request.BusinessID]; !ok {
+		return 0, fmt.Errorf("business ID not found: business not found")
+	}
+	if _, ok := m.collectors[request.CollectorID]; !ok {
+		return 0, fmt.Errorf("collector ID not found: collector not found")
+	}
+
+	request.OpenMarket = false
+	return m.createPickupRequest(request, actor)
+}
+
+// createPickupRequest stores a new pickup request with its first status and enqueues its event.
+// Callers hold m.mu.
+func (m *Memory) createPickupRequest(request types.PickupRequest, actor types.Actor) (int64, error) {
+	request.Status = string(lifecycle.Pending)
+	request.PricePerKg, request.WindowStart, request.WindowEnd = nil, nil, nil
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = types.DateTime{Time: time.Now()}
+	}
+
+	// The event is enqueued first, so that a failure stores nothing, like the rolled back
+	// transaction of the Postgres store. The ID is used up either way, as with a serial column.
+	request.RequestID = m.nextID("pickup_requests")
+	if err := m.enqueueOutboxEvent(events.PickupCreated, &actor, request); err != nil {
+		return 0, err
+	}
+
+	m.pickupRequests[request.RequestID] = clonePickupRequest(request)
+	m.recordStatusChange(request.RequestID, "", request.Status, actor, "")
+	return request.RequestID, nil
+}
+
+func (m *Memory) GetPickupRequestByID(ctx context.Context, id int64) (types.PickupRequest, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.PickupRequest{}, err
+	}
+	defer m.mu.Unlock()
+
+	request, ok := m.pickupRequests[id]
+	if !ok {
+		return types.PickupRequest{}, fmt.Errorf("pickup request %d: %w", id, storage.ErrNotFound)
+	}
+	return clonePickupRequest(request), nil
+}
+
+func (m *Memory) GetAllPickupRequestsForBusiness(ctx context.Context, businessID int64) ([]types.PickupRequest, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	var requests []types.PickupRequest
+	for _, id := range sortedKeys(m.pickupRequests) {
+		if m.pickupRequests[id].BusinessID == businessID {
+			requests = append(requests, clonePickupRequest(m.pickupRequests[id]))
+		}
+	}
+	return requests, nil
+}
+
+// UpdatePickupRequest updates the fields of input that are set, which only a Pending request
+// takes. A status change has to follow the pickup request lifecycle and is recorded in the status
+// history.
+func (m *Memory) UpdatePickupRequest(ctx context.Context, requestID int64, input types.UpdatePickupRequest, actor types.Actor) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	existing, ok := m.pickupRequests[requestID]
+	if !ok {
+		return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+	}
+
+	statusChanged := input.Status != "" && input.Status != existing.Status
+	if statusChanged {
+		if err := cancellation(existing, lifecycle.Status(input.Status)); err != nil {
+			return err
+		}
+	}
+
+	request := existing
+	updated := false
+	if input.WasteType != "" {
+		request.WasteType = input.WasteType
+		updated = true
+	}
+	if input.Quantity != 0 {
+		request.Quantity = input.Quantity
+		updated = true
+	}
+	if !input.PickupDate.IsZero() {
+		request.PickupDate = input.PickupDate
+		updated = true
+	}
+	if input.HandlingRequirements != "" {
+		request.HandlingRequirements = input.HandlingRequirements
+		updated = true
+	}
+	if input.PickupLatitude != nil {
+		request.PickupLatitude = input.PickupLatitude
+		updated = true
+	}
+	if input.PickupLongitude != nil {
+		request.PickupLongitude = input.PickupLongitude
+		updated = true
+	}
+	if updated {
+		if err := lifecycle.Edit(lifecycle.Status(existing.Status)); err != nil {
+			return err
+		}
+	}
+	if input.Status != "" {
+		request.Status = input.Status
+		updated = true
+	}
+	if !updated {
+		return fmt.Errorf("no rows affected")
+	}
+
+	if statusChanged {
+		if err := m.enqueueOutboxEvent(events.PickupCancelled, &actor, request); err != nil {
+			return err
+		}
+	}
+	m.pickupRequests[requestID] = clonePickupRequest(request)
+	if statusChanged {
+		m.recordStatusChange(requestID, existing.Status, input.Status, actor, input.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,628 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) GetCollectorByID(ctx context.Context, id int64) (types.Collector, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Collector{}, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[id]; !ok {
+		return types.Collector{}, fmt.Errorf("collector not found")
+	}
+	return m.collector(id), nil
+}
+
+func (m *Memory) GetCollectors(ctx context.Context) ([]types.Collector, error) {
+	return m.GetAllCollectors(ctx)
+}
+
+// UpdateCollectorProfile updates the fields of update that are set, like the GORM struct update
+// of the Postgres store, which skips zero values.
+func (m *Memory) UpdateCollectorProfile(ctx context.Context, userID int64, update types.CollectorUpdate) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	collector, ok := m.collectors[userID]
+	if !ok {
+		return 0, fmt.Errorf("collector ID not found")
+	}
+
+	user := m.users[userID]
+	updateUser(&user, update.UserUpdate)
+	user.IsActive = user.IsActive || update.IsActive
+	user.IsVerified = user.IsVerified || update.IsVerified
+	user.IsFlagged = user.IsFlagged || update.IsFlagged
+	if !update.LastLogin.IsZero() {
+		user.LastLogin = update.LastLogin
+	}
+
+	if update.Company_name != "" {
+		collector.Company_name = update.Company_name
+	}
+	if update.License_number != "" {
+		collector.License_number = update.License_number
+	}
+	if update.Capacity != 0 {
+		collector.Capacity = update.Capacity
+	}
+	if !update.License_expiry.IsZero() {
+		collector.License_expiry = update.License_expiry
+	}
+	if update.Latitude != nil {
+		collector.Latitude = clonePointer(update.Latitude)
+	}
+	if update.Longitude != nil {
+		collector.Longitude = clonePointer(update.Longitude)
+	}
+
+	if err := m.checkUser(user, userID); err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+	if err := m.checkCollector(collector, userID); err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+
+	m.users[userID] = user
+	m.collectors[userID] = collector
+	return userID, nil
+}
+
+// updateUser sets the profile fields of update that are not empty.
+func updateUser(user *types.User, update types.UserUpdate) {
+	if update.Email != "" {
+		user.Email = update.Email
+	}
+	if update.PasswordHash != "" {
+		user.PasswordHash = update.PasswordHash
+	}
+	if update.FullName != "" {
+		user.FullName = update.FullName
+	}
+	if update.PhoneNumber != "" {
+		user.PhoneNumber = update.PhoneNumber
+	}
+	if update.Address != "" {
+		user.Address = update.Address
+	}
+	if update.ProfileImage != "" {
+		user.ProfileImage = update.ProfileImage
+	}
+}
+
+func (m *Memory) AddCollectorServiceCategory(ctx context.Context, input types.CollectorServiceCategory, userID uint64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	collectorID := int64(userID)
+	if _, ok := m.collectors[collectorID]; !ok {
+		return 0, fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.categories[input.CategoryID]; !ok {
+		return 0, fmt.Errorf("service category not found")
+	}
+
+	key := pair{collectorID, input.CategoryID}
+	if _, ok := m.collectorCategories[key]; ok {
+		return 0, fmt.Errorf("create failed: duplicate key value violates primary key constraint")
+	}
+
+	input.CollectorID = collectorID
+	m.collectorCategories[key] = input
+	return input.CategoryID, nil
+}
+
+func (m *Memory) UpdateCollectorServiceCategory(ctx context.Context, input types.UpdateCollectorServiceCategory, userID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	collectorID := int64(userID)
+	if _, ok := m.collectors[collectorID]; !ok {
+		return fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.categories[input.CategoryID]; !ok {
+		return fmt.Errorf("service category not found")
+	}
+
+	key := pair{collectorID, input.CategoryID}
+	category, ok := m.collectorCategories[key]
+	if !ok || (input.PricePerKg == 0 && input.MaximumCapacity == 0 && input.HandlingRequirements == "") {
+		return fmt.Errorf("no rows affected")
+	}
+
+	if input.PricePerKg != 0 {
+		category.PricePerKg = input.PricePerKg
+	}
+	if input.MaximumCapacity != 0 {
+		category.MaximumCapacity = input.MaximumCapacity
+	}
+	if input.HandlingRequirements != "" {
+		category.HandlingRequirements = input.HandlingRequirements
+	}
+	m.collectorCategories[key] = category
+	return nil
+}
+
+func (m *Memory) DeleteCollectorServiceCategory(ctx context.Context, categoryID int64, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[int64(collectorID)]; !ok {
+		return fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.categories[categoryID]; !ok {
+		return fmt.Errorf("service category not found")
+	}
+
+	key := pair{int64(collectorID), categoryID}
+	if _, ok := m.collectorCategories[key]; !ok {
+		return fmt.Errorf("no rows affected")
+	}
+	delete(m.collectorCategories, key)
+	return nil
+}
+
+// AddCollectorVehicle adds a vehicle to a collector's fleet. Like the column default, a vehicle
+// added with is_active false starts out active.
+func (m *Memory) AddCollectorVehicle(ctx context.Context, input types.CollectorVehicle, userID uint64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	collectorID := int64(userID)
+	if _, ok := m.collectors[collectorID]; !ok {
+		return 0, fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.vehicles[input.VehicleID]; !ok {
+		return 0, fmt.Errorf("vehicle not found")
+	}
+
+	key := pair{collectorID, input.VehicleID}
+	if _, ok := m.collectorVehicles[key]; ok {
+		return 0, fmt.Errorf("create failed: duplicate key value violates primary key constraint")
+	}
+	if err := m.checkVehicleNumber(input.VehicleNumber, key); err != nil {
+		return 0, fmt.Errorf("create failed: %w", err)
+	}
+
+	input.CollectorID = collectorID
+	input.IsActive = true
+	m.collectorVehicles[key] = input
+	return input.VehicleID, nil
+}
+
+func (m *Memory) checkVehicleNumber(vehicleNumber string, key pair) error {
+	for existingKey, existing := range m.collectorVehicles {
+		if existingKey != key && existing.VehicleNumber == vehicleNumber {
+			return fmt.Errorf("duplicate key value violates unique constraint on vehicle_number")
+		}
+	}
+	return nil
+}
+
+func (m *Memory) UpdateCollectorVehicle(ctx context.Context, input types.UpdateCollectorVehicle, userID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	collectorID := int64(userID)
+	if _, ok := m.collectors[collectorID]; !ok {
+		return fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.vehicles[input.VehicleID]; !ok {
+		return fmt.Errorf("vehicle not found")
+	}
+
+	key := pair{collectorID, input.VehicleID}
+	vehicle, ok := m.collectorVehicles[key]
+	if !ok {
+		return fmt.Errorf("no rows affected")
+	}
+
+	updated := false
+	if input.VehicleNumber != "" {
+		vehicle.VehicleNumber = input.VehicleNumber
+		updated = true
+	}
+	if !input.MaintenanceDate.IsZero() {
+		vehicle.MaintenanceDate = input.MaintenanceDate
+		updated = true
+	}
+	if input.IsActive != nil {
+		vehicle.IsActive = *input.IsActive
+		updated = true
+	}
+	if input.GPSTrackingID != "" {
+		vehicle.GPSTrackingID = input.GPSTrackingID
+		updated = true
+	}
+	if input.RegistrationDocument != "" {
+		vehicle.RegistrationDocument = input.RegistrationDocument
+		updated = true
+	}
+	if !input.RegistrationExpiry.IsZero() {
+		vehicle.RegistrationExpiry = input.RegistrationExpiry
+		updated = true
+	}
+	if !updated {
+		return fmt.Errorf("no rows affected")
+	}
+
+	if err := m.checkVehicleNumber(vehicle.VehicleNumber, key); err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+	m.collectorVehicles[key] = vehicle
+	return nil
+}
+
+func (m *Memory) DeleteCollectorVehicle(ctx context.Context, vehicleID int64, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[int64(collectorID)]; !ok {
+		return fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.vehicles[vehicleID]; !ok {
+		return fmt.Errorf("vehicle not found")
+	}
+
+	key := pair{int64(collectorID), vehicleID}
+	if _, ok := m.collectorVehicles[key]; !ok {
+		return fmt.Errorf("no rows affected")
+	}
+	delete(m.collectorVehicles, key)
+	return nil
+}
+
+func (m *Memory) GetCollectorVehicle(ctx context.Context, collectorID int64, vehicleID int64) (types.CollectorVehicle, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.CollectorVehicle{}, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[collectorID]; !ok {
+		return types.CollectorVehicle{}, fmt.Errorf("collector ID not found")
+	}
+	if _, ok := m.vehicles[vehicleID]; !ok {
+		return types.CollectorVehicle{}, fmt.Errorf("vehicle not found")
+	}
+
+	vehicle, ok := m.collectorVehicles[pair{collectorID, vehicleID}]
+	if !ok {
+		return types.CollectorVehicle{}, fmt.Errorf("failed to fetch vehicle: record not found")
+	}
+	return vehicle, nil
+}
+
+func (m *Memory) GetCollectorServiceCategories(ctx context.Context, collectorID int64) ([]types.CollectorServiceCategory, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	categories := []types.CollectorServiceCategory{}
+	for _, key := range sortedPairs(m.collectorCategories) {
+		if key.a == collectorID {
+			categories = append(categories, m.collectorCategories[key])
+		}
+	}
+	return categories, nil
+}
+
+func (m *Memory) GetNearbyCollectors(ctx context.Context, query types.NearbyCollectorsQuery) ([]types.NearbyCollector, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	center := geo.Point{Lat: query.Latitude, Lng: query.Longitude}
+	collectors := []types.NearbyCollector{}
+	for _, id := range sortedKeys(m.collectors) {
+		collector, user := m.collectors[id], m.users[id]
+		if !user.IsActive || collector.Latitude == nil || collector.Longitude == nil {
+			continue
+		}
+		depot := geo.Point{Lat: *collector.Latitude, Lng: *collector.Longitude}
+		distance := geo.Distance(center, depot) / 1000
+		if distance > query.RadiusKm {
+			continue
+		}
+
+		var price, capacity *float64
+		for _, key := range sortedPairs(m.collectorCategories) {
+			offer := m.collectorCategories[key]
+			if key.a != id || (query.WasteType != "" && m.categories[key.b].WasteType != query.WasteType) {
+				continue
+			}
+			if price == nil || offer.PricePerKg < *price {
+				price = &offer.PricePerKg
+			}
+			if capacity == nil || offer.MaximumCapacity > *capacity {
+				capacity = &offer.MaximumCapacity
+			}
+		}
+		if query.WasteType != "" && price == nil {
+			continue
+		}
+
+		collectors = append(collectors, types.NearbyCollector{
+			CollectorID:     id,
+			CompanyName:     collector.Company_name,
+			Address:         user.Address,
+			Latitude:        depot.Lat,
+			Longitude:       depot.Lng,
+			IsVerified:      user.IsVerified,
+			DistanceKm:      distance,
+			PricePerKg:      price,
+			MaximumCapacity: capacity,
+		})
+	}
+	sort.SliceStable(collectors, func(i, j int) bool {
+		return collectors[i].DistanceKm < collectors[j].DistanceKm
+	})
+	return collectors, nil
+}
+
+func (m *Memory) GetMatchCandidates(ctx context.Context, query types.MatchCandidatesQuery) ([]types.MatchCandidate, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	candidates := []types.MatchCandidate{}
+	for _, key := range sortedPairs(m.collectorCategories) {
+		collector, user := m.collectors[key.a], m.users[key.a]
+		if m.categories[key.b].WasteType != query.WasteType || !user.IsActive {
+			continue
+		}
+		offer := m.collectorCategories[key]
+		candidate := types.MatchCandidate{
+			CollectorID:     key.a,
+			CompanyName:     collector.Company_name,
+			IsVerified:      user.IsVerified,
+			Latitude:        clonePointer(collector.Latitude),
+			Longitude:       clonePointer(collector.Longitude),
+			PricePerKg:      offer.PricePerKg,
+			MaximumCapacity: offer.MaximumCapacity,
+		}
+		for _, request := range m.pickupRequests {
+			if request.CollectorID != key.a {
+				continue
+			}
+			switch lifecycle.Status(request.Status) {
+			case lifecycle.Pending, lifecycle.Accepted, lifecycle.Assigned, lifecycle.InTransit:
+				if request.WasteType == query.WasteType && !request.PickupDate.Before(query.From) && request.PickupDate.Before(query.To) {
+					candidate.BookedQuantity += request.Quantity
+				}
+			case lifecycle.Completed:
+				candidate.CompletedPickups++
+				candidate.EndedPickups++
+			case lifecycle.Rejected, lifecycle.Cancelled:
+				candidate.EndedPickups++
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+func (m *Memory) GetCollectorVehicles(ctx context.Context, collectorID int64) ([]types.CollectorVehicle, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	vehicles := []types.CollectorVehicle{}
+	for _, key := range sortedPairs(m.collectorVehicles) {
+		if key.a == collectorID {
+			vehicles = append(vehicles, m.collectorVehicles[key])
+		}
+	}
+	return vehicles, nil
+}
+
+// collectorDriver returns a driver of the collector. Callers hold m.mu.
+func (m *Memory) collectorDriver(collectorID int64, driverID int64) (types.CollectorDriver, error) {
+	driver, ok := m.drivers[driverID]
+	if !ok || driver.CollectorID != collectorID {
+		return types.CollectorDriver{}, fmt.Errorf("driver not found: record not found")
+	}
+	return m.driver(driverID), nil
+}
+
+func (m *Memory) GetCollectorDriver(ctx context.Context, collectorID int64, driverID int64) (types.CollectorDriver, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.CollectorDriver{}, err
+	}
+	defer m.mu.Unlock()
+
+	return m.collectorDriver(collectorID, driverID)
+}
+
+func (m *Memory) GetCollectorDrivers(ctx context.Context, collectorID int64) ([]types.CollectorDriver, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	drivers := []types.CollectorDriver{}
+	for _, id := range sortedKeys(m.drivers) {
+		if m.drivers[id].CollectorID == collectorID {
+			drivers = append(drivers, m.driver(id))
+		}
+	}
+	return drivers, nil
+}
+
+// UpdateCollectorDriver updates existing driver details.
+func (m *Memory) UpdateCollectorDriver(ctx context.Context, input types.UpdateCollectorDriver, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[int64(collectorID)]; !ok {
+		return fmt.Errorf("collector not found")
+	}
+	if _, err := m.collectorDriver(int64(collectorID), input.DriverID); err != nil {
+		return fmt.Errorf("driver ID not found")
+	}
+
+	driver := m.drivers[input.DriverID]
+	updated := false
+	if input.LicenseNumber != "" {
+		driver.LicenseNumber = input.LicenseNumber
+		updated = true
+	}
+	if !input.LicenseExpiry.IsZero() {
+		driver.LicenseExpiry = input.LicenseExpiry
+		updated = true
+	}
+	if input.IsEmployed != nil {
+		driver.IsEmployed = *input.IsEmployed
+		updated = true
+	}
+	if input.IsActive != nil {
+		driver.IsActive = *input.IsActive
+		updated = true
+	}
+	if input.DriverName != "" {
+		driver.DriverName = input.DriverName
+		updated = true
+	}
+	if input.Rating != 0 {
+		driver.Rating = input.Rating
+		updated = true
+	}
+	if !input.JoiningDate.IsZero() {
+		driver.JoiningDate = input.JoiningDate
+		updated = true
+	}
+	if !updated {
+		return fmt.Errorf("no rows updated")
+	}
+
+	if err := m.checkDriver(driver.LicenseNumber, input.DriverID); err != nil {
+		return err
+	}
+	m.drivers[input.DriverID] = driver
+	return nil
+}
+
+// DeleteCollectorDriver removes a driver from a collector, and with it the driver's vehicle
+// assignment and locations. The driver's user is kept.
+func (m *Memory) DeleteCollectorDriver(ctx context.Context, driverID int64, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[int64(collectorID)]; !ok {
+		return fmt.Errorf("collector not found")
+	}
+	if _, err := m.collectorDriver(int64(collectorID), driverID); err != nil {
+		return fmt.Errorf("driver ID not found")
+	}
+
+	delete(m.drivers, driverID)
+	delete(m.vehicleDrivers, driverID)
+	for locationID, location := range m.driverLocations {
+		if location.DriverID == driverID {
+			delete(m.driverLocations, locationID)
+		}
+	}
+	return nil
+}
+
+// AssignVehicleToDriver assigns a vehicle to a driver. A driver has at most one vehicle.
+func (m *Memory) AssignVehicleToDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.checkVehicleDriver(driverID, vehicleID, int64(collectorID)); err != nil {
+		return err
+	}
+	if _, ok := m.vehicleDrivers[driverID]; ok {
+		return fmt.Errorf("duplicate key value violates primary key constraint on driver_id")
+	}
+
+	m.vehicleDrivers[driverID] = vehicleID
+	return nil
+}
+
+func (m *Memory) UnassignVehicleFromDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.checkVehicleDriver(driverID, vehicleID, int64(collectorID)); err != nil {
+		return err
+	}
+	if assigned, ok := m.vehicleDrivers[driverID]; !ok || assigned != vehicleID {
+		return fmt.Errorf("no rows deleted")
+	}
+
+	delete(m.vehicleDrivers, driverID)
+	return nil
+}
+
+// checkVehicleDriver checks that the collector, its driver and the vehicle exist.
+func (m *Memory) checkVehicleDriver(driverID int64, vehicleID int64, collectorID int64) error {
+	if _, ok := m.collectors[collectorID]; !ok {
+		return fmt.Errorf("collector ID not found")
+	}
+	if _, err := m.collectorDriver(collectorID, driverID); err != nil {
+		return fmt.Errorf("driver ID not found")
+	}
+	if _, ok := m.vehicles[vehicleID]; !ok {
+		return fmt.Errorf("vehicle ID not found")
+	}
+	return nil
+}
+
+func (m *Memory) AcceptPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error {
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.Accepted, actor, "", events.PickupAccepted, nil)
+}
+
+func (m *Memory) RejectPickupRequest(ctx context.Context, requestID int64, actor types.Actor, reason string) error {
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.Rejected, actor, reason, events.PickupRejected, nil)
+}
+
+func (m *Memory) AssignTripToDriver(ctx context.Context, requestID int64, driverID int64, actor types.Actor) error {
+	reason := fmt.Sprintf("driver %d assigned", driverID)
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.Assigned, actor, reason, events.DriverAssigned, func(request *types.PickupRequest) {
+		request.AssignedDriver = driverID
+	})
+}
+
+func (m *Memory) UnassignTripFromDriver(ctx context.Context, requestID int64, actor types.Actor) error {
+	// Unassigning the driver by setting to -1, the request goes back to Accepted
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.Accepted, actor, "driver unassigned", events.DriverUnassigned, func(request *types.PickupRequest) {
+		request.AssignedDriver = -1
+	})
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) CreateDeadLetter(ctx context.Context, deadLetter types.DeadLetter) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	deadLetter.DeadLetterID = m.nextID("dead_letters")
+	deadLetter.Attributes = cloneAttributes(deadLetter.Attributes)
+	deadLetter.Status = "pending"
+	deadLetter.CreatedAt = types.DateTime{Time: time.Now()}
+	deadLetter.ResolvedAt = nil
+	m.deadLetters[deadLetter.DeadLetterID] = deadLetter
+	return deadLetter.DeadLetterID, nil
+}
+
+// ListDeadLetters returns the dead letters with the given status, or all of them if status is
+// empty, newest first.
+func (m *Memory) ListDeadLetters(ctx context.Context, status string) ([]types.DeadLetter, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	ids := sortedKeys(m.deadLetters)
+	deadLetters := []types.DeadLetter{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		deadLetter := m.deadLetters[ids[i]]
+		if status == "" || deadLetter.Status == status {
+			deadLetters = append(deadLetters, cloneDeadLetter(deadLetter))
+		}
+	}
+	return deadLetters, nil
+}
+
+func (m *Memory) GetDeadLetter(ctx context.Context, deadLetterID int64) (types.DeadLetter, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.DeadLetter{}, err
+	}
+	defer m.mu.Unlock()
+
+	deadLetter, ok := m.deadLetters[deadLetterID]
+	if !ok {
+		return types.DeadLetter{}, fmt.Errorf("dead letter not found")
+	}
+	return cloneDeadLetter(deadLetter), nil
+}
+
+// ResolveDeadLetter moves a pending dead letter to status (replayed or discarded).
+func (m *Memory) ResolveDeadLetter(ctx context.Context, deadLetterID int64, status string) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	deadLetter, ok := m.deadLetters[deadLetterID]
+	if !ok || deadLetter.Status != "pending" {
+		return fmt.Errorf("pending dead letter with ID %d not found", deadLetterID)
+	}
+	if status != "replayed" && status != "discarded" {
+		return fmt.Errorf("failed to resolve dead letter: status %q violates check constraint", status)
+	}
+
+	deadLetter.Status = status
+	deadLetter.ResolvedAt = &types.DateTime{Time: time.Now()}
+	m.deadLetters[deadLetterID] = deadLetter
+	return nil
+}
+
+// cloneAttributes copies message attributes. Like the JSON column they are stored in, empty
+// attributes are read back as nil.
+func cloneAttributes(attributes map[string]string) map[string]string {
+	if len(attributes) == 0 {
+		return nil
+	}
+	return maps.Clone(attributes)
+}
+
+func cloneDeadLetter(deadLetter types.DeadLetter) types.DeadLetter {
+	deadLetter.Attributes = cloneAttributes(deadLetter.Attributes)
+	if deadLetter.ResolvedAt != nil {
+		resolvedAt := *deadLetter.ResolvedAt
+		deadLetter.ResolvedAt = &resolvedAt
+	}
+	return deadLetter
+}
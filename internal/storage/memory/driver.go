@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) StartDelivery(ctx context.Context, requestID int64, actor types.Actor) error {
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.InTransit, actor, "", events.DeliveryStarted, nil)
+}
+
+func (m *Memory) EndDelivery(ctx context.Context, requestID int64, actor types.Actor) error {
+	return m.transitionPickupRequest(ctx, requestID, lifecycle.Completed, actor, "", events.DeliveryCompleted, nil)
+}
+
+func (m *Memory) GetActiveTrip(ctx context.Context, driverID int64) (types.PickupRequest, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.PickupRequest{}, err
+	}
+	defer m.mu.Unlock()
+
+	var active *types.PickupRequest
+	for _, id := range sortedKeys(m.pickupRequests) {
+		request := m.pickupRequests[id]
+		if request.AssignedDriver != driverID {
+			continue
+		}
+		if request.Status == string(lifecycle.InTransit) {
+			return clonePickupRequest(request), nil
+		}
+		if request.Status == string(lifecycle.Assigned) && active == nil {
+			active = &request
+		}
+	}
+	if active == nil {
+		return types.PickupRequest{}, fmt.Errorf("active trip of driver %d: %w", driverID, storage.ErrNotFound)
+	}
+	return clonePickupRequest(*active), nil
+}
+
+func (m *Memory) StoreDriverLocation(ctx context.Context, location types.DriverLocation) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.drivers[location.DriverID]; !ok {
+		return 0, fmt.Errorf("failed to store driver location: driver %d violates foreign key constraint", location.DriverID)
+	}
+	if location.VehicleID == 0 {
+		location.VehicleID = m.vehicleDrivers[location.DriverID]
+	} else if _, ok := m.vehicles[location.VehicleID]; !ok {
+		return 0, fmt.Errorf("failed to store driver location: vehicle %d violates foreign key constraint", location.VehicleID)
+	}
+
+	location.Point = ""
+	if location.TripID != 0 {
+		if _, ok := m.pickupRequests[location.TripID]; !ok {
+			return 0, fmt.Errorf("failed to store driver location: trip %d not found", location.TripID)
+		}
+		if len(m.tripLocations(location.TripID)) == 0 {
+			location.Point = types.LocationStart
+		}
+	}
+
+	location.LocationID = m.nextID("driver_locations")
+	m.driverLocations[location.LocationID] = location
+	return location.LocationID, nil
+}
+
+func (m *Memory) MarkTripEnd(ctx context.Context, tripID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	var end *types.DriverLocation
+	for _, location := range m.tripLocations(tripID) {
+		if location.Point == types.LocationEnd {
+			location.Point = ""
+			m.driverLocations[location.LocationID] = location
+		}
+		if location.Point != types.LocationStart {
+			end = &location
+		}
+	}
+	if end != nil {
+		end.Point = types.LocationEnd
+		m.driverLocations[end.LocationID] = *end
+	}
+	return nil
+}
+
+func (m *Memory) GetTripLocations(ctx context.Context, tripID int64) ([]types.DriverLocation, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	return m.tripLocations(tripID), nil
+}
+
+// tripLocations returns the locations of a trip by time. Callers hold m.mu.
+func (m *Memory) tripLocations(tripID int64) []types.DriverLocation {
+	locations := []types.DriverLocation{}
+	for _, id := range sortedKeys(m.driverLocations) {
+		if location := m.driverLocations[id]; location.TripID == tripID {
+			locations = append(locations, location)
+		}
+	}
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].Timestamp.Before(locations[j].Timestamp.Time)
+	})
+	return locations
+}
+
+func (m *Memory) GetLatestTripLocations(ctx context.Context, tripID int64, limit int) ([]types.DriverLocation, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	locations := m.tripLocations(tripID)
+	if len(locations) > limit {
+		locations = locations[len(locations)-limit:]
+	}
+	return locations, nil
+}
+
+func (m *Memory) SavePickupETA(ctx context.Context, eta types.PickupETA) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.pickupRequests[eta.RequestID]; !ok {
+		return fmt.Errorf("failed to save the ETA of pickup request %d: violates foreign key constraint", eta.RequestID)
+	}
+	eta.RemainingSeconds = eta.ArrivalAt.Sub(eta.LocatedAt.Time).Seconds()
+	m.etas[eta.RequestID] = eta
+	return nil
+}
+
+func (m *Memory) GetPickupETA(ctx context.Context, requestID int64) (types.PickupETA, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.PickupETA{}, err
+	}
+	defer m.mu.Unlock()
+
+	eta, ok := m.etas[requestID]
+	if !ok {
+		return types.PickupETA{}, fmt.Errorf("ETA of pickup request %d: %w", requestID, storage.ErrNotFound)
+	}
+	return eta, nil
+}
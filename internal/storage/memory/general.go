@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) GetAllServiceCategories(ctx context.Context) ([]types.ServiceCategory, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	categories := make([]types.ServiceCategory, 0, len(m.categories))
+	for _, id := range sortedKeys(m.categories) {
+		categories = append(categories, m.categories[id])
+	}
+	return categories, nil
+}
+
+func (m *Memory) GetServiceCategory(ctx context.Context, categoryID uint64) (types.ServiceCategory, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.ServiceCategory{}, err
+	}
+	defer m.mu.Unlock()
+
+	category, ok := m.categories[int64(categoryID)]
+	if !ok {
+		return types.ServiceCategory{}, fmt.Errorf("failed to fetch service category: record not found")
+	}
+	return category, nil
+}
+
+func (m *Memory) GetAllVehicles(ctx context.Context) ([]types.Vehicle, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	vehicles := make([]types.Vehicle, 0, len(m.vehicles))
+	for _, id := range sortedKeys(m.vehicles) {
+		vehicles = append(vehicles, m.vehicles[id])
+	}
+	return vehicles, nil
+}
+
+func (m *Memory) GetVehicle(ctx context.Context, vehicleID uint64) (types.Vehicle, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Vehicle{}, err
+	}
+	defer m.mu.Unlock()
+
+	vehicle, ok := m.vehicles[int64(vehicleID)]
+	if !ok {
+		return types.Vehicle{}, fmt.Errorf("failed to fetch vehicle: record not found")
+	}
+	return vehicle, nil
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, userID uint64) (types.User, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.User{}, err
+	}
+	defer m.mu.Unlock()
+
+	user, ok := m.users[int64(userID)]
+	if !ok {
+		return types.User{}, fmt.Errorf("failed to fetch user: record not found")
+	}
+	return cloneUser(user), nil
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (types.User, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.User{}, err
+	}
+	defer m.mu.Unlock()
+
+	for _, id := range sortedKeys(m.users) {
+		if m.users[id].Email == email {
+			return cloneUser(m.users[id]), nil
+		}
+	}
+	return types.User{}, fmt.Errorf("user not found")
+}
+
+func (m *Memory) GetNotificationPreferences(ctx context.Context, userID int64) (types.NotificationPreferences, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.NotificationPreferences{}, err
+	}
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return types.NotificationPreferences{}, fmt.Errorf("user not found")
+	}
+	return types.NotificationPreferences{
+		Channels: slices.Clone(user.NotificationChannels),
+		Locale:   user.Locale,
+	}, nil
+}
+
+func (m *Memory) SetNotificationPreferences(ctx context.Context, userID int64, preferences types.NotificationPreferences) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	user.NotificationChannels = slices.Clone(preferences.Channels)
+	if len(user.NotificationChannels) == 0 {
+		user.NotificationChannels = nil // Stored as an empty column, read back as the defaults
+	}
+	user.Locale = preferences.Locale
+	m.users[userID] = user
+	return nil
+}
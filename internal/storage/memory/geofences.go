@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) CreateSite(ctx context.Context, site types.Site) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[site.OwnerID]; !ok {
+		return 0, fmt.Errorf("failed to create site: violates foreign key constraint")
+	}
+	if site.Kind != types.SitePickup && site.Kind != types.SiteDropOff {
+		return 0, fmt.Errorf("failed to create site: violates check constraint chk_sites_kind")
+	}
+
+	now := types.DateTime{Time: time.Now()}
+	site.SiteID = m.nextID("sites")
+	site.RadiusMeters = clonePointer(site.RadiusMeters)
+	site.DwellSeconds = clonePointer(site.DwellSeconds)
+	site.CreatedAt = now
+	site.UpdatedAt = now
+	m.sites[site.SiteID] = site
+	return site.SiteID, nil
+}
+
+func (m *Memory) GetSite(ctx context.Context, siteID int64) (types.Site, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Site{}, err
+	}
+	defer m.mu.Unlock()
+
+	site, ok := m.sites[siteID]
+	if !ok {
+		return types.Site{}, fmt.Errorf("site %d: %w", siteID, storage.ErrNotFound)
+	}
+	return cloneSite(site), nil
+}
+
+func (m *Memory) ListSites(ctx context.Context, ownerID int64) ([]types.Site, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	sites := []types.Site{}
+	for _, id := range sortedKeys(m.sites) {
+		if site := m.sites[id]; site.OwnerID == ownerID {
+			sites = append(sites, cloneSite(site))
+		}
+	}
+	return sites, nil
+}
+
+func (m *Memory) UpdateSite(ctx context.Context, site types.Site) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	stored, ok := m.sites[site.SiteID]
+	if !ok {
+		return fmt.Errorf("site %d: %w", site.SiteID, storage.ErrNotFound)
+	}
+	stored.Name = site.Name
+	stored.Address = site.Address
+	stored.Latitude = site.Latitude
+	stored.Longitude = site.Longitude
+	stored.RadiusMeters = clonePointer(site.RadiusMeters)
+	stored.DwellSeconds = clonePointer(site.DwellSeconds)
+	stored.AutoAdvance = site.AutoAdvance
+	stored.UpdatedAt = types.DateTime{Time: time.Now()}
+	m.sites[site.SiteID] = stored
+	return nil
+}
+
+// DeleteSite deletes a site and the visits of trips to it, like the foreign key.
+func (m *Memory) DeleteSite(ctx context.Context, siteID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.sites[siteID]; !ok {
+		return fmt.Errorf("site %d: %w", siteID, storage.ErrNotFound)
+	}
+	delete(m.sites, siteID)
+	for key := range m.siteVisits {
+		if key.b == siteID {
+			delete(m.siteVisits, key)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetSiteVisits(ctx context.Context, tripID int64) ([]types.SiteVisit, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	visits := []types.SiteVisit{}
+	for _, key := range sortedPairs(m.siteVisits) {
+		if key.a == tripID {
+			visits = append(visits, cloneSiteVisit(m.siteVisits[key]))
+		}
+	}
+	return visits, nil
+}
+
+// SaveSiteVisit stores a visit if it is still at the version it was read at, and enqueues event,
+// if not nil, with it. A new visit has version 0 and conflicts with any stored one.
+func (m *Memory) SaveSiteVisit(ctx context.Context, visit types.SiteVisit, event *types.GeofenceEvent) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	_, tripExists := m.pickupRequests[visit.TripID]
+	_, siteExists := m.sites[visit.SiteID]
+	if !tripExists || !siteExists {
+		return fmt.Errorf("failed to save site visit: violates foreign key constraint")
+	}
+
+	key := pair{visit.TripID, visit.SiteID}
+	if stored, ok := m.siteVisits[key]; (ok && stored.Version != visit.Version) || (!ok && visit.Version != 0) {
+		return fmt.Errorf("visit of trip %d to site %d: %w", visit.TripID, visit.SiteID, storage.ErrConflict)
+	}
+
+	if event != nil {
+		actor := types.Actor{UserID: event.DriverID, Role: "Driver"}
+		if err := m.enqueueOutboxEvent(events.Type(event.Event), &actor, *event); err != nil {
+			return err
+		}
+	}
+	visit.Version++
+	m.siteVisits[key] = cloneSiteVisit(visit)
+	return nil
+}
+
+func cloneSite(site types.Site) types.Site {
+	site.RadiusMeters = clonePointer(site.RadiusMeters)
+	site.DwellSeconds = clonePointer(site.DwellSeconds)
+	return site
+}
+
+func cloneSiteVisit(visit types.SiteVisit) types.SiteVisit {
+	visit.EnteredAt = clonePointer(visit.EnteredAt)
+	visit.ArrivedAt = clonePointer(visit.ArrivedAt)
+	visit.ExitedAt = clonePointer(visit.ExitedAt)
+	return visit
+}
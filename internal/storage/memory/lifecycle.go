@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// transitionPickupRequest moves a pickup request to the given status, records the change in the
+// status history and enqueues an event of the given type with the updated request, all under
+// the lock so that concurrent transitions are serialised. mutate, if non-nil, can set other
+// fields before saving.
+func (m *Memory) transitionPickupRequest(ctx context.Context, requestID int64, to lifecycle.Status, actor types.Actor, reason string, eventType events.Type, mutate func(*types.PickupRequest)) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	request, ok := m.pickupRequests[requestID]
+	if !ok {
+		return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+	}
+
+	if err := transition(request, to); err != nil {
+		return err
+	}
+
+	from := request.Status
+	request.Status = string(to)
+	if mutate != nil {
+		mutate(&request)
+	}
+
+	if err := m.enqueueOutboxEvent(eventType, &actor, request); err != nil {
+		return err
+	}
+	m.pickupRequests[requestID] = request
+	m.recordStatusChange(requestID, from, string(to), actor, reason)
+	return nil
+}
+
+// transition validates a status change of request, which open-market requests only go through
+// once a collector wins them.
+func transition(request types.PickupRequest, to lifecycle.Status) error {
+	if request.OpenMarket && request.CollectorID == 0 {
+		return lifecycle.TransitionOpen(lifecycle.Status(request.Status), to)
+	}
+	return lifecycle.Transition(lifecycle.Status(request.Status), to)
+}
+
+// cancellation validates a status change made by updating request, which can only cancel it.
+func cancellation(request types.PickupRequest, to lifecycle.Status) error {
+	if to != lifecycle.Cancelled {
+		return &lifecycle.TransitionError{From: lifecycle.Status(request.Status), To: to}
+	}
+	return transition(request, to)
+}
+
+// recordStatusChange appends an entry to the status history of a pickup request. Callers hold
+// m.mu.
+func (m *Memory) recordStatusChange(requestID int64, from string, to string, actor types.Actor, reason string) {
+	m.statusHistory = append(m.statusHistory, types.PickupRequestStatusChange{
+		HistoryID:   m.nextID("pickup_request_status_histories"),
+		RequestID:   requestID,
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		OldStatus:   from,
+		NewStatus:   to,
+		Reason:      reason,
+		ChangedAt:   types.DateTime{Time: time.Now()},
+	})
+}
+
+// GetPickupRequestTimeline returns the status history of a pickup request, oldest first. It is
+// kept in the order it was recorded in, so it needs no sorting.
+func (m *Memory) GetPickupRequestTimeline(ctx context.Context, requestID int64) ([]types.PickupRequestStatusChange, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	timeline := []types.PickupRequestStatusChange{}
+	for _, entry := range m.statusHistory {
+		if entry.RequestID == requestID {
+			timeline = append(timeline, entry)
+		}
+	}
+	return timeline, nil
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) CreateOpenPickupRequest(ctx context.Context, request types.PickupRequest, invitations []types.MarketInvitation, actor types.Actor) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.businesses[request.BusinessID]; !ok {
+		return 0, fmt.Errorf("business ID not found: business not found")
+	}
+	invited := make(map[int64]bool, len(invitations))
+	for _, invitation := range invitations {
+		if _, ok := m.collectors[invitation.CollectorID]; !ok {
+			return 0, fmt.Errorf("failed to invite collectors: violates foreign key constraint fk_collectors_market_invitations")
+		}
+		if invited[invitation.CollectorID] {
+			return 0, fmt.Errorf("failed to invite collectors: duplicate key value violates unique constraint market_invitations_pkey")
+		}
+		invited[invitation.CollectorID] = true
+	}
+
+	request.CollectorID = 0
+	request.OpenMarket = true
+	requestID, err := m.createPickupRequest(request, actor)
+	if err != nil {
+		return 0, err
+	}
+	createdAt := m.pickupRequests[requestID].CreatedAt
+	for _, invitation := range invitations {
+		invitation.RequestID = requestID
+		invitation.CreatedAt = createdAt
+		m.marketInvitations[pair{requestID, invitation.CollectorID}] = invitation
+	}
+	return requestID, nil
+}
+
+func (m *Memory) ListMarketInvitations(ctx context.Context, requestID int64) ([]types.MarketInvitation, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	invitations := []types.MarketInvitation{}
+	for _, key := range sortedPairs(m.marketInvitations) {
+		if key.a == requestID {
+			invitations = append(invitations, m.marketInvitations[key])
+		}
+	}
+	return invitations, nil
+}
+
+func (m *Memory) ListOpenPickupRequests(ctx context.Context, collectorID int64) ([]types.OpenPickupRequest, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	requests := []types.OpenPickupRequest{}
+	for _, key := range sortedPairs(m.marketInvitations) {
+		request := m.pickupRequests[key.a]
+		if key.b != collectorID || m.openPickupRequest(key.a) != nil {
+			continue
+		}
+		open := types.OpenPickupRequest{
+			PickupRequest:  clonePickupRequest(request),
+			ListPricePerKg: m.marketInvitations[key].PricePerKg,
+		}
+		for _, bid := range m.pickupBids {
+			if bid.RequestID == key.a && bid.CollectorID == collectorID {
+				open.Bid = &bid
+			}
+		}
+		requests = append(requests, open)
+	}
+	return requests, nil
+}
+
+func (m *Memory) PlaceBid(ctx context.Context, bid types.PickupBid) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.openPickupRequest(bid.RequestID); err != nil {
+		return 0, err
+	}
+	if _, err := m.marketInvitation(bid.RequestID, bid.CollectorID); err != nil {
+		return 0, err
+	}
+	if !bid.WindowEnd.After(bid.WindowStart.Time) {
+		return 0, fmt.Errorf("failed to place bid: violates check constraint chk_pickup_bids_window")
+	}
+
+	now := types.DateTime{Time: time.Now()}
+	bid.BidID, bid.CreatedAt = 0, now
+	for _, existing := range m.pickupBids {
+		if existing.RequestID == bid.RequestID && existing.CollectorID == bid.CollectorID {
+			bid.BidID, bid.CreatedAt = existing.BidID, existing.CreatedAt
+		}
+	}
+	if bid.BidID == 0 {
+		bid.BidID = m.nextID("pickup_bids")
+	}
+	bid.UpdatedAt = now
+	m.pickupBids[bid.BidID] = bid
+	return bid.BidID, nil
+}
+
+func (m *Memory) ListPickupBids(ctx context.Context, requestID int64) ([]types.PickupBid, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	bids := []types.PickupBid{}
+	for _, bidID := range sortedKeys(m.pickupBids) {
+		if m.pickupBids[bidID].RequestID == requestID {
+			bids = append(bids, m.pickupBids[bidID])
+		}
+	}
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].PricePerKg < bids[j].PricePerKg
+	})
+	return bids, nil
+}
+
+func (m *Memory) AcceptOpenPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.openPickupRequest(requestID); err != nil {
+		return err
+	}
+	invitation, err := m.marketInvitation(requestID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	return m.awardPickupRequest(requestID, actor.UserID, invitation.PricePerKg, nil, nil, actor, "accepted at the list price")
+}
+
+func (m *Memory) AcceptPickupBid(ctx context.Context, requestID int64, bidID int64, actor types.Actor) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.openPickupRequest(requestID); err != nil {
+		return err
+	}
+	bid, ok := m.pickupBids[bidID]
+	if !ok || bid.RequestID != requestID {
+		return fmt.Errorf("bid %d on pickup request %d: %w", bidID, requestID, storage.ErrNotFound)
+	}
+	reason := fmt.Sprintf("bid %d accepted", bidID)
+	return m.awardPickupRequest(requestID, bid.CollectorID, bid.PricePerKg, &bid.WindowStart, &bid.WindowEnd, actor, reason)
+}
+
+// openPickupRequest fails unless a collector can still win a pickup request. Callers hold m.mu.
+func (m *Memory) openPickupRequest(requestID int64) error {
+	request, ok := m.pickupRequests[requestID]
+	if !ok {
+		return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+	}
+	if !request.OpenMarket || request.CollectorID != 0 || request.Status != string(lifecycle.Pending) {
+		return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotOpen)
+	}
+	return nil
+}
+
+// marketInvitation returns the invitation of a collector to a request. Callers hold m.mu.
+func (m *Memory) marketInvitation(requestID int64, collectorID int64) (types.MarketInvitation, error) {
+	invitation, ok := m.marketInvitations[pair{requestID, collectorID}]
+	if !ok {
+		return invitation, fmt.Errorf("invitation of collector %d to pickup request %d: %w", collectorID, requestID, storage.ErrNotFound)
+	}
+	return invitation, nil
+}
+
+// awardPickupRequest accepts an open-market request for a collector, at the agreed price and
+// window. Callers hold m.mu and checked that the request is open.
+func (m *Memory) awardPickupRequest(requestID int64, collectorID int64, price float64, windowStart *types.DateTime, windowEnd *types.DateTime, actor types.Actor, reason string) error {
+	request := clonePickupRequest(m.pickupRequests[requestID])
+	if err := lifecycle.Transition(lifecycle.Status(request.Status), lifecycle.Accepted); err != nil {
+		return err
+	}
+
+	from := request.Status
+	request.Status = string(lifecycle.Accepted)
+	request.CollectorID = collectorID
+	request.PricePerKg = &price
+	request.WindowStart, request.WindowEnd = clonePointer(windowStart), clonePointer(windowEnd)
+	if err := m.enqueueOutboxEvent(events.PickupAccepted, &actor, request); err != nil {
+		return err
+	}
+	m.pickupRequests[requestID] = request
+	m.recordStatusChange(requestID, from, request.Status, actor, reason)
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package memory is a storage.Storage kept in process memory, for tests and local runs without
+// a database. It enforces the uniqueness, foreign key and check constraints of the GORM models,
+// including their cascading deletes, and every method is safe for concurrent use.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// pair is the key of the tables with a composite primary key.
+type pair struct {
+	a, b int64
+}
+
+type outboxRow struct {
+	event         types.OutboxEvent
+	status        string
+	nextAttemptAt time.Time
+	lastError     string
+	deliveredAt   *time.Time
+}
+
+type processedKey struct {
+	subscriptionID, eventID string
+}
+
+type templateKey struct {
+	name, locale string
+}
+
+type Memory struct {
+	mu sync.Mutex
+
+	users               map[int64]types.User
+	businesses          map[int64]types.Business        // User fields are taken from users
+	collectors          map[int64]types.Collector       // User fields are taken from users
+	drivers             map[int64]types.CollectorDriver // User fields are taken from users
+	categories          map[int64]types.ServiceCategory
+	vehicles            map[int64]types.Vehicle
+	collectorCategories map[pair]types.CollectorServiceCategory // (collector, category)
+	collectorVehicles   map[pair]types.CollectorVehicle         // (collector, vehicle)
+	vehicleDrivers      map[int64]int64                         // driver -> vehicle
+
+	pickupRequests    map[int64]types.PickupRequest
+	statusHistory     []types.PickupRequestStatusChange
+	driverLocations   map[int64]types.DriverLocation
+	etas              map[int64]types.PickupETA // By pickup request
+	sites             map[int64]types.Site
+	siteVisits        map[pair]types.SiteVisit // (trip, site)
+	serviceAreas      map[int64]types.ServiceArea
+	marketInvitations map[pair]types.MarketInvitation // (request, collector)
+	pickupBids        map[int64]types.PickupBid
+
+	outbox          map[int64]*outboxRow
+	deadLetters     map[int64]types.DeadLetter
+	processedEvents map[processedKey]time.Time // Expiry of each record
+	templates       map[templateKey]types.NotificationTemplate
+	notifications   map[int64]types.Notification
+	webhooks        map[int64]types.WebhookEndpoint
+	deliveries      map[int64]types.WebhookDelivery
+	adminAudit      []types.AdminAuditRecord
+
+	lastID map[string]int64 // Per table, like the serial columns
+}
+
+// New returns an empty store.
+func New() *Memory {
+	return &Memory{
+		users:               make(map[int64]types.User),
+		businesses:          make(map[int64]types.Business),
+		collectors:          make(map[int64]types.Collector),
+		drivers:             make(map[int64]types.CollectorDriver),
+		categories:          make(map[int64]types.ServiceCategory),
+		vehicles:            make(map[int64]types.Vehicle),
+		collectorCategories: make(map[pair]types.CollectorServiceCategory),
+		collectorVehicles:   make(map[pair]types.CollectorVehicle),
+		vehicleDrivers:      make(map[int64]int64),
+		pickupRequests:      make(map[int64]types.PickupRequest),
+		driverLocations:     make(map[int64]types.DriverLocation),
+		etas:                make(map[int64]types.PickupETA),
+		sites:               make(map[int64]types.Site),
+		siteVisits:          make(map[pair]types.SiteVisit),
+		serviceAreas:        make(map[int64]types.ServiceArea),
+		marketInvitations:   make(map[pair]types.MarketInvitation),
+		pickupBids:          make(map[int64]types.PickupBid),
+		outbox:              make(map[int64]*outboxRow),
+		deadLetters:         make(map[int64]types.DeadLetter),
+		processedEvents:     make(map[processedKey]time.Time),
+		templates:           make(map[templateKey]types.NotificationTemplate),
+		notifications:       make(map[int64]types.Notification),
+		webhooks:            make(map[int64]types.WebhookEndpoint),
+		deliveries:          make(map[int64]types.WebhookDelivery),
+		lastID:              make(map[string]int64),
+	}
+}
+
+// lock takes m.mu unless ctx is already done, the one point at which an in-memory call can be
+// cancelled.
+func (m *Memory) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	return nil
+}
+
+// nextID returns the next value of the serial primary key of table. Callers hold m.mu.
+func (m *Memory) nextID(table string) int64 {
+	m.lastID[table]++
+	return m.lastID[table]
+}
+
+// sortedKeys returns the keys of a table in ascending order, the order rows come back from
+// Postgres for small tables without an ORDER BY.
+func sortedKeys[V any](table map[int64]V) []int64 {
+	keys := make([]int64, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func sortedPairs[V any](table map[pair]V) []pair {
+	keys := make([]pair, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].a != keys[j].a {
+			return keys[i].a < keys[j].a
+		}
+		return keys[i].b < keys[j].b
+	})
+	return keys
+}
+
+// page returns the rows of a page, for the LIMIT and OFFSET of list queries.
+func page[T any](rows []T, limit int, offset int) []T {
+	if offset >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// cloneUser copies the slices of a user, so that callers cannot modify stored rows.
+func cloneUser(user types.User) types.User {
+	user.NotificationChannels = slices.Clone(user.NotificationChannels)
+	return user
+}
+
+// clonePickupRequest copies the coordinates of a pickup request, for the same reason.
+func clonePickupRequest(request types.PickupRequest) types.PickupRequest {
+	request.PickupLatitude = clonePointer(request.PickupLatitude)
+	request.PickupLongitude = clonePointer(request.PickupLongitude)
+	request.PricePerKg = clonePointer(request.PricePerKg)
+	request.WindowStart = clonePointer(request.WindowStart)
+	request.WindowEnd = clonePointer(request.WindowEnd)
+	return request
+}
+
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	return &clone
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) ListNotificationTemplates(ctx context.Context) ([]types.NotificationTemplate, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	templates := make([]types.NotificationTemplate, 0, len(m.templates))
+	for _, template := range m.templates {
+		templates = append(templates, cloneTemplate(template))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Locale < templates[j].Locale
+	})
+	return templates, nil
+}
+
+func (m *Memory) GetNotificationTemplate(ctx context.Context, name string, locale string) (types.NotificationTemplate, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.NotificationTemplate{}, err
+	}
+	defer m.mu.Unlock()
+
+	template, ok := m.templates[templateKey{name, locale}]
+	if !ok {
+		return types.NotificationTemplate{}, fmt.Errorf("notification template %s (%s): %w", name, locale, storage.ErrNotFound)
+	}
+	return cloneTemplate(template), nil
+}
+
+// SaveNotificationTemplate creates or replaces the override of a template in a locale.
+func (m *Memory) SaveNotificationTemplate(ctx context.Context, template types.NotificationTemplate) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	template.Source = "override"
+	template.UpdatedAt = &types.DateTime{Time: time.Now()}
+	m.templates[templateKey{template.Name, template.Locale}] = template
+	return nil
+}
+
+func (m *Memory) DeleteNotificationTemplate(ctx context.Context, name string, locale string) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	key := templateKey{name, locale}
+	if _, ok := m.templates[key]; !ok {
+		return fmt.Errorf("notification template %s (%s): %w", name, locale, storage.ErrNotFound)
+	}
+	delete(m.templates, key)
+	return nil
+}
+
+func cloneTemplate(template types.NotificationTemplate) types.NotificationTemplate {
+	updatedAt := *template.UpdatedAt
+	template.UpdatedAt = &updatedAt
+	return template
+}
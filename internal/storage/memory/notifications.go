@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// CreateNotification stores a notification, unless one was already recorded for the same user,
+// event and type. Notifications without an event are never duplicates.
+func (m *Memory) CreateNotification(ctx context.Context, notification types.Notification) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if notification.EventID != "" {
+		for _, existing := range m.notifications {
+			if existing.UserID == notification.UserID && existing.EventID == notification.EventID && existing.Type == notification.Type {
+				return nil
+			}
+		}
+	}
+
+	notification.NotificationID = m.nextID("notifications")
+	notification.IsRead = false
+	notification.CreatedAt = types.DateTime{Time: time.Now()}
+	notification.ReadAt = nil
+	m.notifications[notification.NotificationID] = notification
+	return nil
+}
+
+func (m *Memory) ListNotifications(ctx context.Context, userID int64, unreadOnly bool, limit int, offset int) ([]types.Notification, int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, 0, err
+	}
+	defer m.mu.Unlock()
+
+	var matching []types.Notification
+	for _, notification := range m.notifications {
+		if notification.UserID == userID && (!unreadOnly || !notification.IsRead) {
+			matching = append(matching, cloneNotification(notification))
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt.Time) {
+			return matching[i].CreatedAt.After(matching[j].CreatedAt.Time)
+		}
+		return matching[i].NotificationID > matching[j].NotificationID
+	})
+	return page(matching, limit, offset), int64(len(matching)), nil
+}
+
+func (m *Memory) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	var count int64
+	for _, notification := range m.notifications {
+		if notification.UserID == userID && !notification.IsRead {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications read. Marking a read notification
+// again keeps its original read time.
+func (m *Memory) MarkNotificationRead(ctx context.Context, userID int64, notificationID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	notification, ok := m.notifications[notificationID]
+	if !ok || notification.UserID != userID {
+		return fmt.Errorf("notification %d: %w", notificationID, storage.ErrNotFound)
+	}
+	if !notification.IsRead {
+		notification.IsRead = true
+		notification.ReadAt = &types.DateTime{Time: time.Now()}
+		m.notifications[notificationID] = notification
+	}
+	return nil
+}
+
+func (m *Memory) MarkAllNotificationsRead(ctx context.Context, userID int64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var marked int64
+	for id, notification := range m.notifications {
+		if notification.UserID == userID && !notification.IsRead {
+			notification.IsRead = true
+			notification.ReadAt = &types.DateTime{Time: now}
+			m.notifications[id] = notification
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func cloneNotification(notification types.Notification) types.Notification {
+	if notification.ReadAt != nil {
+		readAt := *notification.ReadAt
+		notification.ReadAt = &readAt
+	}
+	return notification
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// enqueueOutboxEvent wraps payload in an event envelope and stores it in the outbox. Callers hold
+// m.mu, so the event is stored together with the state change it describes.
+func (m *Memory) enqueueOutboxEvent(eventType events.Type, actor *types.Actor, payload any) error {
+	envelope, err := events.New(eventType, actor, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	now := time.Now()
+	id := m.nextID("outbox_events")
+	m.outbox[id] = &outboxRow{
+		event: types.OutboxEvent{
+			OutboxID:   id,
+			Topic:      eventType.Topic(),
+			Payload:    data,
+			Attributes: envelope.Attributes(),
+			CreatedAt:  types.DateTime{Time: now},
+		},
+		status:        "pending",
+		nextAttemptAt: now,
+	}
+	return nil
+}
+
+// ClaimOutboxEvents returns up to limit pending events that are due for publishing. The claimed
+// events are leased for the given duration so that later claims skip them meanwhile.
+func (m *Memory) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]types.OutboxEvent, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	claimed := []types.OutboxEvent{}
+	for _, id := range sortedKeys(m.outbox) {
+		if len(claimed) == limit {
+			break
+		}
+		row := m.outbox[id]
+		if row.status != "pending" || row.nextAttemptAt.After(now) {
+			continue
+		}
+
+		row.nextAttemptAt = now.Add(lease)
+		event := row.event
+		event.Payload = slices.Clone(event.Payload)
+		event.Attributes = maps.Clone(event.Attributes)
+		claimed = append(claimed, event)
+	}
+	return claimed, nil
+}
+
+func (m *Memory) MarkOutboxEventDelivered(ctx context.Context, outboxID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	row, ok := m.outbox[outboxID]
+	if !ok {
+		return fmt.Errorf("outbox event with ID %d not found", outboxID)
+	}
+	now := time.Now()
+	row.status = "delivered"
+	row.deliveredAt = &now
+	row.lastError = ""
+	return nil
+}
+
+func (m *Memory) MarkOutboxEventFailed(ctx context.Context, outboxID int64, lastError string, nextAttemptAt time.Time) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	row, ok := m.outbox[outboxID]
+	if !ok {
+		return fmt.Errorf("outbox event with ID %d not found", outboxID)
+	}
+	row.event.Attempts++
+	row.lastError = lastError
+	row.nextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (m *Memory) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, row := range m.outbox {
+		if row.status == "delivered" && row.deliveredAt.Before(before) {
+			delete(m.outbox, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+var roles = map[string]bool{
+	"Business":   true,
+	"Collector":  true,
+	"Admin":      true,
+	"Government": true,
+	"Driver":     true,
+}
+
+// insertUser adds a user, checking the constraints of the users table. Callers hold m.mu.
+func (m *Memory) insertUser(user types.User) (int64, error) {
+	if err := m.checkUser(user, 0); err != nil {
+		return 0, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	user.UserID = m.nextID("users")
+	m.users[user.UserID] = cloneUser(user)
+	return user.UserID, nil
+}
+
+// checkUser checks the constraints of the users table for a row to be written with the given
+// ID, 0 for a new row.
+func (m *Memory) checkUser(user types.User, userID int64) error {
+	if !roles[user.Role] {
+		return fmt.Errorf("role %q violates check constraint", user.Role)
+	}
+	for id, existing := range m.users {
+		if id != userID && existing.Email == user.Email {
+			return fmt.Errorf("duplicate key value violates unique constraint on email")
+		}
+	}
+	return nil
+}
+
+func (m *Memory) UpdateLastLogin(ctx context.Context, userID int64, lastLogin types.DateTime) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	// Like the UPDATE it mirrors, a missing user is not an error
+	if user, ok := m.users[userID]; ok {
+		user.LastLogin = lastLogin
+		m.users[userID] = user
+	}
+	return nil
+}
+
+func (m *Memory) ChangePassword(ctx context.Context, userID int64, passwordHash string) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return fmt.Errorf("user with ID %d not found", userID)
+	}
+	user.PasswordHash = passwordHash
+	user.MustChangePassword = false
+	m.users[userID] = user
+	return nil
+}
+
+func (m *Memory) CreateCollectorUser(ctx context.Context, user types.Collector) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.checkCollector(user, 0); err != nil {
+		return 0, fmt.Errorf("failed to insert collector: %w", err)
+	}
+	id, err := m.insertUser(user.User)
+	if err != nil {
+		return 0, err
+	}
+
+	user.User = types.User{}
+	user.Latitude, user.Longitude = clonePointer(user.Latitude), clonePointer(user.Longitude)
+	m.collectors[id] = user
+	return id, nil
+}
+
+func (m *Memory) checkCollector(collector types.Collector, userID int64) error {
+	for id, existing := range m.collectors {
+		if id != userID && existing.License_number == collector.License_number {
+			return fmt.Errorf("duplicate key value violates unique constraint on license_number")
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateBusinessUser(ctx context.Context, user types.Business) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.checkBusiness(user, 0); err != nil {
+		return 0, fmt.Errorf("failed to insert business: %w", err)
+	}
+	id, err := m.insertUser(user.User)
+	if err != nil {
+		return 0, err
+	}
+
+	user.User = types.User{}
+	user.Latitude, user.Longitude = clonePointer(user.Latitude), clonePointer(user.Longitude)
+	m.businesses[id] = user
+	return id, nil
+}
+
+func (m *Memory) checkBusiness(business types.Business, userID int64) error {
+	for id, existing := range m.businesses {
+		if id == userID {
+			continue
+		}
+		if existing.Registration_number == business.Registration_number {
+			return fmt.Errorf("duplicate key value violates unique constraint on registration_number")
+		}
+		if existing.Gst_id == business.Gst_id {
+			return fmt.Errorf("duplicate key value violates unique constraint on gst_id")
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetCollectorByEmail(ctx context.Context, email string) (types.Collector, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Collector{}, err
+	}
+	defer m.mu.Unlock()
+
+	for _, id := range sortedKeys(m.collectors) {
+		if m.users[id].Email == email {
+			return m.collector(id), nil
+		}
+	}
+	return types.Collector{}, fmt.Errorf("collector not found")
+}
+
+func (m *Memory) GetBusinessByEmail(ctx context.Context, email string) (types.Business, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.Business{}, err
+	}
+	defer m.mu.Unlock()
+
+	for _, id := range sortedKeys(m.businesses) {
+		if m.users[id].Email == email {
+			return m.business(id), nil
+		}
+	}
+	return types.Business{}, fmt.Errorf("business not found")
+}
+
+func (m *Memory) CreateCollectorDriver(ctx context.Context, driver types.CollectorDriver, collectorID int64) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.collectors[collectorID]; !ok {
+		return 0, fmt.Errorf("failed to create collector driver: collector %d violates foreign key constraint", collectorID)
+	}
+	if err := m.checkDriver(driver.LicenseNumber, 0); err != nil {
+		return 0, fmt.Errorf("failed to create collector driver: %w", err)
+	}
+	userID, err := m.insertUser(driver.User)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create driver user: %w", err)
+	}
+
+	driver.User = types.User{}
+	driver.CollectorID = collectorID
+	m.drivers[userID] = driver
+	return userID, nil
+}
+
+func (m *Memory) checkDriver(licenseNumber string, driverID int64) error {
+	for id, existing := range m.drivers {
+		if id != driverID && existing.LicenseNumber == licenseNumber {
+			return fmt.Errorf("duplicate key value violates unique constraint on license_number")
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetCollectorDriverByEmail(ctx context.Context, email string) (types.CollectorDriver, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.CollectorDriver{}, err
+	}
+	defer m.mu.Unlock()
+
+	for _, id := range sortedKeys(m.drivers) {
+		if m.users[id].Email == email {
+			return m.driver(id), nil
+		}
+	}
+	return types.CollectorDriver{}, fmt.Errorf("driver not found")
+}
+
+// collector, business and driver join a row with its user. Callers hold m.mu and have checked
+// that the row exists.
+func (m *Memory) collector(id int64) types.Collector {
+	collector := m.collectors[id]
+	collector.User = cloneUser(m.users[id])
+	collector.Latitude, collector.Longitude = clonePointer(collector.Latitude), clonePointer(collector.Longitude)
+	return collector
+}
+
+func (m *Memory) business(id int64) types.Business {
+	business := m.businesses[id]
+	business.User = cloneUser(m.users[id])
+	business.Latitude, business.Longitude = clonePointer(business.Latitude), clonePointer(business.Longitude)
+	return business
+}
+
+func (m *Memory) driver(id int64) types.CollectorDriver {
+	driver := m.drivers[id]
+	driver.User = cloneUser(m.users[id])
+	return driver
+}
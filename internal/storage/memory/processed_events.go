@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// IsEventProcessed reports whether the subscription handled the event and the record has not
+// expired yet.
+func (m *Memory) IsEventProcessed(ctx context.Context, subscriptionID string, eventID string) (bool, error) {
+	if err := m.lock(ctx); err != nil {
+		return false, err
+	}
+	defer m.mu.Unlock()
+
+	expiresAt, ok := m.processedEvents[processedKey{subscriptionID, eventID}]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+func (m *Memory) MarkEventProcessed(ctx context.Context, subscriptionID string, eventID string, ttl time.Duration) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	// An expired record of an earlier delivery is refreshed
+	m.processedEvents[processedKey{subscriptionID, eventID}] = time.Now().Add(ttl)
+	return nil
+}
+
+func (m *Memory) DeleteExpiredProcessedEvents(ctx context.Context) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for key, expiresAt := range m.processedEvents {
+		if !expiresAt.After(now) {
+			delete(m.processedEvents, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) CreateServiceArea(ctx context.Context, area types.ServiceArea) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	if err := m.checkServiceArea(area); err != nil {
+		return 0, fmt.Errorf("failed to create service area: %w", err)
+	}
+
+	now := types.DateTime{Time: time.Now()}
+	area = cloneServiceArea(area)
+	area.AreaID = m.nextID("service_areas")
+	area.CreatedAt = now
+	area.UpdatedAt = now
+	m.serviceAreas[area.AreaID] = area
+	return area.AreaID, nil
+}
+
+func (m *Memory) GetServiceArea(ctx context.Context, areaID int64) (types.ServiceArea, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.ServiceArea{}, err
+	}
+	defer m.mu.Unlock()
+
+	area, ok := m.serviceAreas[areaID]
+	if !ok {
+		return types.ServiceArea{}, fmt.Errorf("service area %d: %w", areaID, storage.ErrNotFound)
+	}
+	return cloneServiceArea(area), nil
+}
+
+func (m *Memory) ListServiceAreas(ctx context.Context, collectorID int64) ([]types.ServiceArea, error) {
+	return m.listServiceAreas(ctx, func(area types.ServiceArea) bool { return area.CollectorID == collectorID })
+}
+
+func (m *Memory) ListAllServiceAreas(ctx context.Context) ([]types.ServiceArea, error) {
+	return m.listServiceAreas(ctx, func(types.ServiceArea) bool { return true })
+}
+
+func (m *Memory) listServiceAreas(ctx context.Context, match func(types.ServiceArea) bool) ([]types.ServiceArea, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	areas := []types.ServiceArea{}
+	for _, id := range sortedKeys(m.serviceAreas) {
+		if area := m.serviceAreas[id]; match(area) {
+			areas = append(areas, cloneServiceArea(area))
+		}
+	}
+	return areas, nil
+}
+
+func (m *Memory) UpdateServiceArea(ctx context.Context, area types.ServiceArea) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	stored, ok := m.serviceAreas[area.AreaID]
+	if !ok {
+		return fmt.Errorf("service area %d: %w", area.AreaID, storage.ErrNotFound)
+	}
+	area.CollectorID = stored.CollectorID
+	if err := m.checkServiceArea(area); err != nil {
+		return fmt.Errorf("failed to update service area: %w", err)
+	}
+
+	area = cloneServiceArea(area)
+	area.CreatedAt = stored.CreatedAt
+	area.UpdatedAt = types.DateTime{Time: time.Now()}
+	m.serviceAreas[area.AreaID] = area
+	return nil
+}
+
+func (m *Memory) DeleteServiceArea(ctx context.Context, areaID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.serviceAreas[areaID]; !ok {
+		return fmt.Errorf("service area %d: %w", areaID, storage.ErrNotFound)
+	}
+	delete(m.serviceAreas, areaID)
+	return nil
+}
+
+// checkServiceArea enforces the foreign keys and the check constraint of service_areas.
+func (m *Memory) checkServiceArea(area types.ServiceArea) error {
+	if _, ok := m.collectors[area.CollectorID]; !ok {
+		return fmt.Errorf("violates foreign key constraint fk_collectors_service_areas")
+	}
+	if area.CategoryID != nil {
+		if _, ok := m.categories[*area.CategoryID]; !ok {
+			return fmt.Errorf("violates foreign key constraint fk_service_categories_service_areas")
+		}
+	}
+	if (len(area.Polygon) > 0) == (area.Pincodes != nil) {
+		return fmt.Errorf("violates check constraint chk_service_areas_shape")
+	}
+	return nil
+}
+
+func cloneServiceArea(area types.ServiceArea) types.ServiceArea {
+	area.CategoryID = clonePointer(area.CategoryID)
+	area.Pincodes = slices.Clone(area.Pincodes)
+	area.Polygon = slices.Clone(area.Polygon)
+	return area
+}
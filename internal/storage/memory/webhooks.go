@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func (m *Memory) CreateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	now := types.DateTime{Time: time.Now()}
+	endpoint.EndpointID = m.nextID("webhook_endpoints")
+	endpoint.EventTypes = cloneList(endpoint.EventTypes)
+	endpoint.CreatedAt = now
+	endpoint.UpdatedAt = now
+	m.webhooks[endpoint.EndpointID] = endpoint
+	return endpoint.EndpointID, nil
+}
+
+func (m *Memory) GetWebhookEndpoint(ctx context.Context, endpointID int64) (types.WebhookEndpoint, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.WebhookEndpoint{}, err
+	}
+	defer m.mu.Unlock()
+
+	endpoint, ok := m.webhooks[endpointID]
+	if !ok {
+		return types.WebhookEndpoint{}, fmt.Errorf("webhook endpoint %d: %w", endpointID, storage.ErrNotFound)
+	}
+	endpoint.EventTypes = cloneList(endpoint.EventTypes)
+	return endpoint, nil
+}
+
+func (m *Memory) ListWebhookEndpoints(ctx context.Context, userID int64) ([]types.WebhookEndpoint, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	endpoints := []types.WebhookEndpoint{}
+	for _, id := range sortedKeys(m.webhooks) {
+		endpoint := m.webhooks[id]
+		if endpoint.UserID == userID {
+			endpoint.EventTypes = cloneList(endpoint.EventTypes)
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// ListSubscribedWebhookEndpoints returns the active endpoints of the given users that subscribed
+// to eventType.
+func (m *Memory) ListSubscribedWebhookEndpoints(ctx context.Context, userIDs []int64, eventType string) ([]types.WebhookEndpoint, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	endpoints := []types.WebhookEndpoint{}
+	for _, id := range sortedKeys(m.webhooks) {
+		endpoint := m.webhooks[id]
+		if endpoint.IsActive && slices.Contains(userIDs, endpoint.UserID) && slices.Contains(endpoint.EventTypes, eventType) {
+			endpoint.EventTypes = cloneList(endpoint.EventTypes)
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (m *Memory) UpdateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	existing, ok := m.webhooks[endpoint.EndpointID]
+	if !ok {
+		return fmt.Errorf("webhook endpoint %d: %w", endpoint.EndpointID, storage.ErrNotFound)
+	}
+	existing.URL = endpoint.URL
+	existing.Description = endpoint.Description
+	existing.EventTypes = cloneList(endpoint.EventTypes)
+	existing.IsActive = endpoint.IsActive
+	existing.UpdatedAt = types.DateTime{Time: time.Now()}
+	m.webhooks[endpoint.EndpointID] = existing
+	return nil
+}
+
+func (m *Memory) DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	if _, ok := m.webhooks[endpointID]; !ok {
+		return fmt.Errorf("webhook endpoint %d: %w", endpointID, storage.ErrNotFound)
+	}
+	delete(m.webhooks, endpointID)
+	for id, delivery := range m.deliveries {
+		if delivery.EndpointID == endpointID {
+			delete(m.deliveries, id)
+		}
+	}
+	return nil
+}
+
+// CreateWebhookDelivery queues an event for an endpoint. It returns 0 without an error if the
+// event was already queued for it, e.g. when the event is redelivered.
+func (m *Memory) CreateWebhookDelivery(ctx context.Context, delivery types.WebhookDelivery) (int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer m.mu.Unlock()
+
+	for _, existing := range m.deliveries {
+		if existing.EndpointID == delivery.EndpointID && existing.EventID == delivery.EventID {
+			return 0, nil
+		}
+	}
+
+	now := types.DateTime{Time: time.Now()}
+	delivery = types.WebhookDelivery{
+		DeliveryID:    m.nextID("webhook_deliveries"),
+		EndpointID:    delivery.EndpointID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	m.deliveries[delivery.DeliveryID] = delivery
+	return delivery.DeliveryID, nil
+}
+
+func (m *Memory) GetWebhookDelivery(ctx context.Context, deliveryID int64) (types.WebhookDelivery, error) {
+	if err := m.lock(ctx); err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	defer m.mu.Unlock()
+
+	delivery, ok := m.deliveries[deliveryID]
+	if !ok {
+		return types.WebhookDelivery{}, fmt.Errorf("webhook delivery %d: %w", deliveryID, storage.ErrNotFound)
+	}
+	return cloneDelivery(delivery), nil
+}
+
+func (m *Memory) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int, offset int) ([]types.WebhookDelivery, int64, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, 0, err
+	}
+	defer m.mu.Unlock()
+
+	ids := sortedKeys(m.deliveries)
+	var matching []types.WebhookDelivery
+	for i := len(ids) - 1; i >= 0; i-- {
+		delivery := m.deliveries[ids[i]]
+		if delivery.EndpointID == endpointID {
+			matching = append(matching, cloneDelivery(delivery))
+		}
+	}
+	return page(matching, limit, offset), int64(len(matching)), nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due. The claimed
+// deliveries are leased for the given duration so that later claims skip them meanwhile.
+func (m *Memory) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error) {
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	claimed := []types.WebhookDelivery{}
+	for _, id := range sortedKeys(m.deliveries) {
+		if len(claimed) == limit {
+			break
+		}
+		delivery := m.deliveries[id]
+		if delivery.Status != "pending" || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+
+		// The claimed delivery is returned as it was before the lease, like the rows Postgres
+		// selects before updating them
+		claimed = append(claimed, cloneDelivery(delivery))
+		delivery.NextAttemptAt = types.DateTime{Time: now.Add(lease)}
+		m.deliveries[id] = delivery
+	}
+	return claimed, nil
+}
+
+// RecordWebhookAttempt records the outcome of a delivery attempt. status is pending if the
+// delivery is retried at nextAttemptAt.
+func (m *Memory) RecordWebhookAttempt(ctx context.Context, deliveryID int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error {
+	if err := m.lock(ctx); err != nil {
+		return err
+	}
+	defer m.mu.Unlock()
+
+	delivery, ok := m.deliveries[deliveryID]
+	if !ok {
+		return fmt.Errorf("webhook delivery %d: %w", deliveryID, storage.ErrNotFound)
+	}
+	if status != "pending" && status != "succeeded" && status != "failed" {
+		return fmt.Errorf("failed to record webhook attempt: status %q violates check constraint", status)
+	}
+
+	delivery.Status = status
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = lastError
+	delivery.NextAttemptAt = types.DateTime{Time: nextAttemptAt}
+	if status == "succeeded" {
+		delivery.DeliveredAt = &types.DateTime{Time: time.Now()}
+	}
+	m.deliveries[deliveryID] = delivery
+	return nil
+}
+
+// cloneList copies a list stored as a comma separated column, which reads back as nil if empty.
+func cloneList(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return slices.Clone(list)
+}
+
+func cloneDelivery(delivery types.WebhookDelivery) types.WebhookDelivery {
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		delivery.DeliveredAt = &deliveredAt
+	}
+	return delivery
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -9,13 +10,16 @@ import (
 )
 
 // VerifyUser sets the user's is_verified status to true
-func (p *Postgres) VerifyUser(userID string) error {
+func (p *Postgres) VerifyUser(ctx context.Context, userID string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	id, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	result, err := p.SqlDB.Exec("UPDATE users SET is_verified = true WHERE user_id = $1", id)
+	result, err := p.SqlDB.ExecContext(ctx, "UPDATE users SET is_verified = true WHERE user_id = $1", id)
 	if err != nil {
 		return fmt.Errorf("failed to verify user: %w", err)
 	}
@@ -33,13 +37,16 @@ func (p *Postgres) VerifyUser(userID string) error {
 }
 
 // UnverifyUser sets the user's is_verified status to false
-func (p *Postgres) UnverifyUser(userID string) error {
+func (p *Postgres) UnverifyUser(ctx context.Context, userID string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	id, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	result, err := p.SqlDB.Exec("UPDATE users SET is_verified = false WHERE user_id = $1", id)
+	result, err := p.SqlDB.ExecContext(ctx, "UPDATE users SET is_verified = false WHERE user_id = $1", id)
 	if err != nil {
 		return fmt.Errorf("failed to unverify user: %w", err)
 	}
@@ -57,13 +64,16 @@ func (p *Postgres) UnverifyUser(userID string) error {
 }
 
 // FlagUser sets is_flagged to true and is_active to false
-func (p *Postgres) FlagUser(userID string) error {
+func (p *Postgres) FlagUser(ctx context.Context, userID string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	id, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	result, err := p.SqlDB.Exec(
+	result, err := p.SqlDB.ExecContext(ctx,
 		"UPDATE users SET is_flagged = true, is_active = false WHERE user_id = $1",
 		id,
 	)
@@ -84,13 +94,16 @@ func (p *Postgres) FlagUser(userID string) error {
 }
 
 // UnflagUser sets is_flagged to false and is_active to true
-func (p *Postgres) UnflagUser(userID string) error {
+func (p *Postgres) UnflagUser(ctx context.Context, userID string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	id, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	result, err := p.SqlDB.Exec(
+	result, err := p.SqlDB.ExecContext(ctx,
 		"UPDATE users SET is_flagged = false, is_active = true WHERE user_id = $1",
 		id,
 	)
@@ -111,9 +124,12 @@ func (p *Postgres) UnflagUser(userID string) error {
 }
 
 // AddServiceCategory inserts a new waste service category and returns its ID.
-func (p *Postgres) AddServiceCategory(sc types.ServiceCategory) (int64, error) {
+func (p *Postgres) AddServiceCategory(ctx context.Context, sc types.ServiceCategory) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var id int64
-	err := p.SqlDB.QueryRow(
+	err := p.SqlDB.QueryRowContext(ctx,
 		`INSERT INTO service_categories (waste_type)
 		 VALUES ($1)
 		 RETURNING category_id`,
@@ -126,9 +142,12 @@ func (p *Postgres) AddServiceCategory(sc types.ServiceCategory) (int64, error) {
 }
 
 // AddVehicle inserts a new vehicle and returns its ID.
-func (p *Postgres) AddVehicle(v types.Vehicle) (int64, error) {
+func (p *Postgres) AddVehicle(ctx context.Context, v types.Vehicle) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var id int64
-	err := p.SqlDB.QueryRow(
+	err := p.SqlDB.QueryRowContext(ctx,
 		`INSERT INTO vehicles (vehicle_type, capacity)
 		 VALUES ($1, $2)
 		 RETURNING vehicle_id`,
@@ -140,9 +159,11 @@ func (p *Postgres) AddVehicle(v types.Vehicle) (int64, error) {
 	return id, nil
 }
 
-func (p *Postgres) DeleteServiceCategory(categoryID uint64) error {
+func (p *Postgres) DeleteServiceCategory(ctx context.Context, categoryID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 
-	result, err := p.SqlDB.Exec("DELETE FROM service_categories WHERE category_id = $1", categoryID)
+	result, err := p.SqlDB.ExecContext(ctx, "DELETE FROM service_categories WHERE category_id = $1", categoryID)
 	if err != nil {
 		return fmt.Errorf("failed to delete service category: %w", err)
 	}
@@ -159,9 +180,11 @@ func (p *Postgres) DeleteServiceCategory(categoryID uint64) error {
 	return nil
 }
 
-func (p *Postgres) DeleteVehicle(vehicleID uint64) error {
+func (p *Postgres) DeleteVehicle(ctx context.Context, vehicleID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 
-	result, err := p.SqlDB.Exec("DELETE FROM vehicles WHERE vehicle_id = $1", vehicleID)
+	result, err := p.SqlDB.ExecContext(ctx, "DELETE FROM vehicles WHERE vehicle_id = $1", vehicleID)
 	if err != nil {
 		return fmt.Errorf("failed to delete vehicle: %w", err)
 	}
@@ -178,13 +201,16 @@ func (p *Postgres) DeleteVehicle(vehicleID uint64) error {
 	return nil
 }
 
-func (p *Postgres) GetAllCollectors() ([]types.Collector, error) {
+func (p *Postgres) GetAllCollectors(ctx context.Context) ([]types.Collector, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	type collectorJoin struct {
 		models.Collector
 		models.User
 	}
 	var joins []collectorJoin
-	if err := p.GormDB.Table("collectors").
+	if err := p.GormDB.WithContext(ctx).Table("collectors").
 		Joins("INNER JOIN users ON users.user_id = collectors.user_id").
 		Scan(&joins).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch collectors: %w", err)
@@ -196,13 +222,16 @@ func (p *Postgres) GetAllCollectors() ([]types.Collector, error) {
 	return collectors, nil
 }
 
-func (p *Postgres) GetAllBusinesses() ([]types.Business, error) {
+func (p *Postgres) GetAllBusinesses(ctx context.Context) ([]types.Business, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	type businessJoin struct {
 		models.Business
 		models.User
 	}
 	var joins []businessJoin
-	if err := p.GormDB.Table("businesses").
+	if err := p.GormDB.WithContext(ctx).Table("businesses").
 		Joins("INNER JOIN users ON users.user_id = businesses.user_id").
 		Scan(&joins).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch businesses: %w", err)
@@ -214,9 +243,12 @@ func (p *Postgres) GetAllBusinesses() ([]types.Business, error) {
 	return businesses, nil
 }
 
-func (p *Postgres) GetAllUsers() ([]types.User, error) {
+func (p *Postgres) GetAllUsers(ctx context.Context) ([]types.User, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var userModels []models.User
-	if err := p.GormDB.Find(&userModels).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Find(&userModels).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch users: %w", err)
 	}
 
@@ -236,14 +268,19 @@ func (p *Postgres) GetAllUsers() ([]types.User, error) {
 			LastLogin:    types.DateTime{Time: u.LastLogin},
 			IsVerified:   u.IsVerified,
 			IsFlagged:    u.IsFlagged,
+
+			MustChangePassword: u.MustChangePassword,
 		})
 	}
 	return users, nil
 }
 
-func (p *Postgres) GetAllPickupRequests() ([]types.PickupRequest, error) {
+func (p *Postgres) GetAllPickupRequests(ctx context.Context) ([]types.PickupRequest, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var models []models.PickupRequest
-	err := p.GormDB.Find(&models).Error
+	err := p.GormDB.WithContext(ctx).Find(&models).Error
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
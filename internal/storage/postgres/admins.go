@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+)
+
+// bootstrapLockID is the key of the advisory lock BootstrapAdmin holds, so that replicas booting
+// at the same time create a single admin.
+const bootstrapLockID int64 = 7_301_944_219
+
+func (p *Postgres) BootstrapAdmin(ctx context.Context, admin types.User) (bool, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	created := false
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", bootstrapLockID).Error; err != nil {
+			return fmt.Errorf("failed to take the bootstrap lock: %w", err)
+		}
+
+		var admins int64
+		if err := tx.Model(&models.User{}).Where("role = ?", "Admin").Count(&admins).Error; err != nil {
+			return fmt.Errorf("failed to count admins: %w", err)
+		}
+		if admins > 0 {
+			return nil
+		}
+
+		if _, err := createAdmin(tx, admin, "bootstrap", nil); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (p *Postgres) CreateAdminUser(ctx context.Context, admin types.User, createdBy int64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var id int64
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		id, err = createAdmin(tx, admin, "create", &createdBy)
+		return err
+	})
+	return id, err
+}
+
+// createAdmin inserts an admin who has to change their password, with its audit record.
+func createAdmin(tx *gorm.DB, admin types.User, action string, actorUserID *int64) (int64, error) {
+	user := models.User{
+		Email:              admin.Email,
+		PasswordHash:       admin.PasswordHash,
+		FullName:           admin.FullName,
+		PhoneNumber:        admin.PhoneNumber,
+		Address:            admin.Address,
+		Registration:       admin.Registration.Time,
+		Role:               "Admin",
+		IsActive:           true,
+		ProfileImage:       admin.ProfileImage,
+		LastLogin:          admin.LastLogin.Time,
+		IsVerified:         true,
+		MustChangePassword: true,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, fmt.Errorf("failed to insert admin: %w", err)
+	}
+
+	record := models.AdminAuditRecord{Action: action, AdminUserID: user.UserID, ActorUserID: actorUserID}
+	if err := tx.Create(&record).Error; err != nil {
+		return 0, fmt.Errorf("failed to record admin creation: %w", err)
+	}
+	return user.UserID, nil
+}
+
+func (p *Postgres) GetAdminAuditRecords(ctx context.Context) ([]types.AdminAuditRecord, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var recordModels []models.AdminAuditRecord
+	if err := p.GormDB.WithContext(ctx).Order("audit_id").Find(&recordModels).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch admin audit records: %w", err)
+	}
+
+	records := make([]types.AdminAuditRecord, 0, len(recordModels))
+	for _, model := range recordModels {
+		records = append(records, convertAdminAuditRecordModelToType(model))
+	}
+	return records, nil
+}
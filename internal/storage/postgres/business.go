@@ -1,20 +1,28 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetBusinessByID retrieves a business by its ID.
-func (p *Postgres) GetBusinessByID(id int64) (types.Business, error) {
+func (p *Postgres) GetBusinessByID(ctx context.Context, id int64) (types.Business, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var businessModel models.Business
 	var userModel models.User
 
-	err := p.GormDB.Where("user_id = ?", id).First(&businessModel).Error
+	err := p.GormDB.WithContext(ctx).Where("user_id = ?", id).First(&businessModel).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return types.Business{}, fmt.Errorf("business not found")
@@ -22,7 +30,7 @@ func (p *Postgres) GetBusinessByID(id int64) (types.Business, error) {
 		return types.Business{}, fmt.Errorf("database error: %w", err)
 	}
 
-	err = p.GormDB.First(&userModel, "user_id = ?", businessModel.UserID).Error
+	err = p.GormDB.WithContext(ctx).First(&userModel, "user_id = ?", businessModel.UserID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return types.Business{}, fmt.Errorf("associated user not found")
@@ -34,14 +42,17 @@ func (p *Postgres) GetBusinessByID(id int64) (types.Business, error) {
 }
 
 // UpdateBusinessProfile updates a business's profile.
-func (p *Postgres) UpdateBusinessProfile(userID int64, update types.BusinessUpdate) (int64, error) {
+func (p *Postgres) UpdateBusinessProfile(ctx context.Context, userID int64, update types.BusinessUpdate) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	// Verify business exists
-	_, err := p.GetBusinessByID(userID)
+	_, err := p.GetBusinessByID(ctx, userID)
 	if err != nil {
 		return 0, fmt.Errorf("business ID not found")
 	}
 
-	err = p.GormDB.Transaction(func(tx *gorm.DB) error {
+	err = p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Update User fields
 		userUpdates := models.User{
 			Email:        update.Email,
@@ -62,6 +73,8 @@ func (p *Postgres) UpdateBusinessProfile(userID int64, update types.BusinessUpda
 			RegistrationNumber: update.Registration_number,
 			GstID:              update.Gst_id,
 			BusinessAddress:    update.Business_address,
+			Latitude:           update.Latitude,
+			Longitude:          update.Longitude,
 		}
 		if err := tx.Model(&models.Business{}).Where("user_id = ?", userID).Updates(businessUpdates).Error; err != nil {
 			return err
@@ -76,43 +89,70 @@ func (p *Postgres) UpdateBusinessProfile(userID int64, update types.BusinessUpda
 	return userID, nil
 }
 
-func (p *Postgres) CreatePickupRequest(request types.PickupRequest) (int64, error) {
-	_, err := p.GetBusinessByID(request.BusinessID)
+func (p *Postgres) CreatePickupRequest(ctx context.Context, request types.PickupRequest, actor types.Actor) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err := p.GetBusinessByID(ctx, request.BusinessID)
 	if err != nil {
 		return 0, fmt.Errorf("business ID not found: %w", err)
 	}
 
-	_, err = p.GetCollectorByID(request.CollectorID)
+	_, err = p.GetCollectorByID(ctx, request.CollectorID)
 	if err != nil {
 		return 0, fmt.Errorf("collector ID not found: %w", err)
 	}
 
-	model := models.PickupRequest{
+	model := newPickupRequestModel(request)
+	model.CollectorID = &request.CollectorID
+	err = p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createPickupRequest(tx, &model, actor)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return model.RequestID, nil
+}
+
+// newPickupRequestModel returns the row of a new pickup request, without its collector.
+func newPickupRequestModel(request types.PickupRequest) models.PickupRequest {
+	return models.PickupRequest{
 		BusinessID:           request.BusinessID,
-		CollectorID:          request.CollectorID,
 		WasteType:            request.WasteType,
 		Quantity:             request.Quantity,
 		PickupDate:           request.PickupDate.Time,
-		Status:               request.Status,
+		Status:               string(lifecycle.Pending),
 		HandlingRequirements: request.HandlingRequirements,
 		AssignedDriver:       request.AssignedDriver,
 		AssignedVehicle:      request.AssignedVehicle,
 		CreatedAt:            request.CreatedAt.Time,
+		PickupLatitude:       request.PickupLatitude,
+		PickupLongitude:      request.PickupLongitude,
 	}
+}
 
-	if err := p.GormDB.Create(&model).Error; err != nil {
-		return 0, fmt.Errorf("failed to create pickup request: %w", err)
+// createPickupRequest inserts a new pickup request with its first status and enqueues its
+// event.
+func createPickupRequest(tx *gorm.DB, model *models.PickupRequest, actor types.Actor) error {
+	if err := tx.Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create pickup request: %w", err)
 	}
-
-	return model.RequestID, nil
+	if err := recordStatusChange(tx, model.RequestID, "", model.Status, actor, ""); err != nil {
+		return err
+	}
+	return enqueueOutboxEvent(tx, events.PickupCreated, &actor, convertPickupRequestModelToType(*model))
 }
 
-func (p *Postgres) GetPickupRequestByID(id int64) (types.PickupRequest, error) {
+func (p *Postgres) GetPickupRequestByID(ctx context.Context, id int64) (types.PickupRequest, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var model models.PickupRequest
-	err := p.GormDB.First(&model, "request_id = ?", id).Error
+	err := p.GormDB.WithContext(ctx).First(&model, "request_id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return types.PickupRequest{}, fmt.Errorf("pickup request not found")
+			return types.PickupRequest{}, fmt.Errorf("pickup request %d: %w", id, storage.ErrNotFound)
 		}
 		return types.PickupRequest{}, fmt.Errorf("database error: %w", err)
 	}
@@ -120,9 +160,12 @@ func (p *Postgres) GetPickupRequestByID(id int64) (types.PickupRequest, error) {
 	return convertPickupRequestModelToType(model), nil
 }
 
-func (p *Postgres) GetAllPickupRequestsForBusiness(businessID int64) ([]types.PickupRequest, error) {
+func (p *Postgres) GetAllPickupRequestsForBusiness(ctx context.Context, businessID int64) ([]types.PickupRequest, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var models []models.PickupRequest
-	err := p.GormDB.Where("business_id = ?", businessID).Find(&models).Error
+	err := p.GormDB.WithContext(ctx).Where("business_id = ?", businessID).Find(&models).Error
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
@@ -135,37 +178,68 @@ func (p *Postgres) GetAllPickupRequestsForBusiness(businessID int64) ([]types.Pi
 	return requests, nil
 }
 
-func (p *Postgres) UpdatePickupRequest(requestID int64, input types.UpdatePickupRequest) error {
-	// Check if the pickup request exists
-	var existing models.PickupRequest
-	err := p.GormDB.First(&existing, "request_id = ?", requestID).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("pickup request not found")
+func (p *Postgres) UpdatePickupRequest(ctx context.Context, requestID int64, input types.UpdatePickupRequest, actor types.Actor) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Check if the pickup request exists, locking it against concurrent transitions
+		var existing models.PickupRequest
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, "request_id = ?", requestID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+			}
+			return fmt.Errorf("database error: %w", err)
 		}
-		return fmt.Errorf("database error: %w", err)
-	}
 
-	updates := models.PickupRequest{
-		WasteType:            input.WasteType,
-		Quantity:             input.Quantity,
-		PickupDate:           input.PickupDate.Time,
-		Status:               input.Status,
-		HandlingRequirements: input.HandlingRequirements,
-		AssignedDriver:       input.AssignedDriver,
-		AssignedVehicle:      input.AssignedVehicle,
-		CreatedAt:            input.CreatedAt.Time,
-	}
+		// Details can only change while Pending, and status changes have to follow the pickup
+		// request lifecycle
+		edited := input.WasteType != "" || input.Quantity != 0 || !input.PickupDate.IsZero() || input.HandlingRequirements != "" ||
+			input.PickupLatitude != nil || input.PickupLongitude != nil
+		if edited {
+			if err := lifecycle.Edit(lifecycle.Status(existing.Status)); err != nil {
+				return err
+			}
+		}
+		statusChanged := input.Status != "" && input.Status != existing.Status
+		if statusChanged {
+			if err := cancellation(existing, lifecycle.Status(input.Status)); err != nil {
+				return err
+			}
+		}
 
-	result := p.GormDB.Model(&models.PickupRequest{}).
-		Where("request_id = ?", requestID).
-		Updates(updates)
+		updates := models.PickupRequest{
+			WasteType:            input.WasteType,
+			Quantity:             input.Quantity,
+			PickupDate:           input.PickupDate.Time,
+			Status:               input.Status,
+			HandlingRequirements: input.HandlingRequirements,
+			PickupLatitude:       input.PickupLatitude,
+			PickupLongitude:      input.PickupLongitude,
+		}
 
-	if result.Error != nil {
-		return fmt.Errorf("update failed: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("no rows affected")
-	}
-	return nil
+		result := tx.Model(&models.PickupRequest{}).
+			Where("request_id = ?", requestID).
+			Updates(updates)
+
+		if result.Error != nil {
+			return fmt.Errorf("update failed: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("no rows affected")
+		}
+
+		if !statusChanged {
+			return nil
+		}
+		if err := recordStatusChange(tx, requestID, existing.Status, input.Status, actor, input.Reason); err != nil {
+			return err
+		}
+		var updated models.PickupRequest
+		if err := tx.First(&updated, "request_id = ?", requestID).Error; err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		return enqueueOutboxEvent(tx, events.PickupCancelled, &actor, convertPickupRequestModelToType(updated))
+	})
 }
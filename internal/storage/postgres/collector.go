@@ -1,20 +1,29 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/geo"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 	"gorm.io/gorm"
 )
 
-func (p *Postgres) GetCollectorByID(id int64) (types.Collector, error) {
+func (p *Postgres) GetCollectorByID(ctx context.Context, id int64) (types.Collector, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var collectorModel models.Collector
 	var userModel models.User
 
 	// Fetching the collector
-	err := p.GormDB.Where("user_id = ?", id).First(&collectorModel).Error
+	err := p.GormDB.WithContext(ctx).Where("user_id = ?", id).First(&collectorModel).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return types.Collector{}, fmt.Errorf("collector not found")
@@ -23,7 +32,7 @@ func (p *Postgres) GetCollectorByID(id int64) (types.Collector, error) {
 	}
 
 	// Fetching the corresponding user
-	err = p.GormDB.First(&userModel, "user_id = ?", collectorModel.UserID).Error
+	err = p.GormDB.WithContext(ctx).First(&userModel, "user_id = ?", collectorModel.UserID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return types.Collector{}, fmt.Errorf("associated user not found")
@@ -34,40 +43,21 @@ func (p *Postgres) GetCollectorByID(id int64) (types.Collector, error) {
 	return convertCollectorModelToType(collectorModel, userModel), nil
 }
 
-func (p *Postgres) GetCollectors() ([]types.Collector, error) {
-	var collectorModels []models.Collector
-
-	// Fetching all collectors
-	err := p.GormDB.Find(&collectorModels).Error
-	if err != nil {
-		return nil, fmt.Errorf("database error: %w", err)
-	}
-
-	collectors := make([]types.Collector, 0, len(collectorModels))
-
-	for _, model := range collectorModels {
-		var userModel models.User
-
-		// Fetching the user associated with each collector
-		err := p.GormDB.First(&userModel, "user_id = ?", model.UserID).Error
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch user for collector %d: %w", model.UserID, err)
-		}
-
-		collectors = append(collectors, convertCollectorModelToType(model, userModel))
-	}
-
-	return collectors, nil
+// GetCollectors returns every collector with its user, joined in a single query.
+func (p *Postgres) GetCollectors(ctx context.Context) ([]types.Collector, error) {
+	return p.GetAllCollectors(ctx)
 }
 
-func (p *Postgres) UpdateCollectorProfile(userID int64, update types.CollectorUpdate) (int64, error) {
+func (p *Postgres) UpdateCollectorProfile(ctx context.Context, userID int64, update types.CollectorUpdate) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 
-	_, err1 := p.GetCollectorByID(userID)
+	_, err1 := p.GetCollectorByID(ctx, userID)
 	if err1 != nil {
 		return 0, fmt.Errorf("collector ID not found")
 	}
 
-	err := p.GormDB.Transaction(func(tx *gorm.DB) error {
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Updating User fields
 		userUpdates := models.User{
 			Email:        update.Email,
@@ -92,6 +82,8 @@ func (p *Postgres) UpdateCollectorProfile(userID int64, update types.CollectorUp
 			LicenseNumber: update.License_number,
 			Capacity:      update.Capacity,
 			LicenseExpiry: update.License_expiry.Time,
+			Latitude:      update.Latitude,
+			Longitude:     update.Longitude,
 		}
 
 		if err := tx.Model(&models.Collector{}).Where("user_id = ?", userID).Updates(collectorUpdates).Error; err != nil {
@@ -108,13 +100,16 @@ func (p *Postgres) UpdateCollectorProfile(userID int64, update types.CollectorUp
 	return userID, nil
 }
 
-func (p *Postgres) AddCollectorServiceCategory(input types.CollectorServiceCategory, userID uint64) (int64, error) {
-	_, err1 := p.GetCollectorByID(int64(userID))
+func (p *Postgres) AddCollectorServiceCategory(ctx context.Context, input types.CollectorServiceCategory, userID uint64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(userID))
 	if err1 != nil {
 		return 0, fmt.Errorf("collector ID not found")
 	}
 
-	_, err := p.GetServiceCategory(uint64(input.CategoryID))
+	_, err := p.GetServiceCategory(ctx, uint64(input.CategoryID))
 	if err != nil {
 		return 0, fmt.Errorf("service category not found")
 	}
@@ -126,23 +121,26 @@ func (p *Postgres) AddCollectorServiceCategory(input types.CollectorServiceCateg
 		HandlingRequirements: input.HandlingRequirements,
 	}
 
-	if err := p.GormDB.Create(&model).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Create(&model).Error; err != nil {
 		return 0, fmt.Errorf("create failed: %w", err)
 	}
 
 	return model.CategoryID, nil
 }
 
-func (p *Postgres) UpdateCollectorServiceCategory(input types.UpdateCollectorServiceCategory, userID uint64) error {
-	_, err1 := p.GetCollectorByID(int64(userID))
+func (p *Postgres) UpdateCollectorServiceCategory(ctx context.Context, input types.UpdateCollectorServiceCategory, userID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(userID))
 	if err1 != nil {
 		return fmt.Errorf("collector ID not found")
 	}
-	_, err := p.GetServiceCategory(uint64(input.CategoryID))
+	_, err := p.GetServiceCategory(ctx, uint64(input.CategoryID))
 	if err != nil {
 		return fmt.Errorf("service category not found")
 	}
-	result := p.GormDB.Model(&models.CollectorServiceCategory{}).
+	result := p.GormDB.WithContext(ctx).Model(&models.CollectorServiceCategory{}).
 		Where("category_id = ? AND collector_id = ?", input.CategoryID, userID).
 		Updates(models.CollectorServiceCategory{
 			PricePerKg:           input.PricePerKg,
@@ -161,16 +159,19 @@ func (p *Postgres) UpdateCollectorServiceCategory(input types.UpdateCollectorSer
 	return nil
 }
 
-func (p *Postgres) DeleteCollectorServiceCategory(categoryID int64, collectorID uint64) error {
-	_, err1 := p.GetCollectorByID(categoryID)
+func (p *Postgres) DeleteCollectorServiceCategory(ctx context.Context, categoryID int64, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(collectorID))
 	if err1 != nil {
 		return fmt.Errorf("collector ID not found")
 	}
-	_, err := p.GetServiceCategory(uint64(categoryID))
+	_, err := p.GetServiceCategory(ctx, uint64(categoryID))
 	if err != nil {
 		return fmt.Errorf("service category not found")
 	}
-	result := p.GormDB.Where("category_id = ? AND collector_id = ?", categoryID, collectorID).Delete(&models.CollectorServiceCategory{})
+	result := p.GormDB.WithContext(ctx).Where("category_id = ? AND collector_id = ?", categoryID, collectorID).Delete(&models.CollectorServiceCategory{})
 
 	if result.Error != nil {
 		return fmt.Errorf("delete failed: %w", result.Error)
@@ -183,12 +184,15 @@ func (p *Postgres) DeleteCollectorServiceCategory(categoryID int64, collectorID
 	return nil
 }
 
-func (p *Postgres) AddCollectorVehicle(input types.CollectorVehicle, userID uint64) (int64, error) {
-	_, err1 := p.GetCollectorByID(int64(userID))
+func (p *Postgres) AddCollectorVehicle(ctx context.Context, input types.CollectorVehicle, userID uint64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(userID))
 	if err1 != nil {
 		return 0, fmt.Errorf("collector ID not found")
 	}
-	_, err := p.GetVehicle(uint64(input.VehicleID))
+	_, err := p.GetVehicle(ctx, uint64(input.VehicleID))
 	if err != nil {
 		return 0, fmt.Errorf("vehicle not found")
 	}
@@ -203,19 +207,22 @@ func (p *Postgres) AddCollectorVehicle(input types.CollectorVehicle, userID uint
 		RegistrationExpiry:   input.RegistrationExpiry.Time,
 	}
 
-	if err := p.GormDB.Create(&model).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Create(&model).Error; err != nil {
 		return 0, fmt.Errorf("create failed: %w", err)
 	}
 
 	return model.VehicleID, nil
 }
 
-func (p *Postgres) UpdateCollectorVehicle(input types.UpdateCollectorVehicle, userID uint64) error {
-	_, err1 := p.GetCollectorByID(int64(userID))
+func (p *Postgres) UpdateCollectorVehicle(ctx context.Context, input types.UpdateCollectorVehicle, userID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(userID))
 	if err1 != nil {
 		return fmt.Errorf("collector ID not found")
 	}
-	_, err := p.GetVehicle(uint64(input.VehicleID))
+	_, err := p.GetVehicle(ctx, uint64(input.VehicleID))
 	if err != nil {
 		return fmt.Errorf("vehicle not found")
 	}
@@ -245,7 +252,7 @@ func (p *Postgres) UpdateCollectorVehicle(input types.UpdateCollectorVehicle, us
 		updates["registration_expiry"] = input.RegistrationExpiry.Time
 	}
 
-	result := p.GormDB.Model(&models.CollectorVehicle{}).
+	result := p.GormDB.WithContext(ctx).Model(&models.CollectorVehicle{}).
 		Where("vehicle_id = ? AND collector_id = ?", input.VehicleID, userID).
 		Updates(updates)
 
@@ -258,16 +265,19 @@ func (p *Postgres) UpdateCollectorVehicle(input types.UpdateCollectorVehicle, us
 	return nil
 }
 
-func (p *Postgres) DeleteCollectorVehicle(vehicleID int64, collectorID uint64) error {
-	_, err1 := p.GetCollectorByID(int64(collectorID))
+func (p *Postgres) DeleteCollectorVehicle(ctx context.Context, vehicleID int64, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(collectorID))
 	if err1 != nil {
 		return fmt.Errorf("collector ID not found")
 	}
-	_, err := p.GetVehicle(uint64(vehicleID))
+	_, err := p.GetVehicle(ctx, uint64(vehicleID))
 	if err != nil {
 		return fmt.Errorf("vehicle not found")
 	}
-	result := p.GormDB.Where("vehicle_id = ? AND collector_id = ?", vehicleID, collectorID).Delete(&models.CollectorVehicle{})
+	result := p.GormDB.WithContext(ctx).Where("vehicle_id = ? AND collector_id = ?", vehicleID, collectorID).Delete(&models.CollectorVehicle{})
 
 	if result.Error != nil {
 		return fmt.Errorf("delete failed: %w", result.Error)
@@ -280,18 +290,21 @@ func (p *Postgres) DeleteCollectorVehicle(vehicleID int64, collectorID uint64) e
 	return nil
 }
 
-func (p *Postgres) GetCollectorVehicle(collectorID int64, vehicleID int64) (types.CollectorVehicle, error) {
-	_, err1 := p.GetCollectorByID(int64(collectorID))
+func (p *Postgres) GetCollectorVehicle(ctx context.Context, collectorID int64, vehicleID int64) (types.CollectorVehicle, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err1 := p.GetCollectorByID(ctx, int64(collectorID))
 	if err1 != nil {
 		return types.CollectorVehicle{}, fmt.Errorf("collector ID not found")
 	}
-	_, err2 := p.GetVehicle(uint64(vehicleID))
+	_, err2 := p.GetVehicle(ctx, uint64(vehicleID))
 	if err2 != nil {
 		return types.CollectorVehicle{}, fmt.Errorf("vehicle not found")
 	}
 
 	var vehicle models.CollectorVehicle
-	err := p.GormDB.Where("vehicle_id = ? AND collector_id = ?", vehicleID, collectorID).First(&vehicle).Error
+	err := p.GormDB.WithContext(ctx).Where("vehicle_id = ? AND collector_id = ?", vehicleID, collectorID).First(&vehicle).Error
 	if err != nil {
 		return types.CollectorVehicle{}, fmt.Errorf("failed to fetch vehicle: %w", err)
 	}
@@ -307,10 +320,13 @@ func (p *Postgres) GetCollectorVehicle(collectorID int64, vehicleID int64) (type
 	}, nil
 }
 
-func (p *Postgres) GetCollectorServiceCategories(collectorID int64) ([]types.CollectorServiceCategory, error) {
+func (p *Postgres) GetCollectorServiceCategories(ctx context.Context, collectorID int64) ([]types.CollectorServiceCategory, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var models []models.CollectorServiceCategory
 
-	if err := p.GormDB.Where("collector_id = ?", collectorID).Find(&models).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Where("collector_id = ?", collectorID).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
@@ -328,10 +344,122 @@ func (p *Postgres) GetCollectorServiceCategories(collectorID int64) ([]types.Col
 	return categories, nil
 }
 
-func (p *Postgres) GetCollectorVehicles(collectorID int64) ([]types.CollectorVehicle, error) {
+// GetNearbyCollectors narrows the collectors down to a bounding box of the search circle, which
+// the location index serves, then measures the distances of the candidates.
+func (p *Postgres) GetNearbyCollectors(ctx context.Context, query types.NearbyCollectorsQuery) ([]types.NearbyCollector, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	center := geo.Point{Lat: query.Latitude, Lng: query.Longitude}
+	sw, ne := geo.BoundingBox(center, query.RadiusKm*1000)
+
+	var rows []struct {
+		UserID          int64
+		CompanyName     string
+		Address         string
+		Latitude        float64
+		Longitude       float64
+		IsVerified      bool
+		PricePerKg      *float64
+		MaximumCapacity *float64
+	}
+	err := p.GormDB.WithContext(ctx).Raw(`
+		SELECT c.user_id, c.company_name, COALESCE(u.address, '') AS address, c.latitude, c.longitude, u.is_verified,
+		       o.price_per_kg, o.maximum_capacity
+		FROM collectors c
+		JOIN users u ON u.user_id = c.user_id
+		LEFT JOIN (
+			SELECT csc.collector_id, MIN(csc.price_per_kg) AS price_per_kg, MAX(csc.maximum_capacity) AS maximum_capacity
+			FROM collector_service_categories csc
+			JOIN service_categories sc ON sc.category_id = csc.category_id
+			WHERE @waste_type = '' OR sc.waste_type = @waste_type
+			GROUP BY csc.collector_id
+		) o ON o.collector_id = c.user_id
+		WHERE u.is_active
+		  AND c.latitude BETWEEN @south AND @north
+		  AND c.longitude BETWEEN @west AND @east
+		  AND (@waste_type = '' OR o.collector_id IS NOT NULL)`,
+		sql.Named("waste_type", query.WasteType),
+		sql.Named("south", sw.Lat), sql.Named("north", ne.Lat),
+		sql.Named("west", sw.Lng), sql.Named("east", ne.Lng),
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	collectors := make([]types.NearbyCollector, 0, len(rows))
+	for _, row := range rows {
+		distance := geo.Distance(center, geo.Point{Lat: row.Latitude, Lng: row.Longitude}) / 1000
+		if distance > query.RadiusKm {
+			continue
+		}
+		collectors = append(collectors, types.NearbyCollector{
+			CollectorID:     row.UserID,
+			CompanyName:     row.CompanyName,
+			Address:         row.Address,
+			Latitude:        row.Latitude,
+			Longitude:       row.Longitude,
+			IsVerified:      row.IsVerified,
+			DistanceKm:      distance,
+			PricePerKg:      row.PricePerKg,
+			MaximumCapacity: row.MaximumCapacity,
+		})
+	}
+	sort.Slice(collectors, func(i, j int) bool {
+		if collectors[i].DistanceKm != collectors[j].DistanceKm {
+			return collectors[i].DistanceKm < collectors[j].DistanceKm
+		}
+		return collectors[i].CollectorID < collectors[j].CollectorID
+	})
+	return collectors, nil
+}
+
+// GetMatchCandidates counts the bookings and the record of every collector offering the waste
+// type in subqueries, which the collector_id index of pickup_requests serves.
+func (p *Postgres) GetMatchCandidates(ctx context.Context, query types.MatchCandidatesQuery) ([]types.MatchCandidate, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	candidates := []types.MatchCandidate{}
+	err := p.GormDB.WithContext(ctx).Raw(`
+		SELECT c.user_id AS collector_id, c.company_name, u.is_verified, c.latitude, c.longitude,
+		       csc.price_per_kg, csc.maximum_capacity,
+		       COALESCE((
+		           SELECT SUM(pr.quantity) FROM pickup_requests pr
+		           WHERE pr.collector_id = c.user_id AND pr.waste_type = sc.waste_type
+		             AND pr.pickup_date >= @from AND pr.pickup_date < @to
+		             AND pr.status IN ('Pending', 'Accepted', 'Assigned', 'InTransit')
+		       ), 0) AS booked_quantity,
+		       (
+		           SELECT COUNT(*) FROM pickup_requests pr
+		           WHERE pr.collector_id = c.user_id AND pr.status = 'Completed'
+		       ) AS completed_pickups,
+		       (
+		           SELECT COUNT(*) FROM pickup_requests pr
+		           WHERE pr.collector_id = c.user_id AND pr.status IN ('Completed', 'Rejected', 'Cancelled')
+		       ) AS ended_pickups
+		FROM collector_service_categories csc
+		JOIN service_categories sc ON sc.category_id = csc.category_id
+		JOIN collectors c ON c.user_id = csc.collector_id
+		JOIN users u ON u.user_id = c.user_id
+		WHERE sc.waste_type = @waste_type AND u.is_active
+		ORDER BY c.user_id`,
+		sql.Named("waste_type", query.WasteType),
+		sql.Named("from", query.From), sql.Named("to", query.To),
+	).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return candidates, nil
+}
+
+func (p *Postgres) GetCollectorVehicles(ctx context.Context, collectorID int64) ([]types.CollectorVehicle, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var models []models.CollectorVehicle
 
-	if err := p.GormDB.Where("collector_id = ?", collectorID).Find(&models).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Where("collector_id = ?", collectorID).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
@@ -352,15 +480,18 @@ func (p *Postgres) GetCollectorVehicles(collectorID int64) ([]types.CollectorVeh
 	return vehicles, nil
 }
 
-func (p *Postgres) GetCollectorDriver(collectorID int64, driverID int64) (types.CollectorDriver, error) {
+func (p *Postgres) GetCollectorDriver(ctx context.Context, collectorID int64, driverID int64) (types.CollectorDriver, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var driverModel models.CollectorDriver
-	err := p.GormDB.Where("driver_id = ? AND collector_id = ?", driverID, collectorID).First(&driverModel).Error
+	err := p.GormDB.WithContext(ctx).Where("driver_id = ? AND collector_id = ?", driverID, collectorID).First(&driverModel).Error
 	if err != nil {
 		return types.CollectorDriver{}, fmt.Errorf("driver not found: %w", err)
 	}
 
 	var userModel models.User
-	err = p.GormDB.Where("user_id = ?", driverModel.UserID).First(&userModel).Error
+	err = p.GormDB.WithContext(ctx).Where("user_id = ?", driverModel.UserID).First(&userModel).Error
 	if err != nil {
 		return types.CollectorDriver{}, fmt.Errorf("associated user not found: %w", err)
 	}
@@ -368,9 +499,12 @@ func (p *Postgres) GetCollectorDriver(collectorID int64, driverID int64) (types.
 	return convertCollectorDriverModelToType(driverModel, userModel), nil
 }
 
-func (p *Postgres) GetCollectorDrivers(collectorID int64) ([]types.CollectorDriver, error) {
+func (p *Postgres) GetCollectorDrivers(ctx context.Context, collectorID int64) ([]types.CollectorDriver, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var driverModels []models.CollectorDriver
-	err := p.GormDB.Where("collector_id = ?", collectorID).Find(&driverModels).Error
+	err := p.GormDB.WithContext(ctx).Where("collector_id = ?", collectorID).Find(&driverModels).Error
 	if err != nil {
 		return nil, fmt.Errorf("error fetching drivers: %w", err)
 	}
@@ -378,7 +512,7 @@ func (p *Postgres) GetCollectorDrivers(collectorID int64) ([]types.CollectorDriv
 	drivers := make([]types.CollectorDriver, 0, len(driverModels))
 	for _, dm := range driverModels {
 		var userModel models.User
-		if err := p.GormDB.Where("user_id = ?", dm.UserID).First(&userModel).Error; err != nil {
+		if err := p.GormDB.WithContext(ctx).Where("user_id = ?", dm.UserID).First(&userModel).Error; err != nil {
 			return nil, fmt.Errorf("error fetching user for driver %d: %w", dm.UserID, err)
 		}
 		drivers = append(drivers, convertCollectorDriverModelToType(dm, userModel))
@@ -387,12 +521,15 @@ func (p *Postgres) GetCollectorDrivers(collectorID int64) ([]types.CollectorDriv
 }
 
 // UpdateCollectorDriver updates existing driver details.
-func (p *Postgres) UpdateCollectorDriver(input types.UpdateCollectorDriver, collectorID uint64) error {
-	_, err := p.GetCollectorByID(int64(collectorID))
+func (p *Postgres) UpdateCollectorDriver(ctx context.Context, input types.UpdateCollectorDriver, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err := p.GetCollectorByID(ctx, int64(collectorID))
 	if err != nil {
 		return err
 	}
-	_, err = p.GetCollectorDriver(int64(collectorID), input.DriverID)
+	_, err = p.GetCollectorDriver(ctx, int64(collectorID), input.DriverID)
 	if err != nil {
 		return fmt.Errorf("driver ID not found")
 	}
@@ -418,7 +555,7 @@ func (p *Postgres) UpdateCollectorDriver(input types.UpdateCollectorDriver, coll
 	if !input.JoiningDate.Time.IsZero() {
 		updates["joining_date"] = input.JoiningDate.Time
 	}
-	result := p.GormDB.Model(&models.CollectorDriver{}).
+	result := p.GormDB.WithContext(ctx).Model(&models.CollectorDriver{}).
 		Where("driver_id = ? AND collector_id = ?", input.DriverID, collectorID).
 		Updates(updates)
 	if result.Error != nil {
@@ -431,16 +568,19 @@ func (p *Postgres) UpdateCollectorDriver(input types.UpdateCollectorDriver, coll
 }
 
 // DeleteCollectorDriver removes a driver from a collector.
-func (p *Postgres) DeleteCollectorDriver(driverID int64, collectorID uint64) error {
-	_, err := p.GetCollectorByID(int64(collectorID))
+func (p *Postgres) DeleteCollectorDriver(ctx context.Context, driverID int64, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err := p.GetCollectorByID(ctx, int64(collectorID))
 	if err != nil {
 		return err
 	}
-	_, err = p.GetCollectorDriver(int64(collectorID), driverID)
+	_, err = p.GetCollectorDriver(ctx, int64(collectorID), driverID)
 	if err != nil {
 		return fmt.Errorf("driver ID not found")
 	}
-	result := p.GormDB.Where("driver_id = ? AND collector_id = ?", driverID, collectorID).Delete(&models.CollectorDriver{})
+	result := p.GormDB.WithContext(ctx).Where("driver_id = ? AND collector_id = ?", driverID, collectorID).Delete(&models.CollectorDriver{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -451,20 +591,23 @@ func (p *Postgres) DeleteCollectorDriver(driverID int64, collectorID uint64) err
 }
 
 // AssignVehicleToDriver assigns a vehicle to a driver.
-func (p *Postgres) AssignVehicleToDriver(driverID int64, vehicleID int64, collectorID uint64) error {
+func (p *Postgres) AssignVehicleToDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	// Ensuring the collector exists.
-	_, err := p.GetCollectorByID(int64(collectorID))
+	_, err := p.GetCollectorByID(ctx, int64(collectorID))
 	if err != nil {
 		return fmt.Errorf("collector ID not found")
 	}
 	// Ensuring the driver exists and belongs to the collector.
-	_, err = p.GetCollectorDriver(int64(collectorID), driverID)
+	_, err = p.GetCollectorDriver(ctx, int64(collectorID), driverID)
 	if err != nil {
 		return fmt.Errorf("driver ID not found")
 	}
 
 	// Ensuring the vehicle exists.
-	_, err = p.GetVehicle(uint64(vehicleID))
+	_, err = p.GetVehicle(ctx, uint64(vehicleID))
 	if err != nil {
 		return fmt.Errorf("vehicle ID not found")
 	}
@@ -473,31 +616,34 @@ func (p *Postgres) AssignVehicleToDriver(driverID int64, vehicleID int64, collec
 		DriverID:  driverID,
 		VehicleID: vehicleID,
 	}
-	if err := p.GormDB.Create(&assignment).Error; err != nil {
+	if err := p.GormDB.WithContext(ctx).Create(&assignment).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p *Postgres) UnassignVehicleFromDriver(driverID int64, vehicleID int64, collectorID uint64) error {
+func (p *Postgres) UnassignVehicleFromDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	// Ensuring the collector exists.
-	_, err := p.GetCollectorByID(int64(collectorID))
+	_, err := p.GetCollectorByID(ctx, int64(collectorID))
 	if err != nil {
 		return fmt.Errorf("collector ID not found")
 	}
 	// Ensuring the driver exists and belongs to the collector.
-	_, err = p.GetCollectorDriver(int64(collectorID), driverID)
+	_, err = p.GetCollectorDriver(ctx, int64(collectorID), driverID)
 	if err != nil {
 		return fmt.Errorf("driver ID not found")
 	}
 
 	// Ensuring the vehicle exists.
-	_, err = p.GetVehicle(uint64(vehicleID))
+	_, err = p.GetVehicle(ctx, uint64(vehicleID))
 	if err != nil {
 		return fmt.Errorf("vehicle ID not found")
 	}
 
-	result := p.GormDB.Where("driver_id = ? AND vehicle_id = ?", driverID, vehicleID).Delete(&models.VehicleDriver{})
+	result := p.GormDB.WithContext(ctx).Where("driver_id = ? AND vehicle_id = ?", driverID, vehicleID).Delete(&models.VehicleDriver{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -507,62 +653,24 @@ func (p *Postgres) UnassignVehicleFromDriver(driverID int64, vehicleID int64, co
 	return nil
 }
 
-func (p *Postgres) AcceptPickupRequest(requestID int64) error {
-	// Fetching the pickup request
-	var request models.PickupRequest
-	if err := p.GormDB.First(&request, "request_id = ?", requestID).Error; err != nil {
-		return fmt.Errorf("pickup request not found: %w", err)
-	}
-
-	// Updating the status to accepted
-	request.Status = "accepted"
-	if err := p.GormDB.Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to update pickup request status: %w", err)
-	}
-
-	return nil
+func (p *Postgres) AcceptPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error {
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.Accepted, actor, "", events.PickupAccepted, nil)
 }
 
-func (p *Postgres) RejectPickupRequest(requestID int64) error {
-	// Fetching the pickup request
-	var request models.PickupRequest
-	if err := p.GormDB.First(&request, "request_id = ?", requestID).Error; err != nil {
-		return fmt.Errorf("pickup request not found: %w", err)
-	}
-
-	// Updating the status to rejected
-	request.Status = "rejected"
-	if err := p.GormDB.Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to update pickup request status: %w", err)
-	}
-
-	return nil
+func (p *Postgres) RejectPickupRequest(ctx context.Context, requestID int64, actor types.Actor, reason string) error {
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.Rejected, actor, reason, events.PickupRejected, nil)
 }
 
-func (p *Postgres) AssignTripToDriver(requestID int64, driverID int64) error {
-	// Fetching the pickup request
-	var request models.PickupRequest
-	if err := p.GormDB.First(&request, "request_id = ?", requestID).Error; err != nil {
-		return fmt.Errorf("pickup request not found: %w", err)
-	}
-	// Setting assigned driver
-	request.AssignedDriver = driverID
-	if err := p.GormDB.Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to assign trip to driver: %w", err)
-	}
-	return nil
+func (p *Postgres) AssignTripToDriver(ctx context.Context, requestID int64, driverID int64, actor types.Actor) error {
+	reason := fmt.Sprintf("driver %d assigned", driverID)
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.Assigned, actor, reason, events.DriverAssigned, func(request *models.PickupRequest) {
+		request.AssignedDriver = driverID
+	})
 }
 
-func (p *Postgres) UnassignTripFromDriver(requestID int64) error {
-	// Fetching the pickup request
-	var request models.PickupRequest
-	if err := p.GormDB.First(&request, "request_id = ?", requestID).Error; err != nil {
-		return fmt.Errorf("pickup request not found: %w", err)
-	}
-	// Unassign the driver by setting to -1
-	request.AssignedDriver = -1
-	if err := p.GormDB.Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to unassign trip from driver: %w", err)
-	}
-	return nil
+func (p *Postgres) UnassignTripFromDriver(ctx context.Context, requestID int64, actor types.Actor) error {
+	// Unassigning the driver by setting to -1, the request goes back to Accepted
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.Accepted, actor, "driver unassigned", events.DriverUnassigned, func(request *models.PickupRequest) {
+		request.AssignedDriver = -1
+	})
 }
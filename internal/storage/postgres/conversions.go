@@ -1,6 +1,11 @@
 package postgres
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
@@ -22,11 +27,16 @@ func convertCollectorModelToType(collector models.Collector, user models.User) t
 			LastLogin:    types.DateTime{Time: user.LastLogin},
 			IsVerified:   user.IsVerified,
 			IsFlagged:    user.IsFlagged,
+
+			NotificationChannels: splitList(user.NotificationChannels),
+			Locale:               user.Locale,
 		},
 		Company_name:   collector.CompanyName,
 		License_number: collector.LicenseNumber,
 		Capacity:       collector.Capacity,
 		License_expiry: types.Date{Time: collector.LicenseExpiry},
+		Latitude:       collector.Latitude,
+		Longitude:      collector.Longitude,
 	}
 }
 
@@ -47,20 +57,24 @@ func convertBusinessModelToType(b models.Business, u models.User) types.Business
 			LastLogin:    types.DateTime{Time: u.LastLogin},
 			IsVerified:   u.IsVerified,
 			IsFlagged:    u.IsFlagged,
+
+			NotificationChannels: splitList(u.NotificationChannels),
+			Locale:               u.Locale,
 		},
 		Business_name:       b.BusinessName,
 		Business_type:       b.BusinessType,
 		Registration_number: b.RegistrationNumber,
 		Gst_id:              b.GstID,
 		Business_address:    b.BusinessAddress,
+		Latitude:            b.Latitude,
+		Longitude:           b.Longitude,
 	}
 }
 
 func convertPickupRequestModelToType(model models.PickupRequest) types.PickupRequest {
-	return types.PickupRequest{
+	request := types.PickupRequest{
 		RequestID:            model.RequestID,
 		BusinessID:           model.BusinessID,
-		CollectorID:          model.CollectorID,
 		WasteType:            model.WasteType,
 		Quantity:             model.Quantity,
 		PickupDate:           types.DateTime{Time: model.PickupDate},
@@ -69,7 +83,19 @@ func convertPickupRequestModelToType(model models.PickupRequest) types.PickupReq
 		AssignedDriver:       model.AssignedDriver,
 		AssignedVehicle:      model.AssignedVehicle,
 		CreatedAt:            types.DateTime{Time: model.CreatedAt},
+		PickupLatitude:       model.PickupLatitude,
+		PickupLongitude:      model.PickupLongitude,
+		OpenMarket:           model.OpenMarket,
+		PricePerKg:           model.PricePerKg,
+	}
+	if model.CollectorID != nil {
+		request.CollectorID = *model.CollectorID
+	}
+	if model.WindowStart != nil && model.WindowEnd != nil {
+		request.WindowStart = &types.DateTime{Time: *model.WindowStart}
+		request.WindowEnd = &types.DateTime{Time: *model.WindowEnd}
 	}
+	return request
 }
 
 func convertCollectorDriverModelToType(driver models.CollectorDriver, user models.User) types.CollectorDriver {
@@ -88,6 +114,9 @@ func convertCollectorDriverModelToType(driver models.CollectorDriver, user model
 			LastLogin:    types.DateTime{Time: user.LastLogin},
 			IsVerified:   user.IsVerified,
 			IsFlagged:    user.IsFlagged,
+
+			NotificationChannels: splitList(user.NotificationChannels),
+			Locale:               user.Locale,
 		},
 		CollectorID:   driver.CollectorID,
 		LicenseNumber: driver.LicenseNumber,
@@ -99,3 +128,263 @@ func convertCollectorDriverModelToType(driver models.CollectorDriver, user model
 		JoiningDate:   types.Date{Time: driver.JoiningDate},
 	}
 }
+
+func convertStatusHistoryModelToType(model models.PickupRequestStatusHistory) types.PickupRequestStatusChange {
+	return types.PickupRequestStatusChange{
+		HistoryID:   model.HistoryID,
+		RequestID:   model.RequestID,
+		ActorUserID: model.ActorUserID,
+		ActorRole:   model.ActorRole,
+		OldStatus:   model.OldStatus,
+		NewStatus:   model.NewStatus,
+		Reason:      model.Reason,
+		ChangedAt:   types.DateTime{Time: model.ChangedAt},
+	}
+}
+
+func convertOutboxEventModelToType(model models.OutboxEvent) (types.OutboxEvent, error) {
+	event := types.OutboxEvent{
+		OutboxID:  model.OutboxID,
+		Topic:     model.Topic,
+		Payload:   model.Payload,
+		Attempts:  model.Attempts,
+		CreatedAt: types.DateTime{Time: model.CreatedAt},
+	}
+	if model.Attributes != "" {
+		if err := json.Unmarshal([]byte(model.Attributes), &event.Attributes); err != nil {
+			return types.OutboxEvent{}, fmt.Errorf("invalid attributes on outbox event %d: %w", model.OutboxID, err)
+		}
+	}
+	return event, nil
+}
+
+func convertDeadLetterModelToType(model models.DeadLetter) (types.DeadLetter, error) {
+	deadLetter := types.DeadLetter{
+		DeadLetterID:   model.DeadLetterID,
+		SubscriptionID: model.SubscriptionID,
+		Topic:          model.Topic,
+		MessageID:      model.MessageID,
+		EventID:        model.EventID,
+		EventType:      model.EventType,
+		Payload:        string(model.Payload),
+		Attempts:       model.Attempts,
+		LastError:      model.LastError,
+		Status:         model.Status,
+		CreatedAt:      types.DateTime{Time: model.CreatedAt},
+	}
+	if model.ResolvedAt != nil {
+		deadLetter.ResolvedAt = &types.DateTime{Time: *model.ResolvedAt}
+	}
+	if model.Attributes != "" {
+		if err := json.Unmarshal([]byte(model.Attributes), &deadLetter.Attributes); err != nil {
+			return types.DeadLetter{}, fmt.Errorf("invalid attributes on dead letter %d: %w", model.DeadLetterID, err)
+		}
+	}
+	return deadLetter, nil
+}
+
+// splitList parses a comma separated column, such as the notification channels of a user.
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+func convertNotificationTemplateModelToType(model models.NotificationTemplate) types.NotificationTemplate {
+	return types.NotificationTemplate{
+		Name:      model.Name,
+		Locale:    model.Locale,
+		Subject:   model.Subject,
+		Text:      model.TextBody,
+		HTML:      model.HTMLBody,
+		Source:    "override",
+		UpdatedBy: model.UpdatedBy,
+		UpdatedAt: &types.DateTime{Time: model.UpdatedAt},
+	}
+}
+
+func convertNotificationModelToType(model models.Notification) types.Notification {
+	notification := types.Notification{
+		NotificationID: model.NotificationID,
+		UserID:         model.UserID,
+		Type:           model.Type,
+		Heading:        model.Heading,
+		Message:        model.Message,
+		IsRead:         model.IsRead,
+		CreatedAt:      types.DateTime{Time: model.CreatedAt},
+	}
+	if model.EventID != nil {
+		notification.EventID = *model.EventID
+	}
+	if model.PickupRequestID != nil {
+		notification.PickupRequestID = *model.PickupRequestID
+	}
+	if model.ReadAt != nil {
+		notification.ReadAt = &types.DateTime{Time: *model.ReadAt}
+	}
+	return notification
+}
+
+func convertWebhookEndpointModelToType(model models.WebhookEndpoint) types.WebhookEndpoint {
+	return types.WebhookEndpoint{
+		EndpointID:  model.EndpointID,
+		UserID:      model.UserID,
+		URL:         model.URL,
+		Description: model.Description,
+		EventTypes:  splitList(model.EventTypes),
+		Secret:      model.Secret,
+		IsActive:    model.IsActive,
+		CreatedAt:   types.DateTime{Time: model.CreatedAt},
+		UpdatedAt:   types.DateTime{Time: model.UpdatedAt},
+	}
+}
+
+func convertWebhookDeliveryModelToType(model models.WebhookDelivery) types.WebhookDelivery {
+	delivery := types.WebhookDelivery{
+		DeliveryID:     model.DeliveryID,
+		EndpointID:     model.EndpointID,
+		EventID:        model.EventID,
+		EventType:      model.EventType,
+		Payload:        string(model.Payload),
+		Status:         model.Status,
+		Attempts:       model.Attempts,
+		LastStatusCode: model.LastStatusCode,
+		LastError:      model.LastError,
+		NextAttemptAt:  types.DateTime{Time: model.NextAttemptAt},
+		CreatedAt:      types.DateTime{Time: model.CreatedAt},
+	}
+	if model.DeliveredAt != nil {
+		delivery.DeliveredAt = &types.DateTime{Time: *model.DeliveredAt}
+	}
+	return delivery
+}
+
+func convertAdminAuditRecordModelToType(model models.AdminAuditRecord) types.AdminAuditRecord {
+	record := types.AdminAuditRecord{
+		AuditID:     model.AuditID,
+		Action:      model.Action,
+		AdminUserID: model.AdminUserID,
+		CreatedAt:   types.DateTime{Time: model.CreatedAt},
+	}
+	if model.ActorUserID != nil {
+		record.ActorUserID = *model.ActorUserID
+	}
+	return record
+}
+
+func convertDriverLocationModelToType(model models.DriverLocation) types.DriverLocation {
+	return types.DriverLocation{
+		LocationID:  model.LocationID,
+		DriverID:    model.DriverID,
+		CollectorID: model.CollectorID,
+		VehicleID:   model.VehicleID,
+		Latitude:    model.Latitude,
+		Longitude:   model.Longitude,
+		Timestamp:   types.DateTime{Time: model.Timestamp},
+		Accuracy:    model.Accuracy,
+		Speed:       model.Speed,
+		Bearing:     model.Bearing,
+		TripID:      model.TripID,
+		Point:       model.Point,
+	}
+}
+
+func convertPickupETAModelToType(model models.PickupRequestETA) types.PickupETA {
+	return types.PickupETA{
+		RequestID:        model.RequestID,
+		DriverID:         model.DriverID,
+		DriverLatitude:   model.DriverLatitude,
+		DriverLongitude:  model.DriverLongitude,
+		DistanceMeters:   model.DistanceMeters,
+		SpeedKmh:         model.SpeedKmh,
+		RemainingSeconds: model.ArrivalAt.Sub(model.LocatedAt).Seconds(),
+		ArrivalAt:        types.DateTime{Time: model.ArrivalAt},
+		LocatedAt:        types.DateTime{Time: model.LocatedAt},
+		CalculatedAt:     types.DateTime{Time: model.CalculatedAt},
+	}
+}
+
+func convertSiteModelToType(model models.Site) types.Site {
+	return types.Site{
+		SiteID:       model.SiteID,
+		OwnerID:      model.OwnerID,
+		Kind:         model.Kind,
+		Name:         model.Name,
+		Address:      model.Address,
+		Latitude:     model.Latitude,
+		Longitude:    model.Longitude,
+		RadiusMeters: model.RadiusMeters,
+		DwellSeconds: model.DwellSeconds,
+		AutoAdvance:  model.AutoAdvance,
+		CreatedAt:    types.DateTime{Time: model.CreatedAt},
+		UpdatedAt:    types.DateTime{Time: model.UpdatedAt},
+	}
+}
+
+func convertSiteVisitModelToType(model models.SiteVisit) types.SiteVisit {
+	return types.SiteVisit{
+		TripID:     model.TripID,
+		SiteID:     model.SiteID,
+		DriverID:   model.DriverID,
+		EnteredAt:  convertOptionalTime(model.EnteredAt),
+		ArrivedAt:  convertOptionalTime(model.ArrivedAt),
+		ExitedAt:   convertOptionalTime(model.ExitedAt),
+		LastSeenAt: types.DateTime{Time: model.LastSeenAt},
+		Version:    model.Version,
+	}
+}
+
+func convertOptionalTime(t *time.Time) *types.DateTime {
+	if t == nil {
+		return nil
+	}
+	return &types.DateTime{Time: *t}
+}
+
+func convertOptionalDateTime(t *types.DateTime) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+func convertServiceAreaModelToType(model models.ServiceArea) types.ServiceArea {
+	area := types.ServiceArea{
+		AreaID:      model.AreaID,
+		CollectorID: model.CollectorID,
+		CategoryID:  model.CategoryID,
+		Name:        model.Name,
+		CreatedAt:   types.DateTime{Time: model.CreatedAt},
+		UpdatedAt:   types.DateTime{Time: model.UpdatedAt},
+	}
+	if model.Pincodes != nil {
+		area.Pincodes = splitList(*model.Pincodes)
+	}
+	if model.Polygon != nil {
+		area.Polygon = json.RawMessage(*model.Polygon)
+	}
+	return area
+}
+
+func convertMarketInvitationModelToType(model models.MarketInvitation) types.MarketInvitation {
+	return types.MarketInvitation{
+		RequestID:   model.RequestID,
+		CollectorID: model.CollectorID,
+		PricePerKg:  model.PricePerKg,
+		CreatedAt:   types.DateTime{Time: model.CreatedAt},
+	}
+}
+
+func convertPickupBidModelToType(model models.PickupBid) types.PickupBid {
+	return types.PickupBid{
+		BidID:       model.BidID,
+		RequestID:   model.RequestID,
+		CollectorID: model.CollectorID,
+		PricePerKg:  model.PricePerKg,
+		WindowStart: types.DateTime{Time: model.WindowStart},
+		WindowEnd:   types.DateTime{Time: model.WindowEnd},
+		CreatedAt:   types.DateTime{Time: model.CreatedAt},
+		UpdatedAt:   types.DateTime{Time: model.UpdatedAt},
+	}
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+)
+
+func (p *Postgres) CreateDeadLetter(ctx context.Context, deadLetter types.DeadLetter) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var attributes string
+	if len(deadLetter.Attributes) > 0 {
+		raw, err := json.Marshal(deadLetter.Attributes)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal dead letter attributes: %w", err)
+		}
+		attributes = string(raw)
+	}
+
+	model := models.DeadLetter{
+		SubscriptionID: deadLetter.SubscriptionID,
+		Topic:          deadLetter.Topic,
+		MessageID:      deadLetter.MessageID,
+		EventID:        deadLetter.EventID,
+		EventType:      deadLetter.EventType,
+		Payload:        []byte(deadLetter.Payload),
+		Attributes:     attributes,
+		Attempts:       deadLetter.Attempts,
+		LastError:      deadLetter.LastError,
+		Status:         "pending",
+		CreatedAt:      time.Now(),
+	}
+	if err := p.GormDB.WithContext(ctx).Create(&model).Error; err != nil {
+		return 0, fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	return model.DeadLetterID, nil
+}
+
+// ListDeadLetters returns the dead letters with the given status, or all of them if status is
+// empty, newest first.
+func (p *Postgres) ListDeadLetters(ctx context.Context, status string) ([]types.DeadLetter, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	query := p.GormDB.WithContext(ctx).Order("dead_letter_id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var rows []models.DeadLetter
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	deadLetters := make([]types.DeadLetter, 0, len(rows))
+	for _, row := range rows {
+		deadLetter, err := convertDeadLetterModelToType(row)
+		if err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+func (p *Postgres) GetDeadLetter(ctx context.Context, deadLetterID int64) (types.DeadLetter, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var row models.DeadLetter
+	err := p.GormDB.WithContext(ctx).First(&row, "dead_letter_id = ?", deadLetterID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.DeadLetter{}, fmt.Errorf("dead letter not found")
+		}
+		return types.DeadLetter{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertDeadLetterModelToType(row)
+}
+
+// ResolveDeadLetter moves a pending dead letter to status (replayed or discarded).
+func (p *Postgres) ResolveDeadLetter(ctx context.Context, deadLetterID int64, status string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	result := p.GormDB.WithContext(ctx).Model(&models.DeadLetter{}).
+		Where("dead_letter_id = ? AND status = ?", deadLetterID, "pending").
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_at": &now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to resolve dead letter: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pending dead letter with ID %d not found", deadLetterID)
+	}
+	return nil
+}
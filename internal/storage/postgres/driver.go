@@ -1,23 +1,192 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func (p *Postgres) EndDelivery(requestID int64) error {
-	// Fetching the pickup request
+func (p *Postgres) StartDelivery(ctx context.Context, requestID int64, actor types.Actor) error {
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.InTransit, actor, "", events.DeliveryStarted, nil)
+}
+
+func (p *Postgres) EndDelivery(ctx context.Context, requestID int64, actor types.Actor) error {
+	return p.transitionPickupRequest(ctx, requestID, lifecycle.Completed, actor, "", events.DeliveryCompleted, nil)
+}
+
+func (p *Postgres) GetActiveTrip(ctx context.Context, driverID int64) (types.PickupRequest, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var request models.PickupRequest
-	if err := p.GormDB.First(&request, "request_id = ?", requestID).Error; err != nil {
-		return fmt.Errorf("pickup request not found: %w", err)
+	err := p.GormDB.WithContext(ctx).
+		Where("assigned_driver = ? AND status IN ?", driverID, []string{string(lifecycle.Assigned), string(lifecycle.InTransit)}).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "status = ? DESC, request_id ASC", Vars: []interface{}{string(lifecycle.InTransit)}}}).
+		Limit(1).Find(&request).Error
+	if err != nil {
+		return types.PickupRequest{}, fmt.Errorf("database error: %w", err)
 	}
+	if request.RequestID == 0 {
+		return types.PickupRequest{}, fmt.Errorf("active trip of driver %d: %w", driverID, storage.ErrNotFound)
+	}
+	return convertPickupRequestModelToType(request), nil
+}
+
+func (p *Postgres) StoreDriverLocation(ctx context.Context, location types.DriverLocation) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var locationID int64
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		point := ""
+		if location.TripID != 0 {
+			// Locking the trip so that a single location of it is its start
+			var request models.PickupRequest
+			err := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).Select("request_id").
+				Limit(1).Find(&request, "request_id = ?", location.TripID).Error
+			if err != nil {
+				return fmt.Errorf("database error: %w", err)
+			}
+			if request.RequestID == 0 {
+				return fmt.Errorf("trip %d not found", location.TripID)
+			}
+
+			var count int64
+			if err := tx.Model(&models.DriverLocation{}).Where("trip_id = ?", location.TripID).Count(&count).Error; err != nil {
+				return fmt.Errorf("database error: %w", err)
+			}
+			if count == 0 {
+				point = types.LocationStart
+			}
+		}
 
-	// Updating the status to completed
-	request.Status = "Completed"
-	if err := p.GormDB.Save(&request).Error; err != nil {
-		return fmt.Errorf("failed to update pickup request status: %w", err)
+		if location.VehicleID == 0 {
+			var assignment models.VehicleDriver
+			if err := tx.Limit(1).Find(&assignment, "driver_id = ?", location.DriverID).Error; err != nil {
+				return fmt.Errorf("database error: %w", err)
+			}
+			location.VehicleID = assignment.VehicleID
+		}
+
+		// Raw, as GORM would insert the zero IDs rather than NULL
+		return tx.Raw(`INSERT INTO driver_locations
+			(driver_id, collector_id, vehicle_id, trip_id, latitude, longitude, timestamp, accuracy, speed, bearing, date, point)
+			VALUES (?, NULLIF(?, 0), NULLIF(?, 0), NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING location_id`,
+			location.DriverID, location.CollectorID, location.VehicleID, location.TripID,
+			location.Latitude, location.Longitude, location.Timestamp.Time,
+			location.Accuracy, location.Speed, location.Bearing, time.Now(), point,
+		).Scan(&locationID).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store driver location: %w", err)
 	}
+	return locationID, nil
+}
+
+func (p *Postgres) MarkTripEnd(ctx context.Context, tripID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Moving the end, should locations have arrived after it was marked
+		err := tx.Model(&models.DriverLocation{}).
+			Where("trip_id = ? AND point = ?", tripID, types.LocationEnd).
+			Update("point", "").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE driver_locations SET point = ? WHERE location_id = (
+			SELECT location_id FROM driver_locations WHERE trip_id = ? AND point <> ?
+			ORDER BY timestamp DESC, location_id DESC LIMIT 1)`,
+			types.LocationEnd, tripID, types.LocationStart,
+		).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark the end of trip %d: %w", tripID, err)
+	}
 	return nil
 }
+
+func (p *Postgres) GetTripLocations(ctx context.Context, tripID int64) ([]types.DriverLocation, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.DriverLocation
+	err := p.GormDB.WithContext(ctx).Where("trip_id = ?", tripID).Order("timestamp ASC, location_id ASC").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	locations := make([]types.DriverLocation, 0, len(rows))
+	for _, row := range rows {
+		locations = append(locations, convertDriverLocationModelToType(row))
+	}
+	return locations, nil
+}
+
+func (p *Postgres) GetLatestTripLocations(ctx context.Context, tripID int64, limit int) ([]types.DriverLocation, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.DriverLocation
+	err := p.GormDB.WithContext(ctx).Where("trip_id = ?", tripID).
+		Order("timestamp DESC, location_id DESC").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	locations := make([]types.DriverLocation, len(rows))
+	for i, row := range rows {
+		locations[len(rows)-1-i] = convertDriverLocationModelToType(row)
+	}
+	return locations, nil
+}
+
+func (p *Postgres) SavePickupETA(ctx context.Context, eta types.PickupETA) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	model := models.PickupRequestETA{
+		RequestID:       eta.RequestID,
+		DriverID:        eta.DriverID,
+		DriverLatitude:  eta.DriverLatitude,
+		DriverLongitude: eta.DriverLongitude,
+		DistanceMeters:  eta.DistanceMeters,
+		SpeedKmh:        eta.SpeedKmh,
+		ArrivalAt:       eta.ArrivalAt.Time,
+		LocatedAt:       eta.LocatedAt.Time,
+		CalculatedAt:    eta.CalculatedAt.Time,
+	}
+	err := p.GormDB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "request_id"}},
+		UpdateAll: true,
+	}).Create(&model).Error
+	if err != nil {
+		return fmt.Errorf("failed to save the ETA of pickup request %d: %w", eta.RequestID, err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetPickupETA(ctx context.Context, requestID int64) (types.PickupETA, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.PickupRequestETA
+	err := p.GormDB.WithContext(ctx).Limit(1).Find(&model, "request_id = ?", requestID).Error
+	if err != nil {
+		return types.PickupETA{}, fmt.Errorf("database error: %w", err)
+	}
+	if model.RequestID == 0 {
+		return types.PickupETA{}, fmt.Errorf("ETA of pickup request %d: %w", requestID, storage.ErrNotFound)
+	}
+	return convertPickupETAModelToType(model), nil
+}
@@ -1,17 +1,24 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
 )
 
-func (p *Postgres) GetAllServiceCategories() ([]types.ServiceCategory, error) {
+func (p *Postgres) GetAllServiceCategories(ctx context.Context) ([]types.ServiceCategory, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var categories []models.ServiceCategory
-	err := p.GormDB.Find(&categories).Error
+	err := p.GormDB.WithContext(ctx).Find(&categories).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch service categories: %w", err)
 	}
@@ -26,9 +33,12 @@ func (p *Postgres) GetAllServiceCategories() ([]types.ServiceCategory, error) {
 	return result, nil
 }
 
-func (p *Postgres) GetServiceCategory(categoryID uint64) (types.ServiceCategory, error) {
+func (p *Postgres) GetServiceCategory(ctx context.Context, categoryID uint64) (types.ServiceCategory, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var category models.ServiceCategory
-	err := p.GormDB.First(&category, "category_id = ?", categoryID).Error
+	err := p.GormDB.WithContext(ctx).First(&category, "category_id = ?", categoryID).Error
 	if err != nil {
 		return types.ServiceCategory{}, fmt.Errorf("failed to fetch service category: %w", err)
 	}
@@ -39,9 +49,12 @@ func (p *Postgres) GetServiceCategory(categoryID uint64) (types.ServiceCategory,
 	}, nil
 }
 
-func (p *Postgres) GetAllVehicles() ([]types.Vehicle, error) {
+func (p *Postgres) GetAllVehicles(ctx context.Context) ([]types.Vehicle, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var vehicles []models.Vehicle
-	err := p.GormDB.Find(&vehicles).Error
+	err := p.GormDB.WithContext(ctx).Find(&vehicles).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch vehicles: %w", err)
 	}
@@ -57,9 +70,12 @@ func (p *Postgres) GetAllVehicles() ([]types.Vehicle, error) {
 	return result, nil
 }
 
-func (p *Postgres) GetVehicle(vehicleID uint64) (types.Vehicle, error) {
+func (p *Postgres) GetVehicle(ctx context.Context, vehicleID uint64) (types.Vehicle, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var vehicle models.Vehicle
-	err := p.GormDB.First(&vehicle, "vehicle_id = ?", vehicleID).Error
+	err := p.GormDB.WithContext(ctx).First(&vehicle, "vehicle_id = ?", vehicleID).Error
 	if err != nil {
 		return types.Vehicle{}, fmt.Errorf("failed to fetch vehicle: %w", err)
 	}
@@ -71,9 +87,12 @@ func (p *Postgres) GetVehicle(vehicleID uint64) (types.Vehicle, error) {
 	}, nil
 }
 
-func (p *Postgres) GetUserByID(userID uint64) (types.User, error) {
+func (p *Postgres) GetUserByID(ctx context.Context, userID uint64) (types.User, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var user models.User
-	err := p.GormDB.First(&user, "user_id = ?", userID).Error
+	err := p.GormDB.WithContext(ctx).First(&user, "user_id = ?", userID).Error
 	if err != nil {
 		return types.User{}, fmt.Errorf("failed to fetch user: %w", err)
 	}
@@ -92,24 +111,31 @@ func (p *Postgres) GetUserByID(userID uint64) (types.User, error) {
 		LastLogin:    types.DateTime{Time: user.LastLogin},
 		IsVerified:   user.IsVerified,
 		IsFlagged:    user.IsFlagged,
+
+		NotificationChannels: splitList(user.NotificationChannels),
+		Locale:               user.Locale,
+		MustChangePassword:   user.MustChangePassword,
 	}, nil
 }
 
-func (p *Postgres) GetUserByEmail(email string) (types.User, error) {
+func (p *Postgres) GetUserByEmail(ctx context.Context, email string) (types.User, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var user types.User
 	var registration, lastLogin time.Time
 
-	err := p.SqlDB.QueryRow(`
+	err := p.SqlDB.QueryRowContext(ctx, `
 		SELECT user_id, email, password_hash, full_name, phone_number,
 			address, registration_date, role, is_active, profile_image,
-			last_login, is_verified, is_flagged
+			last_login, is_verified, is_flagged, must_change_password
 		FROM users
 		WHERE email = $1
 		LIMIT 1`, email,
 	).Scan(
 		&user.UserID, &user.Email, &user.PasswordHash, &user.FullName, &user.PhoneNumber,
 		&user.Address, &registration, &user.Role, &user.IsActive, &user.ProfileImage,
-		&lastLogin, &user.IsVerified, &user.IsFlagged,
+		&lastLogin, &user.IsVerified, &user.IsFlagged, &user.MustChangePassword,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -122,3 +148,40 @@ func (p *Postgres) GetUserByEmail(email string) (types.User, error) {
 	user.LastLogin = types.DateTime{Time: lastLogin}
 	return user, nil
 }
+
+func (p *Postgres) GetNotificationPreferences(ctx context.Context, userID int64) (types.NotificationPreferences, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var user models.User
+	err := p.GormDB.WithContext(ctx).Select("notification_channels", "locale").First(&user, "user_id = ?", userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.NotificationPreferences{}, fmt.Errorf("user not found")
+		}
+		return types.NotificationPreferences{}, fmt.Errorf("database error: %w", err)
+	}
+	return types.NotificationPreferences{
+		Channels: splitList(user.NotificationChannels),
+		Locale:   user.Locale,
+	}, nil
+}
+
+func (p *Postgres) SetNotificationPreferences(ctx context.Context, userID int64, preferences types.NotificationPreferences) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Model(&models.User{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"notification_channels": strings.Join(preferences.Channels, ","),
+			"locale":                preferences.Locale,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update notification preferences: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (p *Postgres) CreateSite(ctx context.Context, site types.Site) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	model := models.Site{
+		OwnerID:      site.OwnerID,
+		Kind:         site.Kind,
+		Name:         site.Name,
+		Address:      site.Address,
+		Latitude:     site.Latitude,
+		Longitude:    site.Longitude,
+		RadiusMeters: site.RadiusMeters,
+		DwellSeconds: site.DwellSeconds,
+		AutoAdvance:  site.AutoAdvance,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	// Select makes GORM write auto_advance even when it is false
+	if err := p.GormDB.WithContext(ctx).Select("*").Omit("site_id").Create(&model).Error; err != nil {
+		return 0, fmt.Errorf("failed to create site: %w", err)
+	}
+	return model.SiteID, nil
+}
+
+func (p *Postgres) GetSite(ctx context.Context, siteID int64) (types.Site, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.Site
+	err := p.GormDB.WithContext(ctx).First(&model, "site_id = ?", siteID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.Site{}, fmt.Errorf("site %d: %w", siteID, storage.ErrNotFound)
+		}
+		return types.Site{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertSiteModelToType(model), nil
+}
+
+func (p *Postgres) ListSites(ctx context.Context, ownerID int64) ([]types.Site, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.Site
+	if err := p.GormDB.WithContext(ctx).Where("owner_id = ?", ownerID).Order("site_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	sites := make([]types.Site, 0, len(rows))
+	for _, row := range rows {
+		sites = append(sites, convertSiteModelToType(row))
+	}
+	return sites, nil
+}
+
+func (p *Postgres) UpdateSite(ctx context.Context, site types.Site) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Model(&models.Site{}).
+		Where("site_id = ?", site.SiteID).
+		Updates(map[string]interface{}{
+			"name":          site.Name,
+			"address":       site.Address,
+			"latitude":      site.Latitude,
+			"longitude":     site.Longitude,
+			"radius_meters": site.RadiusMeters,
+			"dwell_seconds": site.DwellSeconds,
+			"auto_advance":  site.AutoAdvance,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update site: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("site %d: %w", site.SiteID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteSite deletes a site, and the visits of trips to it with the foreign key.
+func (p *Postgres) DeleteSite(ctx context.Context, siteID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Where("site_id = ?", siteID).Delete(&models.Site{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete site: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("site %d: %w", siteID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (p *Postgres) GetSiteVisits(ctx context.Context, tripID int64) ([]types.SiteVisit, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.SiteVisit
+	if err := p.GormDB.WithContext(ctx).Where("trip_id = ?", tripID).Order("site_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	visits := make([]types.SiteVisit, 0, len(rows))
+	for _, row := range rows {
+		visits = append(visits, convertSiteVisitModelToType(row))
+	}
+	return visits, nil
+}
+
+// SaveSiteVisit stores a visit if it is still at the version it was read at, and enqueues event,
+// if not nil, in the same transaction. A new visit has version 0 and conflicts with any stored one.
+func (p *Postgres) SaveSiteVisit(ctx context.Context, visit types.SiteVisit, event *types.GeofenceEvent) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		model := models.SiteVisit{
+			TripID:     visit.TripID,
+			SiteID:     visit.SiteID,
+			DriverID:   visit.DriverID,
+			EnteredAt:  convertOptionalDateTime(visit.EnteredAt),
+			ArrivedAt:  convertOptionalDateTime(visit.ArrivedAt),
+			ExitedAt:   convertOptionalDateTime(visit.ExitedAt),
+			LastSeenAt: visit.LastSeenAt.Time,
+			Version:    visit.Version + 1,
+		}
+
+		var result *gorm.DB
+		if visit.Version == 0 {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+		} else {
+			result = tx.Model(&models.SiteVisit{}).
+				Where("trip_id = ? AND site_id = ? AND version = ?", visit.TripID, visit.SiteID, visit.Version).
+				Updates(map[string]interface{}{
+					"driver_id":    model.DriverID,
+					"entered_at":   model.EnteredAt,
+					"arrived_at":   model.ArrivedAt,
+					"exited_at":    model.ExitedAt,
+					"last_seen_at": model.LastSeenAt,
+					"version":      model.Version,
+				})
+		}
+		if result.Error != nil {
+			return fmt.Errorf("failed to save site visit: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("visit of trip %d to site %d: %w", visit.TripID, visit.SiteID, storage.ErrConflict)
+		}
+
+		if event == nil {
+			return nil
+		}
+		actor := types.Actor{UserID: event.DriverID, Role: "Driver"}
+		return enqueueOutboxEvent(tx, events.Type(event.Event), &actor, *event)
+	})
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// transitionPickupRequest moves a pickup request to the given status, locking the row so that
+// concurrent transitions are serialised, records the change in the status history and enqueues
+// an event of the given type with the updated request through the outbox, all in one transaction.
+// mutate, if non-nil, can set other fields before saving.
+func (p *Postgres) transitionPickupRequest(ctx context.Context, requestID int64, to lifecycle.Status, actor types.Actor, reason string, eventType events.Type, mutate func(*models.PickupRequest)) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var request models.PickupRequest
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, "request_id = ?", requestID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		if err := transition(request, to); err != nil {
+			return err
+		}
+
+		from := request.Status
+		request.Status = string(to)
+		if mutate != nil {
+			mutate(&request)
+		}
+
+		if err := tx.Save(&request).Error; err != nil {
+			return fmt.Errorf("failed to update pickup request status: %w", err)
+		}
+		if err := recordStatusChange(tx, requestID, from, string(to), actor, reason); err != nil {
+			return err
+		}
+		return enqueueOutboxEvent(tx, eventType, &actor, convertPickupRequestModelToType(request))
+	})
+}
+
+// transition validates a status change of request, which open-market requests only go through
+// once a collector wins them.
+func transition(request models.PickupRequest, to lifecycle.Status) error {
+	if request.CollectorID == nil {
+		return lifecycle.TransitionOpen(lifecycle.Status(request.Status), to)
+	}
+	return lifecycle.Transition(lifecycle.Status(request.Status), to)
+}
+
+// cancellation validates a status change made by updating request, which can only cancel it.
+func cancellation(request models.PickupRequest, to lifecycle.Status) error {
+	if to != lifecycle.Cancelled {
+		return &lifecycle.TransitionError{From: lifecycle.Status(request.Status), To: to}
+	}
+	return transition(request, to)
+}
+
+// recordStatusChange appends an entry to the status history of a pickup request.
+func recordStatusChange(tx *gorm.DB, requestID int64, from string, to string, actor types.Actor, reason string) error {
+	entry := models.PickupRequestStatusHistory{
+		RequestID:   requestID,
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		OldStatus:   from,
+		NewStatus:   to,
+		Reason:      reason,
+		ChangedAt:   time.Now(),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record status history: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) GetPickupRequestTimeline(ctx context.Context, requestID int64) ([]types.PickupRequestStatusChange, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var entries []models.PickupRequestStatusHistory
+	err := p.GormDB.WithContext(ctx).Where("request_id = ?", requestID).Order("changed_at ASC, history_id ASC").Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	timeline := make([]types.PickupRequestStatusChange, 0, len(entries))
+	for _, entry := range entries {
+		timeline = append(timeline, convertStatusHistoryModelToType(entry))
+	}
+	return timeline, nil
+}
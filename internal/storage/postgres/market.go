@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (p *Postgres) CreateOpenPickupRequest(ctx context.Context, request types.PickupRequest, invitations []types.MarketInvitation, actor types.Actor) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err := p.GetBusinessByID(ctx, request.BusinessID)
+	if err != nil {
+		return 0, fmt.Errorf("business ID not found: %w", err)
+	}
+
+	model := newPickupRequestModel(request)
+	model.OpenMarket = true
+	err = p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createPickupRequest(tx, &model, actor); err != nil {
+			return err
+		}
+		if len(invitations) == 0 {
+			return nil
+		}
+
+		rows := make([]models.MarketInvitation, len(invitations))
+		for i, invitation := range invitations {
+			rows[i] = models.MarketInvitation{
+				RequestID:   model.RequestID,
+				CollectorID: invitation.CollectorID,
+				PricePerKg:  invitation.PricePerKg,
+				CreatedAt:   model.CreatedAt,
+			}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to invite collectors: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return model.RequestID, nil
+}
+
+func (p *Postgres) ListMarketInvitations(ctx context.Context, requestID int64) ([]types.MarketInvitation, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.MarketInvitation
+	err := p.GormDB.WithContext(ctx).Where("request_id = ?", requestID).Order("collector_id").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	invitations := make([]types.MarketInvitation, len(rows))
+	for i, row := range rows {
+		invitations[i] = convertMarketInvitationModelToType(row)
+	}
+	return invitations, nil
+}
+
+func (p *Postgres) ListOpenPickupRequests(ctx context.Context, collectorID int64) ([]types.OpenPickupRequest, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var requests []models.PickupRequest
+	err := p.GormDB.WithContext(ctx).
+		Where("collector_id IS NULL AND open_market AND status = ?", string(lifecycle.Pending)).
+		Where("request_id IN (?)", p.GormDB.Model(&models.MarketInvitation{}).Select("request_id").Where("collector_id = ?", collectorID)).
+		Order("request_id").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if len(requests) == 0 {
+		return []types.OpenPickupRequest{}, nil
+	}
+
+	requestIDs := make([]int64, len(requests))
+	for i, request := range requests {
+		requestIDs[i] = request.RequestID
+	}
+	var invitations []models.MarketInvitation
+	if err := p.GormDB.WithContext(ctx).Where("collector_id = ? AND request_id IN ?", collectorID, requestIDs).Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	var bids []models.PickupBid
+	if err := p.GormDB.WithContext(ctx).Where("collector_id = ? AND request_id IN ?", collectorID, requestIDs).Find(&bids).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	listPrices := make(map[int64]float64, len(invitations))
+	for _, invitation := range invitations {
+		listPrices[invitation.RequestID] = invitation.PricePerKg
+	}
+	ownBids := make(map[int64]types.PickupBid, len(bids))
+	for _, bid := range bids {
+		ownBids[bid.RequestID] = convertPickupBidModelToType(bid)
+	}
+
+	open := make([]types.OpenPickupRequest, len(requests))
+	for i, request := range requests {
+		open[i] = types.OpenPickupRequest{
+			PickupRequest:  convertPickupRequestModelToType(request),
+			ListPricePerKg: listPrices[request.RequestID],
+		}
+		if bid, ok := ownBids[request.RequestID]; ok {
+			open[i].Bid = &bid
+		}
+	}
+	return open, nil
+}
+
+// PlaceBid locks the request like the acceptances do, so that no bid is placed on a request
+// after it is won.
+func (p *Postgres) PlaceBid(ctx context.Context, bid types.PickupBid) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	model := models.PickupBid{
+		RequestID:   bid.RequestID,
+		CollectorID: bid.CollectorID,
+		PricePerKg:  bid.PricePerKg,
+		WindowStart: bid.WindowStart.Time,
+		WindowEnd:   bid.WindowEnd.Time,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOpenPickupRequest(tx, bid.RequestID); err != nil {
+			return err
+		}
+		if _, err := findMarketInvitation(tx, bid.RequestID, bid.CollectorID); err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "request_id"}, {Name: "collector_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"price_per_kg", "window_start", "window_end", "updated_at"}),
+		}).Create(&model).Error
+		if err != nil {
+			return fmt.Errorf("failed to place bid: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return model.BidID, nil
+}
+
+func (p *Postgres) ListPickupBids(ctx context.Context, requestID int64) ([]types.PickupBid, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.PickupBid
+	err := p.GormDB.WithContext(ctx).Where("request_id = ?", requestID).Order("price_per_kg, bid_id").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	bids := make([]types.PickupBid, len(rows))
+	for i, row := range rows {
+		bids[i] = convertPickupBidModelToType(row)
+	}
+	return bids, nil
+}
+
+func (p *Postgres) AcceptOpenPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		request, err := lockOpenPickupRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		invitation, err := findMarketInvitation(tx, requestID, actor.UserID)
+		if err != nil {
+			return err
+		}
+		return awardPickupRequest(tx, request, actor.UserID, invitation.PricePerKg, nil, nil, actor, "accepted at the list price")
+	})
+}
+
+func (p *Postgres) AcceptPickupBid(ctx context.Context, requestID int64, bidID int64, actor types.Actor) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		request, err := lockOpenPickupRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		var bid models.PickupBid
+		err = tx.First(&bid, "bid_id = ? AND request_id = ?", bidID, requestID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("bid %d on pickup request %d: %w", bidID, requestID, storage.ErrNotFound)
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+		reason := fmt.Sprintf("bid %d accepted", bidID)
+		return awardPickupRequest(tx, request, bid.CollectorID, bid.PricePerKg, &bid.WindowStart, &bid.WindowEnd, actor, reason)
+	})
+}
+
+// lockOpenPickupRequest locks an open-market pickup request until the end of tx, failing if no
+// collector can win it anymore.
+func lockOpenPickupRequest(tx *gorm.DB, requestID int64) (models.PickupRequest, error) {
+	var request models.PickupRequest
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, "request_id = ?", requestID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return request, fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotFound)
+		}
+		return request, fmt.Errorf("database error: %w", err)
+	}
+	if !request.OpenMarket || request.CollectorID != nil || request.Status != string(lifecycle.Pending) {
+		return request, fmt.Errorf("pickup request %d: %w", requestID, storage.ErrNotOpen)
+	}
+	return request, nil
+}
+
+func findMarketInvitation(tx *gorm.DB, requestID int64, collectorID int64) (models.MarketInvitation, error) {
+	var invitation models.MarketInvitation
+	err := tx.First(&invitation, "request_id = ? AND collector_id = ?", requestID, collectorID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invitation, fmt.Errorf("invitation of collector %d to pickup request %d: %w", collectorID, requestID, storage.ErrNotFound)
+		}
+		return invitation, fmt.Errorf("database error: %w", err)
+	}
+	return invitation, nil
+}
+
+// awardPickupRequest accepts a locked open-market request for a collector, at the agreed price
+// and window, recording the change and enqueueing the pickup.accepted event like any acceptance.
+func awardPickupRequest(tx *gorm.DB, request models.PickupRequest, collectorID int64, price float64, windowStart *time.Time, windowEnd *time.Time, actor types.Actor, reason string) error {
+	if err := lifecycle.Transition(lifecycle.Status(request.Status), lifecycle.Accepted); err != nil {
+		return err
+	}
+
+	from := request.Status
+	request.Status = string(lifecycle.Accepted)
+	request.CollectorID = &collectorID
+	request.PricePerKg = &price
+	request.WindowStart, request.WindowEnd = windowStart, windowEnd
+	if err := tx.Save(&request).Error; err != nil {
+		return fmt.Errorf("failed to award pickup request: %w", err)
+	}
+	if err := recordStatusChange(tx, request.RequestID, from, request.Status, actor, reason); err != nil {
+		return err
+	}
+	return enqueueOutboxEvent(tx, events.PickupAccepted, &actor, convertPickupRequestModelToType(request))
+}
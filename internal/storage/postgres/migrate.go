@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+)
+
+// Migrations are pairs of files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// applied in the order of their version. Every migration runs in its own transaction.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating, so that replicas booting
+// at the same time apply every migration once.
+const migrationLockID int64 = 7_301_944_218
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is a known migration, or an applied one this build does not know about.
+type MigrationStatus struct {
+	Version   int64
+	Name      string     // Empty for migrations unknown to this build
+	AppliedAt *time.Time // Nil if pending
+}
+
+// Migrator applies the migrations embedded in the binary and records them in the
+// schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+// NewMigrator opens the database of the config for the migrate command. Close it when done.
+func NewMigrator(cfg *config.Config) (*Migrator, error) {
+	gormDB, err := models.SetupGorm(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get *sql.DB from GORM: %w", err)
+	}
+	return newMigrator(sqlDB)
+}
+
+func newMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+func loadMigrations() ([]migration, error) {
+	paths, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, path := range paths {
+		file := strings.TrimPrefix(path, "migrations/")
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", file)
+		}
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", file)
+		}
+
+		data, err := migrationFiles.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: name}
+			byVersion[version] = mig
+		} else if mig.name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, mig.name, name)
+		}
+		if direction == "up" {
+			mig.up = string(data)
+		} else {
+			mig.down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", mig.version, mig.name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Up applies every pending migration and returns how many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.version]; ok {
+				continue
+			}
+			err := m.apply(ctx, conn, mig, mig.up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())", mig.version, mig.name)
+			if err != nil {
+				return err
+			}
+			slog.Info("migration applied", slog.Int64("version", mig.version), slog.String("name", mig.name))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the last steps applied migrations and returns how many it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.version]; !ok {
+				continue
+			}
+			err := m.apply(ctx, conn, mig, mig.down, "DELETE FROM schema_migrations WHERE version = $1", mig.version)
+			if err != nil {
+				return err
+			}
+			slog.Info("migration reverted", slog.Int64("version", mig.version), slog.String("name", mig.name))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status returns every migration in the order of their version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, mig := range m.migrations {
+			status := MigrationStatus{Version: mig.version, Name: mig.name}
+			if appliedAt, ok := applied[mig.version]; ok {
+				status.AppliedAt = &appliedAt
+				delete(applied, mig.version)
+			}
+			statuses = append(statuses, status)
+		}
+		for version, appliedAt := range applied {
+			statuses = append(statuses, MigrationStatus{Version: version, AppliedAt: &appliedAt})
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// locked runs fn on a connection holding the migration lock, with the versions applied so far.
+// Advisory locks belong to a session, hence the dedicated connection.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer func() {
+		// Not using ctx, the lock has to be released even if it was cancelled
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			slog.Error("failed to release the migration lock", slog.String("error", err.Error()))
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	rows.Close()
+
+	return fn(conn, applied)
+}
+
+// apply runs the script of a migration and the statement recording it in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig migration, script string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d_%s: %w", mig.version, mig.name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", mig.version, mig.name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", mig.version, mig.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", mig.version, mig.name, err)
+	}
+	return nil
+}
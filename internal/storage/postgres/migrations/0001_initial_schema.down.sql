@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS
+    webhook_deliveries,
+    webhook_endpoints,
+    notifications,
+    notification_templates,
+    processed_events,
+    dead_letters,
+    outbox_events,
+    pickup_request_status_histories,
+    pickup_requests,
+    vehicle_drivers,
+    collector_vehicles,
+    collector_drivers,
+    vehicles,
+    collector_service_categories,
+    service_categories,
+    collectors,
+    businesses,
+    users;
@@ -0,0 +1,219 @@
+-- The schema GORM AutoMigrate created before migrations were versioned. Every statement is a
+-- no-op on a database it already migrated, so that such databases are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id               bigserial PRIMARY KEY,
+    email                 text NOT NULL CONSTRAINT uni_users_email UNIQUE,
+    password_hash         text NOT NULL,
+    full_name             text NOT NULL,
+    phone_number          varchar(20),
+    address               text,
+    registration_date     timestamptz NOT NULL,
+    role                  text NOT NULL CONSTRAINT chk_users_role CHECK (role IN ('Business','Collector','Admin','Government','Driver')),
+    is_active             boolean NOT NULL DEFAULT true,
+    profile_image         text,
+    last_login            timestamptz,
+    is_verified           boolean NOT NULL DEFAULT false,
+    is_flagged            boolean NOT NULL DEFAULT false,
+    notification_channels varchar(255),
+    locale                varchar(10)
+);
+
+CREATE TABLE IF NOT EXISTS businesses (
+    user_id             bigint PRIMARY KEY CONSTRAINT fk_users_business REFERENCES users (user_id) ON DELETE CASCADE,
+    business_name       varchar(255) NOT NULL,
+    business_type       varchar(255) NOT NULL,
+    registration_number varchar(100) NOT NULL CONSTRAINT uni_businesses_registration_number UNIQUE,
+    gst_id              varchar(50) NOT NULL CONSTRAINT uni_businesses_gst_id UNIQUE,
+    business_address    text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS collectors (
+    user_id        bigint PRIMARY KEY CONSTRAINT fk_users_collector REFERENCES users (user_id) ON DELETE CASCADE,
+    company_name   varchar(255) NOT NULL,
+    license_number varchar(100) NOT NULL CONSTRAINT uni_collectors_license_number UNIQUE,
+    capacity       bigint NOT NULL,
+    license_expiry timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS service_categories (
+    category_id bigserial PRIMARY KEY,
+    waste_type  varchar(100) NOT NULL CONSTRAINT uni_service_categories_waste_type UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS collector_service_categories (
+    collector_id          bigint CONSTRAINT fk_collectors_collector_service_categories REFERENCES collectors (user_id) ON DELETE CASCADE,
+    category_id           bigint CONSTRAINT fk_service_categories_collector_service_categories REFERENCES service_categories (category_id) ON DELETE CASCADE,
+    price_per_kg          decimal(10,2) NOT NULL,
+    maximum_capacity      decimal(10,2) NOT NULL,
+    handling_requirements text,
+    PRIMARY KEY (collector_id, category_id)
+);
+
+CREATE TABLE IF NOT EXISTS vehicles (
+    vehicle_id   bigserial PRIMARY KEY,
+    vehicle_type varchar(50) NOT NULL,
+    capacity     decimal(10,2) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_type_capacity ON vehicles (vehicle_type, capacity);
+
+CREATE TABLE IF NOT EXISTS collector_drivers (
+    driver_id      bigint PRIMARY KEY CONSTRAINT fk_users_driver REFERENCES users (user_id) ON DELETE CASCADE,
+    collector_id   bigint CONSTRAINT fk_collectors_collector_drivers REFERENCES collectors (user_id) ON DELETE CASCADE,
+    license_number varchar(100) NOT NULL CONSTRAINT uni_collector_drivers_license_number UNIQUE,
+    driver_name    varchar(100) NOT NULL,
+    license_expiry timestamptz NOT NULL,
+    is_employed    boolean NOT NULL DEFAULT true,
+    is_active      boolean NOT NULL DEFAULT true,
+    rating         decimal(3,2),
+    joining_date   timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS collector_vehicles (
+    collector_id          bigint CONSTRAINT fk_collectors_collector_vehicles REFERENCES collectors (user_id) ON DELETE CASCADE,
+    vehicle_id            bigint CONSTRAINT fk_vehicles_collector_vehicles REFERENCES vehicles (vehicle_id) ON DELETE CASCADE,
+    vehicle_number        varchar(50) NOT NULL CONSTRAINT uni_collector_vehicles_vehicle_number UNIQUE,
+    maintenance_date      timestamptz,
+    is_active             boolean NOT NULL DEFAULT true,
+    gps_tracking_id       varchar(100),
+    registration_document text,
+    registration_expiry   timestamptz,
+    PRIMARY KEY (collector_id, vehicle_id)
+);
+
+CREATE TABLE IF NOT EXISTS vehicle_drivers (
+    driver_id    bigint PRIMARY KEY CONSTRAINT fk_collector_drivers_vehicle_driver REFERENCES collector_drivers (driver_id) ON DELETE CASCADE,
+    collector_id bigint,
+    vehicle_id   bigint CONSTRAINT fk_vehicles_vehicle_drivers REFERENCES vehicles (vehicle_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS pickup_requests (
+    request_id            bigserial PRIMARY KEY,
+    business_id           bigint NOT NULL CONSTRAINT fk_businesses_pickup_requests REFERENCES businesses (user_id) ON DELETE CASCADE,
+    collector_id          bigint NOT NULL CONSTRAINT fk_collectors_pickup_requests REFERENCES collectors (user_id) ON DELETE CASCADE,
+    waste_type            varchar(100) NOT NULL,
+    quantity              decimal(10,2) NOT NULL,
+    pickup_date           timestamptz NOT NULL,
+    status                varchar(50) NOT NULL DEFAULT 'Pending',
+    handling_requirements text,
+    assigned_driver       bigint,
+    assigned_vehicle      bigint,
+    created_at            timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pickup_requests_business_id ON pickup_requests (business_id);
+CREATE INDEX IF NOT EXISTS idx_pickup_requests_collector_id ON pickup_requests (collector_id);
+
+-- Older databases have the check from before the whole lifecycle existed
+ALTER TABLE pickup_requests DROP CONSTRAINT IF EXISTS chk_pickup_requests_status;
+ALTER TABLE pickup_requests ADD CONSTRAINT chk_pickup_requests_status
+    CHECK (status IN ('Pending','Accepted','Rejected','Assigned','InTransit','Completed','Cancelled'));
+
+CREATE TABLE IF NOT EXISTS pickup_request_status_histories (
+    history_id    bigserial PRIMARY KEY,
+    request_id    bigint NOT NULL CONSTRAINT fk_pickup_requests_status_history REFERENCES pickup_requests (request_id) ON DELETE CASCADE,
+    actor_user_id bigint,
+    actor_role    varchar(50),
+    old_status    varchar(50),
+    new_status    varchar(50) NOT NULL,
+    reason        text,
+    changed_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pickup_request_status_histories_request_id ON pickup_request_status_histories (request_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    outbox_id       bigserial PRIMARY KEY,
+    topic           varchar(100) NOT NULL,
+    payload         bytea NOT NULL,
+    attributes      text,
+    status          varchar(20) NOT NULL DEFAULT 'pending' CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending','delivered')),
+    attempts        bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error      text,
+    created_at      timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_status_next_attempt ON outbox_events (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+    dead_letter_id  bigserial PRIMARY KEY,
+    subscription_id varchar(255) NOT NULL,
+    topic           varchar(100) NOT NULL,
+    message_id      varchar(255),
+    event_id        varchar(64),
+    event_type      varchar(100),
+    payload         bytea NOT NULL,
+    attributes      text,
+    attempts        bigint NOT NULL,
+    last_error      text,
+    status          varchar(20) NOT NULL DEFAULT 'pending' CONSTRAINT chk_dead_letters_status CHECK (status IN ('pending','replayed','discarded')),
+    created_at      timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_subscription_id ON dead_letters (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_status ON dead_letters (status);
+
+CREATE TABLE IF NOT EXISTS processed_events (
+    subscription_id varchar(255),
+    event_id        varchar(255),
+    processed_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at      timestamptz NOT NULL,
+    PRIMARY KEY (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_processed_events_expires_at ON processed_events (expires_at);
+
+CREATE TABLE IF NOT EXISTS notification_templates (
+    name       varchar(100),
+    locale     varchar(10),
+    subject    text NOT NULL,
+    text_body  text NOT NULL,
+    html_body  text,
+    updated_by bigint,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (name, locale)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id   bigserial PRIMARY KEY,
+    user_id           bigint NOT NULL,
+    event_id          varchar(64),
+    type              varchar(100) NOT NULL,
+    pickup_request_id bigint,
+    heading           text NOT NULL,
+    message           text NOT NULL,
+    is_read           boolean NOT NULL DEFAULT false,
+    created_at        timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at           timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications (user_id, is_read);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_event ON notifications (user_id, event_id, type);
+CREATE INDEX IF NOT EXISTS idx_notifications_pickup_request_id ON notifications (pickup_request_id);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    endpoint_id bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    url         varchar(2048) NOT NULL,
+    description varchar(255),
+    secret      varchar(100) NOT NULL,
+    event_types text NOT NULL,
+    is_active   boolean NOT NULL DEFAULT true,
+    created_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON webhook_endpoints (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id      bigserial PRIMARY KEY,
+    endpoint_id      bigint NOT NULL,
+    event_id         varchar(64) NOT NULL,
+    event_type       varchar(100) NOT NULL,
+    payload          bytea NOT NULL,
+    status           varchar(20) NOT NULL DEFAULT 'pending' CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('pending','succeeded','failed')),
+    attempts         bigint NOT NULL DEFAULT 0,
+    last_status_code bigint,
+    last_error       text,
+    next_attempt_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at       timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (endpoint_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt ON webhook_deliveries (status, next_attempt_at);
@@ -0,0 +1,19 @@
+-- models.DriverLocation was never part of AutoMigrate, so this table did not exist yet.
+
+CREATE TABLE driver_locations (
+    location_id  bigserial PRIMARY KEY,
+    driver_id    bigint CONSTRAINT fk_collector_drivers_locations REFERENCES collector_drivers (driver_id) ON DELETE CASCADE,
+    collector_id bigint,
+    vehicle_id   bigint CONSTRAINT fk_vehicles_locations REFERENCES vehicles (vehicle_id) ON DELETE CASCADE,
+    latitude     decimal(10,6) NOT NULL,
+    longitude    decimal(10,6) NOT NULL,
+    timestamp    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accuracy     decimal(5,2),
+    speed        decimal(6,2),
+    bearing      decimal(5,2),
+    date         timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    point        text NOT NULL
+);
+CREATE INDEX idx_driver_locations_driver_id ON driver_locations (driver_id);
+CREATE INDEX idx_driver_locations_collector_id ON driver_locations (collector_id);
+CREATE INDEX idx_driver_locations_vehicle_id ON driver_locations (vehicle_id);
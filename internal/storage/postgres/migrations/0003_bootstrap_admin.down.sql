@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS admin_audit_records;
+
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN must_change_password boolean NOT NULL DEFAULT false;
+
+-- The admin every boot used to ensure, which still has the default password as there was no way
+-- to change it
+UPDATE users SET must_change_password = true WHERE email = 'admin@gmail.com' AND role = 'Admin';
+
+-- Not referencing users, the trail is kept even if an admin is removed
+CREATE TABLE admin_audit_records (
+    audit_id      bigserial PRIMARY KEY,
+    action        varchar(20) NOT NULL CONSTRAINT chk_admin_audit_records_action CHECK (action IN ('bootstrap','create')),
+    admin_user_id bigint NOT NULL,
+    actor_user_id bigint,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
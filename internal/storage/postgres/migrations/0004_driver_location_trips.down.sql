@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_driver_locations_trip;
+ALTER TABLE driver_locations DROP COLUMN IF EXISTS trip_id;
@@ -0,0 +1,6 @@
+-- Locations belong to the trip, the pickup request being delivered, so that a trip's track and
+-- its START and END points can be read back.
+
+ALTER TABLE driver_locations ADD COLUMN trip_id bigint
+    CONSTRAINT fk_pickup_requests_driver_locations REFERENCES pickup_requests (request_id) ON DELETE CASCADE;
+CREATE INDEX idx_driver_locations_trip ON driver_locations (trip_id, timestamp);
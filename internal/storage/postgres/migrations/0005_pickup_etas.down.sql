@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS pickup_request_etas;
+
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS pickup_longitude;
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS pickup_latitude;
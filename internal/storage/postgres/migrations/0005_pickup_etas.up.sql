@@ -0,0 +1,16 @@
+-- Pickup sites get coordinates, and the rolling ETA of the assigned driver is kept per request.
+
+ALTER TABLE pickup_requests ADD COLUMN pickup_latitude decimal(10,6);
+ALTER TABLE pickup_requests ADD COLUMN pickup_longitude decimal(10,6);
+
+CREATE TABLE pickup_request_etas (
+    request_id       bigint PRIMARY KEY CONSTRAINT fk_pickup_requests_eta REFERENCES pickup_requests (request_id) ON DELETE CASCADE,
+    driver_id        bigint NOT NULL,
+    driver_latitude  decimal(10,6) NOT NULL,
+    driver_longitude decimal(10,6) NOT NULL,
+    distance_meters  double precision NOT NULL,
+    speed_kmh        double precision NOT NULL,
+    arrival_at       timestamptz NOT NULL,
+    located_at       timestamptz NOT NULL,
+    calculated_at    timestamptz NOT NULL
+);
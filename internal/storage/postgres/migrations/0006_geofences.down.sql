@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS site_visits;
+DROP TABLE IF EXISTS sites;
@@ -0,0 +1,30 @@
+-- Sites are the geofenced pickup locations of businesses and drop-off facilities of collectors.
+-- A visit is where a trip stands at a site, from which arrivals and departures are detected.
+
+CREATE TABLE sites (
+    site_id       bigserial PRIMARY KEY,
+    owner_id      bigint NOT NULL CONSTRAINT fk_users_sites REFERENCES users (user_id) ON DELETE CASCADE,
+    kind          varchar(20) NOT NULL CONSTRAINT chk_sites_kind CHECK (kind IN ('pickup','dropoff')),
+    name          varchar(255) NOT NULL,
+    address       varchar(500),
+    latitude      decimal(10,6) NOT NULL,
+    longitude     decimal(10,6) NOT NULL,
+    radius_meters double precision,
+    dwell_seconds bigint,
+    auto_advance  boolean NOT NULL DEFAULT false,
+    created_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_sites_owner_id ON sites (owner_id);
+
+CREATE TABLE site_visits (
+    trip_id      bigint NOT NULL CONSTRAINT fk_pickup_requests_site_visits REFERENCES pickup_requests (request_id) ON DELETE CASCADE,
+    site_id      bigint NOT NULL CONSTRAINT fk_sites_visits REFERENCES sites (site_id) ON DELETE CASCADE,
+    driver_id    bigint NOT NULL,
+    entered_at   timestamptz,
+    arrived_at   timestamptz,
+    exited_at    timestamptz,
+    last_seen_at timestamptz NOT NULL,
+    version      bigint NOT NULL,
+    PRIMARY KEY (trip_id, site_id)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_collectors_location;
+
+ALTER TABLE collectors DROP COLUMN IF EXISTS longitude;
+ALTER TABLE collectors DROP COLUMN IF EXISTS latitude;
+ALTER TABLE businesses DROP COLUMN IF EXISTS longitude;
+ALTER TABLE businesses DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,9 @@
+-- Businesses are located at their business address and collectors at their depot, geocoded
+-- from the address when registering unless given. Either is NULL when it could not be located.
+
+ALTER TABLE businesses ADD COLUMN latitude decimal(10,6);
+ALTER TABLE businesses ADD COLUMN longitude decimal(10,6);
+
+ALTER TABLE collectors ADD COLUMN latitude decimal(10,6);
+ALTER TABLE collectors ADD COLUMN longitude decimal(10,6);
+CREATE INDEX idx_collectors_location ON collectors (latitude, longitude);
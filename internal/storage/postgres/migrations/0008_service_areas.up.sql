@@ -0,0 +1,16 @@
+-- Service areas are where collectors take pickups from, as pincodes or a GeoJSON polygon, for
+-- one of their categories or, without one, for all of them.
+
+CREATE TABLE service_areas (
+    area_id      bigserial PRIMARY KEY,
+    collector_id bigint NOT NULL CONSTRAINT fk_collectors_service_areas REFERENCES collectors (user_id) ON DELETE CASCADE,
+    category_id  bigint CONSTRAINT fk_service_categories_service_areas REFERENCES service_categories (category_id) ON DELETE CASCADE,
+    name         varchar(255) NOT NULL,
+    pincodes     text,
+    polygon      text,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_service_areas_shape CHECK ((pincodes IS NULL) <> (polygon IS NULL))
+);
+CREATE INDEX idx_service_areas_collector_id ON service_areas (collector_id);
+CREATE INDEX idx_service_areas_category_id ON service_areas (category_id);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS pickup_bids;
+DROP TABLE IF EXISTS market_invitations;
+
+-- Open requests have no collector to fall back on
+DELETE FROM pickup_requests WHERE collector_id IS NULL;
+
+ALTER TABLE pickup_requests DROP CONSTRAINT IF EXISTS chk_pickup_requests_collector;
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS window_end;
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS window_start;
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS price_per_kg;
+ALTER TABLE pickup_requests DROP COLUMN IF EXISTS open_market;
+ALTER TABLE pickup_requests ALTER COLUMN collector_id SET NOT NULL;
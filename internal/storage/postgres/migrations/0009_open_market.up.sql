@@ -0,0 +1,34 @@
+-- Pickup requests made without a collector are open to the collectors able to take them, who
+-- are invited when the request is made. They bid a price and a pickup window, or accept at the
+-- price they listed, and the request goes to the bid the business picks or the first
+-- acceptance, at the agreed price and window.
+
+ALTER TABLE pickup_requests ALTER COLUMN collector_id DROP NOT NULL;
+ALTER TABLE pickup_requests ADD COLUMN open_market boolean NOT NULL DEFAULT false;
+ALTER TABLE pickup_requests ADD COLUMN price_per_kg decimal(10,2);
+ALTER TABLE pickup_requests ADD COLUMN window_start timestamptz;
+ALTER TABLE pickup_requests ADD COLUMN window_end timestamptz;
+ALTER TABLE pickup_requests ADD CONSTRAINT chk_pickup_requests_collector
+    CHECK (open_market OR collector_id IS NOT NULL);
+
+CREATE TABLE market_invitations (
+    request_id   bigint NOT NULL CONSTRAINT fk_pickup_requests_market_invitations REFERENCES pickup_requests (request_id) ON DELETE CASCADE,
+    collector_id bigint NOT NULL CONSTRAINT fk_collectors_market_invitations REFERENCES collectors (user_id) ON DELETE CASCADE,
+    price_per_kg decimal(10,2) NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (request_id, collector_id)
+);
+CREATE INDEX idx_market_invitations_collector_id ON market_invitations (collector_id);
+
+CREATE TABLE pickup_bids (
+    bid_id       bigserial PRIMARY KEY,
+    request_id   bigint NOT NULL CONSTRAINT fk_pickup_requests_pickup_bids REFERENCES pickup_requests (request_id) ON DELETE CASCADE,
+    collector_id bigint NOT NULL CONSTRAINT fk_collectors_pickup_bids REFERENCES collectors (user_id) ON DELETE CASCADE,
+    price_per_kg decimal(10,2) NOT NULL,
+    window_start timestamptz NOT NULL,
+    window_end   timestamptz NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uni_pickup_bids_request_collector UNIQUE (request_id, collector_id),
+    CONSTRAINT chk_pickup_bids_window CHECK (window_end > window_start)
+);
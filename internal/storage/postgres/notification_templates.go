@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (p *Postgres) ListNotificationTemplates(ctx context.Context) ([]types.NotificationTemplate, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.NotificationTemplate
+	if err := p.GormDB.WithContext(ctx).Order("name ASC, locale ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	templates := make([]types.NotificationTemplate, 0, len(rows))
+	for _, row := range rows {
+		templates = append(templates, convertNotificationTemplateModelToType(row))
+	}
+	return templates, nil
+}
+
+func (p *Postgres) GetNotificationTemplate(ctx context.Context, name string, locale string) (types.NotificationTemplate, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var row models.NotificationTemplate
+	err := p.GormDB.WithContext(ctx).First(&row, "name = ? AND locale = ?", name, locale).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.NotificationTemplate{}, fmt.Errorf("notification template %s (%s): %w", name, locale, storage.ErrNotFound)
+		}
+		return types.NotificationTemplate{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertNotificationTemplateModelToType(row), nil
+}
+
+// SaveNotificationTemplate creates or replaces the override of a template in a locale.
+func (p *Postgres) SaveNotificationTemplate(ctx context.Context, template types.NotificationTemplate) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	row := models.NotificationTemplate{
+		Name:      template.Name,
+		Locale:    template.Locale,
+		Subject:   template.Subject,
+		TextBody:  template.Text,
+		HTMLBody:  template.HTML,
+		UpdatedBy: template.UpdatedBy,
+		UpdatedAt: time.Now(),
+	}
+
+	err := p.GormDB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "text_body", "html_body", "updated_by", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to save notification template: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteNotificationTemplate(ctx context.Context, name string, locale string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Where("name = ? AND locale = ?", name, locale).Delete(&models.NotificationTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification template %s (%s): %w", name, locale, storage.ErrNotFound)
+	}
+	return nil
+}
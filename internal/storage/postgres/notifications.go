@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm/clause"
+)
+
+func (p *Postgres) CreateNotification(ctx context.Context, notification types.Notification) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	model := models.Notification{
+		UserID:    notification.UserID,
+		Type:      notification.Type,
+		Heading:   notification.Heading,
+		Message:   notification.Message,
+		CreatedAt: time.Now(),
+	}
+	if notification.EventID != "" {
+		model.EventID = &notification.EventID
+	}
+	if notification.PickupRequestID != 0 {
+		model.PickupRequestID = &notification.PickupRequestID
+	}
+
+	// A redelivered event must not show up twice in the inbox
+	err := p.GormDB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_id"}, {Name: "type"}},
+		DoNothing: true,
+	}).Create(&model).Error
+	if err != nil {
+		return fmt.Errorf("failed to store notification: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) ListNotifications(ctx context.Context, userID int64, unreadOnly bool, limit int, offset int) ([]types.Notification, int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	query := p.GormDB.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("database error: %w", err)
+	}
+
+	var rows []models.Notification
+	err := query.Order("created_at DESC, notification_id DESC").Limit(limit).Offset(offset).Find(&rows).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("database error: %w", err)
+	}
+
+	notifications := make([]types.Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, convertNotificationModelToType(row))
+	}
+	return notifications, total, nil
+}
+
+func (p *Postgres) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var count int64
+	err := p.GormDB.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead marks one of the user's notifications read. Marking a read notification
+// again keeps its original read time.
+func (p *Postgres) MarkNotificationRead(ctx context.Context, userID int64, notificationID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.Notification
+	err := p.GormDB.WithContext(ctx).Select("notification_id").
+		Where("notification_id = ? AND user_id = ?", notificationID, userID).
+		Limit(1).Find(&model).Error
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if model.NotificationID == 0 {
+		return fmt.Errorf("notification %d: %w", notificationID, storage.ErrNotFound)
+	}
+
+	err = p.GormDB.WithContext(ctx).Model(&models.Notification{}).
+		Where("notification_id = ? AND is_read = ?", notificationID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) MarkAllNotificationsRead(ctx context.Context, userID int64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
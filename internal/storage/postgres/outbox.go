@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// enqueueOutboxEvent wraps payload in an event envelope and stores it in the outbox as part of tx,
+// so that it is only published by the outbox relay if the state change it describes is committed.
+func enqueueOutboxEvent(tx *gorm.DB, eventType events.Type, actor *types.Actor, payload any) error {
+	envelope, err := events.New(eventType, actor, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	attributes, err := json.Marshal(envelope.Attributes())
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox attributes: %w", err)
+	}
+
+	now := time.Now()
+	event := models.OutboxEvent{
+		Topic:         eventType.Topic(),
+		Payload:       data,
+		Attributes:    string(attributes),
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxEvents returns up to limit pending events that are due for publishing. The claimed
+// rows are leased for the given duration so that other relay replicas skip them meanwhile.
+func (p *Postgres) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]types.OutboxEvent, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.OutboxEvent
+
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+			Order("outbox_id ASC").
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.OutboxID
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("outbox_id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	events := make([]types.OutboxEvent, 0, len(rows))
+	for _, row := range rows {
+		event, err := convertOutboxEventModelToType(row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (p *Postgres) MarkOutboxEventDelivered(ctx context.Context, outboxID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	result := p.GormDB.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("outbox_id = ?", outboxID).
+		Updates(map[string]interface{}{
+			"status":       "delivered",
+			"delivered_at": &now,
+			"last_error":   "",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark outbox event delivered: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("outbox event with ID %d not found", outboxID)
+	}
+	return nil
+}
+
+func (p *Postgres) MarkOutboxEventFailed(ctx context.Context, outboxID int64, lastError string, nextAttemptAt time.Time) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("outbox_id = ?", outboxID).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("outbox event with ID %d not found", outboxID)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", "delivered", before).
+		Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete delivered outbox events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
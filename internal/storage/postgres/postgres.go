@@ -1,19 +1,21 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/kartikey1188/build-in-progress_01/internal/config"
 	"github.com/kartikey1188/build-in-progress_01/internal/models"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type Postgres struct {
 	GormDB *gorm.DB
 	SqlDB  *sql.DB
+
+	queryTimeout time.Duration // Bounds every storage call on top of the caller's deadline, 0 for none
 }
 
 func New(cfg *config.Config) (*Postgres, error) {
@@ -27,60 +29,28 @@ func New(cfg *config.Config) (*Postgres, error) {
 		return nil, fmt.Errorf("failed to get *sql.DB from GORM: %w", err)
 	}
 
-	if err := autoMigrateTables(gormDB); err != nil {
-		return nil, fmt.Errorf("failed to auto-migrate tables: %w", err)
-	}
-
-	if err := createAdminUser(gormDB); err != nil {
-		return nil, fmt.Errorf("failed to create admin user: %w", err)
+	if cfg.MigrateOnStart {
+		migrator, err := newMigrator(sqlDB)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to migrate the database: %w", err)
+		}
 	}
 
 	return &Postgres{
-		GormDB: gormDB,
-		SqlDB:  sqlDB,
+		GormDB:       gormDB,
+		SqlDB:        sqlDB,
+		queryTimeout: cfg.QueryTimeout,
 	}, nil
 }
 
-func autoMigrateTables(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.Business{},
-		&models.Collector{},
-		&models.ServiceCategory{},
-		&models.CollectorServiceCategory{},
-		&models.Vehicle{},
-		&models.CollectorDriver{},
-		&models.CollectorVehicle{},
-		&models.VehicleDriver{},
-		&models.PickupRequest{},
-	)
-}
-
-func createAdminUser(db *gorm.DB) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("failed to hash admin password: %w", err)
+// withTimeout derives the context the queries of a storage call run with, so that a slow query
+// is cancelled after the query timeout even if the caller would wait longer.
+func (p *Postgres) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-
-	adminUser := models.User{
-		Email:        "admin@gmail.com",
-		PasswordHash: string(hashedPassword),
-		FullName:     "Application Admin",
-		Role:         "Admin",
-		IsActive:     true,
-		IsVerified:   true,
-		IsFlagged:    false,
-		PhoneNumber:  "10000000",
-		Address:      "N/A",
-		ProfileImage: "default.jpg",
-		Registration: time.Now(),
-		LastLogin:    time.Now(),
-	}
-
-	result := db.Where(models.User{Email: adminUser.Email}).FirstOrCreate(&adminUser)
-	if result.Error != nil {
-		return fmt.Errorf("failed to create admin user: %w", result.Error)
-	}
-
-	return nil
+	return context.WithTimeout(ctx, p.queryTimeout)
 }
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/config"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage/storagetest"
+)
+
+// TestConformance runs the storage conformance suite against the database TEST_DATABASE_URL
+// points to. Every table in it but schema_migrations is emptied before each test.
+func TestConformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	p, err := New(&config.Config{StoragePath: url, MigrateOnStart: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.SqlDB.Close()
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		var tables []string
+		err := p.GormDB.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'").Scan(&tables).Error
+		if err != nil {
+			t.Fatalf("failed to list tables: %v", err)
+		}
+		if err := p.GormDB.Exec("TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+			t.Fatalf("failed to empty tables: %v", err)
+		}
+		return p
+	})
+}
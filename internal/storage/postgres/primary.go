@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -8,9 +9,12 @@ import (
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
 
-func (p *Postgres) CreateUser(user types.User) (int64, error) {
+func (p *Postgres) CreateUser(ctx context.Context, user types.User) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var lastID int64
-	err := p.SqlDB.QueryRow(`
+	err := p.SqlDB.QueryRowContext(ctx, `
 		INSERT INTO users (
 			email, password_hash, full_name, phone_number, address,
 			registration_date, role, is_active, profile_image,
@@ -30,47 +34,83 @@ func (p *Postgres) CreateUser(user types.User) (int64, error) {
 	return lastID, nil
 }
 
-func (p *Postgres) UpdateLastLogin(userID int64, lastLogin types.DateTime) error {
-	_, err := p.SqlDB.Exec(`UPDATE users SET last_login = $1 WHERE user_id = $2`, lastLogin.Time, userID)
+func (p *Postgres) UpdateLastLogin(ctx context.Context, userID int64, lastLogin types.DateTime) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	_, err := p.SqlDB.ExecContext(ctx, `UPDATE users SET last_login = $1 WHERE user_id = $2`, lastLogin.Time, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update last login: %w", err)
 	}
 	return nil
 }
 
-func (p *Postgres) CreateCollectorUser(user types.Collector) (int64, error) {
-	id, err := p.CreateUser(user.User)
+func (p *Postgres) ChangePassword(ctx context.Context, userID int64, passwordHash string) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result, err := p.SqlDB.ExecContext(ctx,
+		`UPDATE users SET password_hash = $1, must_change_password = false WHERE user_id = $2`, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", userID)
+	}
+
+	return nil
+}
+
+func (p *Postgres) CreateCollectorUser(ctx context.Context, user types.Collector) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	id, err := p.CreateUser(ctx, user.User)
 	if err != nil {
 		return 0, err
 	}
-	_, err = p.SqlDB.Exec(`
+	_, err = p.SqlDB.ExecContext(ctx, `
 		INSERT INTO collectors (
-			user_id, company_name, license_number, capacity, license_expiry
-		) VALUES ($1, $2, $3, $4, $5)`,
-		id, user.Company_name, user.License_number, user.Capacity, user.License_expiry.Time)
+			user_id, company_name, license_number, capacity, license_expiry, latitude, longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, user.Company_name, user.License_number, user.Capacity, user.License_expiry.Time, user.Latitude, user.Longitude)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert collector: %w", err)
 	}
 	return id, nil
 }
 
-func (p *Postgres) CreateBusinessUser(user types.Business) (int64, error) {
-	id, err := p.CreateUser(user.User)
+func (p *Postgres) CreateBusinessUser(ctx context.Context, user types.Business) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	id, err := p.CreateUser(ctx, user.User)
 	if err != nil {
 		return 0, err
 	}
-	_, err = p.SqlDB.Exec(`
+	_, err = p.SqlDB.ExecContext(ctx, `
 		INSERT INTO businesses (
-			user_id, business_name, business_type, registration_number, gst_id, business_address
-		) VALUES ($1, $2, $3, $4, $5, $6)`,
-		id, user.Business_name, user.Business_type, user.Registration_number, user.Gst_id, user.Business_address)
+			user_id, business_name, business_type, registration_number, gst_id, business_address,
+			latitude, longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		id, user.Business_name, user.Business_type, user.Registration_number, user.Gst_id, user.Business_address,
+		user.Latitude, user.Longitude)
 	if err != nil {
 		return 0, fmt.Errorf("failed to insert business: %w", err)
 	}
 	return id, nil
 }
 
-func (p *Postgres) GetCollectorByEmail(email string) (types.Collector, error) {
+func (p *Postgres) GetCollectorByEmail(ctx context.Context, email string) (types.Collector, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var collector types.Collector
 	var user types.User
 	var registration, lastLogin, licenseExpiry time.Time
@@ -80,18 +120,18 @@ func (p *Postgres) GetCollectorByEmail(email string) (types.Collector, error) {
             u.user_id, u.email, u.password_hash, u.full_name, u.phone_number,
             u.address, u.registration_date, u.role, u.is_active, u.profile_image,
             u.last_login, u.is_verified, u.is_flagged,
-            c.company_name, c.license_number, c.capacity, c.license_expiry
+            c.company_name, c.license_number, c.capacity, c.license_expiry, c.latitude, c.longitude
         FROM users u
         JOIN collectors c ON u.user_id = c.user_id
         WHERE u.email = $1
         LIMIT 1
     `
-	err := p.SqlDB.QueryRow(query, email).Scan(
+	err := p.SqlDB.QueryRowContext(ctx, query, email).Scan(
 		&user.UserID, &user.Email, &user.PasswordHash, &user.FullName, &user.PhoneNumber,
 		&user.Address, &registration, &user.Role, &user.IsActive, &user.ProfileImage,
 		&lastLogin, &user.IsVerified, &user.IsFlagged,
 		&collector.Company_name, &collector.License_number,
-		&collector.Capacity, &licenseExpiry,
+		&collector.Capacity, &licenseExpiry, &collector.Latitude, &collector.Longitude,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -108,7 +148,10 @@ func (p *Postgres) GetCollectorByEmail(email string) (types.Collector, error) {
 	return collector, nil
 }
 
-func (p *Postgres) GetBusinessByEmail(email string) (types.Business, error) {
+func (p *Postgres) GetBusinessByEmail(ctx context.Context, email string) (types.Business, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var business types.Business
 	var user types.User
 	var registration, lastLogin time.Time
@@ -118,18 +161,19 @@ func (p *Postgres) GetBusinessByEmail(email string) (types.Business, error) {
             u.user_id, u.email, u.password_hash, u.full_name, u.phone_number,
             u.address, u.registration_date, u.role, u.is_active, u.profile_image,
             u.last_login, u.is_verified, u.is_flagged,
-            b.business_name, b.business_type, b.registration_number, b.gst_id, b.business_address
+            b.business_name, b.business_type, b.registration_number, b.gst_id, b.business_address,
+            b.latitude, b.longitude
         FROM users u
         JOIN businesses b ON u.user_id = b.user_id
         WHERE u.email = $1
         LIMIT 1
     `
-	err := p.SqlDB.QueryRow(query, email).Scan(
+	err := p.SqlDB.QueryRowContext(ctx, query, email).Scan(
 		&user.UserID, &user.Email, &user.PasswordHash, &user.FullName, &user.PhoneNumber,
 		&user.Address, &registration, &user.Role, &user.IsActive, &user.ProfileImage,
 		&lastLogin, &user.IsVerified, &user.IsFlagged,
 		&business.Business_name, &business.Business_type, &business.Registration_number,
-		&business.Gst_id, &business.Business_address,
+		&business.Gst_id, &business.Business_address, &business.Latitude, &business.Longitude,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -145,15 +189,18 @@ func (p *Postgres) GetBusinessByEmail(email string) (types.Business, error) {
 	return business, nil
 }
 
-func (p *Postgres) CreateCollectorDriver(driver types.CollectorDriver, collectorID int64) (int64, error) {
-	userID, err := p.CreateUser(driver.User)
+func (p *Postgres) CreateCollectorDriver(ctx context.Context, driver types.CollectorDriver, collectorID int64) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	userID, err := p.CreateUser(ctx, driver.User)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create driver user: %w", err)
 	}
 
-	_, err = p.SqlDB.Exec(`
+	_, err = p.SqlDB.ExecContext(ctx, `
         INSERT INTO collector_drivers (
-            user_id, collector_id, license_number, driver_name, 
+            driver_id, collector_id, license_number, driver_name, 
             license_expiry, is_employed, is_active, rating, joining_date
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
 		userID,
@@ -173,7 +220,10 @@ func (p *Postgres) CreateCollectorDriver(driver types.CollectorDriver, collector
 	return userID, nil
 }
 
-func (p *Postgres) GetCollectorDriverByEmail(email string) (types.CollectorDriver, error) {
+func (p *Postgres) GetCollectorDriverByEmail(ctx context.Context, email string) (types.CollectorDriver, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
 	var driver types.CollectorDriver
 	var user types.User
 	var registration, lastLogin, licenseExpiry, joiningDate time.Time
@@ -187,11 +237,11 @@ func (p *Postgres) GetCollectorDriverByEmail(email string) (types.CollectorDrive
             cd.license_expiry, cd.is_employed, cd.is_active,
             cd.rating, cd.joining_date
         FROM users u
-        JOIN collector_drivers cd ON u.user_id = cd.user_id
+        JOIN collector_drivers cd ON u.user_id = cd.driver_id
         WHERE u.email = $1
         LIMIT 1`
 
-	err := p.SqlDB.QueryRow(query, email).Scan(
+	err := p.SqlDB.QueryRowContext(ctx, query, email).Scan(
 		&user.UserID, &user.Email, &user.PasswordHash, &user.FullName, &user.PhoneNumber,
 		&user.Address, &registration, &user.Role, &user.IsActive, &user.ProfileImage,
 		&lastLogin, &user.IsVerified, &user.IsFlagged,
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+// IsEventProcessed reports whether the subscription handled the event and the record has not
+// expired yet.
+func (p *Postgres) IsEventProcessed(ctx context.Context, subscriptionID string, eventID string) (bool, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var count int64
+	err := p.GormDB.WithContext(ctx).Model(&models.ProcessedEvent{}).
+		Where("subscription_id = ? AND event_id = ? AND expires_at > ?", subscriptionID, eventID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (p *Postgres) MarkEventProcessed(ctx context.Context, subscriptionID string, eventID string, ttl time.Duration) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	record := models.ProcessedEvent{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		ProcessedAt:    now,
+		ExpiresAt:      now.Add(ttl),
+	}
+
+	// An expired record of an earlier delivery is refreshed
+	err := p.GormDB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"processed_at", "expires_at"}),
+	}).Create(&record).Error
+	if err != nil {
+		return fmt.Errorf("failed to record processed event: %w", err)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteExpiredProcessedEvents(ctx context.Context) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.ProcessedEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired processed events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+)
+
+func (p *Postgres) CreateServiceArea(ctx context.Context, area types.ServiceArea) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	pincodes, polygon := serviceAreaShape(area)
+	model := models.ServiceArea{
+		CollectorID: area.CollectorID,
+		CategoryID:  area.CategoryID,
+		Name:        area.Name,
+		Pincodes:    pincodes,
+		Polygon:     polygon,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := p.GormDB.WithContext(ctx).Create(&model).Error; err != nil {
+		return 0, fmt.Errorf("failed to create service area: %w", err)
+	}
+	return model.AreaID, nil
+}
+
+func (p *Postgres) GetServiceArea(ctx context.Context, areaID int64) (types.ServiceArea, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.ServiceArea
+	err := p.GormDB.WithContext(ctx).First(&model, "area_id = ?", areaID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ServiceArea{}, fmt.Errorf("service area %d: %w", areaID, storage.ErrNotFound)
+		}
+		return types.ServiceArea{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertServiceAreaModelToType(model), nil
+}
+
+func (p *Postgres) ListServiceAreas(ctx context.Context, collectorID int64) ([]types.ServiceArea, error) {
+	return p.listServiceAreas(ctx, p.GormDB.Where("collector_id = ?", collectorID))
+}
+
+func (p *Postgres) ListAllServiceAreas(ctx context.Context) ([]types.ServiceArea, error) {
+	return p.listServiceAreas(ctx, p.GormDB)
+}
+
+func (p *Postgres) listServiceAreas(ctx context.Context, query *gorm.DB) ([]types.ServiceArea, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.ServiceArea
+	if err := query.WithContext(ctx).Order("area_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	areas := make([]types.ServiceArea, 0, len(rows))
+	for _, row := range rows {
+		areas = append(areas, convertServiceAreaModelToType(row))
+	}
+	return areas, nil
+}
+
+func (p *Postgres) UpdateServiceArea(ctx context.Context, area types.ServiceArea) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	pincodes, polygon := serviceAreaShape(area)
+	result := p.GormDB.WithContext(ctx).Model(&models.ServiceArea{}).
+		Where("area_id = ?", area.AreaID).
+		Updates(map[string]interface{}{
+			"category_id": area.CategoryID,
+			"name":        area.Name,
+			"pincodes":    pincodes,
+			"polygon":     polygon,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update service area: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("service area %d: %w", area.AreaID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteServiceArea(ctx context.Context, areaID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Where("area_id = ?", areaID).Delete(&models.ServiceArea{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete service area: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("service area %d: %w", areaID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// serviceAreaShape returns the columns of the pincodes and the polygon of an area, NULL for the
+// one it does not have.
+func serviceAreaShape(area types.ServiceArea) (pincodes *string, polygon *string) {
+	if area.Pincodes != nil {
+		list := strings.Join(area.Pincodes, ",")
+		pincodes = &list
+	}
+	if len(area.Polygon) > 0 {
+		geoJSON := string(area.Polygon)
+		polygon = &geoJSON
+	}
+	return pincodes, polygon
+}
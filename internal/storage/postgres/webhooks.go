@@ -0,0 +1,265 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/models"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (p *Postgres) CreateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	model := models.WebhookEndpoint{
+		UserID:      endpoint.UserID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Secret:      endpoint.Secret,
+		EventTypes:  strings.Join(endpoint.EventTypes, ","),
+		IsActive:    endpoint.IsActive,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	// Select makes GORM write is_active even when it is false
+	if err := p.GormDB.WithContext(ctx).Select("*").Omit("endpoint_id").Create(&model).Error; err != nil {
+		return 0, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return model.EndpointID, nil
+}
+
+func (p *Postgres) GetWebhookEndpoint(ctx context.Context, endpointID int64) (types.WebhookEndpoint, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.WebhookEndpoint
+	err := p.GormDB.WithContext(ctx).First(&model, "endpoint_id = ?", endpointID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.WebhookEndpoint{}, fmt.Errorf("webhook endpoint %d: %w", endpointID, storage.ErrNotFound)
+		}
+		return types.WebhookEndpoint{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertWebhookEndpointModelToType(model), nil
+}
+
+func (p *Postgres) ListWebhookEndpoints(ctx context.Context, userID int64) ([]types.WebhookEndpoint, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.WebhookEndpoint
+	if err := p.GormDB.WithContext(ctx).Where("user_id = ?", userID).Order("endpoint_id ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	endpoints := make([]types.WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		endpoints = append(endpoints, convertWebhookEndpointModelToType(row))
+	}
+	return endpoints, nil
+}
+
+// ListSubscribedWebhookEndpoints returns the active endpoints of the given users that subscribed
+// to eventType.
+func (p *Postgres) ListSubscribedWebhookEndpoints(ctx context.Context, userIDs []int64, eventType string) ([]types.WebhookEndpoint, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.WebhookEndpoint
+	err := p.GormDB.WithContext(ctx).
+		Where("user_id IN ? AND is_active = ?", userIDs, true).
+		Where("? = ANY(string_to_array(event_types, ','))", eventType).
+		Order("endpoint_id ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	endpoints := make([]types.WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		endpoints = append(endpoints, convertWebhookEndpointModelToType(row))
+	}
+	return endpoints, nil
+}
+
+func (p *Postgres) UpdateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	result := p.GormDB.WithContext(ctx).Model(&models.WebhookEndpoint{}).
+		Where("endpoint_id = ?", endpoint.EndpointID).
+		Updates(map[string]interface{}{
+			"url":         endpoint.URL,
+			"description": endpoint.Description,
+			"event_types": strings.Join(endpoint.EventTypes, ","),
+			"is_active":   endpoint.IsActive,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook endpoint %d: %w", endpoint.EndpointID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+func (p *Postgres) DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	return p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", endpointID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+
+		result := tx.Where("endpoint_id = ?", endpointID).Delete(&models.WebhookEndpoint{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete webhook endpoint: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("webhook endpoint %d: %w", endpointID, storage.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// CreateWebhookDelivery queues an event for an endpoint. It returns 0 without an error if the
+// event was already queued for it, e.g. when the event is redelivered.
+func (p *Postgres) CreateWebhookDelivery(ctx context.Context, delivery types.WebhookDelivery) (int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	model := models.WebhookDelivery{
+		EndpointID:    delivery.EndpointID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       []byte(delivery.Payload),
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	err := p.GormDB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint_id"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(&model).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	return model.DeliveryID, nil
+}
+
+func (p *Postgres) GetWebhookDelivery(ctx context.Context, deliveryID int64) (types.WebhookDelivery, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var model models.WebhookDelivery
+	err := p.GormDB.WithContext(ctx).First(&model, "delivery_id = ?", deliveryID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.WebhookDelivery{}, fmt.Errorf("webhook delivery %d: %w", deliveryID, storage.ErrNotFound)
+		}
+		return types.WebhookDelivery{}, fmt.Errorf("database error: %w", err)
+	}
+	return convertWebhookDeliveryModelToType(model), nil
+}
+
+func (p *Postgres) ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int, offset int) ([]types.WebhookDelivery, int64, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	query := p.GormDB.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("database error: %w", err)
+	}
+
+	var rows []models.WebhookDelivery
+	if err := query.Order("delivery_id DESC").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("database error: %w", err)
+	}
+
+	deliveries := make([]types.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, convertWebhookDeliveryModelToType(row))
+	}
+	return deliveries, total, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due. The claimed rows
+// are leased for the given duration so that other dispatcher replicas skip them meanwhile.
+func (p *Postgres) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	var rows []models.WebhookDelivery
+
+	err := p.GormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+			Order("delivery_id ASC").
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.DeliveryID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("delivery_id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]types.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, convertWebhookDeliveryModelToType(row))
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt records the outcome of a delivery attempt. status is pending if the
+// delivery is retried at nextAttemptAt.
+func (p *Postgres) RecordWebhookAttempt(ctx context.Context, deliveryID int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"status":           status,
+		"attempts":         gorm.Expr("attempts + 1"),
+		"last_status_code": statusCode,
+		"last_error":       lastError,
+		"next_attempt_at":  nextAttemptAt,
+	}
+	if status == "succeeded" {
+		updates["delivered_at"] = time.Now()
+	}
+
+	result := p.GormDB.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("delivery_id = ?", deliveryID).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery %d: %w", deliveryID, storage.ErrNotFound)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package storage
 
 import (
+	"context"
+	"time"
+
 	"github.com/kartikey1188/build-in-progress_01/internal/types"
 )
 
+// Storage is the persistence of the application. Every method takes the context of the request
+// or message it serves, so that its queries are cancelled when that is abandoned.
 type Storage interface {
 	LoginAndRegister
 	Admin
@@ -11,90 +16,231 @@ type Storage interface {
 	General
 	Business
 	Driver
+	Geofences
+	ServiceAreas
+	Market
+	Outbox
+	DeadLetters
+	ProcessedEvents
+	NotificationTemplates
+	Notifications
+	Webhooks
 }
 
 type LoginAndRegister interface {
-	CreateCollectorUser(user types.Collector) (int64, error)
-	CreateBusinessUser(user types.Business) (int64, error)
-	CreateCollectorDriver(user types.CollectorDriver, collectorID int64) (int64, error)
-	UpdateLastLogin(userID int64, lastLogin types.DateTime) error
-	GetCollectorByEmail(email string) (types.Collector, error)
-	GetBusinessByEmail(email string) (types.Business, error)
-	GetCollectorDriverByEmail(email string) (types.CollectorDriver, error)
+	CreateCollectorUser(ctx context.Context, user types.Collector) (int64, error)
+	CreateBusinessUser(ctx context.Context, user types.Business) (int64, error)
+	CreateCollectorDriver(ctx context.Context, user types.CollectorDriver, collectorID int64) (int64, error)
+	UpdateLastLogin(ctx context.Context, userID int64, lastLogin types.DateTime) error
+	ChangePassword(ctx context.Context, userID int64, passwordHash string) error // Also clears must_change_password
+	GetCollectorByEmail(ctx context.Context, email string) (types.Collector, error)
+	GetBusinessByEmail(ctx context.Context, email string) (types.Business, error)
+	GetCollectorDriverByEmail(ctx context.Context, email string) (types.CollectorDriver, error)
 }
 
 type Admin interface {
-	FlagUser(userID string) error
-	UnflagUser(userID string) error
-	VerifyUser(userID string) error
-	UnverifyUser(userID string) error
-
-	AddVehicle(v types.Vehicle) (int64, error)
-	AddServiceCategory(sc types.ServiceCategory) (int64, error)
-	DeleteVehicle(vehicleID uint64) error
-	DeleteServiceCategory(categoryID uint64) error
-	GetAllCollectors() ([]types.Collector, error)
-	GetAllBusinesses() ([]types.Business, error)
-	GetAllUsers() ([]types.User, error)
-
-	GetAllPickupRequests() ([]types.PickupRequest, error)
+	FlagUser(ctx context.Context, userID string) error
+	UnflagUser(ctx context.Context, userID string) error
+	VerifyUser(ctx context.Context, userID string) error
+	UnverifyUser(ctx context.Context, userID string) error
+
+	AddVehicle(ctx context.Context, v types.Vehicle) (int64, error)
+	AddServiceCategory(ctx context.Context, sc types.ServiceCategory) (int64, error)
+	DeleteVehicle(ctx context.Context, vehicleID uint64) error
+	DeleteServiceCategory(ctx context.Context, categoryID uint64) error
+	GetAllCollectors(ctx context.Context) ([]types.Collector, error)
+	GetAllBusinesses(ctx context.Context) ([]types.Business, error)
+	GetAllUsers(ctx context.Context) ([]types.User, error)
+
+	GetAllPickupRequests(ctx context.Context) ([]types.PickupRequest, error)
+
+	// BootstrapAdmin creates the first admin unless an admin exists already, and reports whether
+	// it did. Both it and admins created by other admins have to change their password on first login.
+	BootstrapAdmin(ctx context.Context, admin types.User) (bool, error)
+	CreateAdminUser(ctx context.Context, admin types.User, createdBy int64) (int64, error)
+	GetAdminAuditRecords(ctx context.Context) ([]types.AdminAuditRecord, error)
 }
 
 type General interface {
-	GetAllServiceCategories() ([]types.ServiceCategory, error)
-	GetServiceCategory(categoryID uint64) (types.ServiceCategory, error)
-	GetAllVehicles() ([]types.Vehicle, error)
-	GetVehicle(vehicleID uint64) (types.Vehicle, error)
-	GetUserByID(userID uint64) (types.User, error)
-	GetUserByEmail(email string) (types.User, error)
+	GetAllServiceCategories(ctx context.Context) ([]types.ServiceCategory, error)
+	GetServiceCategory(ctx context.Context, categoryID uint64) (types.ServiceCategory, error)
+	GetAllVehicles(ctx context.Context) ([]types.Vehicle, error)
+	GetVehicle(ctx context.Context, vehicleID uint64) (types.Vehicle, error)
+	GetUserByID(ctx context.Context, userID uint64) (types.User, error)
+	GetUserByEmail(ctx context.Context, email string) (types.User, error)
+	GetNotificationPreferences(ctx context.Context, userID int64) (types.NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, userID int64, preferences types.NotificationPreferences) error
 }
 
 type Collector interface {
-	GetCollectors() ([]types.Collector, error)
-	GetCollectorByID(id int64) (types.Collector, error)
+	GetCollectors(ctx context.Context) ([]types.Collector, error)
+	GetCollectorByID(ctx context.Context, id int64) (types.Collector, error)
 
-	UpdateCollectorProfile(userID int64, input types.CollectorUpdate) (int64, error)
+	UpdateCollectorProfile(ctx context.Context, userID int64, input types.CollectorUpdate) (int64, error)
 
-	AddCollectorServiceCategory(input types.CollectorServiceCategory, userID uint64) (int64, error)
-	UpdateCollectorServiceCategory(input types.UpdateCollectorServiceCategory, userID uint64) error
-	DeleteCollectorServiceCategory(category_id int64, collectorID uint64) error
+	AddCollectorServiceCategory(ctx context.Context, input types.CollectorServiceCategory, userID uint64) (int64, error)
+	UpdateCollectorServiceCategory(ctx context.Context, input types.UpdateCollectorServiceCategory, userID uint64) error
+	DeleteCollectorServiceCategory(ctx context.Context, category_id int64, collectorID uint64) error
 
-	AddCollectorVehicle(input types.CollectorVehicle, userID uint64) (int64, error)
-	UpdateCollectorVehicle(input types.UpdateCollectorVehicle, userID uint64) error
-	DeleteCollectorVehicle(vehicleID int64, collectorID uint64) error
-	GetCollectorVehicle(collectorID int64, vehicleID int64) (types.CollectorVehicle, error)
+	AddCollectorVehicle(ctx context.Context, input types.CollectorVehicle, userID uint64) (int64, error)
+	UpdateCollectorVehicle(ctx context.Context, input types.UpdateCollectorVehicle, userID uint64) error
+	DeleteCollectorVehicle(ctx context.Context, vehicleID int64, collectorID uint64) error
+	GetCollectorVehicle(ctx context.Context, collectorID int64, vehicleID int64) (types.CollectorVehicle, error)
 	// Activating/Deactivating a vehicle can also be done through UpdateCollectorVehicle only
 
-	UpdateCollectorDriver(input types.UpdateCollectorDriver, collectorID uint64) error
-	AssignVehicleToDriver(driverID int64, vehicleID int64, collectorID uint64) error
-	UnassignVehicleFromDriver(driverID int64, vehicleID int64, collectorID uint64) error
-	GetCollectorDriver(collectorID int64, driverID int64) (types.CollectorDriver, error)
-	DeleteCollectorDriver(driverID int64, collectorID uint64) error
-	GetCollectorDrivers(collectorID int64) ([]types.CollectorDriver, error)
+	UpdateCollectorDriver(ctx context.Context, input types.UpdateCollectorDriver, collectorID uint64) error
+	AssignVehicleToDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error
+	UnassignVehicleFromDriver(ctx context.Context, driverID int64, vehicleID int64, collectorID uint64) error
+	GetCollectorDriver(ctx context.Context, collectorID int64, driverID int64) (types.CollectorDriver, error)
+	DeleteCollectorDriver(ctx context.Context, driverID int64, collectorID uint64) error
+	GetCollectorDrivers(ctx context.Context, collectorID int64) ([]types.CollectorDriver, error)
+
+	GetCollectorServiceCategories(ctx context.Context, collectorID int64) ([]types.CollectorServiceCategory, error)
+	// GetNearbyCollectors returns the collectors matching query, nearest first.
+	GetNearbyCollectors(ctx context.Context, query types.NearbyCollectorsQuery) ([]types.NearbyCollector, error)
+	// GetMatchCandidates returns the collectors matching query, by ID.
+	GetMatchCandidates(ctx context.Context, query types.MatchCandidatesQuery) ([]types.MatchCandidate, error)
+	GetCollectorVehicles(ctx context.Context, collectorID int64) ([]types.CollectorVehicle, error)
+
+	AcceptPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error
+	RejectPickupRequest(ctx context.Context, requestID int64, actor types.Actor, reason string) error
+
+	AssignTripToDriver(ctx context.Context, requestID int64, driverID int64, actor types.Actor) error
+	UnassignTripFromDriver(ctx context.Context, requestID int64, actor types.Actor) error
+}
 
-	GetCollectorServiceCategories(collectorID int64) ([]types.CollectorServiceCategory, error)
-	GetCollectorVehicles(collectorID int64) ([]types.CollectorVehicle, error)
+type Business interface {
+	GetBusinessByID(ctx context.Context, id int64) (types.Business, error)
+	GetBusinessByEmail(ctx context.Context, email string) (types.Business, error)
+	UpdateBusinessProfile(ctx context.Context, userID int64, input types.BusinessUpdate) (int64, error)
+
+	GetPickupRequestByID(ctx context.Context, requestID int64) (types.PickupRequest, error)
+	CreatePickupRequest(ctx context.Context, request types.PickupRequest, actor types.Actor) (int64, error)
+	GetAllPickupRequestsForBusiness(ctx context.Context, businessID int64) ([]types.PickupRequest, error)
+	UpdatePickupRequest(ctx context.Context, requestID int64, input types.UpdatePickupRequest, actor types.Actor) error
+	GetPickupRequestTimeline(ctx context.Context, requestID int64) ([]types.PickupRequestStatusChange, error)
+}
 
-	// StoreDriverLocation(location models.DriverLocation) error
+type Driver interface {
+	StartDelivery(ctx context.Context, requestID int64, actor types.Actor) error
+	EndDelivery(ctx context.Context, requestID int64, actor types.Actor) error
+
+	// GetActiveTrip returns the pickup request assigned to the driver that is not delivered yet,
+	// preferring the one in transit. Wraps ErrNotFound.
+	GetActiveTrip(ctx context.Context, driverID int64) (types.PickupRequest, error)
+	// StoreDriverLocation marks the first location of a trip as its LocationStart. The vehicle
+	// defaults to the one assigned to the driver.
+	StoreDriverLocation(ctx context.Context, location types.DriverLocation) (int64, error)
+	// MarkTripEnd marks the latest location of a trip, other than its start, as its LocationEnd.
+	MarkTripEnd(ctx context.Context, tripID int64) error
+	GetTripLocations(ctx context.Context, tripID int64) ([]types.DriverLocation, error)
+	// GetLatestTripLocations returns the last limit locations of a trip, oldest first.
+	GetLatestTripLocations(ctx context.Context, tripID int64, limit int) ([]types.DriverLocation, error)
+
+	SavePickupETA(ctx context.Context, eta types.PickupETA) error               // Replaces the previous ETA of the request
+	GetPickupETA(ctx context.Context, requestID int64) (types.PickupETA, error) // Wraps ErrNotFound
+}
 
-	AcceptPickupRequest(requestID int64) error
-	RejectPickupRequest(requestID int64) error
+// Geofences holds the sites of businesses and collectors and where every trip stands at them.
+// SaveSiteVisit fails with ErrConflict if the visit was saved since it was read, and enqueues
+// event, if not nil, with the visit.
+type Geofences interface {
+	CreateSite(ctx context.Context, site types.Site) (int64, error)
+	GetSite(ctx context.Context, siteID int64) (types.Site, error) // Wraps ErrNotFound
+	ListSites(ctx context.Context, ownerID int64) ([]types.Site, error)
+	UpdateSite(ctx context.Context, site types.Site) error // Wraps ErrNotFound
+	DeleteSite(ctx context.Context, siteID int64) error    // Wraps ErrNotFound, deletes the visits of the site too
+
+	GetSiteVisits(ctx context.Context, tripID int64) ([]types.SiteVisit, error)
+	SaveSiteVisit(ctx context.Context, visit types.SiteVisit, event *types.GeofenceEvent) error
+}
 
-	AssignTripToDriver(requestID int64, driverID int64) error
-	UnassignTripFromDriver(requestID int64) error
+// ServiceAreas holds where collectors take pickups from.
+type ServiceAreas interface {
+	CreateServiceArea(ctx context.Context, area types.ServiceArea) (int64, error)
+	GetServiceArea(ctx context.Context, areaID int64) (types.ServiceArea, error) // Wraps ErrNotFound
+	ListServiceAreas(ctx context.Context, collectorID int64) ([]types.ServiceArea, error)
+	ListAllServiceAreas(ctx context.Context) ([]types.ServiceArea, error)
+	UpdateServiceArea(ctx context.Context, area types.ServiceArea) error // Wraps ErrNotFound
+	DeleteServiceArea(ctx context.Context, areaID int64) error           // Wraps ErrNotFound
 }
 
-type Business interface {
-	GetBusinessByID(id int64) (types.Business, error)
-	GetBusinessByEmail(email string) (types.Business, error)
-	UpdateBusinessProfile(userID int64, input types.BusinessUpdate) (int64, error)
+// Market holds the open-market pickup requests, made without a collector to the collectors
+// invited to them, and their bids. Awarding a request locks it, so that only one collector wins.
+type Market interface {
+	// CreateOpenPickupRequest creates a pickup request without a collector and its invitations.
+	CreateOpenPickupRequest(ctx context.Context, request types.PickupRequest, invitations []types.MarketInvitation, actor types.Actor) (int64, error)
+	ListMarketInvitations(ctx context.Context, requestID int64) ([]types.MarketInvitation, error)
+	// ListOpenPickupRequests returns the requests collectorID is invited to that are still open.
+	ListOpenPickupRequests(ctx context.Context, collectorID int64) ([]types.OpenPickupRequest, error)
+
+	// PlaceBid creates the bid of a collector or replaces its previous one. It wraps ErrNotFound
+	// if the collector is not invited to the request and ErrNotOpen if the request is not open.
+	PlaceBid(ctx context.Context, bid types.PickupBid) (int64, error)
+	ListPickupBids(ctx context.Context, requestID int64) ([]types.PickupBid, error)
+
+	// AcceptOpenPickupRequest awards a request to the collector of actor at the price of its
+	// invitation, AcceptPickupBid to the collector of a bid at its price and window. Both wrap
+	// ErrNotFound without the invitation or the bid, and ErrNotOpen if the request is not open.
+	AcceptOpenPickupRequest(ctx context.Context, requestID int64, actor types.Actor) error
+	AcceptPickupBid(ctx context.Context, requestID int64, bidID int64, actor types.Actor) error
+}
 
-	GetPickupRequestByID(requestID int64) (types.PickupRequest, error)
-	CreatePickupRequest(request types.PickupRequest) (int64, error)
-	GetAllPickupRequestsForBusiness(businessID int64) ([]types.PickupRequest, error)
-	UpdatePickupRequest(requestID int64, input types.UpdatePickupRequest) error
+type Outbox interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]types.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, outboxID int64) error
+	MarkOutboxEventFailed(ctx context.Context, outboxID int64, lastError string, nextAttemptAt time.Time) error
+
+	// DeleteDeliveredOutboxEvents deletes the events delivered before the given time, returning
+	// how many were deleted. Pending events are kept however old they are.
+	DeleteDeliveredOutboxEvents(ctx context.Context, before time.Time) (int64, error)
 }
 
-type Driver interface {
-	EndDelivery(requestID int64) error
+type DeadLetters interface {
+	CreateDeadLetter(ctx context.Context, deadLetter types.DeadLetter) (int64, error)
+	ListDeadLetters(ctx context.Context, status string) ([]types.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, deadLetterID int64) (types.DeadLetter, error)
+	ResolveDeadLetter(ctx context.Context, deadLetterID int64, status string) error
+}
+
+type ProcessedEvents interface {
+	IsEventProcessed(ctx context.Context, subscriptionID string, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, subscriptionID string, eventID string, ttl time.Duration) error
+	DeleteExpiredProcessedEvents(ctx context.Context) (int64, error)
+}
+
+// NotificationTemplates holds the admin overrides of the embedded notification templates.
+type NotificationTemplates interface {
+	ListNotificationTemplates(ctx context.Context) ([]types.NotificationTemplate, error)
+	GetNotificationTemplate(ctx context.Context, name string, locale string) (types.NotificationTemplate, error) // Wraps ErrNotFound
+	SaveNotificationTemplate(ctx context.Context, template types.NotificationTemplate) error
+	DeleteNotificationTemplate(ctx context.Context, name string, locale string) error // Wraps ErrNotFound
+}
+
+// Notifications is the in-app inbox of every user. CreateNotification ignores a notification
+// already recorded for the same user, event and type; ListNotifications returns a page, newest
+// first, and the total count.
+type Notifications interface {
+	CreateNotification(ctx context.Context, notification types.Notification) error
+	ListNotifications(ctx context.Context, userID int64, unreadOnly bool, limit int, offset int) ([]types.Notification, int64, error)
+	CountUnreadNotifications(ctx context.Context, userID int64) (int64, error)
+	MarkNotificationRead(ctx context.Context, userID int64, notificationID int64) error // Wraps ErrNotFound
+	MarkAllNotificationsRead(ctx context.Context, userID int64) (int64, error)
+}
+
+// Webhooks holds the endpoints users registered and the log of deliveries to them.
+// CreateWebhookDelivery returns 0 if the event was already queued for the endpoint.
+type Webhooks interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) (int64, error)
+	GetWebhookEndpoint(ctx context.Context, endpointID int64) (types.WebhookEndpoint, error) // Wraps ErrNotFound
+	ListWebhookEndpoints(ctx context.Context, userID int64) ([]types.WebhookEndpoint, error)
+	ListSubscribedWebhookEndpoints(ctx context.Context, userIDs []int64, eventType string) ([]types.WebhookEndpoint, error) // Active endpoints only
+	UpdateWebhookEndpoint(ctx context.Context, endpoint types.WebhookEndpoint) error
+	DeleteWebhookEndpoint(ctx context.Context, endpointID int64) error // Deletes its deliveries too
+
+	CreateWebhookDelivery(ctx context.Context, delivery types.WebhookDelivery) (int64, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID int64) (types.WebhookDelivery, error) // Wraps ErrNotFound
+	ListWebhookDeliveries(ctx context.Context, endpointID int64, limit int, offset int) ([]types.WebhookDelivery, int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]types.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID int64, status string, statusCode int, lastError string, nextAttemptAt time.Time) error
 }
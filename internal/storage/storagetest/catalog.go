@@ -0,0 +1,166 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testCatalog(t *testing.T, s storage.Storage) {
+	plasticID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Plastic"})
+	mustSucceed(t, err, "AddServiceCategory")
+	paperID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Paper"})
+	mustSucceed(t, err, "AddServiceCategory")
+	_, err = s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Plastic"})
+	mustFail(t, err, "AddServiceCategory(duplicate)")
+
+	category, err := s.GetServiceCategory(t.Context(), uint64(plasticID))
+	mustSucceed(t, err, "GetServiceCategory")
+	if category.WasteType != "Plastic" {
+		t.Errorf("GetServiceCategory = %+v", category)
+	}
+	categories, err := s.GetAllServiceCategories(t.Context())
+	mustSucceed(t, err, "GetAllServiceCategories")
+	if len(categories) != 2 {
+		t.Errorf("GetAllServiceCategories returned %d categories, want 2", len(categories))
+	}
+
+	// Collectors offer categories, once each
+	collectorID := mustCreateCollector(t, s, "green")
+	offer := types.CollectorServiceCategory{CategoryID: plasticID, PricePerKg: 12.5, MaximumCapacity: 500}
+	_, err = s.AddCollectorServiceCategory(t.Context(), offer, uint64(collectorID))
+	mustSucceed(t, err, "AddCollectorServiceCategory")
+	_, err = s.AddCollectorServiceCategory(t.Context(), offer, uint64(collectorID))
+	mustFail(t, err, "AddCollectorServiceCategory(duplicate)")
+	_, err = s.AddCollectorServiceCategory(t.Context(), types.CollectorServiceCategory{CategoryID: paperID + 1000, PricePerKg: 1, MaximumCapacity: 1}, uint64(collectorID))
+	mustFail(t, err, "AddCollectorServiceCategory(unknown category)")
+	_, err = s.AddCollectorServiceCategory(t.Context(), offer, uint64(collectorID+1000))
+	mustFail(t, err, "AddCollectorServiceCategory(unknown collector)")
+	_, err = s.AddCollectorServiceCategory(t.Context(), types.CollectorServiceCategory{CategoryID: paperID, PricePerKg: 4, MaximumCapacity: 800}, uint64(collectorID))
+	mustSucceed(t, err, "AddCollectorServiceCategory(paper)")
+
+	mustSucceed(t, s.UpdateCollectorServiceCategory(t.Context(), types.UpdateCollectorServiceCategory{CategoryID: plasticID, PricePerKg: 15}, uint64(collectorID)), "UpdateCollectorServiceCategory")
+	offers, err := s.GetCollectorServiceCategories(t.Context(), collectorID)
+	mustSucceed(t, err, "GetCollectorServiceCategories")
+	if len(offers) != 2 {
+		t.Fatalf("GetCollectorServiceCategories = %+v, want 2 offers", offers)
+	}
+	for _, o := range offers {
+		if o.CategoryID == plasticID && (o.PricePerKg != 15 || o.MaximumCapacity != 500 || o.CollectorID != collectorID) {
+			t.Errorf("updated offer = %+v, want price 15 and capacity 500", o)
+		}
+	}
+
+	mustSucceed(t, s.DeleteCollectorServiceCategory(t.Context(), paperID, uint64(collectorID)), "DeleteCollectorServiceCategory")
+	mustFail(t, s.DeleteCollectorServiceCategory(t.Context(), paperID, uint64(collectorID)), "DeleteCollectorServiceCategory(again)")
+
+	// Deleting a category deletes the offers of it
+	mustSucceed(t, s.DeleteServiceCategory(t.Context(), uint64(plasticID)), "DeleteServiceCategory")
+	mustFail(t, s.DeleteServiceCategory(t.Context(), uint64(plasticID)), "DeleteServiceCategory(again)")
+	_, err = s.GetServiceCategory(t.Context(), uint64(plasticID))
+	mustFail(t, err, "GetServiceCategory(deleted)")
+	offers, _ = s.GetCollectorServiceCategories(t.Context(), collectorID)
+	if len(offers) != 0 {
+		t.Errorf("offers after deleting their categories = %+v", offers)
+	}
+
+	// Vehicles are unique by type and capacity
+	truckID, err := s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Truck", Capacity: 5000})
+	mustSucceed(t, err, "AddVehicle")
+	_, err = s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Truck", Capacity: 5000})
+	mustFail(t, err, "AddVehicle(duplicate)")
+	_, err = s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Truck", Capacity: 8000})
+	mustSucceed(t, err, "AddVehicle(other capacity)")
+
+	vehicle, err := s.GetVehicle(t.Context(), uint64(truckID))
+	mustSucceed(t, err, "GetVehicle")
+	if vehicle.VehicleType != "Truck" || vehicle.Capacity != 5000 {
+		t.Errorf("GetVehicle = %+v", vehicle)
+	}
+	vehicles, err := s.GetAllVehicles(t.Context())
+	mustSucceed(t, err, "GetAllVehicles")
+	if len(vehicles) != 2 {
+		t.Errorf("GetAllVehicles returned %d vehicles, want 2", len(vehicles))
+	}
+	mustSucceed(t, s.DeleteVehicle(t.Context(), uint64(truckID)), "DeleteVehicle")
+	mustFail(t, s.DeleteVehicle(t.Context(), uint64(truckID)), "DeleteVehicle(again)")
+	_, err = s.GetVehicle(t.Context(), uint64(truckID))
+	mustFail(t, err, "GetVehicle(deleted)")
+}
+
+func testCollectorVehicles(t *testing.T, s storage.Storage) {
+	collectorID := mustCreateCollector(t, s, "green")
+	otherID := mustCreateCollector(t, s, "red")
+	driverID := mustCreateDriver(t, s, "dave", collectorID)
+
+	truckID, err := s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Truck", Capacity: 5000})
+	mustSucceed(t, err, "AddVehicle")
+	vanID, err := s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Van", Capacity: 1500})
+	mustSucceed(t, err, "AddVehicle")
+
+	// Like the column default, a vehicle added inactive starts out active
+	truck := types.CollectorVehicle{
+		VehicleID:          truckID,
+		VehicleNumber:      "KA-01-1234",
+		RegistrationExpiry: types.Date{Time: time.Now().AddDate(2, 0, 0)},
+	}
+	_, err = s.AddCollectorVehicle(t.Context(), truck, uint64(collectorID))
+	mustSucceed(t, err, "AddCollectorVehicle")
+	_, err = s.AddCollectorVehicle(t.Context(), truck, uint64(collectorID))
+	mustFail(t, err, "AddCollectorVehicle(duplicate)")
+	_, err = s.AddCollectorVehicle(t.Context(), types.CollectorVehicle{VehicleID: vanID, VehicleNumber: "KA-01-1234"}, uint64(otherID))
+	mustFail(t, err, "AddCollectorVehicle(taken vehicle number)")
+	_, err = s.AddCollectorVehicle(t.Context(), types.CollectorVehicle{VehicleID: vanID + 1000, VehicleNumber: "KA-09-0000"}, uint64(collectorID))
+	mustFail(t, err, "AddCollectorVehicle(unknown vehicle)")
+
+	got, err := s.GetCollectorVehicle(t.Context(), collectorID, truckID)
+	mustSucceed(t, err, "GetCollectorVehicle")
+	if !got.IsActive || got.VehicleNumber != "KA-01-1234" || got.CollectorID != collectorID {
+		t.Errorf("GetCollectorVehicle = %+v, want an active KA-01-1234", got)
+	}
+	_, err = s.GetCollectorVehicle(t.Context(), otherID, truckID)
+	mustFail(t, err, "GetCollectorVehicle(other collector)")
+
+	inactive := false
+	mustSucceed(t, s.UpdateCollectorVehicle(t.Context(), types.UpdateCollectorVehicle{VehicleID: truckID, IsActive: &inactive, GPSTrackingID: "gps-1"}, uint64(collectorID)), "UpdateCollectorVehicle")
+	got, _ = s.GetCollectorVehicle(t.Context(), collectorID, truckID)
+	if got.IsActive || got.GPSTrackingID != "gps-1" || got.VehicleNumber != "KA-01-1234" {
+		t.Errorf("updated vehicle = %+v", got)
+	}
+	mustFail(t, s.UpdateCollectorVehicle(t.Context(), types.UpdateCollectorVehicle{VehicleID: truckID, GPSTrackingID: "gps-2"}, uint64(otherID)), "UpdateCollectorVehicle(other collector)")
+
+	_, err = s.AddCollectorVehicle(t.Context(), types.CollectorVehicle{VehicleID: vanID, VehicleNumber: "KA-02-5678"}, uint64(collectorID))
+	mustSucceed(t, err, "AddCollectorVehicle(van)")
+	fleet, err := s.GetCollectorVehicles(t.Context(), collectorID)
+	mustSucceed(t, err, "GetCollectorVehicles")
+	if len(fleet) != 2 {
+		t.Errorf("GetCollectorVehicles = %+v, want 2 vehicles", fleet)
+	}
+
+	// A driver drives one vehicle at a time
+	mustSucceed(t, s.AssignVehicleToDriver(t.Context(), driverID, truckID, uint64(collectorID)), "AssignVehicleToDriver")
+	mustFail(t, s.AssignVehicleToDriver(t.Context(), driverID, vanID, uint64(collectorID)), "AssignVehicleToDriver(second vehicle)")
+	mustFail(t, s.AssignVehicleToDriver(t.Context(), driverID, vanID, uint64(otherID)), "AssignVehicleToDriver(other collector)")
+	mustFail(t, s.UnassignVehicleFromDriver(t.Context(), driverID, vanID, uint64(collectorID)), "UnassignVehicleFromDriver(unassigned vehicle)")
+	mustSucceed(t, s.UnassignVehicleFromDriver(t.Context(), driverID, truckID, uint64(collectorID)), "UnassignVehicleFromDriver")
+	mustSucceed(t, s.AssignVehicleToDriver(t.Context(), driverID, truckID, uint64(collectorID)), "AssignVehicleToDriver(again)")
+
+	// Deleting a vehicle deletes the collectors' vehicles and the driver assignments of it
+	mustSucceed(t, s.DeleteVehicle(t.Context(), uint64(truckID)), "DeleteVehicle")
+	fleet, _ = s.GetCollectorVehicles(t.Context(), collectorID)
+	if len(fleet) != 1 || fleet[0].VehicleID != vanID {
+		t.Errorf("fleet after deleting the truck = %+v, want the van only", fleet)
+	}
+	mustSucceed(t, s.AssignVehicleToDriver(t.Context(), driverID, vanID, uint64(collectorID)), "AssignVehicleToDriver(after deleting the truck)")
+
+	// A driver with a vehicle can be deleted, the assignment goes with it
+	mustSucceed(t, s.DeleteCollectorDriver(t.Context(), driverID, uint64(collectorID)), "DeleteCollectorDriver")
+
+	// Removing a vehicle from a fleet frees its number
+	mustSucceed(t, s.DeleteCollectorVehicle(t.Context(), vanID, uint64(collectorID)), "DeleteCollectorVehicle")
+	mustFail(t, s.DeleteCollectorVehicle(t.Context(), vanID, uint64(collectorID)), "DeleteCollectorVehicle(again)")
+	_, err = s.AddCollectorVehicle(t.Context(), types.CollectorVehicle{VehicleID: vanID, VehicleNumber: "KA-02-5678"}, uint64(otherID))
+	mustSucceed(t, err, "AddCollectorVehicle(van to other collector)")
+}
@@ -0,0 +1,87 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testDeadLetters(t *testing.T, s storage.Storage) {
+	firstID, err := s.CreateDeadLetter(t.Context(), types.DeadLetter{
+		SubscriptionID: "accept-sub",
+		Topic:          "pickup-requests",
+		MessageID:      "m-1",
+		EventID:        "e-1",
+		EventType:      "pickup.accepted",
+		Payload:        `{"id":"e-1"}`,
+		Attributes:     map[string]string{"event_type": "pickup.accepted"},
+		Attempts:       5,
+		LastError:      "handler failed",
+	})
+	mustSucceed(t, err, "CreateDeadLetter")
+	secondID, err := s.CreateDeadLetter(t.Context(), types.DeadLetter{SubscriptionID: "reject-sub", Topic: "pickup-requests", Payload: "{}", Attempts: 5})
+	mustSucceed(t, err, "CreateDeadLetter")
+
+	deadLetter, err := s.GetDeadLetter(t.Context(), firstID)
+	mustSucceed(t, err, "GetDeadLetter")
+	if deadLetter.Status != "pending" || deadLetter.Payload != `{"id":"e-1"}` || deadLetter.Attributes["event_type"] != "pickup.accepted" || deadLetter.Attempts != 5 || deadLetter.ResolvedAt != nil {
+		t.Errorf("GetDeadLetter = %+v", deadLetter)
+	}
+	_, err = s.GetDeadLetter(t.Context(), secondID+1000)
+	mustFail(t, err, "GetDeadLetter(unknown)")
+
+	all, err := s.ListDeadLetters(t.Context(), "")
+	mustSucceed(t, err, "ListDeadLetters")
+	if len(all) != 2 || all[0].DeadLetterID != secondID {
+		t.Errorf("ListDeadLetters = %+v, want both, newest first", all)
+	}
+
+	mustSucceed(t, s.ResolveDeadLetter(t.Context(), firstID, "replayed"), "ResolveDeadLetter")
+	mustFail(t, s.ResolveDeadLetter(t.Context(), firstID, "discarded"), "ResolveDeadLetter(resolved)")
+	deadLetter, _ = s.GetDeadLetter(t.Context(), firstID)
+	if deadLetter.Status != "replayed" || deadLetter.ResolvedAt == nil {
+		t.Errorf("resolved dead letter = %+v", deadLetter)
+	}
+
+	pending, _ := s.ListDeadLetters(t.Context(), "pending")
+	if len(pending) != 1 || pending[0].DeadLetterID != secondID {
+		t.Errorf("pending dead letters = %+v", pending)
+	}
+	replayed, _ := s.ListDeadLetters(t.Context(), "replayed")
+	if len(replayed) != 1 || replayed[0].DeadLetterID != firstID {
+		t.Errorf("replayed dead letters = %+v", replayed)
+	}
+}
+
+func testProcessedEvents(t *testing.T, s storage.Storage) {
+	processed, err := s.IsEventProcessed(t.Context(), "sub", "e-1")
+	mustSucceed(t, err, "IsEventProcessed")
+	if processed {
+		t.Errorf("unseen event is processed")
+	}
+
+	mustSucceed(t, s.MarkEventProcessed(t.Context(), "sub", "e-1", time.Hour), "MarkEventProcessed")
+	mustSucceed(t, s.MarkEventProcessed(t.Context(), "sub", "e-1", time.Hour), "MarkEventProcessed(again)")
+	if processed, _ := s.IsEventProcessed(t.Context(), "sub", "e-1"); !processed {
+		t.Errorf("marked event is not processed")
+	}
+	if processed, _ := s.IsEventProcessed(t.Context(), "other-sub", "e-1"); processed {
+		t.Errorf("event is processed for another subscription")
+	}
+
+	mustSucceed(t, s.MarkEventProcessed(t.Context(), "sub", "e-2", -time.Second), "MarkEventProcessed(expired)")
+	if processed, _ := s.IsEventProcessed(t.Context(), "sub", "e-2"); processed {
+		t.Errorf("expired event is processed")
+	}
+
+	deleted, err := s.DeleteExpiredProcessedEvents(t.Context())
+	mustSucceed(t, err, "DeleteExpiredProcessedEvents")
+	if deleted != 1 {
+		t.Errorf("deleted %d expired events, want 1", deleted)
+	}
+	if processed, _ := s.IsEventProcessed(t.Context(), "sub", "e-1"); !processed {
+		t.Errorf("unexpired event was deleted")
+	}
+}
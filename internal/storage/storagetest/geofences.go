@@ -0,0 +1,120 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testGeofences(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	driverID := mustCreateDriver(t, s, "dave", collectorID)
+	requestID := mustCreatePickupRequest(t, s, businessID, collectorID)
+
+	radius, dwell := 150.0, int64(90)
+	siteID, err := s.CreateSite(t.Context(), types.Site{
+		OwnerID:      businessID,
+		Kind:         types.SitePickup,
+		Name:         "Warehouse",
+		Latitude:     12.9716,
+		Longitude:    77.5946,
+		RadiusMeters: &radius,
+		DwellSeconds: &dwell,
+		AutoAdvance:  true,
+	})
+	mustSucceed(t, err, "CreateSite")
+	radius = 0
+	site, err := s.GetSite(t.Context(), siteID)
+	mustSucceed(t, err, "GetSite")
+	if site.Kind != types.SitePickup || site.Name != "Warehouse" || site.Latitude != 12.9716 || site.RadiusMeters == nil || *site.RadiusMeters != 150 || site.DwellSeconds == nil || *site.DwellSeconds != 90 || !site.AutoAdvance {
+		t.Errorf("GetSite = %+v", site)
+	}
+	_, err = s.CreateSite(t.Context(), types.Site{OwnerID: collectorID, Kind: types.SiteDropOff, Name: "Yard", Latitude: 13, Longitude: 77.6})
+	mustSucceed(t, err, "CreateSite(drop-off)")
+	_, err = s.CreateSite(t.Context(), types.Site{OwnerID: businessID + 1000, Kind: types.SitePickup, Name: "Nowhere"})
+	mustFail(t, err, "CreateSite(unknown owner)")
+	_, err = s.CreateSite(t.Context(), types.Site{OwnerID: businessID, Kind: "depot", Name: "Depot"})
+	mustFail(t, err, "CreateSite(unknown kind)")
+	_, err = s.GetSite(t.Context(), siteID+1000)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetSite(unknown) = %v, want ErrNotFound", err)
+	}
+
+	sites, err := s.ListSites(t.Context(), businessID)
+	mustSucceed(t, err, "ListSites")
+	if len(sites) != 1 || sites[0].SiteID != siteID {
+		t.Errorf("ListSites = %+v, want the business's site only", sites)
+	}
+
+	// Sites without a radius or dwell time use the configured defaults
+	site.Name = "Main warehouse"
+	site.RadiusMeters, site.DwellSeconds, site.AutoAdvance = nil, nil, false
+	mustSucceed(t, s.UpdateSite(t.Context(), site), "UpdateSite")
+	site, _ = s.GetSite(t.Context(), siteID)
+	if site.Name != "Main warehouse" || site.RadiusMeters != nil || site.DwellSeconds != nil || site.AutoAdvance {
+		t.Errorf("updated site = %+v", site)
+	}
+	err = s.UpdateSite(t.Context(), types.Site{SiteID: siteID + 1000, Name: "Gone"})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateSite(unknown) = %v, want ErrNotFound", err)
+	}
+
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	entered := types.DateTime{Time: start}
+	visit := types.SiteVisit{TripID: requestID, SiteID: siteID, DriverID: driverID, EnteredAt: &entered, LastSeenAt: entered}
+	mustSucceed(t, s.SaveSiteVisit(t.Context(), visit, nil), "SaveSiteVisit")
+	err = s.SaveSiteVisit(t.Context(), visit, nil)
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("SaveSiteVisit(second new visit) = %v, want ErrConflict", err)
+	}
+
+	visits, err := s.GetSiteVisits(t.Context(), requestID)
+	mustSucceed(t, err, "GetSiteVisits")
+	if len(visits) != 1 || visits[0].Version != 1 || visits[0].EnteredAt == nil || !visits[0].EnteredAt.Equal(start) || visits[0].ArrivedAt != nil {
+		t.Fatalf("GetSiteVisits = %+v, want the new visit at version 1", visits)
+	}
+
+	// Saving the arrival enqueues its event with it
+	claimed, err := s.ClaimOutboxEvents(t.Context(), 100, time.Minute)
+	mustSucceed(t, err, "ClaimOutboxEvents")
+	for _, event := range claimed {
+		mustSucceed(t, s.MarkOutboxEventDelivered(t.Context(), event.OutboxID), "MarkOutboxEventDelivered")
+	}
+	arrived := visits[0]
+	arrived.ArrivedAt = arrived.EnteredAt
+	arrived.LastSeenAt = types.DateTime{Time: start.Add(2 * time.Minute)}
+	event := types.GeofenceEvent{Event: "driver.arrived", RequestID: requestID, BusinessID: businessID, CollectorID: collectorID, DriverID: driverID, SiteID: siteID, SiteKind: types.SitePickup, At: entered, DetectedAt: arrived.LastSeenAt}
+	mustSucceed(t, s.SaveSiteVisit(t.Context(), arrived, &event), "SaveSiteVisit(arrived)")
+	claimed, err = s.ClaimOutboxEvents(t.Context(), 100, time.Minute)
+	mustSucceed(t, err, "ClaimOutboxEvents")
+	if len(claimed) != 1 || claimed[0].Topic != "GEOFENCE" || claimed[0].Attributes["event_type"] != "driver.arrived" {
+		t.Errorf("outbox after the arrival = %+v, want one driver.arrived event", claimed)
+	}
+
+	// A visit read before the arrival was saved is stale
+	err = s.SaveSiteVisit(t.Context(), visits[0], nil)
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("SaveSiteVisit(stale) = %v, want ErrConflict", err)
+	}
+	visits, _ = s.GetSiteVisits(t.Context(), requestID)
+	if len(visits) != 1 || visits[0].Version != 2 || visits[0].ArrivedAt == nil {
+		t.Errorf("GetSiteVisits after the arrival = %+v", visits)
+	}
+
+	visit.TripID = requestID + 1000
+	mustFail(t, s.SaveSiteVisit(t.Context(), visit, nil), "SaveSiteVisit(unknown trip)")
+
+	mustSucceed(t, s.DeleteSite(t.Context(), siteID), "DeleteSite")
+	visits, _ = s.GetSiteVisits(t.Context(), requestID)
+	if len(visits) != 0 {
+		t.Errorf("GetSiteVisits after DeleteSite = %+v, want none", visits)
+	}
+	err = s.DeleteSite(t.Context(), siteID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteSite(deleted) = %v, want ErrNotFound", err)
+	}
+}
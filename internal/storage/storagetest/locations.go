@@ -0,0 +1,198 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testDriverLocations(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	driverID := mustCreateDriver(t, s, "dave", collectorID)
+	collectorActor := types.Actor{UserID: collectorID, Role: "Collector"}
+	driverActor := types.Actor{UserID: driverID, Role: "Driver"}
+
+	truckID, err := s.AddVehicle(t.Context(), types.Vehicle{VehicleType: "Truck", Capacity: 5000})
+	mustSucceed(t, err, "AddVehicle")
+	_, err = s.AddCollectorVehicle(t.Context(), types.CollectorVehicle{VehicleID: truckID, VehicleNumber: "KA-01-1234"}, uint64(collectorID))
+	mustSucceed(t, err, "AddCollectorVehicle")
+	mustSucceed(t, s.AssignVehicleToDriver(t.Context(), driverID, truckID, uint64(collectorID)), "AssignVehicleToDriver")
+
+	_, err = s.GetActiveTrip(t.Context(), driverID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetActiveTrip(no assignment) = %v, want ErrNotFound", err)
+	}
+
+	// The trip in transit is preferred over one only assigned
+	assignedID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	tripID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	for _, requestID := range []int64{assignedID, tripID} {
+		mustSucceed(t, s.AcceptPickupRequest(t.Context(), requestID, collectorActor), "AcceptPickupRequest")
+		mustSucceed(t, s.AssignTripToDriver(t.Context(), requestID, driverID, collectorActor), "AssignTripToDriver")
+	}
+	trip, err := s.GetActiveTrip(t.Context(), driverID)
+	mustSucceed(t, err, "GetActiveTrip")
+	if trip.RequestID != assignedID {
+		t.Errorf("GetActiveTrip = request %d, want the first assigned %d", trip.RequestID, assignedID)
+	}
+	mustSucceed(t, s.StartDelivery(t.Context(), tripID, driverActor), "StartDelivery")
+	trip, _ = s.GetActiveTrip(t.Context(), driverID)
+	if trip.RequestID != tripID {
+		t.Errorf("GetActiveTrip = request %d, want the one in transit %d", trip.RequestID, tripID)
+	}
+
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int, latitude float64) types.DriverLocation {
+		return types.DriverLocation{
+			DriverID:    driverID,
+			CollectorID: collectorID,
+			TripID:      tripID,
+			Latitude:    latitude,
+			Longitude:   77.5946,
+			Timestamp:   types.DateTime{Time: start.Add(time.Duration(minutes) * time.Minute)},
+			Speed:       32.5,
+		}
+	}
+
+	// Locations of a buffered batch can arrive out of order
+	for _, location := range []types.DriverLocation{at(5, 12.9720), at(0, 12.9716), at(10, 12.9731)} {
+		_, err := s.StoreDriverLocation(t.Context(), location)
+		mustSucceed(t, err, "StoreDriverLocation")
+	}
+	_, err = s.StoreDriverLocation(t.Context(), types.DriverLocation{DriverID: driverID + 1000, TripID: tripID, Latitude: 1, Longitude: 1})
+	mustFail(t, err, "StoreDriverLocation(unknown driver)")
+
+	locations, err := s.GetTripLocations(t.Context(), tripID)
+	mustSucceed(t, err, "GetTripLocations")
+	if len(locations) != 3 {
+		t.Fatalf("GetTripLocations returned %d locations, want 3", len(locations))
+	}
+	first := locations[0]
+	if !first.Timestamp.Equal(start) || first.Latitude != 12.9716 || first.VehicleID != truckID || first.Speed != 32.5 {
+		t.Errorf("first location = %+v, want the one at 09:00 with the driver's vehicle", first)
+	}
+	// START goes to the first location stored, not the earliest one
+	if locations[1].Point != types.LocationStart || locations[0].Point != "" || locations[2].Point != "" {
+		t.Errorf("points = %q %q %q, want START on the first stored", locations[0].Point, locations[1].Point, locations[2].Point)
+	}
+
+	mustSucceed(t, s.MarkTripEnd(t.Context(), tripID), "MarkTripEnd")
+	locations, _ = s.GetTripLocations(t.Context(), tripID)
+	if locations[2].Point != types.LocationEnd {
+		t.Errorf("last location point = %q, want END", locations[2].Point)
+	}
+
+	// A location arriving late moves the end
+	_, err = s.StoreDriverLocation(t.Context(), at(12, 12.9735))
+	mustSucceed(t, err, "StoreDriverLocation(late)")
+	mustSucceed(t, s.MarkTripEnd(t.Context(), tripID), "MarkTripEnd(again)")
+	locations, _ = s.GetTripLocations(t.Context(), tripID)
+	ends := 0
+	for _, location := range locations {
+		if location.Point == types.LocationEnd {
+			ends++
+		}
+	}
+	if ends != 1 || locations[3].Point != types.LocationEnd {
+		t.Errorf("after a late location, %d ENDs and last point %q, want a single END on the last", ends, locations[3].Point)
+	}
+
+	mustSucceed(t, s.MarkTripEnd(t.Context(), assignedID), "MarkTripEnd(no locations)")
+	none, err := s.GetTripLocations(t.Context(), assignedID)
+	mustSucceed(t, err, "GetTripLocations(no locations)")
+	if none == nil || len(none) != 0 {
+		t.Errorf("GetTripLocations(no locations) = %#v, want an empty slice", none)
+	}
+
+	// Locations go with the driver, like the foreign key
+	mustSucceed(t, s.DeleteCollectorDriver(t.Context(), driverID, uint64(collectorID)), "DeleteCollectorDriver")
+	locations, _ = s.GetTripLocations(t.Context(), tripID)
+	if len(locations) != 0 {
+		t.Errorf("GetTripLocations after deleting the driver = %d locations, want none", len(locations))
+	}
+}
+
+func testPickupETAs(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	driverID := mustCreateDriver(t, s, "dave", collectorID)
+
+	// Pickup coordinates are optional, and kept apart from the caller's values
+	latitude, longitude := 12.9716, 77.5946
+	requestID, err := s.CreatePickupRequest(t.Context(), types.PickupRequest{
+		BusinessID:      businessID,
+		CollectorID:     collectorID,
+		WasteType:       "Plastic",
+		Quantity:        10,
+		PickupDate:      types.DateTime{Time: time.Now().Add(time.Hour)},
+		PickupLatitude:  &latitude,
+		PickupLongitude: &longitude,
+	}, types.Actor{UserID: businessID, Role: "Business"})
+	mustSucceed(t, err, "CreatePickupRequest")
+	latitude = 0
+	request, err := s.GetPickupRequestByID(t.Context(), requestID)
+	mustSucceed(t, err, "GetPickupRequestByID")
+	if request.PickupLatitude == nil || *request.PickupLatitude != 12.9716 || request.PickupLongitude == nil || *request.PickupLongitude != 77.5946 {
+		t.Errorf("pickup coordinates = %v, %v, want 12.9716, 77.5946", request.PickupLatitude, request.PickupLongitude)
+	}
+	plain := mustCreatePickupRequest(t, s, businessID, collectorID)
+	request, _ = s.GetPickupRequestByID(t.Context(), plain)
+	if request.PickupLatitude != nil || request.PickupLongitude != nil {
+		t.Errorf("pickup coordinates of a request without = %v, %v, want nil", request.PickupLatitude, request.PickupLongitude)
+	}
+	movedLatitude, movedLongitude := 12.98, 77.6
+	update := types.UpdatePickupRequest{PickupLatitude: &movedLatitude, PickupLongitude: &movedLongitude}
+	mustSucceed(t, s.UpdatePickupRequest(t.Context(), plain, update, actor), "UpdatePickupRequest(coordinates)")
+	request, _ = s.GetPickupRequestByID(t.Context(), plain)
+	if request.PickupLatitude == nil || *request.PickupLatitude != 12.98 || *request.PickupLongitude != 77.6 {
+		t.Errorf("updated pickup coordinates = %v, %v, want 12.98, 77.6", request.PickupLatitude, request.PickupLongitude)
+	}
+
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		_, err := s.StoreDriverLocation(t.Context(), types.DriverLocation{
+			DriverID:  driverID,
+			TripID:    requestID,
+			Latitude:  12.9 + float64(i)/100,
+			Longitude: 77.5,
+			Timestamp: types.DateTime{Time: start.Add(time.Duration(i) * time.Minute)},
+		})
+		mustSucceed(t, err, "StoreDriverLocation")
+	}
+	latest, err := s.GetLatestTripLocations(t.Context(), requestID, 2)
+	mustSucceed(t, err, "GetLatestTripLocations")
+	if len(latest) != 2 || latest[0].Latitude != 12.92 || latest[1].Latitude != 12.93 {
+		t.Errorf("GetLatestTripLocations = %+v, want the last two, oldest first", latest)
+	}
+
+	_, err = s.GetPickupETA(t.Context(), requestID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetPickupETA(none) = %v, want ErrNotFound", err)
+	}
+	eta := types.PickupETA{
+		RequestID:       requestID,
+		DriverID:        driverID,
+		DriverLatitude:  12.93,
+		DriverLongitude: 77.5,
+		DistanceMeters:  5200,
+		SpeedKmh:        26,
+		ArrivalAt:       types.DateTime{Time: start.Add(15 * time.Minute)},
+		LocatedAt:       types.DateTime{Time: start.Add(3 * time.Minute)},
+		CalculatedAt:    types.DateTime{Time: start.Add(3 * time.Minute)},
+	}
+	mustSucceed(t, s.SavePickupETA(t.Context(), eta), "SavePickupETA")
+	eta.DistanceMeters = 4000
+	eta.ArrivalAt = types.DateTime{Time: start.Add(12 * time.Minute)}
+	mustSucceed(t, s.SavePickupETA(t.Context(), eta), "SavePickupETA(again)")
+	got, err := s.GetPickupETA(t.Context(), requestID)
+	mustSucceed(t, err, "GetPickupETA")
+	if got.DistanceMeters != 4000 || got.RemainingSeconds != 540 || !got.ArrivalAt.Equal(eta.ArrivalAt.Time) || got.DriverID != driverID {
+		t.Errorf("GetPickupETA = %+v, want the second ETA, 540s after the location", got)
+	}
+	eta.RequestID = requestID + 1000
+	mustFail(t, s.SavePickupETA(t.Context(), eta), "SavePickupETA(unknown request)")
+}
@@ -0,0 +1,179 @@
+package storagetest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testMarket(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	greenID := mustCreateCollector(t, s, "green")
+	blueID := mustCreateCollector(t, s, "blue")
+	outsiderID := mustCreateCollector(t, s, "outsider")
+	businessActor := types.Actor{UserID: businessID, Role: "Business"}
+	greenActor := types.Actor{UserID: greenID, Role: "Collector"}
+	blueActor := types.Actor{UserID: blueID, Role: "Collector"}
+
+	createOpen := func() int64 {
+		t.Helper()
+		requestID, err := s.CreateOpenPickupRequest(t.Context(), types.PickupRequest{
+			BusinessID: businessID,
+			WasteType:  "Plastic",
+			Quantity:   80,
+			PickupDate: types.DateTime{Time: time.Now().Add(48 * time.Hour)},
+		}, []types.MarketInvitation{{CollectorID: greenID, PricePerKg: 10}, {CollectorID: blueID, PricePerKg: 8}}, businessActor)
+		mustSucceed(t, err, "CreateOpenPickupRequest")
+		return requestID
+	}
+	requestID := createOpen()
+
+	request, err := s.GetPickupRequestByID(t.Context(), requestID)
+	mustSucceed(t, err, "GetPickupRequestByID")
+	if !request.OpenMarket || request.CollectorID != 0 || request.Status != string(lifecycle.Pending) || request.PricePerKg != nil {
+		t.Errorf("open pickup request = %+v", request)
+	}
+	invitations, err := s.ListMarketInvitations(t.Context(), requestID)
+	mustSucceed(t, err, "ListMarketInvitations")
+	if len(invitations) != 2 || invitations[0].CollectorID != greenID || invitations[1].CollectorID != blueID || invitations[1].PricePerKg != 8 {
+		t.Errorf("ListMarketInvitations = %+v, want green then blue at 8", invitations)
+	}
+	_, err = s.CreateOpenPickupRequest(t.Context(), types.PickupRequest{BusinessID: businessID, WasteType: "Plastic", Quantity: 1},
+		[]types.MarketInvitation{{CollectorID: 9999, PricePerKg: 1}}, businessActor)
+	mustFail(t, err, "CreateOpenPickupRequest(unknown collector)")
+
+	// Nobody wins an open request through the regular lifecycle
+	if err := s.AcceptPickupRequest(t.Context(), requestID, greenActor); !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("AcceptPickupRequest(open) = %v, want ErrIllegalTransition", err)
+	}
+	err = s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Status: string(lifecycle.Accepted)}, businessActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("UpdatePickupRequest(open, Accepted) = %v, want ErrIllegalTransition", err)
+	}
+
+	// Bids
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	bid := func(collectorID int64, price float64, from time.Time, hours int) (int64, error) {
+		return s.PlaceBid(t.Context(), types.PickupBid{
+			RequestID:   requestID,
+			CollectorID: collectorID,
+			PricePerKg:  price,
+			WindowStart: types.DateTime{Time: from},
+			WindowEnd:   types.DateTime{Time: from.Add(time.Duration(hours) * time.Hour)},
+		})
+	}
+	if _, err := bid(outsiderID, 5, start, 2); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("PlaceBid(not invited) = %v, want ErrNotFound", err)
+	}
+	_, err = bid(greenID, 9, start, 0)
+	mustFail(t, err, "PlaceBid(empty window)")
+	greenBidID, err := bid(greenID, 9.5, start, 2)
+	mustSucceed(t, err, "PlaceBid")
+	blueBidID, err := bid(blueID, 9, start.Add(time.Hour), 3)
+	mustSucceed(t, err, "PlaceBid")
+	rebidID, err := bid(greenID, 7.5, start, 4)
+	mustSucceed(t, err, "PlaceBid(again)")
+	if rebidID != greenBidID {
+		t.Errorf("PlaceBid(again) = bid %d, want bid %d replaced", rebidID, greenBidID)
+	}
+
+	bids, err := s.ListPickupBids(t.Context(), requestID)
+	mustSucceed(t, err, "ListPickupBids")
+	if len(bids) != 2 || bids[0].BidID != greenBidID || bids[0].PricePerKg != 7.5 || bids[1].BidID != blueBidID {
+		t.Fatalf("ListPickupBids = %+v, want green at 7.5 then blue", bids)
+	}
+	if !bids[0].WindowEnd.Equal(start.Add(4 * time.Hour)) {
+		t.Errorf("replaced bid window ends at %v, want %v", bids[0].WindowEnd, start.Add(4*time.Hour))
+	}
+
+	open, err := s.ListOpenPickupRequests(t.Context(), blueID)
+	mustSucceed(t, err, "ListOpenPickupRequests")
+	if len(open) != 1 || open[0].RequestID != requestID || open[0].ListPricePerKg != 8 || open[0].Bid == nil || open[0].Bid.BidID != blueBidID {
+		t.Errorf("ListOpenPickupRequests(blue) = %+v", open)
+	}
+	open, err = s.ListOpenPickupRequests(t.Context(), outsiderID)
+	mustSucceed(t, err, "ListOpenPickupRequests(not invited)")
+	if len(open) != 0 {
+		t.Errorf("ListOpenPickupRequests(not invited) = %+v", open)
+	}
+
+	// The business picks a bid
+	if err := s.AcceptPickupBid(t.Context(), requestID, 9999, businessActor); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("AcceptPickupBid(unknown bid) = %v, want ErrNotFound", err)
+	}
+	mustSucceed(t, s.AcceptPickupBid(t.Context(), requestID, blueBidID, businessActor), "AcceptPickupBid")
+	request, _ = s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Status != string(lifecycle.Accepted) || request.CollectorID != blueID || !sameValue(request.PricePerKg, 9) ||
+		request.WindowStart == nil || !request.WindowStart.Equal(start.Add(time.Hour)) {
+		t.Errorf("pickup request after AcceptPickupBid = %+v", request)
+	}
+	if err := s.AcceptPickupBid(t.Context(), requestID, greenBidID, businessActor); !errors.Is(err, storage.ErrNotOpen) {
+		t.Errorf("AcceptPickupBid(won) = %v, want ErrNotOpen", err)
+	}
+	if _, err := bid(greenID, 5, start, 2); !errors.Is(err, storage.ErrNotOpen) {
+		t.Errorf("PlaceBid(won) = %v, want ErrNotOpen", err)
+	}
+	open, _ = s.ListOpenPickupRequests(t.Context(), greenID)
+	if len(open) != 0 {
+		t.Errorf("ListOpenPickupRequests after the award = %+v", open)
+	}
+	timeline, _ := s.GetPickupRequestTimeline(t.Context(), requestID)
+	if len(timeline) != 2 || timeline[1].NewStatus != string(lifecycle.Accepted) || timeline[1].Reason != "bid "+id(blueBidID)+" accepted" {
+		t.Errorf("timeline after AcceptPickupBid = %+v", timeline)
+	}
+
+	// The first of the invited collectors to accept at the list price wins
+	racedID := createOpen()
+	if err := s.AcceptOpenPickupRequest(t.Context(), racedID, types.Actor{UserID: outsiderID, Role: "Collector"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("AcceptOpenPickupRequest(not invited) = %v, want ErrNotFound", err)
+	}
+	const attempts = 8
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			contender := greenActor
+			if i%2 == 1 {
+				contender = blueActor
+			}
+			errs[i] = s.AcceptOpenPickupRequest(t.Context(), racedID, contender)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else if !errors.Is(err, storage.ErrNotOpen) {
+			t.Errorf("concurrent AcceptOpenPickupRequest: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d of %d concurrent acceptances succeeded, want 1", succeeded, attempts)
+	}
+	raced, _ := s.GetPickupRequestByID(t.Context(), racedID)
+	wantPrice := map[int64]float64{greenID: 10, blueID: 8}[raced.CollectorID]
+	if raced.Status != string(lifecycle.Accepted) || wantPrice == 0 || !sameValue(raced.PricePerKg, wantPrice) || raced.WindowStart != nil {
+		t.Errorf("pickup request after concurrent acceptances = %+v", raced)
+	}
+	timeline, _ = s.GetPickupRequestTimeline(t.Context(), racedID)
+	if len(timeline) != 2 || timeline[1].Reason != "accepted at the list price" {
+		t.Errorf("timeline after concurrent acceptances = %+v", timeline)
+	}
+
+	// An open request can still be cancelled
+	cancelledID := createOpen()
+	err = s.UpdatePickupRequest(t.Context(), cancelledID, types.UpdatePickupRequest{Status: string(lifecycle.Cancelled), Reason: "plans changed"}, businessActor)
+	mustSucceed(t, err, "UpdatePickupRequest(open, Cancelled)")
+	if err := s.AcceptOpenPickupRequest(t.Context(), cancelledID, greenActor); !errors.Is(err, storage.ErrNotOpen) {
+		t.Errorf("AcceptOpenPickupRequest(cancelled) = %v, want ErrNotOpen", err)
+	}
+}
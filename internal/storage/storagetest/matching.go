@@ -0,0 +1,106 @@
+package storagetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testMatchCandidates(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	greenID := mustCreateLocatedCollector(t, s, "green", 18.5204, 73.8567)
+	blueID := mustCreateCollector(t, s, "blue")
+	paperOnlyID := mustCreateCollector(t, s, "paper")
+	flaggedID := mustCreateCollector(t, s, "flagged")
+	mustSucceed(t, s.VerifyUser(t.Context(), id(greenID)), "VerifyUser")
+
+	plasticID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Plastic"})
+	mustSucceed(t, err, "AddServiceCategory")
+	paperID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Paper"})
+	mustSucceed(t, err, "AddServiceCategory")
+	offers := []struct {
+		collectorID int64
+		categoryID  int64
+		price       float64
+		capacity    float64
+	}{
+		{greenID, plasticID, 10, 500},
+		{greenID, paperID, 4, 800},
+		{blueID, plasticID, 8, 300},
+		{paperOnlyID, paperID, 3, 1000},
+		{flaggedID, plasticID, 1, 5000},
+	}
+	for _, offer := range offers {
+		_, err := s.AddCollectorServiceCategory(t.Context(), types.CollectorServiceCategory{
+			CategoryID: offer.categoryID, PricePerKg: offer.price, MaximumCapacity: offer.capacity,
+		}, uint64(offer.collectorID))
+		mustSucceed(t, err, "AddCollectorServiceCategory")
+	}
+	mustSucceed(t, s.FlagUser(t.Context(), id(flaggedID)), "FlagUser")
+
+	// Green has two open plastic pickups on the day, a paper one, one on the next day, and a
+	// completed and a cancelled one before
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	book := func(collectorID int64, wasteType string, quantity float64, at time.Time) int64 {
+		t.Helper()
+		requestID, err := s.CreatePickupRequest(t.Context(), types.PickupRequest{
+			BusinessID:  businessID,
+			CollectorID: collectorID,
+			WasteType:   wasteType,
+			Quantity:    quantity,
+			PickupDate:  types.DateTime{Time: at},
+		}, types.Actor{UserID: businessID, Role: "Business"})
+		mustSucceed(t, err, "CreatePickupRequest")
+		return requestID
+	}
+	book(greenID, "Plastic", 100, day.Add(9*time.Hour))
+	accepted := book(greenID, "Plastic", 50, day.Add(15*time.Hour))
+	mustSucceed(t, s.AcceptPickupRequest(t.Context(), accepted, types.Actor{UserID: greenID, Role: "Collector"}), "AcceptPickupRequest")
+	book(greenID, "Paper", 70, day.Add(10*time.Hour))
+	book(greenID, "Plastic", 200, day.Add(24*time.Hour))
+
+	completed := book(greenID, "Plastic", 10, day.Add(-48*time.Hour))
+	greenActor := types.Actor{UserID: greenID, Role: "Collector"}
+	mustSucceed(t, s.AcceptPickupRequest(t.Context(), completed, greenActor), "AcceptPickupRequest")
+	mustSucceed(t, s.AssignTripToDriver(t.Context(), completed, 42, greenActor), "AssignTripToDriver")
+	driverActor := types.Actor{UserID: 42, Role: "Driver"}
+	mustSucceed(t, s.StartDelivery(t.Context(), completed, driverActor), "StartDelivery")
+	mustSucceed(t, s.EndDelivery(t.Context(), completed, driverActor), "EndDelivery")
+	cancelled := book(greenID, "Plastic", 10, day.Add(-24*time.Hour))
+	mustSucceed(t, s.UpdatePickupRequest(t.Context(), cancelled, types.UpdatePickupRequest{Status: "Cancelled", Reason: "plans changed"},
+		types.Actor{UserID: businessID, Role: "Business"}), "UpdatePickupRequest(cancel)")
+
+	candidates, err := s.GetMatchCandidates(t.Context(), types.MatchCandidatesQuery{WasteType: "Plastic", From: day, To: day.AddDate(0, 0, 1)})
+	mustSucceed(t, err, "GetMatchCandidates")
+	if len(candidates) != 2 || candidates[0].CollectorID != greenID || candidates[1].CollectorID != blueID {
+		t.Fatalf("GetMatchCandidates = %+v, want green then blue", candidates)
+	}
+	green := candidates[0]
+	if green.CompanyName != "green Recycling" || !green.IsVerified || !sameCoordinates(green.Latitude, green.Longitude, 18.5204, 73.8567) {
+		t.Errorf("green candidate = %+v", green)
+	}
+	if green.PricePerKg != 10 || green.MaximumCapacity != 500 || green.BookedQuantity != 150 {
+		t.Errorf("green offer = %v per kg, %v booked of %v, want 10, 150 of 500", green.PricePerKg, green.BookedQuantity, green.MaximumCapacity)
+	}
+	if green.CompletedPickups != 1 || green.EndedPickups != 2 {
+		t.Errorf("green record = %d of %d completed, want 1 of 2", green.CompletedPickups, green.EndedPickups)
+	}
+	blue := candidates[1]
+	if blue.IsVerified || blue.Latitude != nil || blue.BookedQuantity != 0 || blue.EndedPickups != 0 {
+		t.Errorf("blue candidate = %+v", blue)
+	}
+
+	candidates, err = s.GetMatchCandidates(t.Context(), types.MatchCandidatesQuery{WasteType: "Paper", From: day, To: day.AddDate(0, 0, 1)})
+	mustSucceed(t, err, "GetMatchCandidates(paper)")
+	if len(candidates) != 2 || candidates[0].CollectorID != greenID || candidates[0].BookedQuantity != 70 || candidates[1].CollectorID != paperOnlyID {
+		t.Errorf("GetMatchCandidates(paper) = %+v, want green with 70 kg booked, then paper", candidates)
+	}
+
+	candidates, err = s.GetMatchCandidates(t.Context(), types.MatchCandidatesQuery{WasteType: "Glass", From: day, To: day.AddDate(0, 0, 1)})
+	mustSucceed(t, err, "GetMatchCandidates(unknown waste type)")
+	if len(candidates) != 0 {
+		t.Errorf("GetMatchCandidates(unknown waste type) = %+v", candidates)
+	}
+}
@@ -0,0 +1,137 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testNearbyCollectors(t *testing.T, s storage.Storage) {
+	// Businesses and collectors keep their coordinates, which updates only change when given
+	b := business("located")
+	b.Latitude, b.Longitude = pointer(18.5204), pointer(73.8567)
+	businessID, err := s.CreateBusinessUser(t.Context(), b)
+	mustSucceed(t, err, "CreateBusinessUser")
+	stored, err := s.GetBusinessByEmail(t.Context(), b.Email)
+	mustSucceed(t, err, "GetBusinessByEmail")
+	if !sameCoordinates(stored.Latitude, stored.Longitude, 18.5204, 73.8567) {
+		t.Errorf("business coordinates = %v, %v, want 18.5204, 73.8567", stored.Latitude, stored.Longitude)
+	}
+	_, err = s.UpdateBusinessProfile(t.Context(), businessID, types.BusinessUpdate{Business_name: "Renamed"})
+	mustSucceed(t, err, "UpdateBusinessProfile")
+	_, err = s.UpdateBusinessProfile(t.Context(), businessID, types.BusinessUpdate{Latitude: pointer(19.076), Longitude: pointer(72.8777)})
+	mustSucceed(t, err, "UpdateBusinessProfile(coordinates)")
+	stored, err = s.GetBusinessByID(t.Context(), businessID)
+	mustSucceed(t, err, "GetBusinessByID")
+	if stored.Business_name != "Renamed" || !sameCoordinates(stored.Latitude, stored.Longitude, 19.076, 72.8777) {
+		t.Errorf("updated business = %+v, want it renamed and moved to 19.076, 72.8777", stored)
+	}
+
+	unlocatedID := mustCreateCollector(t, s, "unlocated")
+	unlocated, err := s.GetCollectorByID(t.Context(), unlocatedID)
+	mustSucceed(t, err, "GetCollectorByID")
+	if unlocated.Latitude != nil || unlocated.Longitude != nil {
+		t.Errorf("coordinates of a collector registered without = %v, %v", unlocated.Latitude, unlocated.Longitude)
+	}
+
+	// Depots in Pune, 3 km away from it, in Mumbai, and a flagged one, which is inactive
+	centreID := mustCreateLocatedCollector(t, s, "centre", 18.5204, 73.8567)
+	outskirtsID := mustCreateLocatedCollector(t, s, "outskirts", 18.5474, 73.8567)
+	mumbaiID := mustCreateLocatedCollector(t, s, "mumbai", 19.076, 72.8777)
+	flaggedID := mustCreateLocatedCollector(t, s, "flagged", 18.5210, 73.8570)
+	mustSucceed(t, s.FlagUser(t.Context(), id(flaggedID)), "FlagUser")
+
+	moved, err := s.GetCollectorByID(t.Context(), outskirtsID)
+	mustSucceed(t, err, "GetCollectorByID")
+	if !sameCoordinates(moved.Latitude, moved.Longitude, 18.5474, 73.8567) {
+		t.Errorf("collector coordinates = %v, %v, want 18.5474, 73.8567", moved.Latitude, moved.Longitude)
+	}
+
+	plasticID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Plastic"})
+	mustSucceed(t, err, "AddServiceCategory")
+	paperID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Paper"})
+	mustSucceed(t, err, "AddServiceCategory")
+	offers := []struct {
+		collectorID int64
+		categoryID  int64
+		price       float64
+		capacity    float64
+	}{
+		{centreID, plasticID, 10, 500},
+		{centreID, paperID, 4, 800},
+		{outskirtsID, plasticID, 8, 300},
+		{mumbaiID, plasticID, 6, 1000},
+		{flaggedID, plasticID, 1, 5000},
+	}
+	for _, offer := range offers {
+		_, err := s.AddCollectorServiceCategory(t.Context(), types.CollectorServiceCategory{
+			CategoryID: offer.categoryID, PricePerKg: offer.price, MaximumCapacity: offer.capacity,
+		}, uint64(offer.collectorID))
+		mustSucceed(t, err, "AddCollectorServiceCategory")
+	}
+
+	pune := types.NearbyCollectorsQuery{Latitude: 18.5204, Longitude: 73.8567, RadiusKm: 10}
+	nearby, err := s.GetNearbyCollectors(t.Context(), pune)
+	mustSucceed(t, err, "GetNearbyCollectors")
+	if len(nearby) != 2 || nearby[0].CollectorID != centreID || nearby[1].CollectorID != outskirtsID {
+		t.Fatalf("GetNearbyCollectors = %+v, want the centre then the outskirts", nearby)
+	}
+	if nearby[0].DistanceKm > 0.01 || nearby[1].DistanceKm < 2.9 || nearby[1].DistanceKm > 3.1 {
+		t.Errorf("distances = %v and %v km, want 0 and 3", nearby[0].DistanceKm, nearby[1].DistanceKm)
+	}
+	if nearby[0].CompanyName != "centre Recycling" || nearby[0].Address != "1 Test Road" {
+		t.Errorf("nearby collector = %+v", nearby[0])
+	}
+	// Without a waste type, the lowest price and the largest capacity over all offers
+	if !sameValue(nearby[0].PricePerKg, 4) || !sameValue(nearby[0].MaximumCapacity, 800) {
+		t.Errorf("offer of the centre = %v, %v, want 4 and 800", nearby[0].PricePerKg, nearby[0].MaximumCapacity)
+	}
+
+	pune.WasteType = "Plastic"
+	nearby, err = s.GetNearbyCollectors(t.Context(), pune)
+	mustSucceed(t, err, "GetNearbyCollectors(plastic)")
+	if len(nearby) != 2 || !sameValue(nearby[0].PricePerKg, 10) || !sameValue(nearby[0].MaximumCapacity, 500) || !sameValue(nearby[1].PricePerKg, 8) {
+		t.Errorf("GetNearbyCollectors(plastic) = %+v, want the plastic offers of the centre and the outskirts", nearby)
+	}
+
+	pune.WasteType = "Paper"
+	nearby, err = s.GetNearbyCollectors(t.Context(), pune)
+	mustSucceed(t, err, "GetNearbyCollectors(paper)")
+	if len(nearby) != 1 || nearby[0].CollectorID != centreID {
+		t.Errorf("GetNearbyCollectors(paper) = %+v, want the centre only", nearby)
+	}
+
+	pune.WasteType = "Glass"
+	nearby, err = s.GetNearbyCollectors(t.Context(), pune)
+	mustSucceed(t, err, "GetNearbyCollectors(unknown waste type)")
+	if len(nearby) != 0 {
+		t.Errorf("GetNearbyCollectors(unknown waste type) = %+v", nearby)
+	}
+
+	pune.WasteType, pune.RadiusKm = "", 200
+	nearby, err = s.GetNearbyCollectors(t.Context(), pune)
+	mustSucceed(t, err, "GetNearbyCollectors(200 km)")
+	if len(nearby) != 3 || nearby[2].CollectorID != mumbaiID {
+		t.Errorf("GetNearbyCollectors(200 km) = %+v, want Mumbai last", nearby)
+	}
+}
+
+func mustCreateLocatedCollector(t *testing.T, s storage.Storage, name string, lat float64, lng float64) int64 {
+	t.Helper()
+	c := collector(name)
+	c.Latitude, c.Longitude = pointer(lat), pointer(lng)
+	collectorID, err := s.CreateCollectorUser(t.Context(), c)
+	if err != nil {
+		t.Fatalf("CreateCollectorUser(%s): %v", name, err)
+	}
+	return collectorID
+}
+
+func sameCoordinates(lat *float64, lng *float64, wantLat float64, wantLng float64) bool {
+	return sameValue(lat, wantLat) && sameValue(lng, wantLng)
+}
+
+func sameValue(value *float64, want float64) bool {
+	return value != nil && *value > want-1e-6 && *value < want+1e-6
+}
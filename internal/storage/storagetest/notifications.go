@@ -0,0 +1,120 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testNotificationTemplates(t *testing.T, s storage.Storage) {
+	_, err := s.GetNotificationTemplate(t.Context(), "pickup.created.collector", "en")
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetNotificationTemplate(missing) = %v, want ErrNotFound", err)
+	}
+
+	template := types.NotificationTemplate{Name: "pickup.created.collector", Locale: "en", Subject: "New request", Text: "Hello", UpdatedBy: 1}
+	mustSucceed(t, s.SaveNotificationTemplate(t.Context(), template), "SaveNotificationTemplate")
+	template.Subject = "New pickup request"
+	template.HTML = "<p>Hello</p>"
+	mustSucceed(t, s.SaveNotificationTemplate(t.Context(), template), "SaveNotificationTemplate(replace)")
+	mustSucceed(t, s.SaveNotificationTemplate(t.Context(), types.NotificationTemplate{Name: "pickup.created.collector", Locale: "hi", Subject: "S", Text: "T"}), "SaveNotificationTemplate(hi)")
+	mustSucceed(t, s.SaveNotificationTemplate(t.Context(), types.NotificationTemplate{Name: "delivery.started.business", Locale: "en", Subject: "S", Text: "T"}), "SaveNotificationTemplate(other)")
+
+	got, err := s.GetNotificationTemplate(t.Context(), "pickup.created.collector", "en")
+	mustSucceed(t, err, "GetNotificationTemplate")
+	if got.Subject != "New pickup request" || got.HTML != "<p>Hello</p>" || got.Source != "override" || got.UpdatedBy != 1 || got.UpdatedAt == nil {
+		t.Errorf("GetNotificationTemplate = %+v", got)
+	}
+
+	templates, err := s.ListNotificationTemplates(t.Context())
+	mustSucceed(t, err, "ListNotificationTemplates")
+	var keys []string
+	for _, template := range templates {
+		keys = append(keys, template.Name+"/"+template.Locale)
+	}
+	want := []string{"delivery.started.business/en", "pickup.created.collector/en", "pickup.created.collector/hi"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] || keys[2] != want[2] {
+		t.Errorf("ListNotificationTemplates = %v, want %v", keys, want)
+	}
+
+	mustSucceed(t, s.DeleteNotificationTemplate(t.Context(), "pickup.created.collector", "hi"), "DeleteNotificationTemplate")
+	if err := s.DeleteNotificationTemplate(t.Context(), "pickup.created.collector", "hi"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteNotificationTemplate(deleted) = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetNotificationTemplate(t.Context(), "pickup.created.collector", "en"); err != nil {
+		t.Errorf("deleting one locale deleted another: %v", err)
+	}
+}
+
+func testNotifications(t *testing.T, s storage.Storage) {
+	userID := mustCreateBusiness(t, s, "acme")
+	otherID := mustCreateBusiness(t, s, "globex")
+
+	notify := func(userID int64, eventID string, heading string) {
+		t.Helper()
+		mustSucceed(t, s.CreateNotification(t.Context(), types.Notification{
+			UserID:          userID,
+			EventID:         eventID,
+			Type:            "pickup.accepted.business",
+			PickupRequestID: 7,
+			Heading:         heading,
+			Message:         heading + " message",
+		}), "CreateNotification")
+	}
+	notify(userID, "e-1", "first")
+	notify(userID, "e-1", "first again") // A redelivered event is recorded once
+	notify(userID, "", "second")
+	notify(userID, "", "third") // Notifications without an event are never duplicates
+	notify(otherID, "e-1", "other")
+
+	notifications, total, err := s.ListNotifications(t.Context(), userID, false, 10, 0)
+	mustSucceed(t, err, "ListNotifications")
+	if total != 3 || len(notifications) != 3 {
+		t.Fatalf("ListNotifications = %d of %d, want 3 of 3", len(notifications), total)
+	}
+	if notifications[0].Heading != "third" || notifications[2].Heading != "first" {
+		t.Errorf("notifications are not newest first: %q, %q, %q", notifications[0].Heading, notifications[1].Heading, notifications[2].Heading)
+	}
+	first := notifications[2]
+	if first.EventID != "e-1" || first.PickupRequestID != 7 || first.IsRead || first.ReadAt != nil || first.CreatedAt.IsZero() {
+		t.Errorf("stored notification = %+v", first)
+	}
+
+	paged, total, _ := s.ListNotifications(t.Context(), userID, false, 2, 2)
+	if total != 3 || len(paged) != 1 || paged[0].NotificationID != first.NotificationID {
+		t.Errorf("second page = %+v of %d", paged, total)
+	}
+
+	if err := s.MarkNotificationRead(t.Context(), otherID, first.NotificationID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("MarkNotificationRead(someone else's) = %v, want ErrNotFound", err)
+	}
+	mustSucceed(t, s.MarkNotificationRead(t.Context(), userID, first.NotificationID), "MarkNotificationRead")
+	mustSucceed(t, s.MarkNotificationRead(t.Context(), userID, first.NotificationID), "MarkNotificationRead(again)")
+
+	unread, err := s.CountUnreadNotifications(t.Context(), userID)
+	mustSucceed(t, err, "CountUnreadNotifications")
+	if unread != 2 {
+		t.Errorf("unread count = %d, want 2", unread)
+	}
+	unreadOnly, total, _ := s.ListNotifications(t.Context(), userID, true, 10, 0)
+	if total != 2 || len(unreadOnly) != 2 {
+		t.Errorf("unread notifications = %d of %d, want 2 of 2", len(unreadOnly), total)
+	}
+
+	marked, err := s.MarkAllNotificationsRead(t.Context(), userID)
+	mustSucceed(t, err, "MarkAllNotificationsRead")
+	if marked != 2 {
+		t.Errorf("MarkAllNotificationsRead marked %d, want 2", marked)
+	}
+	notifications, _, _ = s.ListNotifications(t.Context(), userID, false, 10, 0)
+	for _, notification := range notifications {
+		if !notification.IsRead || notification.ReadAt == nil {
+			t.Errorf("notification %d is unread after marking all read", notification.NotificationID)
+		}
+	}
+	if unread, _ := s.CountUnreadNotifications(t.Context(), otherID); unread != 1 {
+		t.Errorf("other user's unread count = %d, want 1", unread)
+	}
+}
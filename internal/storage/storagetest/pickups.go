@@ -0,0 +1,288 @@
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/lifecycle"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testPickupLifecycle(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	collectorActor := types.Actor{UserID: collectorID, Role: "Collector"}
+
+	// Pickup requests reference an existing business and collector
+	_, err := s.CreatePickupRequest(t.Context(), types.PickupRequest{BusinessID: businessID + 1000, CollectorID: collectorID, Quantity: 1}, actor)
+	mustFail(t, err, "CreatePickupRequest(unknown business)")
+	_, err = s.CreatePickupRequest(t.Context(), types.PickupRequest{BusinessID: businessID, CollectorID: collectorID + 1000, Quantity: 1}, actor)
+	mustFail(t, err, "CreatePickupRequest(unknown collector)")
+
+	requestID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	request, err := s.GetPickupRequestByID(t.Context(), requestID)
+	mustSucceed(t, err, "GetPickupRequestByID")
+	if request.Status != string(lifecycle.Pending) || request.BusinessID != businessID || request.Quantity != 120.5 {
+		t.Errorf("created pickup request = %+v", request)
+	}
+	if request.CreatedAt.IsZero() {
+		t.Errorf("created pickup request has no creation time")
+	}
+	_, err = s.GetPickupRequestByID(t.Context(), requestID+1000)
+	mustFail(t, err, "GetPickupRequestByID(unknown)")
+
+	mustSucceed(t, s.AcceptPickupRequest(t.Context(), requestID, collectorActor), "AcceptPickupRequest")
+	mustSucceed(t, s.AssignTripToDriver(t.Context(), requestID, 42, collectorActor), "AssignTripToDriver")
+	request, _ = s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Status != string(lifecycle.Assigned) || request.AssignedDriver != 42 {
+		t.Errorf("assigned pickup request = %+v", request)
+	}
+
+	mustSucceed(t, s.UnassignTripFromDriver(t.Context(), requestID, collectorActor), "UnassignTripFromDriver")
+	request, _ = s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Status != string(lifecycle.Accepted) || request.AssignedDriver != -1 {
+		t.Errorf("unassigned pickup request = %+v, want Accepted without a driver", request)
+	}
+
+	mustSucceed(t, s.AssignTripToDriver(t.Context(), requestID, 43, collectorActor), "AssignTripToDriver(again)")
+	driverActor := types.Actor{UserID: 43, Role: "Driver"}
+	mustSucceed(t, s.StartDelivery(t.Context(), requestID, driverActor), "StartDelivery")
+	mustSucceed(t, s.EndDelivery(t.Context(), requestID, driverActor), "EndDelivery")
+
+	// Completed requests are final
+	err = s.AcceptPickupRequest(t.Context(), requestID, collectorActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("AcceptPickupRequest(completed) = %v, want ErrIllegalTransition", err)
+	}
+	err = s.StartDelivery(t.Context(), requestID+1000, driverActor)
+	mustFail(t, err, "StartDelivery(unknown)")
+
+	timeline, err := s.GetPickupRequestTimeline(t.Context(), requestID)
+	mustSucceed(t, err, "GetPickupRequestTimeline")
+	var statuses []string
+	for _, change := range timeline {
+		statuses = append(statuses, change.NewStatus)
+	}
+	want := []string{"Pending", "Accepted", "Assigned", "Accepted", "Assigned", "InTransit", "Completed"}
+	if !slices.Equal(statuses, want) {
+		t.Fatalf("timeline statuses = %v, want %v", statuses, want)
+	}
+	if timeline[0].OldStatus != "" || timeline[0].ActorUserID != businessID || timeline[0].ActorRole != "Business" {
+		t.Errorf("creation entry = %+v", timeline[0])
+	}
+	if timeline[2].OldStatus != "Accepted" || timeline[2].Reason != "driver 42 assigned" || timeline[2].ActorUserID != collectorID {
+		t.Errorf("assignment entry = %+v", timeline[2])
+	}
+	if timeline[6].ActorRole != "Driver" {
+		t.Errorf("completion entry = %+v", timeline[6])
+	}
+
+	rejectedID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	mustSucceed(t, s.RejectPickupRequest(t.Context(), rejectedID, collectorActor, "no capacity"), "RejectPickupRequest")
+	timeline, _ = s.GetPickupRequestTimeline(t.Context(), rejectedID)
+	if len(timeline) != 2 || timeline[1].NewStatus != "Rejected" || timeline[1].Reason != "no capacity" {
+		t.Errorf("rejection timeline = %+v", timeline)
+	}
+	err = s.AssignTripToDriver(t.Context(), rejectedID, 42, collectorActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("AssignTripToDriver(rejected) = %v, want ErrIllegalTransition", err)
+	}
+
+	requests, err := s.GetAllPickupRequestsForBusiness(t.Context(), businessID)
+	mustSucceed(t, err, "GetAllPickupRequestsForBusiness")
+	if len(requests) != 2 {
+		t.Errorf("GetAllPickupRequestsForBusiness returned %d requests, want 2", len(requests))
+	}
+	all, err := s.GetAllPickupRequests(t.Context())
+	mustSucceed(t, err, "GetAllPickupRequests")
+	if len(all) != 2 {
+		t.Errorf("GetAllPickupRequests returned %d requests, want 2", len(all))
+	}
+}
+
+func testUpdatePickupRequest(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	requestID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	businessActor := types.Actor{UserID: businessID, Role: "Business"}
+
+	// Other fields change without a status history entry
+	mustSucceed(t, s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Quantity: 80, HandlingRequirements: "Bagged"}, businessActor), "UpdatePickupRequest")
+	request, _ := s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Quantity != 80 || request.HandlingRequirements != "Bagged" || request.WasteType != "Plastic" || request.Status != "Pending" {
+		t.Errorf("updated pickup request = %+v", request)
+	}
+	timeline, _ := s.GetPickupRequestTimeline(t.Context(), requestID)
+	if len(timeline) != 1 {
+		t.Errorf("timeline after a field update = %+v, want the creation only", timeline)
+	}
+
+	err := s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Status: "Completed"}, businessActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("UpdatePickupRequest(Pending to Completed) = %v, want ErrIllegalTransition", err)
+	}
+	// Updates only cancel, accepting is left to the collector
+	err = s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Status: "Accepted"}, businessActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("UpdatePickupRequest(Pending to Accepted) = %v, want ErrIllegalTransition", err)
+	}
+	request, _ = s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Status != "Pending" {
+		t.Errorf("status after an illegal update = %q", request.Status)
+	}
+
+	mustSucceed(t, s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Status: "Cancelled", Reason: "plans changed"}, businessActor), "UpdatePickupRequest(cancel)")
+	timeline, _ = s.GetPickupRequestTimeline(t.Context(), requestID)
+	if len(timeline) != 2 || timeline[1].OldStatus != "Pending" || timeline[1].NewStatus != "Cancelled" || timeline[1].Reason != "plans changed" {
+		t.Errorf("timeline after cancelling = %+v", timeline)
+	}
+
+	// Like every transition, the cancellation is published, unlike field updates
+	claimed, err := s.ClaimOutboxEvents(t.Context(), 10, time.Minute)
+	mustSucceed(t, err, "ClaimOutboxEvents")
+	if len(claimed) != 2 {
+		t.Fatalf("claimed %d outbox events, want the creation and the cancellation", len(claimed))
+	}
+	var payload types.PickupRequest
+	envelope, err := events.Decode(claimed[1].Payload, &payload)
+	mustSucceed(t, err, "events.Decode")
+	if envelope.Type != events.PickupCancelled || envelope.Actor == nil || envelope.Actor.UserID != businessID ||
+		payload.Status != "Cancelled" || payload.Quantity != 80 {
+		t.Errorf("cancellation event = %+v with %+v", envelope, payload)
+	}
+
+	// Details are fixed once the request leaves Pending
+	err = s.UpdatePickupRequest(t.Context(), requestID, types.UpdatePickupRequest{Quantity: 5, PickupDate: types.DateTime{Time: time.Now()}}, businessActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("UpdatePickupRequest(edit Cancelled) = %v, want ErrIllegalTransition", err)
+	}
+	request, _ = s.GetPickupRequestByID(t.Context(), requestID)
+	if request.Quantity != 80 {
+		t.Errorf("quantity after editing a cancelled request = %v, want 80", request.Quantity)
+	}
+	accepted := mustCreatePickupRequest(t, s, businessID, collectorID)
+	mustSucceed(t, s.AcceptPickupRequest(t.Context(), accepted, types.Actor{UserID: collectorID, Role: "Collector"}), "AcceptPickupRequest")
+	err = s.UpdatePickupRequest(t.Context(), accepted, types.UpdatePickupRequest{HandlingRequirements: "Loose"}, businessActor)
+	if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+		t.Errorf("UpdatePickupRequest(edit Accepted) = %v, want ErrIllegalTransition", err)
+	}
+
+	mustFail(t, s.UpdatePickupRequest(t.Context(), requestID+1000, types.UpdatePickupRequest{Quantity: 1}, businessActor), "UpdatePickupRequest(unknown)")
+}
+
+// testConcurrentTransitions checks that transitions of a request are serialised, so that only one
+// of several concurrent acceptances succeeds.
+func testConcurrentTransitions(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	requestID := mustCreatePickupRequest(t, s, businessID, collectorID)
+
+	const attempts = 8
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.AcceptPickupRequest(t.Context(), requestID, types.Actor{UserID: collectorID, Role: "Collector"})
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else if !errors.Is(err, lifecycle.ErrIllegalTransition) {
+			t.Errorf("concurrent AcceptPickupRequest: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d of %d concurrent acceptances succeeded, want 1", succeeded, attempts)
+	}
+	timeline, _ := s.GetPickupRequestTimeline(t.Context(), requestID)
+	if len(timeline) != 2 {
+		t.Errorf("timeline after concurrent acceptances has %d entries, want 2", len(timeline))
+	}
+}
+
+func testOutbox(t *testing.T, s storage.Storage) {
+	businessID := mustCreateBusiness(t, s, "acme")
+	collectorID := mustCreateCollector(t, s, "green")
+	requestID := mustCreatePickupRequest(t, s, businessID, collectorID)
+	collectorActor := types.Actor{UserID: collectorID, Role: "Collector"}
+	mustSucceed(t, s.AcceptPickupRequest(t.Context(), requestID, collectorActor), "AcceptPickupRequest")
+	mustSucceed(t, s.AssignTripToDriver(t.Context(), requestID, 42, collectorActor), "AssignTripToDriver")
+
+	// A rejected transition enqueues nothing
+	_ = s.EndDelivery(t.Context(), requestID, collectorActor)
+
+	claimed, err := s.ClaimOutboxEvents(t.Context(), 10, time.Minute)
+	mustSucceed(t, err, "ClaimOutboxEvents")
+	want := []events.Type{events.PickupCreated, events.PickupAccepted, events.DriverAssigned}
+	if len(claimed) != len(want) {
+		t.Fatalf("claimed %d outbox events, want %d", len(claimed), len(want))
+	}
+	for i, event := range claimed {
+		var payload types.PickupRequest
+		envelope, err := events.Decode(event.Payload, &payload)
+		mustSucceed(t, err, "events.Decode")
+		if envelope.Type != want[i] || event.Topic != want[i].Topic() || event.Attributes[events.TypeAttribute] != string(want[i]) {
+			t.Errorf("outbox event %d = %s on %s, want %s", i, envelope.Type, event.Topic, want[i])
+		}
+		if event.Attributes[events.IDAttribute] != envelope.ID || payload.RequestID != requestID {
+			t.Errorf("outbox event %d has ID attribute %q and request %d", i, event.Attributes[events.IDAttribute], payload.RequestID)
+		}
+		if i == 2 && (payload.AssignedDriver != 42 || payload.Status != "Assigned") {
+			t.Errorf("assignment event payload = %+v, want the updated request", payload)
+		}
+	}
+
+	// Claimed events are leased
+	again, err := s.ClaimOutboxEvents(t.Context(), 10, time.Minute)
+	mustSucceed(t, err, "ClaimOutboxEvents(leased)")
+	if len(again) != 0 {
+		t.Errorf("claimed %d leased events", len(again))
+	}
+
+	// A failed event is retried once due, a delivered one never again
+	mustSucceed(t, s.MarkOutboxEventFailed(t.Context(), claimed[0].OutboxID, "broker down", time.Now().Add(-time.Second)), "MarkOutboxEventFailed")
+	mustSucceed(t, s.MarkOutboxEventDelivered(t.Context(), claimed[1].OutboxID), "MarkOutboxEventDelivered")
+	mustSucceed(t, s.MarkOutboxEventFailed(t.Context(), claimed[2].OutboxID, "broker down", time.Now().Add(time.Hour)), "MarkOutboxEventFailed")
+	again, _ = s.ClaimOutboxEvents(t.Context(), 10, time.Minute)
+	if len(again) != 1 || again[0].OutboxID != claimed[0].OutboxID || again[0].Attempts != 1 {
+		t.Errorf("claimed after a failure = %+v, want the failed event with 1 attempt", again)
+	}
+
+	mustFail(t, s.MarkOutboxEventDelivered(t.Context(), claimed[2].OutboxID+1000), "MarkOutboxEventDelivered(unknown)")
+	mustFail(t, s.MarkOutboxEventFailed(t.Context(), claimed[2].OutboxID+1000, "x", time.Now()), "MarkOutboxEventFailed(unknown)")
+
+	// Delivered events are deleted once old enough, pending ones are kept
+	deleted, err := s.DeleteDeliveredOutboxEvents(t.Context(), time.Now().Add(-time.Minute))
+	mustSucceed(t, err, "DeleteDeliveredOutboxEvents(recent)")
+	if deleted != 0 {
+		t.Errorf("deleted %d events delivered after the cutoff", deleted)
+	}
+	deleted, err = s.DeleteDeliveredOutboxEvents(t.Context(), time.Now().Add(time.Minute))
+	mustSucceed(t, err, "DeleteDeliveredOutboxEvents")
+	if deleted != 1 {
+		t.Errorf("deleted %d events, want the delivered one", deleted)
+	}
+	mustFail(t, s.MarkOutboxEventDelivered(t.Context(), claimed[1].OutboxID), "MarkOutboxEventDelivered(deleted)")
+	mustSucceed(t, s.MarkOutboxEventFailed(t.Context(), claimed[2].OutboxID, "broker down", time.Now().Add(time.Hour)), "MarkOutboxEventFailed(pending)")
+
+	// Claims return at most limit events, oldest first
+	for range 3 {
+		mustCreatePickupRequest(t, s, businessID, collectorID)
+	}
+	first, _ := s.ClaimOutboxEvents(t.Context(), 2, time.Minute)
+	second, _ := s.ClaimOutboxEvents(t.Context(), 2, time.Minute)
+	if len(first) != 2 || len(second) != 1 || first[0].OutboxID >= first[1].OutboxID || first[1].OutboxID >= second[0].OutboxID {
+		t.Errorf("limited claims = %+v then %+v", first, second)
+	}
+}
@@ -0,0 +1,109 @@
+package storagetest
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testServiceAreas(t *testing.T, s storage.Storage) {
+	greenID := mustCreateCollector(t, s, "green")
+	blueID := mustCreateCollector(t, s, "blue")
+	plasticID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Plastic"})
+	mustSucceed(t, err, "AddServiceCategory")
+	paperID, err := s.AddServiceCategory(t.Context(), types.ServiceCategory{WasteType: "Paper"})
+	mustSucceed(t, err, "AddServiceCategory")
+
+	pincodesID, err := s.CreateServiceArea(t.Context(), types.ServiceArea{
+		CollectorID: greenID,
+		CategoryID:  &plasticID,
+		Name:        "Central Pune",
+		Pincodes:    []string{"411001", "411002"},
+	})
+	mustSucceed(t, err, "CreateServiceArea(pincodes)")
+	square := json.RawMessage(`{"type":"Polygon","coordinates":[[[73.8,18.5],[73.9,18.5],[73.9,18.6],[73.8,18.6],[73.8,18.5]]]}`)
+	polygonID, err := s.CreateServiceArea(t.Context(), types.ServiceArea{CollectorID: greenID, Name: "Pune", Polygon: square})
+	mustSucceed(t, err, "CreateServiceArea(polygon)")
+	paperAreaID, err := s.CreateServiceArea(t.Context(), types.ServiceArea{
+		CollectorID: blueID,
+		CategoryID:  &paperID,
+		Name:        "Mumbai",
+		Pincodes:    []string{"400001"},
+	})
+	mustSucceed(t, err, "CreateServiceArea(paper)")
+
+	area, err := s.GetServiceArea(t.Context(), pincodesID)
+	mustSucceed(t, err, "GetServiceArea")
+	if area.CollectorID != greenID || area.CategoryID == nil || *area.CategoryID != plasticID || area.Name != "Central Pune" ||
+		!slices.Equal(area.Pincodes, []string{"411001", "411002"}) || len(area.Polygon) != 0 {
+		t.Errorf("GetServiceArea(pincodes) = %+v", area)
+	}
+	area, err = s.GetServiceArea(t.Context(), polygonID)
+	mustSucceed(t, err, "GetServiceArea")
+	if area.CategoryID != nil || area.Pincodes != nil || !json.Valid(area.Polygon) {
+		t.Errorf("GetServiceArea(polygon) = %+v", area)
+	}
+	_, err = s.GetServiceArea(t.Context(), polygonID+1000)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetServiceArea(unknown) = %v, want ErrNotFound", err)
+	}
+
+	// An area is either pincodes or a polygon, of a known collector and category
+	_, err = s.CreateServiceArea(t.Context(), types.ServiceArea{CollectorID: greenID, Name: "Both", Pincodes: []string{"411001"}, Polygon: square})
+	mustFail(t, err, "CreateServiceArea(pincodes and polygon)")
+	_, err = s.CreateServiceArea(t.Context(), types.ServiceArea{CollectorID: greenID, Name: "Neither"})
+	mustFail(t, err, "CreateServiceArea(no pincodes nor polygon)")
+	_, err = s.CreateServiceArea(t.Context(), types.ServiceArea{CollectorID: greenID + 1000, Name: "Nobody's", Pincodes: []string{"411001"}})
+	mustFail(t, err, "CreateServiceArea(unknown collector)")
+	unknownCategory := paperID + 1000
+	_, err = s.CreateServiceArea(t.Context(), types.ServiceArea{CollectorID: greenID, CategoryID: &unknownCategory, Name: "Unknown", Pincodes: []string{"411001"}})
+	mustFail(t, err, "CreateServiceArea(unknown category)")
+
+	areas, err := s.ListServiceAreas(t.Context(), greenID)
+	mustSucceed(t, err, "ListServiceAreas")
+	if len(areas) != 2 || areas[0].AreaID != pincodesID || areas[1].AreaID != polygonID {
+		t.Errorf("ListServiceAreas = %+v, want the two areas of the collector", areas)
+	}
+	areas, err = s.ListAllServiceAreas(t.Context())
+	mustSucceed(t, err, "ListAllServiceAreas")
+	if len(areas) != 3 {
+		t.Errorf("ListAllServiceAreas = %+v, want 3 areas", areas)
+	}
+
+	// Updates replace the shape of an area
+	area.Name, area.Pincodes, area.Polygon = "Pune pincodes", []string{"411003"}, nil
+	mustSucceed(t, s.UpdateServiceArea(t.Context(), area), "UpdateServiceArea")
+	area, err = s.GetServiceArea(t.Context(), polygonID)
+	mustSucceed(t, err, "GetServiceArea")
+	if area.Name != "Pune pincodes" || !slices.Equal(area.Pincodes, []string{"411003"}) || len(area.Polygon) != 0 {
+		t.Errorf("updated service area = %+v", area)
+	}
+	area.Polygon = square
+	mustFail(t, s.UpdateServiceArea(t.Context(), area), "UpdateServiceArea(pincodes and polygon)")
+	err = s.UpdateServiceArea(t.Context(), types.ServiceArea{AreaID: polygonID + 1000, CollectorID: greenID, Name: "Gone", Pincodes: []string{"411001"}})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateServiceArea(unknown) = %v, want ErrNotFound", err)
+	}
+
+	mustSucceed(t, s.DeleteServiceArea(t.Context(), polygonID), "DeleteServiceArea")
+	err = s.DeleteServiceArea(t.Context(), polygonID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteServiceArea(deleted) = %v, want ErrNotFound", err)
+	}
+
+	// The areas of a category go with it
+	mustSucceed(t, s.DeleteServiceCategory(t.Context(), uint64(paperID)), "DeleteServiceCategory")
+	_, err = s.GetServiceArea(t.Context(), paperAreaID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetServiceArea(area of a deleted category) = %v, want ErrNotFound", err)
+	}
+	areas, err = s.ListAllServiceAreas(t.Context())
+	mustSucceed(t, err, "ListAllServiceAreas")
+	if len(areas) != 1 || areas[0].AreaID != pincodesID {
+		t.Errorf("ListAllServiceAreas = %+v, want the pincode area of green only", areas)
+	}
+}
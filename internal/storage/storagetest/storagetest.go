@@ -0,0 +1,172 @@
+// Package storagetest is the conformance suite of storage.Storage. Every backend runs it from its
+// own tests, so that they agree on results, constraints and errors.
+package storagetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Run runs the suite against the stores returned by newStorage, which is called once per test and
+// has to return an empty store. Every test starts by bootstrapping the admin, user 1.
+func Run(t *testing.T, newStorage func(t *testing.T) storage.Storage) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s storage.Storage)
+	}{
+		{"Users", testUsers},
+		{"CollectorsAndDrivers", testCollectorsAndDrivers},
+		{"Catalog", testCatalog},
+		{"CollectorVehicles", testCollectorVehicles},
+		{"PickupLifecycle", testPickupLifecycle},
+		{"UpdatePickupRequest", testUpdatePickupRequest},
+		{"ConcurrentTransitions", testConcurrentTransitions},
+		{"DriverLocations", testDriverLocations},
+		{"PickupETAs", testPickupETAs},
+		{"Geofences", testGeofences},
+		{"NearbyCollectors", testNearbyCollectors},
+		{"ServiceAreas", testServiceAreas},
+		{"MatchCandidates", testMatchCandidates},
+		{"Market", testMarket},
+		{"Outbox", testOutbox},
+		{"DeadLetters", testDeadLetters},
+		{"ProcessedEvents", testProcessedEvents},
+		{"NotificationTemplates", testNotificationTemplates},
+		{"Notifications", testNotifications},
+		{"Webhooks", testWebhooks},
+		{"Admins", testAdmins},
+		{"CancelledContext", testCancelledContext},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newStorage(t)
+			created, err := s.BootstrapAdmin(t.Context(), user("admin", "Admin"))
+			mustSucceed(t, err, "BootstrapAdmin")
+			if !created {
+				t.Fatalf("BootstrapAdmin did not create the admin of an empty store")
+			}
+			test.run(t, s)
+		})
+	}
+}
+
+var actor = types.Actor{UserID: 1, Role: "Admin"}
+
+func user(name string, role string) types.User {
+	return types.User{
+		Email:        name + "@example.com",
+		PasswordHash: "hash",
+		FullName:     name,
+		PhoneNumber:  "9000000000",
+		Address:      "1 Test Road",
+		Role:         role,
+		IsActive:     true,
+		Registration: types.Date{Time: time.Now()},
+		LastLogin:    types.DateTime{Time: time.Now()},
+	}
+}
+
+func business(name string) types.Business {
+	return types.Business{
+		User:                user(name, "Business"),
+		Business_name:       name + " Ltd",
+		Business_type:       "Manufacturing",
+		Registration_number: "REG-" + name,
+		Gst_id:              "GST-" + name,
+		Business_address:    "2 Test Road",
+	}
+}
+
+func collector(name string) types.Collector {
+	return types.Collector{
+		User:           user(name, "Collector"),
+		Company_name:   name + " Recycling",
+		License_number: "LIC-" + name,
+		Capacity:       1000,
+		License_expiry: types.Date{Time: time.Now().AddDate(1, 0, 0)},
+	}
+}
+
+func driver(name string) types.CollectorDriver {
+	return types.CollectorDriver{
+		User:          user(name, "Driver"),
+		LicenseNumber: "DL-" + name,
+		DriverName:    name,
+		LicenseExpiry: types.Date{Time: time.Now().AddDate(1, 0, 0)},
+		IsEmployed:    true,
+		IsActive:      true,
+		Rating:        4.5,
+		JoiningDate:   types.Date{Time: time.Now()},
+	}
+}
+
+func mustCreateBusiness(t *testing.T, s storage.Storage, name string) int64 {
+	t.Helper()
+	id, err := s.CreateBusinessUser(t.Context(), business(name))
+	if err != nil {
+		t.Fatalf("CreateBusinessUser(%s): %v", name, err)
+	}
+	return id
+}
+
+func mustCreateCollector(t *testing.T, s storage.Storage, name string) int64 {
+	t.Helper()
+	id, err := s.CreateCollectorUser(t.Context(), collector(name))
+	if err != nil {
+		t.Fatalf("CreateCollectorUser(%s): %v", name, err)
+	}
+	return id
+}
+
+func mustCreateDriver(t *testing.T, s storage.Storage, name string, collectorID int64) int64 {
+	t.Helper()
+	id, err := s.CreateCollectorDriver(t.Context(), driver(name), collectorID)
+	if err != nil {
+		t.Fatalf("CreateCollectorDriver(%s): %v", name, err)
+	}
+	return id
+}
+
+func mustCreatePickupRequest(t *testing.T, s storage.Storage, businessID int64, collectorID int64) int64 {
+	t.Helper()
+	id, err := s.CreatePickupRequest(t.Context(), types.PickupRequest{
+		BusinessID:  businessID,
+		CollectorID: collectorID,
+		WasteType:   "Plastic",
+		Quantity:    120.5,
+		PickupDate:  types.DateTime{Time: time.Now().Add(24 * time.Hour)},
+	}, types.Actor{UserID: businessID, Role: "Business"})
+	if err != nil {
+		t.Fatalf("CreatePickupRequest: %v", err)
+	}
+	return id
+}
+
+// mustSucceed fails the test if err is not nil.
+func mustSucceed(t *testing.T, err error, call string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", call, err)
+	}
+}
+
+// mustFail fails the test if err is nil, for calls that have to be rejected.
+func mustFail(t *testing.T, err error, call string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("%s succeeded, want an error", call)
+	}
+}
+
+func id(n int64) string {
+	return fmt.Sprint(n)
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
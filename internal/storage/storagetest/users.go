@@ -0,0 +1,261 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testUsers(t *testing.T, s storage.Storage) {
+	admin, err := s.GetUserByEmail(t.Context(), "admin@example.com")
+	mustSucceed(t, err, "GetUserByEmail(admin)")
+	if admin.UserID != actor.UserID || admin.Role != "Admin" || !admin.IsVerified || !admin.MustChangePassword {
+		t.Errorf("admin user = %+v, want a verified Admin who has to change their password", admin)
+	}
+
+	businessID := mustCreateBusiness(t, s, "acme")
+
+	got, err := s.GetBusinessByID(t.Context(), businessID)
+	mustSucceed(t, err, "GetBusinessByID")
+	if got.UserID != businessID || got.Email != "acme@example.com" || got.Gst_id != "GST-acme" || got.Role != "Business" {
+		t.Errorf("GetBusinessByID = %+v", got)
+	}
+	byEmail, err := s.GetBusinessByEmail(t.Context(), "acme@example.com")
+	mustSucceed(t, err, "GetBusinessByEmail")
+	if byEmail.UserID != businessID || byEmail.Business_name != "acme Ltd" {
+		t.Errorf("GetBusinessByEmail = %+v", byEmail)
+	}
+	_, err = s.GetBusinessByEmail(t.Context(), "nobody@example.com")
+	mustFail(t, err, "GetBusinessByEmail(unknown)")
+	_, err = s.GetBusinessByID(t.Context(), businessID+1000)
+	mustFail(t, err, "GetBusinessByID(unknown)")
+
+	// Uniqueness of the users and businesses tables
+	duplicate := business("other")
+	duplicate.Email = "acme@example.com"
+	_, err = s.CreateBusinessUser(t.Context(), duplicate)
+	mustFail(t, err, "CreateBusinessUser(duplicate email)")
+
+	duplicate = business("other2")
+	duplicate.Gst_id = "GST-acme"
+	_, err = s.CreateBusinessUser(t.Context(), duplicate)
+	mustFail(t, err, "CreateBusinessUser(duplicate gst_id)")
+
+	invalid := business("other3")
+	invalid.Role = "Superuser"
+	_, err = s.CreateBusinessUser(t.Context(), invalid)
+	mustFail(t, err, "CreateBusinessUser(invalid role)")
+
+	user, err := s.GetUserByID(t.Context(), uint64(businessID))
+	mustSucceed(t, err, "GetUserByID")
+	if user.Email != "acme@example.com" {
+		t.Errorf("GetUserByID email = %q", user.Email)
+	}
+	_, err = s.GetUserByID(t.Context(), uint64(businessID+1000))
+	mustFail(t, err, "GetUserByID(unknown)")
+
+	// Admin actions
+	mustSucceed(t, s.FlagUser(t.Context(), id(businessID)), "FlagUser")
+	user, _ = s.GetUserByID(t.Context(), uint64(businessID))
+	if !user.IsFlagged || user.IsActive {
+		t.Errorf("flagged user = %+v, want flagged and inactive", user)
+	}
+	mustSucceed(t, s.UnflagUser(t.Context(), id(businessID)), "UnflagUser")
+	mustSucceed(t, s.VerifyUser(t.Context(), id(businessID)), "VerifyUser")
+	user, _ = s.GetUserByID(t.Context(), uint64(businessID))
+	if user.IsFlagged || !user.IsActive || !user.IsVerified {
+		t.Errorf("unflagged and verified user = %+v", user)
+	}
+	mustSucceed(t, s.UnverifyUser(t.Context(), id(businessID)), "UnverifyUser")
+	mustFail(t, s.FlagUser(t.Context(), id(businessID+1000)), "FlagUser(unknown)")
+	mustFail(t, s.VerifyUser(t.Context(), "not-a-number"), "VerifyUser(invalid)")
+
+	lastLogin := time.Now().Add(-time.Hour).Truncate(time.Second)
+	mustSucceed(t, s.UpdateLastLogin(t.Context(), businessID, types.DateTime{Time: lastLogin}), "UpdateLastLogin")
+	user, _ = s.GetUserByID(t.Context(), uint64(businessID))
+	if !user.LastLogin.Equal(lastLogin) {
+		t.Errorf("last login = %v, want %v", user.LastLogin.Time, lastLogin)
+	}
+
+	// Profile updates change the fields that are set only
+	_, err = s.UpdateBusinessProfile(t.Context(), businessID, types.BusinessUpdate{
+		UserUpdate:    types.UserUpdate{FullName: "Acme Renamed"},
+		Business_name: "Acme Renamed Ltd",
+	})
+	mustSucceed(t, err, "UpdateBusinessProfile")
+	got, _ = s.GetBusinessByID(t.Context(), businessID)
+	if got.FullName != "Acme Renamed" || got.Business_name != "Acme Renamed Ltd" || got.Email != "acme@example.com" || got.Gst_id != "GST-acme" {
+		t.Errorf("updated business = %+v", got)
+	}
+
+	otherID := mustCreateBusiness(t, s, "globex")
+	_, err = s.UpdateBusinessProfile(t.Context(), otherID, types.BusinessUpdate{UserUpdate: types.UserUpdate{Email: "acme@example.com"}})
+	mustFail(t, err, "UpdateBusinessProfile(taken email)")
+	_, err = s.UpdateBusinessProfile(t.Context(), businessID+1000, types.BusinessUpdate{Business_name: "x"})
+	mustFail(t, err, "UpdateBusinessProfile(unknown)")
+
+	businesses, err := s.GetAllBusinesses(t.Context())
+	mustSucceed(t, err, "GetAllBusinesses")
+	if len(businesses) != 2 {
+		t.Errorf("GetAllBusinesses returned %d businesses, want 2", len(businesses))
+	}
+
+	// Notification preferences
+	preferences, err := s.GetNotificationPreferences(t.Context(), businessID)
+	mustSucceed(t, err, "GetNotificationPreferences")
+	if len(preferences.Channels) != 0 || preferences.Locale != "" {
+		t.Errorf("default preferences = %+v, want none", preferences)
+	}
+	want := types.NotificationPreferences{Channels: []string{"email", "sms"}, Locale: "hi-IN"}
+	mustSucceed(t, s.SetNotificationPreferences(t.Context(), businessID, want), "SetNotificationPreferences")
+	preferences, _ = s.GetNotificationPreferences(t.Context(), businessID)
+	if !slices.Equal(preferences.Channels, want.Channels) || preferences.Locale != want.Locale {
+		t.Errorf("preferences = %+v, want %+v", preferences, want)
+	}
+	got, _ = s.GetBusinessByID(t.Context(), businessID)
+	if got.GetLocale() != "hi-IN" || !slices.Equal(got.GetNotificationChannels(), want.Channels) {
+		t.Errorf("business preferences = %v %q", got.GetNotificationChannels(), got.GetLocale())
+	}
+	mustFail(t, s.SetNotificationPreferences(t.Context(), businessID+1000, want), "SetNotificationPreferences(unknown)")
+	_, err = s.GetNotificationPreferences(t.Context(), businessID+1000)
+	mustFail(t, err, "GetNotificationPreferences(unknown)")
+}
+
+func testCollectorsAndDrivers(t *testing.T, s storage.Storage) {
+	collectorID := mustCreateCollector(t, s, "green")
+
+	got, err := s.GetCollectorByID(t.Context(), collectorID)
+	mustSucceed(t, err, "GetCollectorByID")
+	if got.UserID != collectorID || got.License_number != "LIC-green" || got.Capacity != 1000 {
+		t.Errorf("GetCollectorByID = %+v", got)
+	}
+	byEmail, err := s.GetCollectorByEmail(t.Context(), "green@example.com")
+	mustSucceed(t, err, "GetCollectorByEmail")
+	if byEmail.UserID != collectorID {
+		t.Errorf("GetCollectorByEmail = %+v", byEmail)
+	}
+	_, err = s.GetCollectorByID(t.Context(), collectorID+1000)
+	mustFail(t, err, "GetCollectorByID(unknown)")
+
+	duplicate := collector("blue")
+	duplicate.License_number = "LIC-green"
+	_, err = s.CreateCollectorUser(t.Context(), duplicate)
+	mustFail(t, err, "CreateCollectorUser(duplicate license)")
+
+	otherID := mustCreateCollector(t, s, "red")
+	collectors, err := s.GetCollectors(t.Context())
+	mustSucceed(t, err, "GetCollectors")
+	if len(collectors) != 2 {
+		t.Errorf("GetCollectors returned %d collectors, want 2", len(collectors))
+	}
+
+	_, err = s.UpdateCollectorProfile(t.Context(), collectorID, types.CollectorUpdate{Company_name: "Green Renamed", Capacity: 2500})
+	mustSucceed(t, err, "UpdateCollectorProfile")
+	got, _ = s.GetCollectorByID(t.Context(), collectorID)
+	if got.Company_name != "Green Renamed" || got.Capacity != 2500 || got.License_number != "LIC-green" {
+		t.Errorf("updated collector = %+v", got)
+	}
+	_, err = s.UpdateCollectorProfile(t.Context(), otherID, types.CollectorUpdate{License_number: "LIC-green"})
+	mustFail(t, err, "UpdateCollectorProfile(taken license)")
+
+	// Drivers belong to an existing collector
+	_, err = s.CreateCollectorDriver(t.Context(), driver("nobody"), collectorID+1000)
+	mustFail(t, err, "CreateCollectorDriver(unknown collector)")
+
+	driverID := mustCreateDriver(t, s, "dave", collectorID)
+	duplicateDriver := driver("dan")
+	duplicateDriver.LicenseNumber = "DL-dave"
+	_, err = s.CreateCollectorDriver(t.Context(), duplicateDriver, collectorID)
+	mustFail(t, err, "CreateCollectorDriver(duplicate license)")
+
+	d, err := s.GetCollectorDriverByEmail(t.Context(), "dave@example.com")
+	mustSucceed(t, err, "GetCollectorDriverByEmail")
+	if d.UserID != driverID || d.CollectorID != collectorID || d.Role != "Driver" || d.LicenseNumber != "DL-dave" {
+		t.Errorf("GetCollectorDriverByEmail = %+v", d)
+	}
+	d, err = s.GetCollectorDriver(t.Context(), collectorID, driverID)
+	mustSucceed(t, err, "GetCollectorDriver")
+	if d.DriverName != "dave" || !d.IsActive || !d.IsEmployed {
+		t.Errorf("GetCollectorDriver = %+v", d)
+	}
+	_, err = s.GetCollectorDriver(t.Context(), otherID, driverID)
+	mustFail(t, err, "GetCollectorDriver(other collector)")
+
+	inactive := false
+	mustSucceed(t, s.UpdateCollectorDriver(t.Context(), types.UpdateCollectorDriver{DriverID: driverID, IsActive: &inactive, Rating: 3.75}, uint64(collectorID)), "UpdateCollectorDriver")
+	d, _ = s.GetCollectorDriver(t.Context(), collectorID, driverID)
+	if d.IsActive || !d.IsEmployed || d.Rating != 3.75 {
+		t.Errorf("updated driver = %+v, want inactive, employed, rated 3.75", d)
+	}
+	mustFail(t, s.UpdateCollectorDriver(t.Context(), types.UpdateCollectorDriver{DriverID: driverID, DriverName: "x"}, uint64(otherID)), "UpdateCollectorDriver(other collector)")
+
+	drivers, err := s.GetCollectorDrivers(t.Context(), collectorID)
+	mustSucceed(t, err, "GetCollectorDrivers")
+	if len(drivers) != 1 || drivers[0].UserID != driverID {
+		t.Errorf("GetCollectorDrivers = %+v", drivers)
+	}
+
+	mustFail(t, s.DeleteCollectorDriver(t.Context(), driverID, uint64(otherID)), "DeleteCollectorDriver(other collector)")
+	mustSucceed(t, s.DeleteCollectorDriver(t.Context(), driverID, uint64(collectorID)), "DeleteCollectorDriver")
+	_, err = s.GetCollectorDriver(t.Context(), collectorID, driverID)
+	mustFail(t, err, "GetCollectorDriver(deleted)")
+	drivers, _ = s.GetCollectorDrivers(t.Context(), collectorID)
+	if len(drivers) != 0 {
+		t.Errorf("GetCollectorDrivers after delete = %+v", drivers)
+	}
+}
+
+func testAdmins(t *testing.T, s storage.Storage) {
+	created, err := s.BootstrapAdmin(t.Context(), user("second", "Admin"))
+	mustSucceed(t, err, "BootstrapAdmin(again)")
+	if created {
+		t.Errorf("BootstrapAdmin created an admin although one exists")
+	}
+	if _, err := s.GetUserByEmail(t.Context(), "second@example.com"); err == nil {
+		t.Errorf("BootstrapAdmin(again) inserted the admin")
+	}
+
+	adminID, err := s.CreateAdminUser(t.Context(), user("later", "Admin"), actor.UserID)
+	mustSucceed(t, err, "CreateAdminUser")
+	_, err = s.CreateAdminUser(t.Context(), user("later", "Admin"), actor.UserID)
+	mustFail(t, err, "CreateAdminUser(duplicate email)")
+
+	admin, err := s.GetUserByID(t.Context(), uint64(adminID))
+	mustSucceed(t, err, "GetUserByID(admin)")
+	if admin.Role != "Admin" || !admin.IsActive || !admin.MustChangePassword {
+		t.Errorf("created admin = %+v, want an active Admin who has to change their password", admin)
+	}
+
+	records, err := s.GetAdminAuditRecords(t.Context())
+	mustSucceed(t, err, "GetAdminAuditRecords")
+	if len(records) != 2 ||
+		records[0].Action != "bootstrap" || records[0].AdminUserID != actor.UserID || records[0].ActorUserID != 0 ||
+		records[1].Action != "create" || records[1].AdminUserID != adminID || records[1].ActorUserID != actor.UserID {
+		t.Errorf("GetAdminAuditRecords = %+v", records)
+	}
+
+	mustSucceed(t, s.ChangePassword(t.Context(), adminID, "new hash"), "ChangePassword")
+	admin, _ = s.GetUserByEmail(t.Context(), "later@example.com")
+	if admin.PasswordHash != "new hash" || admin.MustChangePassword {
+		t.Errorf("admin after ChangePassword = %+v", admin)
+	}
+	mustFail(t, s.ChangePassword(t.Context(), adminID+1000, "hash"), "ChangePassword(unknown)")
+}
+
+func testCancelledContext(t *testing.T, s storage.Storage) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := s.GetUserByID(ctx, 1)
+	mustFail(t, err, "GetUserByID(cancelled)")
+	_, err = s.CreateBusinessUser(ctx, business("cancelled"))
+	mustFail(t, err, "CreateBusinessUser(cancelled)")
+
+	if _, err := s.GetBusinessByEmail(t.Context(), "cancelled@example.com"); err == nil {
+		t.Errorf("CreateBusinessUser with a cancelled context created the business")
+	}
+}
@@ -0,0 +1,138 @@
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+func testWebhooks(t *testing.T, s storage.Storage) {
+	userID := mustCreateBusiness(t, s, "acme")
+	otherID := mustCreateBusiness(t, s, "globex")
+
+	endpointID, err := s.CreateWebhookEndpoint(t.Context(), types.WebhookEndpoint{
+		UserID:      userID,
+		URL:         "https://example.com/hooks",
+		Description: "ERP",
+		EventTypes:  []string{"pickup.accepted", "pickup.rejected"},
+		Secret:      "whsec_test",
+		IsActive:    true,
+	})
+	mustSucceed(t, err, "CreateWebhookEndpoint")
+	inactiveID, err := s.CreateWebhookEndpoint(t.Context(), types.WebhookEndpoint{UserID: userID, URL: "https://example.com/off", EventTypes: []string{"pickup.accepted"}, Secret: "whsec_off"})
+	mustSucceed(t, err, "CreateWebhookEndpoint(inactive)")
+	_, err = s.CreateWebhookEndpoint(t.Context(), types.WebhookEndpoint{UserID: otherID, URL: "https://example.org/hooks", EventTypes: []string{"pickup.accepted"}, Secret: "whsec_other", IsActive: true})
+	mustSucceed(t, err, "CreateWebhookEndpoint(other user)")
+
+	endpoint, err := s.GetWebhookEndpoint(t.Context(), endpointID)
+	mustSucceed(t, err, "GetWebhookEndpoint")
+	if endpoint.UserID != userID || endpoint.Secret != "whsec_test" || !endpoint.IsActive || !slices.Equal(endpoint.EventTypes, []string{"pickup.accepted", "pickup.rejected"}) {
+		t.Errorf("GetWebhookEndpoint = %+v", endpoint)
+	}
+	inactive, _ := s.GetWebhookEndpoint(t.Context(), inactiveID)
+	if inactive.IsActive {
+		t.Errorf("endpoint created inactive is active")
+	}
+	if _, err := s.GetWebhookEndpoint(t.Context(), endpointID+1000); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetWebhookEndpoint(unknown) = %v, want ErrNotFound", err)
+	}
+
+	endpoints, err := s.ListWebhookEndpoints(t.Context(), userID)
+	mustSucceed(t, err, "ListWebhookEndpoints")
+	if len(endpoints) != 2 || endpoints[0].EndpointID != endpointID {
+		t.Errorf("ListWebhookEndpoints = %+v", endpoints)
+	}
+
+	subscribed, err := s.ListSubscribedWebhookEndpoints(t.Context(), []int64{userID}, "pickup.accepted")
+	mustSucceed(t, err, "ListSubscribedWebhookEndpoints")
+	if len(subscribed) != 1 || subscribed[0].EndpointID != endpointID {
+		t.Errorf("subscribed endpoints = %+v, want the active one only", subscribed)
+	}
+	subscribed, _ = s.ListSubscribedWebhookEndpoints(t.Context(), []int64{userID, otherID}, "pickup.accepted")
+	if len(subscribed) != 2 {
+		t.Errorf("subscribed endpoints of both users = %+v, want 2", subscribed)
+	}
+	subscribed, _ = s.ListSubscribedWebhookEndpoints(t.Context(), []int64{userID}, "delivery.started")
+	if len(subscribed) != 0 {
+		t.Errorf("endpoints subscribed to an unsubscribed type = %+v", subscribed)
+	}
+
+	endpoint.URL = "https://example.com/hooks/v2"
+	endpoint.EventTypes = []string{"delivery.started"}
+	endpoint.IsActive = false
+	mustSucceed(t, s.UpdateWebhookEndpoint(t.Context(), endpoint), "UpdateWebhookEndpoint")
+	updated, _ := s.GetWebhookEndpoint(t.Context(), endpointID)
+	if updated.URL != endpoint.URL || updated.IsActive || !slices.Equal(updated.EventTypes, endpoint.EventTypes) || updated.Secret != "whsec_test" {
+		t.Errorf("updated endpoint = %+v", updated)
+	}
+	if err := s.UpdateWebhookEndpoint(t.Context(), types.WebhookEndpoint{EndpointID: endpointID + 1000, URL: "https://x"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateWebhookEndpoint(unknown) = %v, want ErrNotFound", err)
+	}
+
+	// Deliveries are queued once per endpoint and event
+	deliveryID, err := s.CreateWebhookDelivery(t.Context(), types.WebhookDelivery{EndpointID: endpointID, EventID: "e-1", EventType: "pickup.accepted", Payload: `{"id":"e-1"}`})
+	mustSucceed(t, err, "CreateWebhookDelivery")
+	if deliveryID == 0 {
+		t.Fatalf("CreateWebhookDelivery returned 0 for a new delivery")
+	}
+	duplicateID, err := s.CreateWebhookDelivery(t.Context(), types.WebhookDelivery{EndpointID: endpointID, EventID: "e-1", EventType: "pickup.accepted", Payload: "{}"})
+	mustSucceed(t, err, "CreateWebhookDelivery(duplicate)")
+	if duplicateID != 0 {
+		t.Errorf("CreateWebhookDelivery(duplicate) = %d, want 0", duplicateID)
+	}
+	secondID, err := s.CreateWebhookDelivery(t.Context(), types.WebhookDelivery{EndpointID: endpointID, EventID: "e-2", EventType: "pickup.rejected", Payload: "{}"})
+	mustSucceed(t, err, "CreateWebhookDelivery")
+
+	delivery, err := s.GetWebhookDelivery(t.Context(), deliveryID)
+	mustSucceed(t, err, "GetWebhookDelivery")
+	if delivery.Status != "pending" || delivery.Attempts != 0 || delivery.Payload != `{"id":"e-1"}` || delivery.DeliveredAt != nil {
+		t.Errorf("GetWebhookDelivery = %+v", delivery)
+	}
+
+	claimed, err := s.ClaimWebhookDeliveries(t.Context(), 10, time.Minute)
+	mustSucceed(t, err, "ClaimWebhookDeliveries")
+	if len(claimed) != 2 || claimed[0].DeliveryID != deliveryID || claimed[1].DeliveryID != secondID {
+		t.Fatalf("claimed deliveries = %+v", claimed)
+	}
+	if again, _ := s.ClaimWebhookDeliveries(t.Context(), 10, time.Minute); len(again) != 0 {
+		t.Errorf("claimed %d leased deliveries", len(again))
+	}
+
+	// A retry is claimed once due, a final outcome never again
+	mustSucceed(t, s.RecordWebhookAttempt(t.Context(), deliveryID, "pending", 503, "service unavailable", time.Now().Add(-time.Second)), "RecordWebhookAttempt(retry)")
+	mustSucceed(t, s.RecordWebhookAttempt(t.Context(), secondID, "failed", 410, "gone", time.Now()), "RecordWebhookAttempt(failed)")
+	claimed, _ = s.ClaimWebhookDeliveries(t.Context(), 10, time.Minute)
+	if len(claimed) != 1 || claimed[0].DeliveryID != deliveryID || claimed[0].Attempts != 1 || claimed[0].LastStatusCode != 503 {
+		t.Errorf("claimed after a retry = %+v", claimed)
+	}
+	mustSucceed(t, s.RecordWebhookAttempt(t.Context(), deliveryID, "succeeded", 200, "", time.Now()), "RecordWebhookAttempt(succeeded)")
+	delivery, _ = s.GetWebhookDelivery(t.Context(), deliveryID)
+	if delivery.Status != "succeeded" || delivery.Attempts != 2 || delivery.DeliveredAt == nil || delivery.LastError != "" {
+		t.Errorf("delivered delivery = %+v", delivery)
+	}
+	if err := s.RecordWebhookAttempt(t.Context(), secondID+1000, "failed", 0, "x", time.Now()); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("RecordWebhookAttempt(unknown) = %v, want ErrNotFound", err)
+	}
+
+	deliveries, total, err := s.ListWebhookDeliveries(t.Context(), endpointID, 1, 0)
+	mustSucceed(t, err, "ListWebhookDeliveries")
+	if total != 2 || len(deliveries) != 1 || deliveries[0].DeliveryID != secondID {
+		t.Errorf("ListWebhookDeliveries = %+v of %d, want the newest of 2", deliveries, total)
+	}
+
+	// Deleting an endpoint deletes its deliveries
+	mustSucceed(t, s.DeleteWebhookEndpoint(t.Context(), endpointID), "DeleteWebhookEndpoint")
+	if err := s.DeleteWebhookEndpoint(t.Context(), endpointID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteWebhookEndpoint(deleted) = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetWebhookDelivery(t.Context(), deliveryID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetWebhookDelivery(of a deleted endpoint) = %v, want ErrNotFound", err)
+	}
+	if endpoints, _ := s.ListWebhookEndpoints(t.Context(), userID); len(endpoints) != 1 {
+		t.Errorf("endpoints after deleting one = %+v", endpoints)
+	}
+}
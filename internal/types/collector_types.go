@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type ServiceCategory struct {
 	CategoryID int64  `json:"category_id"`                   // Primary key
 	WasteType  string `json:"waste_type" binding:"required"` // Type of waste accepted
@@ -13,6 +18,110 @@ type CollectorServiceCategory struct {
 	HandlingRequirements string  `json:"handling_requirements,omitempty"`     // Special handling instructions
 }
 
+// NearbyCollectorsQuery finds the active collectors with a depot within RadiusKm of a point,
+// offering WasteType if it is set.
+type NearbyCollectorsQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	WasteType string
+}
+
+// NearbyCollector is a collector found around a point, with the price and capacity of its offer
+// for the waste type searched, or its lowest price and largest capacity over all its offers.
+type NearbyCollector struct {
+	CollectorID     int64    `json:"collector_id"`
+	CompanyName     string   `json:"company_name"`
+	Address         string   `json:"address,omitempty"`
+	Latitude        float64  `json:"latitude"` // Of the depot
+	Longitude       float64  `json:"longitude"`
+	IsVerified      bool     `json:"is_verified"`
+	DistanceKm      float64  `json:"distance_km"`                // In a straight line
+	PricePerKg      *float64 `json:"price_per_kg,omitempty"`     // Nil for collectors offering nothing yet
+	MaximumCapacity *float64 `json:"maximum_capacity,omitempty"` // Nil for collectors offering nothing yet
+}
+
+// MatchCandidatesQuery finds the active collectors offering WasteType, with the quantity of it
+// they are booked for between From and To.
+type MatchCandidatesQuery struct {
+	WasteType string
+	From      time.Time
+	To        time.Time
+}
+
+// MatchCandidate is a collector offering the waste type of a pickup, with its offer, its bookings
+// and its record.
+type MatchCandidate struct {
+	CollectorID      int64
+	CompanyName      string
+	IsVerified       bool
+	Latitude         *float64 // Of the depot
+	Longitude        *float64
+	PricePerKg       float64
+	MaximumCapacity  float64 // Per day
+	BookedQuantity   float64 // By the open pickup requests of the period
+	CompletedPickups int64
+	EndedPickups     int64 // Completed, rejected or cancelled
+}
+
+// MatchRequest describes a pickup to find collectors for. Without coordinates or a pincode, it is
+// at the business's.
+type MatchRequest struct {
+	WasteType  string   `json:"waste_type" binding:"required"`
+	Quantity   float64  `json:"quantity" binding:"required,gt=0"`
+	PickupDate DateTime `json:"pickup_date"`
+	Latitude   *float64 `json:"latitude,omitempty" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude  *float64 `json:"longitude,omitempty" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Pincode    string   `json:"pincode,omitempty" binding:"omitempty,len=6,numeric"`
+}
+
+// CollectorMatch is a collector able to take a pickup, with its score out of 100 and the factors
+// it is made of.
+type CollectorMatch struct {
+	CollectorID       int64         `json:"collector_id"`
+	CompanyName       string        `json:"company_name"`
+	IsVerified        bool          `json:"is_verified"`
+	Coverage          string        `json:"coverage"`              // Of the pickup location by the service areas of the collector
+	DistanceKm        *float64      `json:"distance_km,omitempty"` // Nil when the pickup or the depot is not located
+	PricePerKg        float64       `json:"price_per_kg"`
+	EstimatedCost     float64       `json:"estimated_cost"`
+	RemainingCapacity float64       `json:"remaining_capacity"`        // On the pickup date, before the pickup
+	CompletionRate    *float64      `json:"completion_rate,omitempty"` // Nil for collectors without finished pickups
+	Score             float64       `json:"score"`
+	Breakdown         []MatchFactor `json:"breakdown"`
+}
+
+// MatchFactor is one part of the score of a CollectorMatch: the collector's score for the factor,
+// from 0 to 1, times its weight gives the points it adds.
+type MatchFactor struct {
+	Factor string  `json:"factor"`
+	Weight float64 `json:"weight"`
+	Score  float64 `json:"score"`
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+}
+
+// ServiceArea is where a collector takes pickups from: a list of pincodes, or a GeoJSON Polygon
+// or MultiPolygon. Areas without a category apply to every category of the collector, and
+// collectors without any area for a category take pickups of it from anywhere.
+type ServiceArea struct {
+	AreaID      int64           `json:"area_id"`
+	CollectorID int64           `json:"collector_id"`
+	CategoryID  *int64          `json:"category_id,omitempty"` // Nil for every category
+	Name        string          `json:"name"`
+	Pincodes    []string        `json:"pincodes,omitempty"`
+	Polygon     json.RawMessage `json:"polygon,omitempty"` // GeoJSON geometry or feature
+	CreatedAt   DateTime        `json:"created_at"`
+	UpdatedAt   DateTime        `json:"updated_at"`
+}
+
+type ServiceAreaInput struct {
+	CategoryID *int64          `json:"category_id"`
+	Name       string          `json:"name" binding:"required,max=255"`
+	Pincodes   []string        `json:"pincodes" binding:"required_without=Polygon,excluded_with=Polygon,max=5000,dive,len=6,numeric"`
+	Polygon    json.RawMessage `json:"polygon" binding:"required_without=Pincodes"`
+}
+
 type Vehicle struct {
 	VehicleID   int64   `json:"vehicle_id"`                      // Primary key
 	VehicleType string  `json:"vehicle_type" binding:"required"` // Type of vehicle
@@ -60,15 +169,72 @@ type CollectorDriverLocation struct {
 type PickupRequest struct {
 	RequestID            int64    `json:"request_id"`
 	BusinessID           int64    `json:"business_id" binding:"required"`
-	CollectorID          int64    `json:"collector_id" binding:"required"`
+	CollectorID          int64    `json:"collector_id"` // 0 for open-market requests not awarded yet
 	WasteType            string   `json:"waste_type"`
 	Quantity             float64  `json:"quantity" binding:"required"`
 	PickupDate           DateTime `json:"pickup_date"`
-	Status               string   `json:"status" binding:"required,oneof=Pending Assigned Completed Cancelled"`
+	Status               string   `json:"status" binding:"omitempty,eq=Pending"` // New requests always start out Pending
 	HandlingRequirements string   `json:"handling_requirements"`
 	AssignedDriver       int64    `json:"assigned_driver,omitempty"`
 	AssignedVehicle      int64    `json:"assigned_vehicle,omitempty"`
 	CreatedAt            DateTime `json:"created_at"`
+
+	PickupLatitude  *float64 `json:"pickup_latitude,omitempty" binding:"omitempty,required_with=PickupLongitude,min=-90,max=90"` // Of the pickup site, for ETAs
+	PickupLongitude *float64 `json:"pickup_longitude,omitempty" binding:"omitempty,required_with=PickupLatitude,min=-180,max=180"`
+
+	// Made without a collector_id, to the collectors able to take it, and awarded to the bid the
+	// business picks or the first collector accepting. Only set by the API.
+	OpenMarket  bool      `json:"open_market"`
+	PricePerKg  *float64  `json:"price_per_kg,omitempty"` // Agreed on the open market
+	WindowStart *DateTime `json:"window_start,omitempty"` // Of the winning bid
+	WindowEnd   *DateTime `json:"window_end,omitempty"`
+
+	ETA *PickupETA `json:"eta,omitempty"` // Of the assigned driver, only set by the API
+}
+
+// MarketInvitation lets a collector bid on, or accept, an open-market pickup request, at the
+// price per kg it listed for the waste type when the request was made.
+type MarketInvitation struct {
+	RequestID   int64    `json:"request_id"`
+	CollectorID int64    `json:"collector_id"`
+	PricePerKg  float64  `json:"price_per_kg"`
+	CreatedAt   DateTime `json:"created_at"`
+}
+
+// OpenPickupRequest is an open-market pickup request a collector is invited to, with its own
+// bid if it made one.
+type OpenPickupRequest struct {
+	PickupRequest
+	ListPricePerKg float64    `json:"list_price_per_kg"` // Of the invitation
+	Bid            *PickupBid `json:"bid,omitempty"`
+}
+
+// PickupBid is the offer of a collector for an open-market pickup request: a price per kg and a
+// window for the pickup. Collectors have one bid per request, bidding again replaces it.
+type PickupBid struct {
+	BidID       int64    `json:"bid_id"`
+	RequestID   int64    `json:"request_id"`
+	CollectorID int64    `json:"collector_id"`
+	PricePerKg  float64  `json:"price_per_kg" binding:"required,gt=0"`
+	WindowStart DateTime `json:"window_start"`
+	WindowEnd   DateTime `json:"window_end"`
+	CreatedAt   DateTime `json:"created_at"`
+	UpdatedAt   DateTime `json:"updated_at"`
+}
+
+// PickupETA estimates when the driver assigned to a pickup request reaches the pickup site,
+// from the driver's latest locations.
+type PickupETA struct {
+	RequestID        int64    `json:"request_id"`
+	DriverID         int64    `json:"driver_id"`
+	DriverLatitude   float64  `json:"driver_latitude"`
+	DriverLongitude  float64  `json:"driver_longitude"`
+	DistanceMeters   float64  `json:"distance_meters"` // By road, estimated
+	SpeedKmh         float64  `json:"speed_kmh"`
+	RemainingSeconds float64  `json:"remaining_seconds"`
+	ArrivalAt        DateTime `json:"arrival_at"`
+	LocatedAt        DateTime `json:"located_at"` // Time of the latest location
+	CalculatedAt     DateTime `json:"calculated_at"`
 }
 
 type DriverLocation struct {
@@ -84,4 +250,122 @@ type DriverLocation struct {
 	Bearing     float32  `json:"bearing,omitempty"`  // Optional direction
 	IsActive    bool     `json:"is_active"`          // Whether the driver is active
 	TripID      int64    `json:"trip_id,omitempty"`  // Optional trip association
+	Point       string   `json:"point,omitempty"`    // LocationStart or LocationEnd, empty in between
+}
+
+// Points of DriverLocation marking where the driver's trip began and ended
+const (
+	LocationStart = "START"
+	LocationEnd   = "END"
+)
+
+// DriverLocationInput is a GPS point reported by a driver. The driver, vehicle and trip are
+// taken from the driver's active assignment.
+type DriverLocationInput struct {
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	Timestamp DateTime `json:"timestamp"` // Defaults to the time of the request
+	Accuracy  float32  `json:"accuracy" binding:"min=0,max=999"`
+	Speed     float32  `json:"speed" binding:"min=0,max=9999"`
+	Bearing   float32  `json:"bearing" binding:"min=0,max=360"`
+}
+
+// TripTrack is the path a driver took to deliver a pickup request. The distance and timing
+// are measured on every location, Points may be simplified.
+type TripTrack struct {
+	RequestID       int64            `json:"request_id"`
+	Tolerance       float64          `json:"tolerance_meters"` // Of the simplification, 0 if none
+	RecordedPoints  int              `json:"recorded_points"`
+	DistanceMeters  float64          `json:"distance_meters"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	AverageSpeedKmh float64          `json:"average_speed_kmh"`
+	MaxSpeedKmh     float64          `json:"max_speed_kmh"`
+	Points          []DriverLocation `json:"points"`
+}
+
+// DriverLocationBatch is a set of points a driver buffered while offline.
+type DriverLocationBatch struct {
+	Points []DriverLocationInput `json:"points" binding:"required,min=1,max=500,dive"`
+}
+
+// Site is a place drivers are detected arriving at and departing from, by their locations
+// entering and leaving a circular fence around it: a pickup location of a business or a drop-off
+// facility of a collector.
+type Site struct {
+	SiteID       int64    `json:"site_id"`
+	OwnerID      int64    `json:"owner_id"` // The business or collector
+	Kind         string   `json:"kind"`     // SitePickup or SiteDropOff, after the role of the owner
+	Name         string   `json:"name"`
+	Address      string   `json:"address,omitempty"`
+	Latitude     float64  `json:"latitude"`
+	Longitude    float64  `json:"longitude"`
+	RadiusMeters *float64 `json:"radius_meters,omitempty"` // The configured default if nil
+	DwellSeconds *int64   `json:"dwell_seconds,omitempty"` // The configured default if nil
+	AutoAdvance  bool     `json:"auto_advance"`            // Start deliveries on departures from pickup sites, end them on arrivals at drop-off sites
+	CreatedAt    DateTime `json:"created_at"`
+	UpdatedAt    DateTime `json:"updated_at"`
+}
+
+// Kinds of Site
+const (
+	SitePickup  = "pickup"
+	SiteDropOff = "dropoff"
+)
+
+type SiteInput struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	Address      string   `json:"address" binding:"max=500"`
+	Latitude     *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	RadiusMeters *float64 `json:"radius_meters" binding:"omitempty,min=10,max=5000"`
+	DwellSeconds *int64   `json:"dwell_seconds" binding:"omitempty,min=0,max=3600"`
+	AutoAdvance  bool     `json:"auto_advance"`
+}
+
+// SiteVisit is where a trip stands at a site: outside of its fence, inside for less than the
+// dwell time, arrived, or outside again for less than the dwell time after arriving.
+type SiteVisit struct {
+	TripID     int64     `json:"trip_id"`
+	SiteID     int64     `json:"site_id"`
+	DriverID   int64     `json:"driver_id"`
+	EnteredAt  *DateTime `json:"entered_at,omitempty"` // Start of the current stay inside the fence
+	ArrivedAt  *DateTime `json:"arrived_at,omitempty"` // Set to EnteredAt once the stay lasted the dwell time
+	ExitedAt   *DateTime `json:"exited_at,omitempty"`  // Left the fence after arriving, a departure once outside for the dwell time
+	LastSeenAt DateTime  `json:"last_seen_at"`         // Timestamp of the latest location that changed the visit
+	Version    int64     `json:"-"`                    // Of the stored visit, 0 if there is none yet
+}
+
+// GeofenceEvent is the payload of the driver.arrived and driver.departed events, raised once a
+// driver stayed inside, or outside, the fence of a site for its dwell time.
+type GeofenceEvent struct {
+	Event       string   `json:"event"` // driver.arrived or driver.departed
+	RequestID   int64    `json:"request_id"`
+	BusinessID  int64    `json:"business_id"`
+	CollectorID int64    `json:"collector_id"`
+	DriverID    int64    `json:"driver_id"`
+	SiteID      int64    `json:"site_id"`
+	SiteKind    string   `json:"site_kind"`
+	SiteName    string   `json:"site_name"`
+	AutoAdvance bool     `json:"auto_advance"`
+	Latitude    float64  `json:"latitude"` // Of the location that confirmed the event
+	Longitude   float64  `json:"longitude"`
+	At          DateTime `json:"at"`          // When the driver entered or left the fence
+	DetectedAt  DateTime `json:"detected_at"` // Timestamp of the location that confirmed the event
+}
+
+// Actor identifies the user behind a change, as taken from the JWT of the request
+type Actor struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type PickupRequestStatusChange struct {
+	HistoryID   int64    `json:"history_id"`
+	RequestID   int64    `json:"request_id"`
+	ActorUserID int64    `json:"actor_user_id"`
+	ActorRole   string   `json:"actor_role"`
+	OldStatus   string   `json:"old_status"` // Empty for the creation of the request
+	NewStatus   string   `json:"new_status"`
+	Reason      string   `json:"reason,omitempty"`
+	ChangedAt   DateTime `json:"changed_at"`
 }
@@ -23,6 +23,10 @@ type BusinessUpdate struct {
 	Registration_number string `json:"registration_number"`
 	Gst_id              string `json:"gst_id"`
 	Business_address    string `json:"business_address"`
+
+	// Kept unless given, or geocoded from a new business address
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
 }
 
 type CollectorUpdate struct {
@@ -31,6 +35,10 @@ type CollectorUpdate struct {
 	License_number string `json:"license_number"`
 	Capacity       int64  `json:"capacity"`
 	License_expiry Date   `json:"license_expiry"`
+
+	// Kept unless given, or geocoded from a new address
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
 }
 
 type UpdateCollectorServiceCategory struct {
@@ -68,9 +76,10 @@ type UpdatePickupRequest struct {
 	WasteType            string   `json:"waste_type"`
 	Quantity             float64  `json:"quantity"`
 	PickupDate           DateTime `json:"pickup_date"`
-	Status               string   `json:"status" binding:"required,oneof=Pending Assigned Completed Cancelled"`
+	Status               string   `json:"status" binding:"omitempty,oneof=Cancelled"` // Businesses can only cancel; collectors and drivers move requests along
 	HandlingRequirements string   `json:"handling_requirements"`
-	AssignedDriver       int64    `json:"assigned_driver,omitempty"`
-	AssignedVehicle      int64    `json:"assigned_vehicle,omitempty"`
-	CreatedAt            DateTime `json:"created_at"`
+	Reason               string   `json:"reason,omitempty"` // Recorded in the status history when the status changes
+
+	PickupLatitude  *float64 `json:"pickup_latitude,omitempty" binding:"omitempty,required_with=PickupLongitude,min=-90,max=90"`
+	PickupLongitude *float64 `json:"pickup_longitude,omitempty" binding:"omitempty,required_with=PickupLatitude,min=-180,max=180"`
 }
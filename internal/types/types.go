@@ -19,6 +19,11 @@ type User struct {
 	LastLogin    DateTime `json:"last_login"`
 	IsVerified   bool     `json:"is_verified"`
 	IsFlagged    bool     `json:"is_flagged"`
+
+	NotificationChannels []string `json:"notification_channels,omitempty"`
+	Locale               string   `json:"locale,omitempty"`
+
+	MustChangePassword bool `json:"must_change_password,omitempty"` // Set for admins given their password by someone else
 }
 
 type Business struct {
@@ -28,6 +33,10 @@ type Business struct {
 	Registration_number string `json:"registration_number" binding:"required"`
 	Gst_id              string `json:"gst_id" binding:"required"`
 	Business_address    string `json:"business_address" binding:"required"`
+
+	// Of the business address, geocoded from it when not given
+	Latitude  *float64 `json:"latitude,omitempty" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
 }
 
 type Collector struct {
@@ -36,6 +45,10 @@ type Collector struct {
 	License_number string `json:"license_number" binding:"required"`
 	Capacity       int64  `json:"capacity" binding:"required"`
 	License_expiry Date   `json:"license_expiry" binding:"required"`
+
+	// Of the depot at the user's address, geocoded from it when not given
+	Latitude  *float64 `json:"latitude,omitempty" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude,omitempty" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
 }
 
 type Date struct {
@@ -83,14 +96,166 @@ func (dt DateTime) MarshalJSON() ([]byte, error) {
 	return []byte(formatted), nil
 }
 
+type OutboxEvent struct {
+	OutboxID   int64             `json:"outbox_id"`
+	Topic      string            `json:"topic"`
+	Payload    []byte            `json:"payload"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Attempts   int               `json:"attempts"`
+	CreatedAt  DateTime          `json:"created_at"`
+}
+
+type DeadLetter struct {
+	DeadLetterID   int64             `json:"dead_letter_id"`
+	SubscriptionID string            `json:"subscription_id"`
+	Topic          string            `json:"topic"`
+	MessageID      string            `json:"message_id"`
+	EventID        string            `json:"event_id,omitempty"`
+	EventType      string            `json:"event_type,omitempty"`
+	Payload        string            `json:"payload"`
+	Attributes     map[string]string `json:"attributes,omitempty"`
+	Attempts       int               `json:"attempts"`
+	LastError      string            `json:"last_error"`
+	Status         string            `json:"status"`
+	CreatedAt      DateTime          `json:"created_at"`
+	ResolvedAt     *DateTime         `json:"resolved_at,omitempty"`
+}
+
+type Notification struct {
+	NotificationID  int64     `json:"notification_id"`
+	UserID          int64     `json:"user_id"`
+	EventID         string    `json:"event_id,omitempty"`
+	Type            string    `json:"type"` // The template it was rendered from, e.g. pickup.accepted.business
+	PickupRequestID int64     `json:"pickup_request_id,omitempty"`
+	Heading         string    `json:"heading"`
+	Message         string    `json:"message"`
+	IsRead          bool      `json:"is_read"`
+	CreatedAt       DateTime  `json:"created_at"`
+	ReadAt          *DateTime `json:"read_at,omitempty"`
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int64          `json:"total"`
+	Unread        int64          `json:"unread"`
+	Page          int            `json:"page"`
+	PageSize      int            `json:"page_size"`
+}
+
+type WebhookEndpoint struct {
+	EndpointID  int64    `json:"endpoint_id"`
+	UserID      int64    `json:"user_id"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	EventTypes  []string `json:"event_types"`
+	Secret      string   `json:"secret,omitempty"` // Only returned when the endpoint is created
+	IsActive    bool     `json:"is_active"`
+	CreatedAt   DateTime `json:"created_at"`
+	UpdatedAt   DateTime `json:"updated_at"`
+}
+
+type WebhookEndpointInput struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Description string   `json:"description" binding:"max=255"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	IsActive    *bool    `json:"is_active"` // Defaults to true on creation, unchanged on update
+}
+
+type WebhookDelivery struct {
+	DeliveryID     int64     `json:"delivery_id"`
+	EndpointID     int64     `json:"endpoint_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Payload        string    `json:"payload"`
+	Status         string    `json:"status"` // pending, succeeded or failed
+	Attempts       int       `json:"attempts"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	NextAttemptAt  DateTime  `json:"next_attempt_at"`
+	CreatedAt      DateTime  `json:"created_at"`
+	DeliveredAt    *DateTime `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+}
+
+type NotificationTemplate struct {
+	Name      string    `json:"name"`
+	Locale    string    `json:"locale"`
+	Subject   string    `json:"subject" binding:"required"`
+	Text      string    `json:"text" binding:"required"`
+	HTML      string    `json:"html"`
+	Source    string    `json:"source"` // embedded or override
+	UpdatedBy int64     `json:"updated_by,omitempty"`
+	UpdatedAt *DateTime `json:"updated_at,omitempty"`
+}
+
+// Notifiable is implemented by User, and so by Business, Collector and CollectorDriver
 type Notifiable interface {
+	GetUserID() int64
 	GetEmail() string
+	GetPhoneNumber() string
+	GetNotificationChannels() []string // Empty for the default channels
+	GetLocale() string                 // Empty for the default locale
+}
+
+func (u User) GetUserID() int64 {
+	return u.UserID
+}
+
+func (u User) GetEmail() string {
+	return u.Email
+}
+
+func (u User) GetPhoneNumber() string {
+	return u.PhoneNumber
+}
+
+func (u User) GetNotificationChannels() []string {
+	return u.NotificationChannels
+}
+
+func (u User) GetLocale() string {
+	return u.Locale
+}
+
+type NotificationPreferences struct {
+	Channels []string `json:"channels" binding:"required,min=1,dive,oneof=email sms webhook log"`
+	Locale   string   `json:"locale" binding:"omitempty,max=10"` // e.g. "en" or "hi-IN"
+}
+
+// NotificationTemplatePreview renders a draft, or the current template for fields left empty,
+// with a real pickup request or sample data.
+type NotificationTemplatePreview struct {
+	Subject         string `json:"subject,omitempty"`
+	Text            string `json:"text,omitempty"`
+	HTML            string `json:"html,omitempty"`
+	PickupRequestID int64  `json:"pickup_request_id,omitempty"`
+}
+
+// AdminAuditRecord records how an admin account came to exist
+type AdminAuditRecord struct {
+	AuditID     int64    `json:"audit_id"`
+	Action      string   `json:"action"` // bootstrap or create
+	AdminUserID int64    `json:"admin_user_id"`
+	ActorUserID int64    `json:"actor_user_id,omitempty"` // The admin who created it, 0 for the bootstrap admin
+	CreatedAt   DateTime `json:"created_at"`
 }
 
-func (b Business) GetEmail() string {
-	return b.Email
+// NewAdmin is an admin account created by another admin. The password is a temporary one, to
+// be changed on first login.
+type NewAdmin struct {
+	Email       string `json:"email" binding:"required,email"`
+	FullName    string `json:"full_name" binding:"required"`
+	PhoneNumber string `json:"phone_number"`
+	Password    string `json:"password" binding:"required,min=8"`
 }
 
-func (c Collector) GetEmail() string {
-	return c.Email
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
 }
@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// HTTPDoer is the part of *http.Client the dispatcher uses, so that tests can stub endpoints.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Dispatcher posts queued deliveries to their endpoints. Deliveries that fail, i.e. get no 2xx
+// response, are retried with exponential backoff until MaxAttempts is reached.
+type Dispatcher struct {
+	store  storage.Webhooks
+	client HTTPDoer
+
+	MaxAttempts  int           // Attempts before a delivery is marked failed
+	BatchSize    int           // Maximum number of deliveries claimed per poll
+	PollInterval time.Duration // Wait between polls when no delivery is due
+	Lease        time.Duration // How long a claimed delivery is hidden from other dispatchers
+	BaseBackoff  time.Duration // Delay before the first retry, doubled on every failure
+	MaxBackoff   time.Duration // Upper bound for the retry delay
+}
+
+func NewDispatcher(store storage.Webhooks, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		store:        store,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  maxAttempts,
+		BatchSize:    20,
+		PollInterval: 2 * time.Second,
+		Lease:        time.Minute,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// Run delivers due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("webhook dispatcher started")
+
+	for {
+		claimed := d.dispatchBatch(ctx)
+
+		if claimed == d.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("webhook dispatcher stopped")
+			return
+		case <-time.After(d.PollInterval):
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchBatch(ctx context.Context) int {
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, d.BatchSize, d.Lease)
+	if err != nil {
+		slog.Error("failed to claim webhook deliveries", slog.String("error", err.Error()))
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		endpoint, err := d.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+		if err != nil {
+			slog.Error("failed to load webhook endpoint", slog.Int64("endpoint_id", delivery.EndpointID), slog.String("error", err.Error()))
+			continue
+		}
+		d.Attempt(ctx, endpoint, delivery)
+	}
+
+	return len(deliveries)
+}
+
+// Attempt posts a delivery once and records the outcome, scheduling a retry if it failed and
+// attempts are left. It returns the updated delivery.
+func (d *Dispatcher) Attempt(ctx context.Context, endpoint types.WebhookEndpoint, delivery types.WebhookDelivery) types.WebhookDelivery {
+	var statusCode int
+	var err error
+	if endpoint.IsActive || delivery.EventType == TestEventType {
+		statusCode, err = d.post(ctx, endpoint, delivery)
+	} else {
+		err = fmt.Errorf("endpoint is disabled")
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	next := time.Now()
+
+	switch {
+	case err == nil:
+		delivery.Status = "succeeded"
+		delivery.DeliveredAt = &types.DateTime{Time: next}
+	case delivery.Attempts >= d.MaxAttempts || !endpoint.IsActive:
+		delivery.Status = "failed"
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = "pending"
+		delivery.LastError = err.Error()
+		next = next.Add(d.backoff(delivery.Attempts - 1))
+	}
+	delivery.NextAttemptAt = types.DateTime{Time: next}
+
+	if err != nil {
+		slog.Warn("webhook delivery failed",
+			slog.Int64("delivery_id", delivery.DeliveryID),
+			slog.Int64("endpoint_id", endpoint.EndpointID),
+			slog.Int("attempts", delivery.Attempts),
+			slog.String("status", delivery.Status),
+			slog.String("error", err.Error()))
+	}
+
+	if err := d.store.RecordWebhookAttempt(ctx, delivery.DeliveryID, delivery.Status, statusCode, delivery.LastError, next); err != nil {
+		// The lease runs out and the delivery is attempted again, receivers dedupe on the delivery header anyway
+		slog.Error("failed to record webhook attempt", slog.Int64("delivery_id", delivery.DeliveryID), slog.String("error", err.Error()))
+	}
+	return delivery
+}
+
+// SendTest queues a test event for an endpoint, whether or not it is active, and attempts it
+// right away. A failed test event is retried like any other delivery.
+func (d *Dispatcher) SendTest(ctx context.Context, endpoint types.WebhookEndpoint) (types.WebhookDelivery, error) {
+	envelope, data, err := testEvent(endpoint)
+	if err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	deliveryID, err := d.store.CreateWebhookDelivery(ctx, types.WebhookDelivery{
+		EndpointID: endpoint.EndpointID,
+		EventID:    envelope.ID,
+		EventType:  TestEventType,
+		Payload:    string(data),
+	})
+	if err != nil {
+		return types.WebhookDelivery{}, err
+	}
+
+	delivery, err := d.store.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return types.WebhookDelivery{}, err
+	}
+	return d.Attempt(ctx, endpoint, delivery), nil
+}
+
+func (d *Dispatcher) post(ctx context.Context, endpoint types.WebhookEndpoint, delivery types.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	now := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// backoff returns the retry delay after the given number of earlier failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	return delay
+}
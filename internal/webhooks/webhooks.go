@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kartikey1188/build-in-progress_01/internal/events"
+	"github.com/kartikey1188/build-in-progress_01/internal/storage"
+	"github.com/kartikey1188/build-in-progress_01/internal/types"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256, keyed with the
+// endpoint secret, of the timestamp header, a dot and the body, prefixed with "sha256=".
+// Receivers should reject timestamps too far in the past to prevent replays.
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// TestEventType is the type of the events sent by SendTest. Every endpoint receives it.
+const TestEventType = "webhook.test"
+
+// EventTypes are the event types endpoints can subscribe to, the body of a delivery being the
+// event envelope.
+var EventTypes = []events.Type{
+	events.PickupCreated,
+	events.PickupAccepted,
+	events.PickupRejected,
+	events.PickupCancelled,
+	events.DriverAssigned,
+	events.DriverUnassigned,
+	events.DeliveryStarted,
+	events.DeliveryCompleted,
+	events.DriverArrived,
+	events.DriverDeparted,
+}
+
+func ValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret for a new endpoint.
+func NewSecret() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return "whsec_" + hex.EncodeToString(b[:])
+}
+
+// Sign returns the signature header value for a body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue queues an event for every active endpoint of the given users subscribed to its type.
+// Events already queued for an endpoint are skipped, so it is safe to call again for a
+// redelivered event.
+func Enqueue(ctx context.Context, store storage.Webhooks, envelope events.Envelope, data []byte, userIDs []int64) error {
+	endpoints, err := store.ListSubscribedWebhookEndpoints(ctx, userIDs, string(envelope.Type))
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		_, err := store.CreateWebhookDelivery(ctx, types.WebhookDelivery{
+			EndpointID: endpoint.EndpointID,
+			EventID:    envelope.ID,
+			EventType:  string(envelope.Type),
+			Payload:    string(data),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// testEvent builds the envelope sent by SendTest.
+func testEvent(endpoint types.WebhookEndpoint) (events.Envelope, []byte, error) {
+	payload, err := json.Marshal(map[string]any{
+		"endpoint_id": endpoint.EndpointID,
+		"message":     "This is a test event.",
+	})
+	if err != nil {
+		return events.Envelope{}, nil, fmt.Errorf("failed to marshal test event: %w", err)
+	}
+
+	envelope := events.Envelope{
+		Version:    events.Version,
+		ID:         events.NewID(),
+		Type:       TestEventType,
+		OccurredAt: time.Now().UTC(),
+		Payload:    payload,
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return events.Envelope{}, nil, fmt.Errorf("failed to marshal test event: %w", err)
+	}
+	return envelope, data, nil
+}